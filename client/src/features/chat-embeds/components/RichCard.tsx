@@ -0,0 +1,159 @@
+import React from 'react';
+import { Icon } from '@iconify/react';
+import type { MessagePayload } from '../../../shared/types';
+
+interface RichCardProps {
+  payload: MessagePayload;
+}
+
+type RichMedia = { type: 'image' | 'video'; url: string; alt: string };
+type RichField = { name: string; value: string; inline: boolean };
+type RichLink = { label: string; url: string };
+
+function str(value: unknown): string {
+  return typeof value === 'string' ? value.trim() : '';
+}
+
+function httpUrl(value: unknown): string {
+  const s = str(value);
+  return /^https?:\/\//i.test(s) ? s : '';
+}
+
+function record(value: unknown): Record<string, any> | null {
+  if (!value || typeof value !== 'object' || Array.isArray(value)) return null;
+  return value as Record<string, any>;
+}
+
+function list(value: unknown): Record<string, any>[] {
+  if (!Array.isArray(value)) return [];
+  return value.map(record).filter((v): v is Record<string, any> => Boolean(v));
+}
+
+function formatTimestamp(value: string): string {
+  const date = new Date(value);
+  if (Number.isNaN(date.getTime())) return '';
+  return date.toLocaleString();
+}
+
+export const RichCard: React.FC<RichCardProps> = ({ payload }) => {
+  const title = str(payload.title);
+  const url = httpUrl(payload.url);
+  const body = str(payload.body);
+  const color = /^#[0-9a-f]{6}$/i.test(str(payload.color)) ? str(payload.color) : '';
+
+  const authorRaw = record(payload.author);
+  const authorName = str(authorRaw?.name);
+  const authorUrl = httpUrl(authorRaw?.url);
+  const authorIcon = httpUrl(authorRaw?.s3_icon_url) || httpUrl(authorRaw?.icon_url);
+
+  const media: RichMedia[] = list(payload.media)
+    .map((m) => ({
+      type: (str(m.type) === 'video' ? 'video' : 'image') as RichMedia['type'],
+      url: httpUrl(m.s3_url) || httpUrl(m.url),
+      alt: str(m.alt),
+    }))
+    .filter((m) => m.url);
+
+  const fields: RichField[] = list(payload.fields)
+    .map((f) => ({ name: str(f.name), value: str(f.value), inline: f.inline === true }))
+    .filter((f) => f.name && f.value);
+
+  const links: RichLink[] = list(payload.links)
+    .map((l) => ({ label: str(l.label), url: httpUrl(l.url) }))
+    .filter((l) => l.label && l.url);
+
+  const footerRaw = record(payload.footer);
+  const footerText = str(footerRaw?.text);
+  const footerIcon = httpUrl(footerRaw?.s3_icon_url) || httpUrl(footerRaw?.icon_url);
+  const timestamp = str(payload.timestamp) ? formatTimestamp(str(payload.timestamp)) : '';
+
+  if (!title && !body) return null;
+
+  return (
+    <div
+      className="mt-1 max-w-[520px] w-full rounded-md border border-mew-darkest bg-mew-darker overflow-hidden border-l-4"
+      style={color ? { borderLeftColor: color } : undefined}
+      onClick={(e) => e.stopPropagation()}
+    >
+      <div className="p-3 space-y-2">
+        {authorName && (
+          <div className="flex items-center gap-2 text-xs text-mew-textMuted min-w-0">
+            {authorIcon && (
+              <img src={authorIcon} alt="" loading="lazy" referrerPolicy="no-referrer" className="h-5 w-5 rounded-full object-cover shrink-0" />
+            )}
+            {authorUrl ? (
+              <a href={authorUrl} target="_blank" rel="noopener noreferrer" className="truncate font-medium text-mew-text hover:underline">
+                {authorName}
+              </a>
+            ) : (
+              <span className="truncate font-medium text-mew-text">{authorName}</span>
+            )}
+          </div>
+        )}
+
+        {title && (
+          url ? (
+            <a href={url} target="_blank" rel="noopener noreferrer" className="block text-sm font-semibold text-primary hover:underline break-words">
+              {title}
+            </a>
+          ) : (
+            <div className="text-sm font-semibold text-mew-text break-words">{title}</div>
+          )
+        )}
+
+        {body && <div className="text-sm text-mew-text whitespace-pre-wrap break-words">{body}</div>}
+
+        {fields.length > 0 && (
+          <div className="grid grid-cols-3 gap-x-4 gap-y-2">
+            {fields.map((f, i) => (
+              <div key={i} className={f.inline ? 'col-span-1 min-w-0' : 'col-span-3 min-w-0'}>
+                <div className="text-xs font-semibold text-mew-textMuted break-words">{f.name}</div>
+                <div className="text-sm text-mew-text whitespace-pre-wrap break-words">{f.value}</div>
+              </div>
+            ))}
+          </div>
+        )}
+
+        {media.length > 0 && (
+          <div className={`grid gap-1 ${media.length > 1 ? 'grid-cols-2' : 'grid-cols-1'}`}>
+            {media.map((m, i) =>
+              m.type === 'video' ? (
+                <video key={i} src={m.url} controls playsInline preload="metadata" className="w-full max-h-[300px] rounded bg-black/20" />
+              ) : (
+                <a key={i} href={m.url} target="_blank" rel="noopener noreferrer" className="block">
+                  <img src={m.url} alt={m.alt} loading="lazy" referrerPolicy="no-referrer" className="w-full max-h-[300px] rounded object-cover bg-black/20" />
+                </a>
+              ),
+            )}
+          </div>
+        )}
+
+        {links.length > 0 && (
+          <div className="flex flex-wrap gap-2">
+            {links.map((l, i) => (
+              <a
+                key={i}
+                href={l.url}
+                target="_blank"
+                rel="noopener noreferrer"
+                className="inline-flex items-center gap-1 rounded px-2.5 py-1 text-xs font-medium bg-mew-dark text-mew-text hover:bg-mew-dark/70 transition-colors"
+              >
+                {l.label}
+                <Icon icon="mdi:open-in-new" width="12" height="12" className="text-mew-textMuted" />
+              </a>
+            ))}
+          </div>
+        )}
+
+        {(footerText || timestamp) && (
+          <div className="flex items-center gap-1.5 text-[11px] text-mew-textMuted">
+            {footerIcon && <img src={footerIcon} alt="" loading="lazy" referrerPolicy="no-referrer" className="h-4 w-4 rounded-full object-cover" />}
+            {footerText && <span className="truncate">{footerText}</span>}
+            {footerText && timestamp && <span className="opacity-60">•</span>}
+            {timestamp && <span className="shrink-0">{timestamp}</span>}
+          </div>
+        )}
+      </div>
+    </div>
+  );
+};
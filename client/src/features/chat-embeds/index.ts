@@ -4,6 +4,7 @@ export { ClaudeCodeCard } from './components/ClaudeCodeCard';
 export { InstagramCard } from './components/InstagramCard';
 export { JpdictCard } from './components/JpdictCard';
 export { PornhubCard } from './components/PornhubCard';
+export { RichCard } from './components/RichCard';
 export { RssCard } from './components/RssCard';
 export { TiktokCard } from './components/TiktokCard';
 export { TwitterCard } from './components/TwitterCard';
@@ -5,7 +5,7 @@ import { Message } from '../../../shared/types';
 import { parseMessageContent } from '../../../shared/utils/messageParser';
 import { AttachmentList } from '../../chat-attachments/components/AttachmentList';
 import ForwardCard from './ForwardCard';
-import { BilibiliCard, ClaudeCodeCard, InstagramCard, JpdictCard, PornhubCard, RssCard, TiktokCard, TwitterCard, UrlEmbed } from '../../chat-embeds';
+import { BilibiliCard, ClaudeCodeCard, InstagramCard, JpdictCard, PornhubCard, RichCard, RssCard, TiktokCard, TwitterCard, UrlEmbed } from '../../chat-embeds';
 import { VoiceMessagePlayer } from '../../chat-voice/components/VoiceMessagePlayer';
 import { useI18n } from '../../../shared/i18n';
 
@@ -28,6 +28,7 @@ const MessageContent: React.FC<MessageContentProps> = ({ message, serverId, chan
     const isForwardCard = message.type === 'app/x-forward-card';
     const isJpdictCard = message.type === 'app/x-jpdict-card';
     const isClaudeCodeCard = message.type === 'app/x-claudecode-card';
+    const isRichCard = message.type === 'app/x-rich-card';
     const isVoiceMessage = message.type === 'message/voice';
 
     if (isForwardCard && message.payload) {
@@ -66,6 +67,10 @@ const MessageContent: React.FC<MessageContentProps> = ({ message, serverId, chan
         return <TiktokCard payload={message.payload} />;
     }
 
+    if (isRichCard && message.payload) {
+        return <RichCard payload={message.payload} />;
+    }
+
     if (isVoiceMessage) {
         const voice = message.payload?.voice as any;
         const src = typeof voice?.url === 'string' ? voice.url : '';
@@ -1,11 +1,29 @@
 import api from './http';
 
+export type ServiceCommandArg = {
+  name: string;
+  type: string;
+  required?: boolean;
+  description?: string;
+};
+
+export type ServiceCommand = {
+  name: string;
+  aliases?: string[];
+  description?: string;
+  usage?: string;
+  args?: ServiceCommandArg[];
+  dmOnly?: boolean;
+  ownerOnly?: boolean;
+};
+
 export type AvailableService = {
   serviceType: string;
   serverName?: string;
   icon?: string;
   description?: string;
   configTemplate?: string;
+  commands?: ServiceCommand[];
   online: boolean;
   connections: number;
 };
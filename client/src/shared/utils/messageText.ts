@@ -127,6 +127,22 @@ function cardPayloadText(type: string, payload: Record<string, any>): string {
       return joinLines(lines);
     }
 
+    case 'app/x-rich-card': {
+      pushUnique(lines, safeTrim(safeRecord(payload.author)?.name));
+      pushUnique(lines, safeTrim(payload.title));
+      pushUnique(lines, safeTrim(payload.body));
+      if (Array.isArray(payload.fields)) {
+        for (const f of payload.fields) {
+          const field = safeRecord(f);
+          const name = safeTrim(field?.name);
+          const value = safeTrim(field?.value);
+          if (name && value) pushUnique(lines, `${name}: ${value}`);
+        }
+      }
+      pushUnique(lines, safeTrim(payload.url));
+      return joinLines(lines);
+    }
+
     default: {
       // Generic best-effort for other app cards (or unknown payload shapes).
       pushUnique(lines, safeTrim(payload.title));
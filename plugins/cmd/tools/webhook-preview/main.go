@@ -0,0 +1,52 @@
+// Command webhook-preview serves the webhook posts and uploads recorded in
+// DEV_MODE as a live-updating local page.
+//
+//	go run ./cmd/tools/webhook-preview [-addr 127.0.0.1:8790] [-dir $MEW_DEV_DIR]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"mew/plugins/pkg"
+	"mew/plugins/pkg/x/devmode/preview"
+)
+
+func main() {
+	sdk.LoadDotEnv("webhook-preview")
+
+	addr := flag.String("addr", "127.0.0.1:8790", "listen address")
+	dir := flag.String("dir", sdk.DevModeDir(), "dev-mode directory (MEW_DEV_DIR)")
+	poll := flag.Duration("poll", time.Second, "how often to check for new records")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           preview.NewServer(*dir, preview.Options{PollInterval: *poll}),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Ends open event streams on shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("[webhook-preview] serving %s on http://%s", *dir, *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"mew/plugins/internal/agents/assistant-agent/infra"
+	sdkapi "mew/plugins/pkg/api"
+)
+
+// catchUpUnread replies to DMs that arrived while the bot was offline. Only
+// the newest unread user message per channel is submitted: earlier unread
+// messages are part of the same session history the prompt is built from.
+// Channels that were never acked are left alone so a fresh bot does not
+// answer old history.
+func (r *Runner) catchUpUnread(ctx context.Context, logPrefix string) {
+	api := r.session.API()
+	channels, err := api.Channels.ListDMs(ctx)
+	if err != nil {
+		log.Printf("%s catch-up: list DM channels failed: %v", logPrefix, err)
+		return
+	}
+	for _, ch := range channels {
+		if ctx.Err() != nil {
+			return
+		}
+		if strings.TrimSpace(ch.LastReadMessageID) == "" {
+			continue
+		}
+		unread, err := api.Messages.Since(ctx, ch.ID, ch.LastReadMessageID, infra.AssistantCatchUpMaxMessages)
+		if err != nil {
+			log.Printf("%s catch-up: fetch unread failed: channel=%s err=%v", logPrefix, ch.ID, err)
+			continue
+		}
+		latest, ok := latestUserMessage(unread, r.botUserID)
+		if !ok {
+			if n := len(unread); n > 0 {
+				r.ackMessage(ctx, logPrefix, ch.ID, unread[n-1].ID)
+			}
+			continue
+		}
+		log.Printf("%s catch-up: channel=%s unread=%d latest=%s", logPrefix, ch.ID, len(unread), latest.ID)
+		if err := r.handleMessage(ctx, logPrefix, latest, nil); err != nil {
+			log.Printf("%s catch-up: handle failed: channel=%s msg=%s err=%v", logPrefix, ch.ID, latest.ID, err)
+		}
+	}
+}
+
+// latestUserMessage returns the newest message not sent by the bot, as long
+// as the bot has not replied after it.
+func latestUserMessage(msgs []sdkapi.ChannelMessage, botUserID string) (sdkapi.ChannelMessage, bool) {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if sdkapi.IsOwnMessage(msgs[i].AuthorRaw, botUserID) {
+			return sdkapi.ChannelMessage{}, false
+		}
+		if msgs[i].AuthorID() != "" {
+			return msgs[i], true
+		}
+	}
+	return sdkapi.ChannelMessage{}, false
+}
+
+// ackMessage advances the channel read marker. Failures only cost a
+// duplicate catch-up reply, so they are logged and dropped.
+func (r *Runner) ackMessage(ctx context.Context, logPrefix, channelID, messageID string) {
+	if r.session == nil || strings.TrimSpace(messageID) == "" {
+		return
+	}
+	if err := r.session.API().Channels.Ack(ctx, channelID, messageID); err != nil && ctx.Err() == nil {
+		log.Printf("%s ack failed: channel=%s msg=%s err=%v", logPrefix, channelID, messageID, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,8 +19,45 @@ import (
 	"mew/plugins/pkg/x/llm"
 )
 
+// HistorySearchArgs are the HistorySearch tool arguments. Only Keyword is
+// required; the rest narrow the search (see instruct_prompt.txt).
+type HistorySearchArgs struct {
+	Keyword string
+	// Scope is "dm" (the current channel, default) or "all" (also the servers
+	// shared with the user).
+	Scope string
+	// From is "user", "me" or empty (anyone).
+	From          string
+	After         string
+	Before        string
+	HasAttachment *bool
+}
+
+func parseHistorySearchArgs(args map[string]any) HistorySearchArgs {
+	str := func(k string) string {
+		v, _ := args[k].(string)
+		return strings.TrimSpace(v)
+	}
+	out := HistorySearchArgs{
+		Keyword: str("keyword"),
+		Scope:   strings.ToLower(str("scope")),
+		From:    strings.ToLower(str("from")),
+		After:   str("after"),
+		Before:  str("before"),
+	}
+	switch v := args["has_attachment"].(type) {
+	case bool:
+		out.HasAttachment = &v
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			out.HasAttachment = &b
+		}
+	}
+	return out
+}
+
 type ToolHandlers struct {
-	HistorySearch func(ctx context.Context, keyword string) (any, error)
+	HistorySearch func(ctx context.Context, args HistorySearchArgs) (any, error)
 	RecordSearch  func(ctx context.Context, recordID string) (any, error)
 	WebSearch     func(ctx context.Context, query string) (any, error)
 }
@@ -164,8 +202,7 @@ func ChatWithTools(
 						payload = map[string]any{"error": "tool handler not configured"}
 						break
 					}
-					keyword, _ := tc.Args["keyword"].(string)
-					payload, toolErr = handlers.HistorySearch(ctx, keyword)
+					payload, toolErr = handlers.HistorySearch(ctx, parseHistorySearchArgs(tc.Args))
 				case opts.RecordSearchToolName:
 					if handlers.RecordSearch == nil {
 						payload = map[string]any{"error": "tool handler not configured"}
@@ -234,6 +234,15 @@ func (q *SendQueue) run() {
 		if b.gen != currentGen || ctx == nil {
 			continue
 		}
+		if transport.ReplyTo != "" && hasTextEvent(b.events) {
+			// Only the first text of a generation threads under the trigger;
+			// later batches (want-more, tool preludes) follow as plain messages.
+			q.mu.Lock()
+			if q.gen == b.gen {
+				q.transport.ReplyTo = ""
+			}
+			q.mu.Unlock()
+		}
 		if err := SendEvents(ctx, transport, b.events); err != nil {
 			if ctx.Err() != nil {
 				continue
@@ -242,3 +251,12 @@ func (q *SendQueue) run() {
 		}
 	}
 }
+
+func hasTextEvent(events []SendEvent) bool {
+	for _, ev := range events {
+		if ev.Kind == ReplyPartText && strings.TrimSpace(ev.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -354,3 +354,29 @@ func TestSendEvents_VoiceFutureUsesPreparedUpload(t *testing.T) {
 		t.Fatalf("unexpected out: %#v", out)
 	}
 }
+
+func TestSendReply_ThreadsOnlyFirstLineUnderTrigger(t *testing.T) {
+	clean, controls := ParseReplyControls("a\nb")
+
+	var refs []string
+	err := SendReply(context.Background(), TransportContext{
+		Emit: func(event string, payload any) error {
+			m, _ := payload.(map[string]any)
+			ref, _ := m["referencedMessageId"].(string)
+			refs = append(refs, ref)
+			return nil
+		},
+		ChannelID: "c1",
+		UserID:    "u1",
+		LogPrefix: "[test]",
+		TypingWPM: 1_000_000_000,
+		ReplyTo:   "m1",
+		Sleep:     func(context.Context, time.Duration) {},
+	}, clean, controls)
+	if err != nil {
+		t.Fatalf("SendReply err: %v", err)
+	}
+	if len(refs) != 2 || refs[0] != "m1" || refs[1] != "" {
+		t.Fatalf("unexpected reply targets: %#v", refs)
+	}
+}
@@ -351,6 +351,10 @@ type UserContentPartsOptions struct {
 	KeepEmptyWhenNoImages bool
 	Download              llm.DownloadFunc
 	Location              *time.Location
+
+	// TranscribeVoice transcribes a voice message's audio. When set, voice
+	// messages without a stored transcript are transcribed on the fly.
+	TranscribeVoice func(ctx context.Context, m sdkapi.ChannelMessage, audio []byte) (string, error)
 }
 
 func BuildL5MessagesWithAttachments(ctx context.Context, sessionMsgs []sdkapi.ChannelMessage, botUserID string, opts UserContentPartsOptions) ([]openaigo.ChatCompletionMessageParamUnion, error) {
@@ -381,13 +385,24 @@ func BuildL5MessagesWithAttachments(ctx context.Context, sessionMsgs []sdkapi.Ch
 			attachments = append(attachments, m.Attachments...)
 			attachments = append(attachments, llm.StickerAttachmentsFromPayload(m.ChannelID, m.Payload)...)
 
+			var transcribe llm.TranscribeFunc
+			if voice, ok := m.Voice(); ok && opts.TranscribeVoice != nil && strings.TrimSpace(m.PlainText) == "" {
+				attachments = append(attachments, voice)
+				msg := m
+				transcribe = func(ctx context.Context, _ sdkapi.AttachmentRef, audio []byte) (string, error) {
+					return opts.TranscribeVoice(ctx, msg, audio)
+				}
+			}
+
 			userMsg, err := llm.BuildUserMessageParam(ctx, m.AuthorUsername(), m.AuthorID(), m.CreatedAt, strings.TrimSpace(m.ContextText()), attachments, llm.BuildUserContentOptions{
-				DefaultImagePrompt:    opts.DefaultImagePrompt,
-				MaxImageBytes:         opts.MaxImageBytes,
-				MaxTotalImageBytes:    opts.MaxTotalImageBytes,
-				KeepEmptyWhenNoImages: opts.KeepEmptyWhenNoImages,
-				Download:              opts.Download,
-				SpeakerMeta:           SpeakerMetaFuncInLocation(opts.Location),
+				DefaultImagePrompt:        opts.DefaultImagePrompt,
+				MaxImageBytes:             opts.MaxImageBytes,
+				MaxTotalImageBytes:        opts.MaxTotalImageBytes,
+				KeepEmptyWhenNoImages:     opts.KeepEmptyWhenNoImages,
+				Download:                  opts.Download,
+				SpeakerMeta:               SpeakerMetaFuncInLocation(opts.Location),
+				Transcribe:                transcribe,
+				ReplaceTextWithTranscript: transcribe != nil,
 			})
 			if err != nil {
 				return nil, err
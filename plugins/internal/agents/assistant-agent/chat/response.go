@@ -1,15 +1,11 @@
 package chat
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"math/rand/v2"
-	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -131,6 +127,10 @@ type SendEvent struct {
 	// Immediate skips typing simulation + inter-line delay for this event.
 	// Useful for "tool prelude" messages that should appear instantly.
 	Immediate bool
+
+	// ReplyTo threads this text event under the given message ID, overriding
+	// TransportContext.ReplyTo.
+	ReplyTo string
 }
 
 type TransportContext struct {
@@ -139,10 +139,13 @@ type TransportContext struct {
 	UserID    string
 	LogPrefix string
 	TypingWPM int
+	// ReplyTo threads the first text line of a send under this message ID
+	// (typically the triggering message in guild channels).
+	ReplyTo string
 	// Sleep overrides SleepWithContext for delay simulation (useful for tests).
 	Sleep func(ctx context.Context, d time.Duration)
 
-	PostMessageHTTP        func(ctx context.Context, channelID, content string) error
+	PostMessageHTTP        func(ctx context.Context, channelID, content, replyTo string) error
 	PostStickerHTTP        func(ctx context.Context, channelID, stickerID string) error
 	SendVoiceHTTP          func(ctx context.Context, channelID, text string) error
 	SendVoicePreparedHTTP  func(ctx context.Context, channelID string, v PreparedVoice) error
@@ -407,7 +410,7 @@ func SendToolPrelude(
 		if c.PostMessageHTTP == nil {
 			return fmt.Errorf("send tool prelude failed (gateway=%v http=%v)", sendErr, fmt.Errorf("postMessageHTTP not configured"))
 		}
-		if err := c.PostMessageHTTP(ctx, c.ChannelID, text); err != nil {
+		if err := c.PostMessageHTTP(ctx, c.ChannelID, text, ""); err != nil {
 			return fmt.Errorf("send tool prelude failed (gateway=%v http=%v)", sendErr, err)
 		}
 		log.Printf("%s gateway send prelude failed, fallback to http ok: channel=%s user=%s err=%v", c.LogPrefix, c.ChannelID, c.UserID, sendErr)
@@ -500,6 +503,10 @@ func BuildSendEvents(reply string, controls ReplyControls) []SendEvent {
 	return events
 }
 
+// SendEvents sends a parsed reply as separate messages with simulated typing
+// delays. It deliberately does not use sdk.LiveMessage: the persona relies on
+// several short messages interleaved with voice/stickers, and the reply
+// controls are only known once the whole completion has been parsed.
 func SendEvents(ctx context.Context, c TransportContext, events []SendEvent) error {
 	if len(events) == 0 {
 		log.Printf("%s empty reply: channel=%s user=%s", c.LogPrefix, c.ChannelID, c.UserID)
@@ -582,6 +589,7 @@ func SendEvents(ctx context.Context, c TransportContext, events []SendEvent) err
 		sleep = SleepWithContext
 	}
 
+	replyTo := strings.TrimSpace(c.ReplyTo)
 	linesSent := 0
 	for i, ev := range events {
 		select {
@@ -638,21 +646,30 @@ func SendEvents(ctx context.Context, c TransportContext, events []SendEvent) err
 			default:
 			}
 
+			ref := strings.TrimSpace(ev.ReplyTo)
+			if ref == "" {
+				ref, replyTo = replyTo, ""
+			}
+
 			var sendErr error
 			if c.Emit == nil {
 				sendErr = fmt.Errorf("emit not configured")
 			} else {
-				sendErr = c.Emit(infra.AssistantUpstreamMessageCreate, map[string]any{
+				body := map[string]any{
 					"channelId": c.ChannelID,
 					"content":   t,
-				})
+				}
+				if ref != "" {
+					body["referencedMessageId"] = ref
+				}
+				sendErr = c.Emit(infra.AssistantUpstreamMessageCreate, body)
 			}
 			if sendErr != nil {
 				// Fallback: if the gateway is disconnected between reply generation and send, use REST API.
 				if c.PostMessageHTTP == nil {
 					return fmt.Errorf("send message failed (gateway=%v http=%v)", sendErr, fmt.Errorf("postMessageHTTP not configured"))
 				}
-				if err := c.PostMessageHTTP(ctx, c.ChannelID, t); err != nil {
+				if err := c.PostMessageHTTP(ctx, c.ChannelID, t, ref); err != nil {
 					return fmt.Errorf("send message failed (gateway=%v http=%v)", sendErr, err)
 				}
 				log.Printf("%s gateway send failed, fallback to http ok: channel=%s user=%s err=%v", c.LogPrefix, c.ChannelID, c.UserID, sendErr)
@@ -670,81 +687,27 @@ func SendEvents(ctx context.Context, c TransportContext, events []SendEvent) err
 }
 
 func PostMessageHTTP(c infra.MewCallContext, channelID, content string) error {
-	ctx := infra.ContextOrBackground(c.Ctx)
-
-	if c.HTTPClient == nil {
-		return fmt.Errorf("missing mew http client")
-	}
-	if strings.TrimSpace(c.APIBase) == "" {
-		return fmt.Errorf("missing api base")
+	if c.API == nil {
+		return fmt.Errorf("missing mew api client")
 	}
-	channelID = strings.TrimSpace(channelID)
-	if channelID == "" {
-		return fmt.Errorf("missing channel id")
-	}
-
-	u := strings.TrimRight(c.APIBase, "/") + "/channels/" + url.PathEscape(channelID) + "/messages"
-	body, _ := json.Marshal(map[string]any{"content": content})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	_, err := c.API.Messages.SendText(infra.ContextOrBackground(c.Ctx), channelID, content)
+	return err
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-		return fmt.Errorf("status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(b)))
+// ReplyMessageHTTP posts content threaded under replyTo; an empty replyTo
+// sends a plain message.
+func ReplyMessageHTTP(c infra.MewCallContext, channelID, replyTo, content string) error {
+	if c.API == nil {
+		return fmt.Errorf("missing mew api client")
 	}
-	return nil
+	_, err := c.API.Messages.Reply(infra.ContextOrBackground(c.Ctx), channelID, replyTo, content)
+	return err
 }
 
 func PostStickerHTTP(c infra.MewCallContext, channelID, stickerID string) error {
-	ctx := infra.ContextOrBackground(c.Ctx)
-
-	if c.HTTPClient == nil {
-		return fmt.Errorf("missing mew http client")
-	}
-	if strings.TrimSpace(c.APIBase) == "" {
-		return fmt.Errorf("missing api base")
-	}
-	channelID = strings.TrimSpace(channelID)
-	if channelID == "" {
-		return fmt.Errorf("missing channel id")
-	}
-	stickerID = strings.TrimSpace(stickerID)
-	if stickerID == "" {
-		return fmt.Errorf("missing sticker id")
-	}
-
-	u := strings.TrimRight(c.APIBase, "/") + "/channels/" + url.PathEscape(channelID) + "/messages"
-	body, _ := json.Marshal(map[string]any{
-		"type": "message/sticker",
-		"payload": map[string]any{
-			"stickerId":    stickerID,
-			"stickerScope": "user",
-		},
-	})
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return err
+	if c.API == nil {
+		return fmt.Errorf("missing mew api client")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		b, _ := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-		return fmt.Errorf("status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(b)))
-	}
-	return nil
+	_, err := c.API.Messages.SendSticker(infra.ContextOrBackground(c.Ctx), channelID, stickerID)
+	return err
 }
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"log"
@@ -30,39 +31,37 @@ func (r *Runner) handleMessageCreate(
 	if err := json.Unmarshal(payload, &msg); err != nil {
 		return err
 	}
+	return r.handleMessage(ctx, logPrefix, msg, emit)
+}
+
+// handleMessage routes one incoming message and submits it to its
+// conversation. emit may be nil (catch-up); replies then go over REST.
+func (r *Runner) handleMessage(
+	ctx context.Context,
+	logPrefix string,
+	msg sdkapi.ChannelMessage,
+	emit socketio.EmitFunc,
+) error {
 	if strings.TrimSpace(msg.ChannelID) == "" || strings.TrimSpace(msg.ID) == "" {
 		return nil
 	}
-	if r.isOwnAuthor(msg.AuthorRaw) {
+
+	// Catch-up runs while the gateway is already delivering, so the same
+	// message can arrive through both.
+	if !r.handled.TryAdd(msg.ID) {
 		return nil
 	}
 
 	channelID := msg.ChannelID
-	trimmed := strings.TrimSpace(msg.Content)
-	rest, mentioned := socketio.StripLeadingBotMention(trimmed, r.botUserID)
-
-	isDM := r.isDMChannel(channelID)
-
-	// If it's neither a known DM channel nor a leading-mention in a guild channel, it might be a newly created DM.
-	if !isDM && !mentioned {
-		if err := r.refreshDMChannels(ctx); err != nil {
-			return nil
-		}
-		isDM = r.isDMChannel(channelID)
-		if !isDM {
-			return nil
-		}
-	}
-
-	// In guild channels, only reply when directly @mentioned at the beginning.
-	if !isDM && !mentioned {
+	addr, err := r.router.Route(ctx, msg)
+	if err != nil || !addr.Addressed {
 		return nil
 	}
 
-	// Strip leading mention for both guild + DM (if present), to keep the user prompt clean.
-	if mentioned {
-		msg.Content = rest
-		msg.Context = rest
+	// Keep the user prompt clean of bot mentions / name prefixes.
+	if addr.Text != msg.ContextText() {
+		msg.Content = addr.Text
+		msg.Context = addr.Text
 	}
 
 	userID := msg.AuthorID()
@@ -71,7 +70,7 @@ func (r *Runner) handleMessageCreate(
 	}
 
 	mode := "DM"
-	if !isDM {
+	if !r.dmChannels.Has(channelID) {
 		mode = "CHANNEL"
 	}
 	log.Printf("%s %s MESSAGE_CREATE: channel=%s msg=%s user=%s content=%q",
@@ -89,9 +88,15 @@ func (r *Runner) handleMessageCreate(
 
 	r.conversations.get(channelID, userID).Submit(chat.ConversationRequest{
 		Ctx:       ctx,
-		Transport: r.buildChatTransport(r.newRequestContext(ctx, userID, channelID, logPrefix), emit),
+		Transport: r.buildChatTransport(r.newRequestContext(ctx, userID, channelID, logPrefix), emit, addr.ReplyTo),
 		Run: func(runCtx context.Context, send func([]chat.SendEvent), prelude func(string)) error {
-			if err := r.processDMMessage(runCtx, logPrefix, msg, send, prelude); err != nil {
+			err := r.processDMMessage(runCtx, logPrefix, msg, send, prelude)
+			// Ack even on failure: errors are reported in-channel and not retried,
+			// so the message should not come back on the next startup catch-up.
+			if runCtx.Err() == nil {
+				r.ackMessage(ctx, logPrefix, channelID, msg.ID)
+			}
+			if err != nil {
 				if runCtx.Err() != nil {
 					return nil
 				}
@@ -103,14 +108,6 @@ func (r *Runner) handleMessageCreate(
 	return nil
 }
 
-func (r *Runner) isOwnAuthor(authorRaw json.RawMessage) bool {
-	return sdk.IsOwnMessage(authorRaw, r.botUserID)
-}
-
-func (r *Runner) isDMChannel(channelID string) bool {
-	return r.dmChannels.Has(channelID)
-}
-
 func (r *Runner) shouldOnDemandRemember(userContent string) bool {
 	s := strings.TrimSpace(userContent)
 	if s == "" {
@@ -356,6 +353,10 @@ func (r *Runner) buildPrompt(
 			httpClient := r.session.HTTPClient()
 			return attachment.DownloadAttachmentBytes(ctx, httpClient, httpClient, r.apiBase, "", att, limit)
 		},
+		TranscribeVoice: func(ctx context.Context, m sdkapi.ChannelMessage, audio []byte) (string, error) {
+			voice, _ := m.Voice()
+			return r.session.API().Messages.Transcribe(ctx, m.ChannelID, m.ID, voice.Filename, voice.ContentType, bytes.NewReader(audio))
+		},
 	})
 	if err != nil {
 		log.Printf("%s build L5 with attachments failed (fallback to text-only): %v", c.LogPrefix, err)
@@ -367,6 +368,20 @@ func (r *Runner) buildPrompt(
 	return l1l4, l5
 }
 
+// sharedServerIDs lists the servers both the bot and userID are members of.
+func (r *Runner) sharedServerIDs(ctx context.Context, userID string) []string {
+	if r.directory == nil || strings.TrimSpace(userID) == "" {
+		return nil
+	}
+	var ids []string
+	for _, srv := range r.directory.Servers() {
+		if _, ok, err := r.directory.Member(ctx, srv.ID, userID); err == nil && ok {
+			ids = append(ids, srv.ID)
+		}
+	}
+	return ids
+}
+
 func (r *Runner) reply(
 	c infra.AssistantRequestContext,
 	l1l4 string,
@@ -374,8 +389,15 @@ func (r *Runner) reply(
 	onToolPrelude func(text string) error,
 ) (reply string, finalMood memory.Mood, gotMood bool, err error) {
 	handlers := chat.ToolHandlers{
-		HistorySearch: func(ctx context.Context, keyword string) (any, error) {
-			return tools.RunHistorySearch(c.History.WithCtx(ctx), c.ChannelID, keyword)
+		HistorySearch: func(ctx context.Context, args chat.HistorySearchArgs) (any, error) {
+			target := tools.HistorySearchTarget{ChannelID: c.ChannelID, UserID: c.UserID, BotUserID: r.botUserID}
+			if args.Scope == "all" && r.directory != nil {
+				target.ServerIDs = r.sharedServerIDs(ctx, c.UserID)
+				target.CanView = func(ctx context.Context, channelID string) (bool, error) {
+					return r.directory.CanView(ctx, channelID, c.UserID)
+				}
+			}
+			return tools.RunHistorySearch(c.History.WithCtx(ctx), target, args)
 		},
 		RecordSearch: func(ctx context.Context, recordID string) (any, error) {
 			return tools.RunRecordSearch(c.History.WithCtx(ctx), c.ChannelID, recordID)
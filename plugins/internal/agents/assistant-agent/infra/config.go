@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"mew/plugins/pkg"
 	"mew/plugins/pkg/x/llm"
 )
 
@@ -30,12 +31,33 @@ type UserConfig struct {
 type ToolConfig struct {
 	ExaAPIKey          string `json:"exa_api_key"`
 	HobbyistTTSToken   string `json:"hobbyist_tts_token"`
+
+	// TTSBackend selects the Voice backend: "server" (the MEW server's TTS
+	// module) or "hobbyist". Empty means hobbyist when hobbyist_tts_token is
+	// set, server otherwise.
+	TTSBackend string `json:"tts_backend"`
+	// TTSModel and TTSVoice are passed to the server backend; empty uses the
+	// server defaults.
+	TTSModel string `json:"tts_model"`
+	TTSVoice string `json:"tts_voice"`
+}
+
+func (c ToolConfig) VoiceBackend() string {
+	if b := strings.ToLower(strings.TrimSpace(c.TTSBackend)); b != "" {
+		return b
+	}
+	if strings.TrimSpace(c.HobbyistTTSToken) != "" {
+		return TTSBackendHobbyist
+	}
+	return TTSBackendServer
 }
 
 type AssistantConfig struct {
 	ChatModel ChatModelConfig `json:"chat_model"`
 	User      UserConfig      `json:"user"`
 	Tool      ToolConfig      `json:"tool"`
+
+	Addressing sdk.AddressingConfig `json:"addressing"`
 }
 
 func (c AssistantConfig) OpenAIChatConfig() (llm.OpenAIChatConfig, error) {
@@ -66,6 +66,14 @@ const (
 	AssistantIncomingQueueSize = 256
 	AssistantWorkerCount       = 4
 
+	// AssistantCatchUpMaxMessages caps how far back the startup catch-up
+	// looks past the last ack in each DM.
+	AssistantCatchUpMaxMessages = 50
+
+	// AssistantHandledMessageMemory is how many recent message IDs are kept
+	// to stop catch-up and the live gateway from answering the same message.
+	AssistantHandledMessageMemory = 1024
+
 	AssistantSessionGap = 30 * time.Minute
 
 	AssistantLogPrefix = "[assistant-agent]"
@@ -111,6 +119,11 @@ const (
 	ExaSearchEndpoint = "https://api.exa.ai/search"
 	HobbyistTTSEndpoint = "https://gsv2p.acgnai.top/infer_single"
 
+	TTSBackendServer   = "server"
+	TTSBackendHobbyist = "hobbyist"
+
+	MaxVoiceAudioBytes int64 = 20 * 1024 * 1024
+
 	DefaultBaselineValence = 0.2
 	DefaultBaselineArousal = 0.1
 	MoodDecayKPerHour      = 0.25
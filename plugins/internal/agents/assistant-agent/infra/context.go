@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"mew/plugins/pkg/api/history"
+	"mew/plugins/pkg/api/rest"
 )
 
 // AssistantRequestContext is a per-request/per-run bundle for assistant-agent.
@@ -43,6 +44,9 @@ type MewCallContext struct {
 	Ctx        context.Context
 	HTTPClient *http.Client
 	APIBase    string
+
+	// API is the bot session's typed REST client.
+	API *rest.Client
 }
 
 func (c MewCallContext) WithCtx(ctx context.Context) MewCallContext {
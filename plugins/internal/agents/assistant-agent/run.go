@@ -46,6 +46,21 @@ func Run() error {
 				"desc":     "Hobbyist TTS token (optional)",
 				"required": false,
 			},
+			"tts_backend": map[string]any{
+				"type":     "string",
+				"desc":     "Voice backend: server or hobbyist. Empty means hobbyist when hobbyist_tts_token is set, otherwise server",
+				"required": false,
+			},
+			"tts_model": map[string]any{
+				"type":     "string",
+				"desc":     "Server TTS model (namiai or qwen3-tts). Empty means server default",
+				"required": false,
+			},
+			"tts_voice": map[string]any{
+				"type":     "string",
+				"desc":     "Server TTS voice. Empty means server default",
+				"required": false,
+			},
 		},
 	})
 	if err != nil {
@@ -4,10 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -24,6 +22,7 @@ import (
 	"mew/plugins/pkg/api/gateway/socketio"
 	"mew/plugins/pkg/api/history"
 	"mew/plugins/pkg/api/messages"
+	"mew/plugins/pkg/api/tts"
 )
 
 type Runner struct {
@@ -46,8 +45,14 @@ type Runner struct {
 	persona  string
 
 	dmChannels *sdk.DMChannelCache
+	directory  *sdk.Directory
+	router     *sdk.MessageRouter
 	fetcher    *history.Fetcher
 
+	// handled holds the IDs of messages already submitted, shared by the
+	// gateway workers and the startup catch-up so neither answers twice.
+	handled *sdk.SeenSet
+
 	userMu   sync.Mutex
 	userLock map[string]*sync.Mutex
 
@@ -55,6 +60,7 @@ type Runner struct {
 	knownUsers   map[string]struct{}
 
 	stickers *tools.StickerService
+	voice    tts.VoiceSynthesizer
 
 	conversations *conversationManager
 }
@@ -88,15 +94,16 @@ func (m *conversationManager) get(channelID, userID string) *chat.ConversationCo
 	return c
 }
 
-func (r *Runner) buildChatTransport(c infra.AssistantRequestContext, emit socketio.EmitFunc) chat.TransportContext {
+func (r *Runner) buildChatTransport(c infra.AssistantRequestContext, emit socketio.EmitFunc, replyTo string) chat.TransportContext {
 	return chat.TransportContext{
 		Emit:      emit,
 		ChannelID: c.ChannelID,
 		UserID:    c.UserID,
 		LogPrefix: c.LogPrefix,
 		TypingWPM: infra.AssistantTypingWPMDefault,
-		PostMessageHTTP: func(ctx context.Context, channelID, content string) error {
-			return chat.PostMessageHTTP(c.Mew.WithCtx(ctx), channelID, content)
+		ReplyTo:   replyTo,
+		PostMessageHTTP: func(ctx context.Context, channelID, content, replyTo string) error {
+			return chat.ReplyMessageHTTP(c.Mew.WithCtx(ctx), channelID, replyTo, content)
 		},
 		PostStickerHTTP: func(ctx context.Context, channelID, stickerID string) error {
 			return chat.PostStickerHTTP(c.Mew.WithCtx(ctx), channelID, stickerID)
@@ -114,40 +121,15 @@ func (r *Runner) buildChatTransport(c infra.AssistantRequestContext, emit socket
 }
 
 func (r *Runner) prepareVoiceAudio(c infra.AssistantRequestContext, ctx context.Context, text string) (chat.PreparedVoice, error) {
-	audioURL, err := tools.RunHobbyistTTS(c.LLM.WithCtx(ctx), text)
-	if err != nil {
-		return chat.PreparedVoice{}, err
+	if r.voice == nil {
+		return chat.PreparedVoice{}, fmt.Errorf("voice synthesizer not initialized")
 	}
-
-	downloadClient := c.LLM.HTTPClient
-	if downloadClient == nil {
-		downloadClient = http.DefaultClient
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	audio, err := r.voice.Synthesize(ctx, text)
 	if err != nil {
 		return chat.PreparedVoice{}, err
 	}
-	resp, err := downloadClient.Do(req)
-	if err != nil {
-		return chat.PreparedVoice{}, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return chat.PreparedVoice{}, fmt.Errorf("tts audio download status=%d", resp.StatusCode)
-	}
-
-	filename := "voice.wav"
-	if u, err := url.Parse(audioURL); err == nil && u != nil {
-		if b := strings.TrimSpace(path.Base(u.Path)); b != "" && b != "." && b != "/" {
-			filename = b
-		}
-	}
-	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
-	if contentType == "" {
-		contentType = "audio/wav"
-	}
 
+	filename := audio.DefaultFilename()
 	tmpPattern := "mew-tts-*"
 	if ext := strings.TrimSpace(path.Ext(filename)); ext != "" {
 		tmpPattern = "mew-tts-*" + ext
@@ -159,7 +141,7 @@ func (r *Runner) prepareVoiceAudio(c infra.AssistantRequestContext, ctx context.
 	defer func() {
 		_ = tmp.Close()
 	}()
-	if _, err := io.Copy(tmp, resp.Body); err != nil {
+	if _, err := tmp.Write(audio.Data); err != nil {
 		_ = os.Remove(tmp.Name())
 		return chat.PreparedVoice{}, err
 	}
@@ -171,11 +153,27 @@ func (r *Runner) prepareVoiceAudio(c infra.AssistantRequestContext, ctx context.
 	return chat.PreparedVoice{
 		TempPath:    tmp.Name(),
 		Filename:    filename,
-		ContentType: contentType,
+		ContentType: audio.ContentType,
 		PlainText:   text,
 	}, nil
 }
 
+// newVoiceSynthesizer picks the Voice backend from tool config. Needs the
+// bot session for the server backend.
+func (r *Runner) newVoiceSynthesizer() tts.VoiceSynthesizer {
+	var synth tts.VoiceSynthesizer
+	switch r.aiConfig.Tool.VoiceBackend() {
+	case infra.TTSBackendHobbyist:
+		synth = tools.HobbyistSynthesizer{LLM: infra.LLMCallContext{HTTPClient: r.llmHTTPClient, Config: r.aiConfig}}
+	default:
+		synth = tts.NewServerSynthesizer(r.session.API(), tts.ServerOptions{
+			Model: strings.TrimSpace(r.aiConfig.Tool.TTSModel),
+			Voice: strings.TrimSpace(r.aiConfig.Tool.TTSVoice),
+		})
+	}
+	return tts.NewCache(synth, 0)
+}
+
 func (r *Runner) sendPreparedVoiceHTTP(c infra.AssistantRequestContext, ctx context.Context, channelID string, v chat.PreparedVoice) error {
 	uploadClient := c.Mew.HTTPClient
 	if uploadClient == nil {
@@ -324,6 +322,7 @@ func NewAssistantRunner(opts RunnerOptions) (*Runner, error) {
 		timeLoc:       timeLoc,
 		persona:       persona,
 		dmChannels:    sdk.NewDMChannelCache(),
+		handled:       sdk.NewSeenSet(infra.AssistantHandledMessageMemory),
 		stickers:      tools.NewStickerService(),
 		fetcher: &history.Fetcher{
 			HTTPClient:         mewHTTPClient,
@@ -354,12 +353,18 @@ func (r *Runner) Run(ctx context.Context) error {
 		r.fetcher.HTTPClient = authed
 	}
 	r.fetcher.UserToken = ""
+	r.voice = r.newVoiceSynthesizer()
 
 	r.loadKnownUsersFromDisk(logPrefix)
 
 	if err := r.refreshDMChannels(ctx); err != nil {
 		log.Printf("%s refresh DM channels failed (will retry later): %v", logPrefix, err)
 	}
+	r.directory = sdk.NewDirectory(r.session)
+	if err := r.directory.Refresh(ctx); err != nil {
+		log.Printf("%s load directory failed (will retry on demand): %v", logPrefix, err)
+	}
+	r.router = sdk.NewMessageRouter(r.session, r.dmChannels, r.aiConfig.Addressing.Policy(r.botUserID)).UseDirectory(r.directory)
 
 	type messageCreateJob struct {
 		payload json.RawMessage
@@ -386,6 +391,9 @@ func (r *Runner) Run(ctx context.Context) error {
 			}
 		})
 	}
+	g.Go(func(ctx context.Context) {
+		r.catchUpUnread(ctx, logPrefix)
+	})
 	g.Go(func(ctx context.Context) {
 		sdk.RunInterval(ctx, 5*time.Minute, true, func(ctx context.Context) {
 			r.runPeriodicFactEngine(ctx, logPrefix)
@@ -399,6 +407,7 @@ func (r *Runner) Run(ctx context.Context) error {
 	})
 	g.Go(func(ctx context.Context) {
 		err := socketio.RunGatewayWithReconnectSession(ctx, r.wsURL, r.session, func(ctx context.Context, eventName string, payload json.RawMessage, emit socketio.EmitFunc) error {
+			r.directory.HandleEvent(eventName, payload)
 			if eventName != infra.AssistantEventMessageCreate {
 				return nil
 			}
@@ -449,6 +458,7 @@ func (r *Runner) newRequestContext(ctx context.Context, userID, channelID, logPr
 			Ctx:        ctx,
 			HTTPClient: r.session.HTTPClient(),
 			APIBase:    r.apiBase,
+			API:        r.session.API(),
 		},
 		History: infra.HistoryCallContext{
 			Ctx:     ctx,
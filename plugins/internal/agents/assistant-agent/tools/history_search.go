@@ -1,35 +1,85 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"mew/plugins/internal/agents/assistant-agent/chat"
 	"mew/plugins/internal/agents/assistant-agent/infra"
+	"mew/plugins/pkg/api/rest"
 )
 
-func RunHistorySearch(c infra.HistoryCallContext, channelID, keyword string) (any, error) {
+// HistorySearchLimit caps the merged HistorySearch results.
+const HistorySearchLimit = 10
+
+// HistorySearchTarget is where a HistorySearch call may look. ServerIDs are
+// only searched for scope "all", and only when CanView is set: server search
+// runs with the bot's token, so every hit is checked against what UserID may
+// read before it is returned.
+type HistorySearchTarget struct {
+	ChannelID string
+	UserID    string
+	BotUserID string
+	ServerIDs []string
+	CanView   func(ctx context.Context, channelID string) (bool, error)
+}
+
+func RunHistorySearch(c infra.HistoryCallContext, target HistorySearchTarget, args chat.HistorySearchArgs) (any, error) {
 	ctx := infra.ContextOrBackground(c.Ctx)
 
-	keyword = strings.TrimSpace(keyword)
+	keyword := strings.TrimSpace(args.Keyword)
 	if keyword == "" {
 		return map[string]any{"messages": []any{}}, nil
 	}
 	if c.Fetcher == nil {
 		return nil, fmt.Errorf("history fetcher not configured")
 	}
-	msgs, err := c.Fetcher.SearchHistory(ctx, channelID, keyword, 10)
+	q, err := historySearchQuery(target, args, c.TimeLoc)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := c.Fetcher.Search(ctx, rest.SearchScope{ChannelID: target.ChannelID}, q)
 	if err != nil {
 		return nil, err
 	}
+	if args.Scope == "all" && target.CanView != nil {
+		visible := map[string]bool{}
+		for _, serverID := range target.ServerIDs {
+			more, err := c.Fetcher.Search(ctx, rest.SearchScope{ServerID: serverID}, q)
+			if err != nil {
+				// A server we cannot search should not hide the DM results.
+				continue
+			}
+			for _, m := range more {
+				ok, seen := visible[m.ChannelID]
+				if !seen {
+					// Permission lookups that fail hide the hit.
+					ok, _ = target.CanView(ctx, m.ChannelID)
+					visible[m.ChannelID] = ok
+				}
+				if ok {
+					msgs = append(msgs, m)
+				}
+			}
+		}
+		sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].CreatedAt.After(msgs[j].CreatedAt) })
+		if len(msgs) > HistorySearchLimit {
+			msgs = msgs[:HistorySearchLimit]
+		}
+	}
 
 	out := make([]map[string]any, 0, len(msgs))
 	for _, m := range msgs {
-		rid, err := c.Fetcher.RecordIDForMessage(ctx, channelID, m.ID)
+		// Session records only exist for the current channel.
 		recordID := ""
-		if err == nil {
-			recordID = rid
+		if m.ChannelID == "" || m.ChannelID == target.ChannelID {
+			if rid, err := c.Fetcher.RecordIDForMessage(ctx, target.ChannelID, m.ID); err == nil {
+				recordID = rid
+			}
 		}
 
 		createdAt := m.CreatedAt
@@ -38,6 +88,7 @@ func RunHistorySearch(c infra.HistoryCallContext, channelID, keyword string) (an
 		}
 		out = append(out, map[string]any{
 			"id":        m.ID,
+			"channelId": m.ChannelID,
 			"createdAt": createdAt.Format(time.RFC3339),
 			"authorId":  m.AuthorID(),
 			"author":    m.AuthorUsername(),
@@ -48,6 +99,43 @@ func RunHistorySearch(c infra.HistoryCallContext, channelID, keyword string) (an
 	return map[string]any{"keyword": keyword, "messages": out}, nil
 }
 
+// historySearchQuery maps the tool arguments onto a search query. Dates are
+// "YYYY-MM-DD" days in loc; both ends are inclusive.
+func historySearchQuery(target HistorySearchTarget, args chat.HistorySearchArgs, loc *time.Location) (rest.SearchQuery, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	q := rest.SearchQuery{
+		Query:         strings.TrimSpace(args.Keyword),
+		HasAttachment: args.HasAttachment,
+		Limit:         HistorySearchLimit,
+	}
+	switch args.From {
+	case "", "any", "all":
+	case "user":
+		q.AuthorID = target.UserID
+	case "me", "bot":
+		q.AuthorID = target.BotUserID
+	default:
+		return rest.SearchQuery{}, fmt.Errorf("invalid from %q (want user or me)", args.From)
+	}
+	if args.After != "" {
+		day, err := time.ParseInLocation("2006-01-02", args.After, loc)
+		if err != nil {
+			return rest.SearchQuery{}, fmt.Errorf("invalid after %q (want YYYY-MM-DD)", args.After)
+		}
+		q.After = day
+	}
+	if args.Before != "" {
+		day, err := time.ParseInLocation("2006-01-02", args.Before, loc)
+		if err != nil {
+			return rest.SearchQuery{}, fmt.Errorf("invalid before %q (want YYYY-MM-DD)", args.Before)
+		}
+		q.Before = day.AddDate(0, 0, 1)
+	}
+	return q, nil
+}
+
 func RunRecordSearch(c infra.HistoryCallContext, channelID, recordID string) (any, error) {
 	ctx := infra.ContextOrBackground(c.Ctx)
 
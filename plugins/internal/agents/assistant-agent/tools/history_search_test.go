@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"mew/plugins/internal/agents/assistant-agent/chat"
+)
+
+func TestHistorySearchQuery(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	target := HistorySearchTarget{ChannelID: "dm1", UserID: "u1", BotUserID: "bot"}
+
+	q, err := historySearchQuery(target, chat.HistorySearchArgs{Keyword: " 火锅 ", From: "user", After: "2026-01-02", Before: "2026-01-03"}, loc)
+	if err != nil {
+		t.Fatalf("historySearchQuery: %v", err)
+	}
+	if q.Query != "火锅" || q.AuthorID != "u1" || q.Limit != HistorySearchLimit {
+		t.Fatalf("unexpected query: %+v", q)
+	}
+	if want := time.Date(2026, 1, 2, 0, 0, 0, 0, loc); !q.After.Equal(want) {
+		t.Fatalf("after = %v, want %v", q.After, want)
+	}
+	if want := time.Date(2026, 1, 4, 0, 0, 0, 0, loc); !q.Before.Equal(want) {
+		t.Fatalf("before should include the whole day: %v, want %v", q.Before, want)
+	}
+
+	if q, _ := historySearchQuery(target, chat.HistorySearchArgs{Keyword: "x", From: "me"}, loc); q.AuthorID != "bot" {
+		t.Fatalf("from=me should search the bot's messages: %+v", q)
+	}
+	if _, err := historySearchQuery(target, chat.HistorySearchArgs{Keyword: "x", After: "yesterday"}, loc); err == nil {
+		t.Fatalf("expected an error for a malformed date")
+	}
+}
@@ -2,13 +2,17 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
 
 	"mew/plugins/internal/agents/assistant-agent/infra"
+	"mew/plugins/pkg/api/tts"
 )
 
 const hobbyistTTSUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:146.0) Gecko/20100101 Firefox/146.0"
@@ -118,3 +122,53 @@ func RunHobbyistTTS(c infra.LLMCallContext, text string) (string, error) {
 	}
 	return strings.TrimSpace(out.AudioURL), nil
 }
+
+// HobbyistSynthesizer adapts RunHobbyistTTS to tts.VoiceSynthesizer by
+// downloading the returned audio URL.
+type HobbyistSynthesizer struct {
+	LLM infra.LLMCallContext
+}
+
+func (s HobbyistSynthesizer) Synthesize(ctx context.Context, text string) (tts.Audio, error) {
+	audioURL, err := RunHobbyistTTS(s.LLM.WithCtx(ctx), text)
+	if err != nil {
+		return tts.Audio{}, err
+	}
+
+	downloadClient := s.LLM.HTTPClient
+	if downloadClient == nil {
+		downloadClient = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	if err != nil {
+		return tts.Audio{}, err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return tts.Audio{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return tts.Audio{}, fmt.Errorf("tts audio download status=%d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, infra.MaxVoiceAudioBytes+1))
+	if err != nil {
+		return tts.Audio{}, err
+	}
+	if int64(len(data)) > infra.MaxVoiceAudioBytes {
+		return tts.Audio{}, fmt.Errorf("tts audio too large (>%d bytes)", infra.MaxVoiceAudioBytes)
+	}
+
+	filename := "voice.wav"
+	if u, err := url.Parse(audioURL); err == nil && u != nil {
+		if b := strings.TrimSpace(path.Base(u.Path)); b != "" && b != "." && b != "/" {
+			filename = b
+		}
+	}
+	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = "audio/wav"
+	}
+	return tts.Audio{Data: data, ContentType: contentType, Filename: filename}, nil
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+
+	"mew/plugins/pkg"
+)
+
+var clearCommand = sdk.CommandSpec{
+	Name:        "clear",
+	Description: "清空当前频道的 Claude Code 会话上下文",
+}
+
+// CommandSpecs lists the slash commands registered with the server for autocomplete.
+func CommandSpecs() []sdk.CommandSpec {
+	return []sdk.CommandSpec{clearCommand}
+}
+
+func (r *ClaudeCodeRunner) newCommandRegistry() *sdk.CommandRegistry {
+	reg := sdk.NewCommandRegistry()
+	// /help goes to Claude Code, like other unknown commands.
+	reg.SetHelpCommand("")
+	reg.Handle(clearCommand, func(ctx context.Context, inv sdk.CommandInvocation) (string, error) {
+		r.setChannelContinued(inv.ChannelID, false)
+		return pickRandomClearReply(), nil
+	})
+	return reg
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"mew/plugins/pkg"
 )
 
 const (
@@ -16,6 +18,8 @@ const (
 type ClaudeCodeConfig struct {
 	ProxyBaseURL  string `json:"proxy_base_url"`
 	TimeoutSecond int    `json:"timeout_seconds"`
+
+	Addressing sdk.AddressingConfig `json:"addressing"`
 }
 
 func ParseClaudeCodeConfig(raw string) (ClaudeCodeConfig, error) {
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"mew/plugins/pkg"
+	"mew/plugins/pkg/api/gateway/socketio"
+)
+
+const claudeCodeLivePlaceholder = "⏳ 处理中…"
+
+// liveReply posts a placeholder card as soon as a prompt starts and edits it
+// (throttled by sdk.LiveMessage) while Claude streams the turn's text. The
+// turn's parsed message/create finalizes the card; the next streamed turn gets
+// a card of its own. The first card carries the reply thread; without it, the
+// first emitted message does.
+type liveReply struct {
+	ctx       context.Context
+	logPrefix string
+	channelID string
+	api       *sdk.APIClient
+	emit      socketio.EmitFunc
+	// live is the card of the turn being streamed; nil between turns.
+	live *sdk.LiveMessage
+	// direct disables cards after a placeholder could not be posted.
+	direct bool
+}
+
+func (r *ClaudeCodeRunner) startLiveReply(ctx context.Context, channelID, replyTo string, emit socketio.EmitFunc) *liveReply {
+	lr := &liveReply{ctx: ctx, logPrefix: r.logPrefix, channelID: channelID, api: r.session.API(), emit: emit}
+	if err := lr.start(replyTo); err != nil {
+		lr.emit = threadedEmit(emit, channelID, replyTo)
+	}
+	return lr
+}
+
+func (lr *liveReply) start(replyTo string) error {
+	live := sdk.NewLiveMessage(lr.api, lr.channelID, sdk.LiveMessageOptions{
+		Type:        claudeCodeCardMessageType,
+		Placeholder: claudeCodeLivePlaceholder,
+		ReplyTo:     replyTo,
+		Payload: func(content string) any {
+			return map[string]any{"content": content}
+		},
+	})
+	if err := live.Start(lr.ctx); err != nil {
+		log.Printf("%s live placeholder failed: channel=%s err=%v", lr.logPrefix, lr.channelID, err)
+		lr.direct = true
+		return err
+	}
+	lr.live = live
+	return nil
+}
+
+// Stream shows the text of the turn being streamed, posting a new card when
+// the previous turn was already finalized.
+func (lr *liveReply) Stream(partial string) {
+	if lr.direct || strings.TrimSpace(partial) == "" {
+		return
+	}
+	if lr.live == nil && lr.start("") != nil {
+		return
+	}
+	lr.live.Set(partial)
+}
+
+// Emit finalizes the current card with the next message/create of this
+// channel and passes everything else through.
+func (lr *liveReply) Emit(event string, payload any) error {
+	if lr.live == nil || strings.TrimSpace(event) != "message/create" {
+		return lr.emit(event, payload)
+	}
+	body, ok := payload.(map[string]any)
+	if !ok || strings.TrimSpace(fmt.Sprintf("%v", body["channelId"])) != lr.channelID {
+		return lr.emit(event, payload)
+	}
+	content, _ := body["content"].(string)
+	if strings.TrimSpace(content) == "" {
+		return lr.emit(event, payload)
+	}
+
+	live := lr.live
+	lr.live = nil
+	if _, err := live.Finish(lr.ctx, content); err != nil {
+		log.Printf("%s live placeholder finalize failed, posting instead: channel=%s err=%v", lr.logPrefix, lr.channelID, err)
+		if derr := live.Discard(lr.ctx); derr != nil {
+			log.Printf("%s live placeholder delete failed: channel=%s err=%v", lr.logPrefix, lr.channelID, derr)
+		}
+		return lr.emit(event, payload)
+	}
+	return nil
+}
+
+// Close removes a card nothing finalized, e.g. when the reply consisted
+// only of files.
+func (lr *liveReply) Close() {
+	if lr.live == nil {
+		return
+	}
+	live := lr.live
+	lr.live = nil
+	if err := live.Discard(lr.ctx); err != nil {
+		log.Printf("%s live placeholder delete failed: channel=%s err=%v", lr.logPrefix, lr.channelID, err)
+	}
+}
+
+// Fail settles the current card with an error callout (keeping any streamed
+// text), or posts the failure as a new message between turns.
+func (lr *liveReply) Fail(err error) error {
+	if lr.live == nil {
+		return emitChannelMessage(lr.emit, lr.channelID, fmt.Sprintf("claude-code 调用失败: %v", err))
+	}
+	live := lr.live
+	lr.live = nil
+	_, ferr := live.Fail(lr.ctx, err)
+	return ferr
+}
@@ -3,19 +3,14 @@ package agent
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io"
 	"log"
-	"mime"
-	"mime/multipart"
-	"net/http"
-	"net/textproto"
-	"net/url"
-	"path"
 	"regexp"
 	"strings"
 
+	"mew/plugins/pkg"
 	"mew/plugins/pkg/api/gateway/socketio"
 )
 
@@ -24,13 +19,6 @@ type trailingFileRef struct {
 	Path string
 }
 
-type channelUploadResponse struct {
-	Filename    string `json:"filename"`
-	ContentType string `json:"contentType"`
-	Key         string `json:"key"`
-	Size        int64  `json:"size"`
-}
-
 var (
 	trailingFileRefLinePattern = regexp.MustCompile(`^\s*(\[[^\]\r\n]+\]\(([^)\r\n]+)\)\s*)+$`)
 	trailingFileRefExtract     = regexp.MustCompile(`\[([^\]\r\n]+)\]\(([^)\r\n]+)\)`)
@@ -100,164 +88,50 @@ func (r *ClaudeCodeRunner) emitMessageWithFileRefs(
 }
 
 func (r *ClaudeCodeRunner) sendAttachmentByBytes(ctx context.Context, channelID, filename string, data []byte) error {
-	httpClient := r.session.HTTPClient()
-	if httpClient == nil {
-		return fmt.Errorf("missing session http client")
-	}
-
-	uploaded, err := uploadToChannel(ctx, httpClient, r.apiBase, channelID, filename, data)
-	if err != nil {
-		return err
-	}
-
-	reqBody := map[string]any{
-		"attachments": []map[string]any{
-			{
-				"filename":    uploaded.Filename,
-				"contentType": uploaded.ContentType,
-				"key":         uploaded.Key,
-				"size":        uploaded.Size,
-			},
-		},
-	}
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return err
-	}
-
-	target := strings.TrimRight(r.apiBase, "/") + "/channels/" + url.PathEscape(channelID) + "/messages"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := httpClient.Do(req)
+	api := r.session.API()
+	if api == nil {
+		return fmt.Errorf("missing session api client")
+	}
+	opts := sdk.UploadOptions{}
+	if len(data) > sdk.ChunkedUploadThreshold {
+		// Build artifacts can be large; log progress in 25% steps.
+		step := int64(0)
+		opts.Progress = func(sent, total int64) {
+			if total <= 0 || sent*4/total <= step {
+				return
+			}
+			step = sent * 4 / total
+			log.Printf("%s attachment upload progress: channel=%s name=%q %d/%d bytes",
+				r.logPrefix, channelID, filename, sent, total)
+		}
+		// Sending the same artifact to the same channel again (e.g. after a
+		// restart or a failed attempt) continues the earlier upload.
+		sum := sha256.Sum256(data)
+		opts.Store = sdk.NewUploadStateStore("")
+		opts.Fingerprint = hex.EncodeToString(sum[:])
+		opts.ResumeID = channelID + ":" + opts.Fingerprint
+	}
+	uploaded, err := api.Uploads.UploadWithOptions(ctx, channelID, filename, "", bytes.NewReader(data), opts)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		msg := strings.TrimSpace(string(raw))
-		if msg == "" {
-			msg = "create attachment message failed"
-		}
-		return fmt.Errorf("status=%d: %s", resp.StatusCode, msg)
-	}
-	return nil
+	_, err = api.Messages.Create(ctx, channelID, sdk.CreateMessage{Attachments: []sdk.UploadedAttachment{uploaded}})
+	return err
 }
 
 func (r *ClaudeCodeRunner) sendCardMessageByAPI(ctx context.Context, channelID, content string) error {
-	httpClient := r.session.HTTPClient()
-	if httpClient == nil {
-		return fmt.Errorf("missing session http client")
-	}
-
-	reqBody := map[string]any{
-		"type":    claudeCodeCardMessageType,
-		"content": content,
-		"payload": map[string]any{
+	api := r.session.API()
+	if api == nil {
+		return fmt.Errorf("missing session api client")
+	}
+	_, err := api.Messages.Create(ctx, channelID, sdk.CreateMessage{
+		Type:    claudeCodeCardMessageType,
+		Content: content,
+		Payload: map[string]any{
 			"content": content,
 		},
-	}
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return err
-	}
-
-	target := strings.TrimRight(r.apiBase, "/") + "/channels/" + url.PathEscape(channelID) + "/messages"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		msg := strings.TrimSpace(string(raw))
-		if msg == "" {
-			msg = "create card message failed"
-		}
-		return fmt.Errorf("status=%d: %s", resp.StatusCode, msg)
-	}
-	return nil
-}
-
-func uploadToChannel(
-	ctx context.Context,
-	httpClient *http.Client,
-	apiBase, channelID, filename string,
-	data []byte,
-) (channelUploadResponse, error) {
-	contentType := mime.TypeByExtension(strings.ToLower(path.Ext(filename)))
-	if strings.TrimSpace(contentType) == "" {
-		contentType = "application/octet-stream"
-	}
-
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
-	h := make(textproto.MIMEHeader)
-	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
-	h.Set("Content-Type", contentType)
-
-	part, err := writer.CreatePart(h)
-	if err != nil {
-		return channelUploadResponse{}, err
-	}
-	if _, err := part.Write(data); err != nil {
-		return channelUploadResponse{}, err
-	}
-	if err := writer.Close(); err != nil {
-		return channelUploadResponse{}, err
-	}
-
-	target := strings.TrimRight(apiBase, "/") + "/channels/" + url.PathEscape(channelID) + "/uploads"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body.Bytes()))
-	if err != nil {
-		return channelUploadResponse{}, err
-	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return channelUploadResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		msg := strings.TrimSpace(string(raw))
-		if msg == "" {
-			msg = "upload attachment failed"
-		}
-		return channelUploadResponse{}, fmt.Errorf("status=%d: %s", resp.StatusCode, msg)
-	}
-
-	var out channelUploadResponse
-	if err := json.Unmarshal(raw, &out); err != nil {
-		return channelUploadResponse{}, err
-	}
-	if strings.TrimSpace(out.Key) == "" {
-		return channelUploadResponse{}, fmt.Errorf("upload response missing key")
-	}
-	if strings.TrimSpace(out.Filename) == "" {
-		out.Filename = filename
-	}
-	if strings.TrimSpace(out.ContentType) == "" {
-		out.ContentType = contentType
-	}
-	return out, nil
+	})
+	return err
 }
 
 func extractFileRefSegments(message string) (string, string, []trailingFileRef) {
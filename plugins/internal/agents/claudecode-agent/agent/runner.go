@@ -37,9 +37,13 @@ type ClaudeCodeRunner struct {
 	proxyBaseURL  string
 	proxyTimeout  int
 
-	botUserID string
+	botUserID  string
+	addressing sdk.AddressingConfig
 
 	dmChannels *sdk.DMChannelCache
+	directory  *sdk.Directory
+	router     *sdk.MessageRouter
+	commands   *sdk.CommandRegistry
 
 	continuedMu      sync.RWMutex
 	channelContinued map[string]bool
@@ -50,6 +54,11 @@ const (
 	claudeCodeIncomingQueueSize    = 128
 	claudeCodeMaxAttachmentBytes   = 20 * 1024 * 1024
 	claudeCodeCardMessageType      = "app/x-claudecode-card"
+
+	// Progress reactions on the triggering message.
+	claudeCodeReactionWorking = "⏳"
+	claudeCodeReactionDone    = "✅"
+	claudeCodeReactionFailed  = "❌"
 )
 
 var clearReplies = []string{
@@ -99,7 +108,7 @@ func NewClaudeCodeRunner(botID, botName, accessToken, rawConfig string, cfg sdk.
 		return nil, err
 	}
 
-	return &ClaudeCodeRunner{
+	r := &ClaudeCodeRunner{
 		botID:            botID,
 		botName:          botName,
 		logPrefix:        fmt.Sprintf("[claudecode-agent] bot=%s name=%q", botID, botName),
@@ -113,9 +122,12 @@ func NewClaudeCodeRunner(botID, botName, accessToken, rawConfig string, cfg sdk.
 		proxyBaseURL:     strings.TrimRight(agentCfg.ProxyBaseURL, "/"),
 		proxyTimeout:     agentCfg.TimeoutSecond,
 		botUserID:        "",
+		addressing:       agentCfg.Addressing,
 		dmChannels:       sdk.NewDMChannelCache(),
 		channelContinued: make(map[string]bool),
-	}, nil
+	}
+	r.commands = r.newCommandRegistry()
+	return r, nil
 }
 
 func (r *ClaudeCodeRunner) Run(ctx context.Context) error {
@@ -137,6 +149,11 @@ func (r *ClaudeCodeRunner) Run(ctx context.Context) error {
 	} else {
 		log.Printf("%s DM channels cache initialized", logPrefix)
 	}
+	r.directory = sdk.NewDirectory(r.session)
+	if err := r.directory.Refresh(runCtx); err != nil {
+		log.Printf("%s load directory failed (will retry on demand): %v", logPrefix, err)
+	}
+	r.router = sdk.NewMessageRouter(r.session, r.dmChannels, r.addressing.Policy(r.botUserID)).UseDirectory(r.directory)
 
 	jobs := make(chan messageCreateJob, claudeCodeIncomingQueueSize)
 	workerDone := make(chan struct{})
@@ -172,6 +189,7 @@ func (r *ClaudeCodeRunner) Run(ctx context.Context) error {
 	}()
 
 	return socketio.RunGatewayWithReconnectSession(runCtx, r.wsURL, r.session, func(ctx context.Context, eventName string, payload json.RawMessage, emit socketio.EmitFunc) error {
+		r.directory.HandleEvent(eventName, payload)
 		if eventName != "MESSAGE_CREATE" {
 			return nil
 		}
@@ -181,6 +199,8 @@ func (r *ClaudeCodeRunner) Run(ctx context.Context) error {
 			return nil
 		}
 		if msg.AuthorID() == r.botUserID {
+			// Remember our own messages so replies to them route to us.
+			r.router.RememberOwnMessage(msg.ID)
 			return nil
 		}
 
@@ -216,7 +236,7 @@ func (r *ClaudeCodeRunner) runScheduledPrompt(ctx context.Context, channelID, pr
 		return fmt.Errorf("scheduler prompt is empty")
 	}
 
-	chunks, sentMessages, err := r.runProxyPrompt(ctx, channelID, "scheduler -p", prompt, false, r.makeCardEmitFunc(ctx))
+	chunks, sentMessages, err := r.runProxyPrompt(ctx, channelID, "scheduler -p", prompt, false, r.makeCardEmitFunc(ctx), nil)
 	if err != nil {
 		_ = r.sendCardMessageByAPI(ctx, channelID, fmt.Sprintf("定时任务执行失败: %v", err))
 		return err
@@ -262,7 +282,7 @@ func (r *ClaudeCodeRunner) handleMessageCreateJob(ctx context.Context, logPrefix
 		sdk.PreviewString(msg.ContextText(), claudeCodeLogContentPreviewLen),
 	)
 
-	ok, err := r.maybeHandleMessage(ctx, msg.ChannelID, msg.ContextText(), msg.Attachments, job.emit)
+	ok, err := r.maybeHandleMessage(ctx, msg, job.emit)
 	if err != nil {
 		log.Printf("%s message handle failed: channel=%s msg=%s err=%v", logPrefix, msg.ChannelID, msg.ID, err)
 		return
@@ -276,41 +296,44 @@ func (r *ClaudeCodeRunner) handleMessageCreateJob(ctx context.Context, logPrefix
 
 func (r *ClaudeCodeRunner) maybeHandleMessage(
 	ctx context.Context,
-	channelID, content string,
-	attachments []sdkapi.AttachmentRef,
+	msg sdkapi.ChannelMessage,
 	emit socketio.EmitFunc,
 ) (ok bool, err error) {
-	trimmed := strings.TrimSpace(content)
-	if trimmed == "" && len(attachments) == 0 {
+	channelID := msg.ChannelID
+	if strings.TrimSpace(msg.ContextText()) == "" && len(msg.Attachments) == 0 {
 		log.Printf("%s skip empty content: channel=%s", r.logPrefix, channelID)
 		return false, nil
 	}
 
-	if rest, mentioned := socketio.StripLeadingBotMention(trimmed, r.botUserID); mentioned {
-		log.Printf("%s route by mention: channel=%s", r.logPrefix, channelID)
-		return r.handleCommand(ctx, channelID, rest, attachments, emit)
-	}
-
-	if r.dmChannels.Has(channelID) {
-		log.Printf("%s route by DM cache hit: channel=%s", r.logPrefix, channelID)
-		return r.handleCommand(ctx, channelID, trimmed, attachments, emit)
-	}
-
-	if err := r.dmChannels.RefreshWithBotSession(ctx, r.session); err != nil {
+	addr, err := r.router.Route(ctx, msg)
+	if err != nil {
 		log.Printf("%s DM channel refresh failed on demand: channel=%s err=%v", r.logPrefix, channelID, err)
 		return false, err
 	}
-	if r.dmChannels.Has(channelID) {
-		log.Printf("%s route by DM cache refresh: channel=%s", r.logPrefix, channelID)
-		return r.handleCommand(ctx, channelID, trimmed, attachments, emit)
+	if !addr.Addressed {
+		log.Printf("%s ignore message not addressed to bot: channel=%s", r.logPrefix, channelID)
+		return false, nil
+	}
+	log.Printf("%s route by %s: channel=%s", r.logPrefix, addr.Reason, channelID)
+
+	text := addr.Text
+	if _, isVoice := msg.Voice(); isVoice {
+		// Voice notes carry only a duration placeholder as text; answer the
+		// transcript instead.
+		transcript, err := r.session.API().Messages.TranscribeVoice(ctx, msg, claudeCodeMaxAttachmentBytes)
+		if err != nil {
+			log.Printf("%s voice transcription failed: channel=%s msg=%s err=%v", r.logPrefix, channelID, msg.ID, err)
+			_ = emitChannelMessage(threadedEmit(emit, channelID, addr.ReplyTo), channelID, fmt.Sprintf("语音识别失败: %v", err))
+			return true, nil
+		}
+		text = transcript
 	}
-	log.Printf("%s ignore non-DM and no leading mention: channel=%s", r.logPrefix, channelID)
-	return false, nil
+	return r.handleCommand(ctx, channelID, msg.ID, msg.AuthorID(), addr.ReplyTo, text, msg.Attachments, emit)
 }
 
 func (r *ClaudeCodeRunner) handleCommand(
 	ctx context.Context,
-	channelID, raw string,
+	channelID, messageID, userID, replyTo, raw string,
 	attachments []sdkapi.AttachmentRef,
 	emit socketio.EmitFunc,
 ) (ok bool, err error) {
@@ -319,12 +342,25 @@ func (r *ClaudeCodeRunner) handleCommand(
 		log.Printf("%s skip empty command: channel=%s", r.logPrefix, channelID)
 		return false, nil
 	}
-
-	if strings.EqualFold(command, "/clear") {
-		r.setChannelContinued(channelID, false)
-		reply := pickRandomClearReply()
-		log.Printf("%s command /clear: channel=%s reply=%q", r.logPrefix, channelID, reply)
-		if err := emitChannelMessage(emit, channelID, reply); err != nil {
+	threaded := threadedEmit(emit, channelID, replyTo)
+
+	// Unknown slash commands fall through to Claude Code, which has its own.
+	reply, handled, err := r.commands.Dispatch(ctx, sdk.CommandContext{
+		ChannelID: channelID,
+		UserID:    userID,
+		IsDM:      r.dmChannels.Has(channelID),
+		IsOwner:   userID != "" && userID == sdk.BotOwnerID(ctx),
+	}, command)
+	if handled {
+		log.Printf("%s command %s: channel=%s reply=%q err=%v", r.logPrefix, command, channelID, sdk.PreviewString(reply, claudeCodeLogContentPreviewLen), err)
+		if err != nil {
+			_ = emitChannelMessage(threaded, channelID, fmt.Sprintf("命令执行失败: %v", err))
+			return true, nil
+		}
+		if reply == "" {
+			return true, nil
+		}
+		if err := emitChannelMessage(threaded, channelID, reply); err != nil {
 			return true, err
 		}
 		return true, nil
@@ -333,7 +369,7 @@ func (r *ClaudeCodeRunner) handleCommand(
 	prompt, err := r.buildPromptWithAttachments(ctx, channelID, command, attachments)
 	if err != nil {
 		log.Printf("%s attachment processing failed: channel=%s err=%v", r.logPrefix, channelID, err)
-		_ = emitChannelMessage(emit, channelID, fmt.Sprintf("附件处理失败: %v", err))
+		_ = emitChannelMessage(threaded, channelID, fmt.Sprintf("附件处理失败: %v", err))
 		return true, nil
 	}
 
@@ -342,6 +378,7 @@ func (r *ClaudeCodeRunner) handleCommand(
 	if continued {
 		mode = "-c -p"
 	}
+	r.markProgress(ctx, channelID, messageID, claudeCodeReactionWorking, "")
 	start := time.Now()
 	log.Printf("%s proxy request start: channel=%s mode=%s attachments=%d prompt_len=%d prompt=%q",
 		r.logPrefix,
@@ -351,31 +388,55 @@ func (r *ClaudeCodeRunner) handleCommand(
 		len(prompt),
 		sdk.PreviewString(prompt, claudeCodeLogContentPreviewLen),
 	)
-	chunks, sentMessages, err := r.runProxyPrompt(ctx, channelID, mode, prompt, continued, emit)
+	live := r.startLiveReply(ctx, channelID, replyTo, emit)
+	chunks, sentMessages, err := r.runProxyPrompt(ctx, channelID, mode, prompt, continued, live.Emit, live.Stream)
 	elapsed := time.Since(start)
 	if err != nil {
 		log.Printf("%s proxy request failed: channel=%s mode=%s elapsed=%s err=%v",
 			r.logPrefix, channelID, mode, elapsed, err)
-		_ = emitChannelMessage(emit, channelID, fmt.Sprintf("claude-code 调用失败: %v", err))
+		r.markProgress(ctx, channelID, messageID, claudeCodeReactionFailed, claudeCodeReactionWorking)
+		_ = live.Fail(err)
 		return true, nil
 	}
+	r.markProgress(ctx, channelID, messageID, claudeCodeReactionDone, claudeCodeReactionWorking)
 
 	r.setChannelContinued(channelID, true)
 	log.Printf("%s proxy request success: channel=%s mode=%s elapsed=%s chunks=%d messages=%d",
 		r.logPrefix, channelID, mode, elapsed, chunks, sentMessages)
 	if chunks == 0 || sentMessages == 0 {
-		if err := emitChannelMessage(emit, channelID, "(empty response)"); err != nil {
+		if err := emitChannelMessage(live.Emit, channelID, "(empty response)"); err != nil {
 			return true, err
 		}
 	}
+	live.Close()
 	return true, nil
 }
 
+// markProgress swaps the bot's progress reaction on the user's message.
+// Reactions are best-effort: failures are logged and never block the reply.
+func (r *ClaudeCodeRunner) markProgress(ctx context.Context, channelID, messageID, add, remove string) {
+	api := r.session.API()
+	if api == nil || strings.TrimSpace(messageID) == "" {
+		return
+	}
+	if remove != "" {
+		if err := api.Messages.Unreact(ctx, channelID, messageID, remove); err != nil {
+			log.Printf("%s remove reaction failed: channel=%s msg=%s emoji=%s err=%v", r.logPrefix, channelID, messageID, remove, err)
+		}
+	}
+	if add != "" {
+		if err := api.Messages.React(ctx, channelID, messageID, add); err != nil {
+			log.Printf("%s add reaction failed: channel=%s msg=%s emoji=%s err=%v", r.logPrefix, channelID, messageID, add, err)
+		}
+	}
+}
+
 func (r *ClaudeCodeRunner) runProxyPrompt(
 	ctx context.Context,
 	channelID, mode, prompt string,
 	useContinue bool,
 	emit socketio.EmitFunc,
+	stream func(partial string),
 ) (chunks int, sentMessages int, err error) {
 	parser := NewClaudeStreamParser()
 	sentMessages = 0
@@ -419,6 +480,11 @@ func (r *ClaudeCodeRunner) runProxyPrompt(
 				return err
 			}
 		}
+		if stream != nil {
+			if partial := strings.TrimSpace(stripCrawlerSiteLines(parser.Partial())); partial != "" {
+				stream(partial)
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -524,6 +590,24 @@ func (r *ClaudeCodeRunner) setChannelContinued(channelID string, continued bool)
 	r.channelContinued[channelID] = continued
 }
 
+// threadedEmit threads the first message/create for channelID under replyTo;
+// later messages are sent as-is.
+func threadedEmit(emit socketio.EmitFunc, channelID, replyTo string) socketio.EmitFunc {
+	replyTo = strings.TrimSpace(replyTo)
+	if replyTo == "" {
+		return emit
+	}
+	return func(event string, payload any) error {
+		if replyTo != "" && strings.TrimSpace(event) == "message/create" {
+			if body, ok := payload.(map[string]any); ok && fmt.Sprintf("%v", body["channelId"]) == channelID {
+				body["referencedMessageId"] = replyTo
+				replyTo = ""
+			}
+		}
+		return emit(event, payload)
+	}
+}
+
 func emitChannelMessage(emit socketio.EmitFunc, channelID, content string) error {
 	return emit("message/create", map[string]any{
 		"channelId": channelID,
@@ -110,6 +110,20 @@ func (p *ClaudeStreamParser) Flush() []string {
 	return []string{out}
 }
 
+// Partial returns the narrative text of the assistant turn still being
+// streamed, or "" between turns. It is what FeedLine will eventually emit for
+// that turn, minus any tool summary.
+func (p *ClaudeStreamParser) Partial() string {
+	if p.currentAssistant == nil {
+		return ""
+	}
+	text := p.currentAssistant.Text.String()
+	if p.currentBlock != nil && p.currentBlock.BlockType == "text" && len(p.currentAssistant.ToolUses) == 0 {
+		text += p.currentBlock.Text.String()
+	}
+	return strings.TrimSpace(text)
+}
+
 func (p *ClaudeStreamParser) handleStreamEvent(line string) ([]string, error) {
 	var ev struct {
 		Type  string `json:"type"`
@@ -128,3 +128,30 @@ func TestFormatToolAction_GenericToolShowsInputAndOutput(t *testing.T) {
 		t.Fatalf("should not fall back to empty-success message: %s", out)
 	}
 }
+
+func TestClaudeStreamParser_PartialFollowsStreamingTurn(t *testing.T) {
+	p := NewClaudeStreamParser()
+	steps := []struct {
+		line string
+		want string
+	}{
+		{`{"type":"stream_event","event":{"type":"message_start","message":{"id":"msg_1"}}}`, ""},
+		{`{"type":"stream_event","event":{"type":"content_block_start","content_block":{"type":"text","text":""}}}`, ""},
+		{`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"Let me "}}}`, "Let me"},
+		{`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"check."}}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"content_block_stop","index":0}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"content_block_start","content_block":{"type":"tool_use","id":"toolu_1","name":"Bash","input":{}}}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"content_block_stop","index":1}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"content_block_start","content_block":{"type":"text","text":""}}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"[User] replay"}}}`, "Let me check."},
+		{`{"type":"stream_event","event":{"type":"message_stop"}}`, ""},
+	}
+	for i, s := range steps {
+		if _, err := p.FeedLine(s.line); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got := p.Partial(); got != s.want {
+			t.Fatalf("step %d: Partial()=%q, want %q", i, got, s.want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ func Run() error {
 		ServerName:     "Claude Code",
 		Description:    "通过 Claude Code CLI 对话（支持 /clear）",
 		ConfigTemplate: cfgTemplate,
+		Commands:       agent.CommandSpecs(),
 		NewRunner: func(botID, botName, accessToken, rawConfig string, cfg sdk.RuntimeConfig) (sdk.Runner, error) {
 			return agent.NewClaudeCodeRunner(botID, botName, accessToken, rawConfig, cfg)
 		},
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	openaigo "github.com/openai/openai-go/v3"
 
+	"mew/plugins/pkg"
 	sdkapi "mew/plugins/pkg/api"
 	"mew/plugins/pkg/api/attachment"
 	"mew/plugins/pkg/x/llm"
@@ -26,7 +28,17 @@ const (
 type outboundMessage struct {
 	Type    string
 	Content string
-	Payload map[string]any
+	Payload any
+
+	// ReplyTo is the message the reply threads under ("" in DMs).
+	ReplyTo string
+
+	// Sent reports that the reply was already delivered as a live message.
+	Sent bool
+}
+
+func jpdictCardPayload(content string) any {
+	return sdk.JpdictCard{Content: content}
 }
 
 func loadJpdictSystemPrompt() (string, error) {
@@ -40,33 +52,58 @@ func loadJpdictSystemPrompt() (string, error) {
 	return strings.TrimSpace(s), nil
 }
 
-func (r *JpdictRunner) handleQuery(ctx context.Context, input string, attachments []sdkapi.AttachmentRef) (outboundMessage, bool, error) {
+func (r *JpdictRunner) handleQuery(ctx context.Context, channelID, replyTo, input string, attachments []sdkapi.AttachmentRef) (outboundMessage, bool, error) {
 	text := strings.TrimSpace(input)
 	if text == "" && len(attachments) == 0 {
 		return outboundMessage{
 			Type:    jpdictCardMessageType,
 			Content: jpdictEmptyInputErrorReplyText,
-			Payload: map[string]any{"content": jpdictEmptyInputErrorReplyText},
+			Payload: jpdictCardPayload(jpdictEmptyInputErrorReplyText),
+			ReplyTo: replyTo,
 		}, true, nil
 	}
 
-	reply, err := r.queryLLM(ctx, text, attachments)
+	// Stream the answer into a live card so long explanations show up as
+	// they are generated; fall back to a single reply if posting fails.
+	live := sdk.NewLiveMessage(r.session.API(), channelID, sdk.LiveMessageOptions{
+		Type:    jpdictCardMessageType,
+		Payload: jpdictCardPayload,
+		ReplyTo: replyTo,
+	})
+	if err := live.Start(ctx); err != nil {
+		log.Printf("[jpdict-agent] live message start failed, falling back: channel=%s err=%v", channelID, err)
+		live = nil
+	}
+
+	reply, err := r.queryLLM(ctx, text, attachments, live)
+	if live != nil {
+		if err != nil {
+			_, err = live.Fail(ctx, err)
+		} else {
+			_, err = live.Finish(ctx, reply)
+		}
+		return outboundMessage{Sent: true}, true, err
+	}
 	if err != nil {
 		return outboundMessage{
 			Type:    jpdictCardMessageType,
 			Content: jpdictRequestFailedPrefix + err.Error(),
-			Payload: map[string]any{"content": jpdictRequestFailedPrefix + err.Error()},
+			Payload: jpdictCardPayload(jpdictRequestFailedPrefix + err.Error()),
+			ReplyTo: replyTo,
 		}, true, nil
 	}
 
 	return outboundMessage{
 		Type:    jpdictCardMessageType,
 		Content: reply,
-		Payload: map[string]any{"content": reply},
+		Payload: jpdictCardPayload(reply),
+		ReplyTo: replyTo,
 	}, true, nil
 }
 
-func (r *JpdictRunner) queryLLM(ctx context.Context, text string, attachments []sdkapi.AttachmentRef) (string, error) {
+// queryLLM asks the model for an answer. With a live message the answer is
+// streamed into it; otherwise the full completion is awaited.
+func (r *JpdictRunner) queryLLM(ctx context.Context, text string, attachments []sdkapi.AttachmentRef, live *sdk.LiveMessage) (string, error) {
 	r.cfgMu.RLock()
 	cfg := r.cfg
 	r.cfgMu.RUnlock()
@@ -96,16 +133,23 @@ func (r *JpdictRunner) queryLLM(ctx context.Context, text string, attachments []
 		return "", err
 	}
 
-	resp, err := llm.CallOpenAIChatCompletionWithRetry(ctx, r.llmHTTPClient, llm.OpenAIChatConfig{
+	llmCfg := llm.OpenAIChatConfig{
 		BaseURL:        baseURL,
 		APIKey:         apiKey,
 		Model:          model,
 		MaxRetries:     1, // avoid double-retry: we retry at this wrapper level
 		RequestTimeout: 75 * time.Second,
-	}, []openaigo.ChatCompletionMessageParamUnion{
+	}
+	msgs := []openaigo.ChatCompletionMessageParamUnion{
 		openaigo.SystemMessage(r.systemPrompt),
 		userMsg,
-	}, nil, llm.CallOpenAIChatCompletionWithRetryOptions{
+	}
+
+	if live != nil {
+		return r.streamLLM(ctx, llmCfg, msgs, live)
+	}
+
+	resp, err := llm.CallOpenAIChatCompletionWithRetry(ctx, r.llmHTTPClient, llmCfg, msgs, nil, llm.CallOpenAIChatCompletionWithRetryOptions{
 		LogPrefix: "[jpdict-agent]",
 	})
 	if err != nil {
@@ -122,3 +166,36 @@ func (r *JpdictRunner) queryLLM(ctx context.Context, text string, attachments []
 	}
 	return out, nil
 }
+
+// streamLLM retries only while nothing has been shown yet; a failure after
+// partial output is returned so the live card keeps what was streamed.
+func (r *JpdictRunner) streamLLM(ctx context.Context, cfg llm.OpenAIChatConfig, msgs []openaigo.ChatCompletionMessageParamUnion, live *sdk.LiveMessage) (string, error) {
+	const maxAttempts = 3
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		out, err := llm.StreamOpenAIChatCompletion(ctx, r.llmHTTPClient, cfg, msgs, func(delta string) error {
+			live.Append(delta)
+			return nil
+		})
+		out = strings.TrimSpace(out)
+		if err == nil && out != "" {
+			return out, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("llm returned empty content")
+		}
+		if out != "" {
+			return "", err
+		}
+		lastErr = err
+		if attempt == maxAttempts-1 {
+			break
+		}
+		backoff := llm.WithJitter(llm.ExpBackoff(attempt, 250*time.Millisecond, 5*time.Second))
+		log.Printf("[jpdict-agent] llm stream failure: retry=%d/%d err=%v backoff=%s", attempt+1, maxAttempts, err, backoff)
+		if !llm.SleepWithContext(ctx, backoff) {
+			return "", ctx.Err()
+		}
+	}
+	return "", lastErr
+}
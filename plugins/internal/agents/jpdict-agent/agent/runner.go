@@ -40,6 +40,8 @@ type JpdictRunner struct {
 	cfg   config.JpdictConfig
 
 	dmChannels *sdk.DMChannelCache
+	directory  *sdk.Directory
+	router     *sdk.MessageRouter
 }
 
 func NewJpdictRunner(serviceType, botID, botName, accessToken, rawConfig string, cfg sdk.RuntimeConfig) (*JpdictRunner, error) {
@@ -108,8 +110,14 @@ func (r *JpdictRunner) Run(ctx context.Context) error {
 	if err := r.refreshDMChannels(ctx); err != nil {
 		log.Printf("%s refresh DM channels failed (will retry later): %v", logPrefix, err)
 	}
+	r.directory = sdk.NewDirectory(r.session)
+	if err := r.directory.Refresh(ctx); err != nil {
+		log.Printf("%s load directory failed (will retry on demand): %v", logPrefix, err)
+	}
+	r.router = sdk.NewMessageRouter(r.session, r.dmChannels, r.cfg.Addressing.Policy(r.botUserID)).UseDirectory(r.directory)
 
 	return socketio.RunGatewayWithReconnectSession(ctx, r.wsURL, r.session, func(ctx context.Context, eventName string, payload json.RawMessage, emit socketio.EmitFunc) error {
+		r.directory.HandleEvent(eventName, payload)
 		if eventName != "MESSAGE_CREATE" {
 			return nil
 		}
@@ -119,10 +127,6 @@ func (r *JpdictRunner) Run(ctx context.Context) error {
 			return nil
 		}
 
-		if msg.AuthorID() == r.botUserID {
-			return nil
-		}
-
 		out, ok, err := r.maybeHandleMessage(ctx, msg)
 		if err != nil {
 			return err
@@ -130,13 +134,21 @@ func (r *JpdictRunner) Run(ctx context.Context) error {
 		if !ok {
 			return nil
 		}
+		if out.Sent {
+			log.Printf("%s replied (live): channel=%s", logPrefix, msg.ChannelID)
+			return nil
+		}
 
-		if err := emit("message/create", map[string]any{
+		body := map[string]any{
 			"channelId": msg.ChannelID,
 			"type":      out.Type,
 			"content":   out.Content,
 			"payload":   out.Payload,
-		}); err != nil {
+		}
+		if out.ReplyTo != "" {
+			body["referencedMessageId"] = out.ReplyTo
+		}
+		if err := emit("message/create", body); err != nil {
 			return fmt.Errorf("send message failed: %w", err)
 		}
 		log.Printf("%s replied: channel=%s", logPrefix, msg.ChannelID)
@@ -151,28 +163,14 @@ func (r *JpdictRunner) Run(ctx context.Context) error {
 }
 
 func (r *JpdictRunner) maybeHandleMessage(ctx context.Context, msg sdkapi.ChannelMessage) (out outboundMessage, ok bool, err error) {
-	trimmed := strings.TrimSpace(msg.Content)
-	attachments := msg.Attachments
-
-	// Channel: require a leading mention.
-	if rest, mentioned := socketio.StripLeadingBotMention(trimmed, r.botUserID); mentioned {
-		return r.handleQuery(ctx, rest, attachments)
+	addr, err := r.router.Route(ctx, msg)
+	if err != nil {
+		return outboundMessage{}, false, err
 	}
-
-	// DM: no mention required, but must be in a DM channel.
-	if !r.isDMChannel(msg.ChannelID) {
-		if err := r.refreshDMChannels(ctx); err != nil {
-			return outboundMessage{}, false, err
-		}
-		if !r.isDMChannel(msg.ChannelID) {
-			return outboundMessage{}, false, nil
-		}
+	if !addr.Addressed {
+		return outboundMessage{}, false, nil
 	}
-	return r.handleQuery(ctx, trimmed, attachments)
-}
-
-func (r *JpdictRunner) isDMChannel(channelID string) bool {
-	return r.dmChannels.Has(channelID)
+	return r.handleQuery(ctx, msg.ChannelID, addr.ReplyTo, addr.Text, msg.Attachments)
 }
 
 func (r *JpdictRunner) refreshDMChannels(ctx context.Context) error {
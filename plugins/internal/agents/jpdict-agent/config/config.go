@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"mew/plugins/pkg"
 )
 
 type JpdictConfig struct {
 	BaseURL string `json:"base_url"`
 	APIKey  string `json:"api_key"`
 	Model   string `json:"model"`
+
+	Addressing sdk.AddressingConfig `json:"addressing"`
 }
 
 func ParseJpdictConfig(raw string) (JpdictConfig, error) {
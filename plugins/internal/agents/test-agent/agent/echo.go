@@ -2,68 +2,64 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
 	"strings"
 
-	"mew/plugins/pkg/api/gateway/socketio"
+	"mew/plugins/pkg"
 )
 
-type socketMessage struct {
-	ChannelID string          `json:"channelId"`
-	Content   string          `json:"content"`
-	AuthorID  json.RawMessage `json:"authorId"`
+var echoCommand = sdk.CommandSpec{
+	Name:        "echo",
+	Description: "原样回复一段文本",
+	Args: []sdk.CommandArg{
+		{Name: "text", Type: sdk.CommandArgRest, Required: true, Description: "要回复的内容"},
+	},
 }
 
-func (r *TestAgentRunner) maybeEcho(ctx context.Context, channelID, content string) (reply string, ok bool, err error) {
-	trimmed := strings.TrimSpace(content)
+// CommandSpecs lists the slash commands registered with the server for autocomplete.
+func CommandSpecs() []sdk.CommandSpec {
+	return []sdk.CommandSpec{echoCommand}
+}
 
-	// Channel: require a leading mention.
-	if rest, mentioned := socketio.StripLeadingBotMention(trimmed, r.botUserID); mentioned {
-		reply, ok := parseEcho(rest)
-		return reply, ok, nil
-	}
+func newCommandRegistry() *sdk.CommandRegistry {
+	reg := sdk.NewCommandRegistry()
+	reg.ReplyUnknown = true
+	reg.Handle(echoCommand, func(ctx context.Context, inv sdk.CommandInvocation) (string, error) {
+		return inv.String("text"), nil
+	})
+	return reg
+}
 
-	// DM: no mention required, but must be in a DM channel.
-	reply, ok = parseEcho(trimmed)
-	if !ok {
-		return "", false, nil
+func (r *TestAgentRunner) maybeEcho(ctx context.Context, msg sdk.ChannelMessage) (reply string, ok bool, err error) {
+	addr, err := r.router.Route(ctx, msg)
+	if err != nil {
+		return "", false, err
 	}
-
-	if r.dmChannels.Has(channelID) {
-		return reply, true, nil
+	if !addr.Addressed {
+		return "", false, nil
 	}
 
-	// DM channels can be created after the bot connects; refresh once on demand.
-	if err := r.dmChannels.RefreshWithBotSession(ctx, r.session); err != nil {
-		return "", false, err
+	// The leading slash is optional: "echo hi" and "/echo hi" both work.
+	text := strings.TrimSpace(addr.Text)
+	if text == "" {
+		return "", false, nil
 	}
-	if r.dmChannels.Has(channelID) {
-		return reply, true, nil
+	if !strings.HasPrefix(text, "/") {
+		if !strings.EqualFold(firstWord(text), echoCommand.Name) {
+			return "", false, nil
+		}
+		text = "/" + text
 	}
-	return "", false, nil
+	return r.commands.Dispatch(ctx, sdk.CommandContext{
+		ChannelID: msg.ChannelID,
+		UserID:    msg.AuthorID(),
+		IsDM:      addr.Reason == sdk.AddressedByDM || r.dmChannels.Has(msg.ChannelID),
+		IsOwner:   msg.AuthorID() != "" && msg.AuthorID() == sdk.BotOwnerID(ctx),
+	}, text)
 }
 
-func parseEcho(content string) (reply string, ok bool) {
-	s := strings.TrimSpace(content)
-	if s == "" {
-		return "", false
-	}
-	if len(s) < 4 {
-		return "", false
-	}
-	if !strings.EqualFold(s[:4], "echo") {
-		return "", false
-	}
-	if len(s) == 4 {
-		return "", false
-	}
-	next := s[4]
-	if next != ' ' && next != '\t' && next != '\n' && next != '\r' {
-		return "", false
-	}
-	rest := strings.TrimSpace(s[4:])
-	if rest == "" {
-		return "", false
+func firstWord(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
 	}
-	return rest, true
+	return ""
 }
@@ -28,6 +28,9 @@ type TestAgentRunner struct {
 	botUserID string
 
 	dmChannels *sdk.DMChannelCache
+	directory  *sdk.Directory
+	router     *sdk.MessageRouter
+	commands   *sdk.CommandRegistry
 }
 
 func NewTestAgentRunner(botID, botName, accessToken, rawConfig string, cfg sdk.RuntimeConfig) (*TestAgentRunner, error) {
@@ -54,6 +57,7 @@ func NewTestAgentRunner(botID, botName, accessToken, rawConfig string, cfg sdk.R
 		httpClient:  httpClient,
 		botUserID:   "",
 		dmChannels:  sdk.NewDMChannelCache(),
+		commands:    newCommandRegistry(),
 	}, nil
 }
 
@@ -70,21 +74,24 @@ func (r *TestAgentRunner) Run(ctx context.Context) error {
 	if err := r.dmChannels.RefreshWithBotSession(ctx, r.session); err != nil {
 		log.Printf("%s refresh DM channels failed (will retry later): %v", logPrefix, err)
 	}
+	r.directory = sdk.NewDirectory(r.session)
+	if err := r.directory.Refresh(ctx); err != nil {
+		log.Printf("%s load directory failed (will retry on demand): %v", logPrefix, err)
+	}
+	r.router = sdk.NewMessageRouter(r.session, r.dmChannels, sdk.AddressingPolicy{BotUserID: r.botUserID}).UseDirectory(r.directory)
 
 	return socketio.RunGatewayWithReconnectSession(ctx, r.wsURL, r.session, func(ctx context.Context, eventName string, payload json.RawMessage, emit socketio.EmitFunc) error {
+		r.directory.HandleEvent(eventName, payload)
 		if eventName != "MESSAGE_CREATE" {
 			return nil
 		}
 
-		var msg socketMessage
+		var msg sdk.ChannelMessage
 		if err := json.Unmarshal(payload, &msg); err != nil {
 			return err
 		}
-		if sdk.IsOwnMessage(msg.AuthorID, r.botUserID) {
-			return nil
-		}
 
-		reply, ok, err := r.maybeEcho(ctx, msg.ChannelID, msg.Content)
+		reply, ok, err := r.maybeEcho(ctx, msg)
 		if err != nil {
 			return err
 		}
@@ -34,11 +34,13 @@ func NewRunner(botID, botName, accessToken string, cfg sdk.RuntimeConfig, tasks
 func (r *Runner) Run(ctx context.Context) error {
 	g := sdk.NewGroup(ctx)
 
-	biliHTTP, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
-		Timeout:   30 * time.Second,
-		CookieJar: true,
-	})
-	if err != nil {
+	biliSession := sdk.HTTPSessionOptions{
+		ClientOptions: sdk.HTTPClientOptions{
+			Timeout:   30 * time.Second,
+			CookieJar: true,
+		},
+	}
+	if err := biliSession.Validate(); err != nil {
 		return err
 	}
 	webhookClient, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
@@ -62,7 +64,21 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
-	srcClient := source.NewClient(biliHTTP)
+	srcClient := source.NewSessionClient(func() (source.Session, error) {
+		sess, err := sdk.NewHTTPSession(biliSession)
+		if err != nil {
+			return nil, err
+		}
+		return sess, nil
+	})
+
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: downloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[bili-fetcher] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
 
 	for i, task := range r.tasks {
 		if !sdk.IsEnabled(task.Enabled) {
@@ -79,7 +95,7 @@ func (r *Runner) Run(ctx context.Context) error {
 				log.Printf("%s load state failed: %v", logPrefix, err)
 			}
 
-			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, downloadClient, uploadClient)
+			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, downloadClient, uploadClient).WithAvatars(avatars)
 
 			w := &Worker{
 				logPrefix:     logPrefix,
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"mew/plugins/internal/fetchers/bilibili-fetcher/source"
@@ -19,6 +20,7 @@ type Uploader struct {
 	logPrefix      string
 	downloadClient *http.Client
 	uploadClient   *http.Client
+	avatars        *sdk.AvatarCache
 }
 
 func NewUploader(apiBase, webhookURL, logPrefix string, downloadClient, uploadClient *http.Client) *Uploader {
@@ -31,11 +33,19 @@ func NewUploader(apiBase, webhookURL, logPrefix string, downloadClient, uploadCl
 	}
 }
 
+// WithAvatars routes author faces through the bot-wide avatar cache, which
+// uploads each face once and refreshes it when the origin image changes.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
+}
+
 func (u *Uploader) BuildWebhook(ctx context.Context, item source.APIItem) (*sdk.WebhookPayload, error) {
 	tCtx := transformContext{
 		ctx:          ctx,
 		phClient:     u.downloadClient,
 		uploadClient: u.uploadClient,
+		avatars:      u.avatars,
 		apiBase:      u.apiBase,
 		webhookURL:   u.webhookURL,
 		logPrefix:    u.logPrefix,
@@ -47,6 +57,7 @@ type transformContext struct {
 	ctx          context.Context
 	phClient     *http.Client
 	uploadClient *http.Client
+	avatars      *sdk.AvatarCache
 	apiBase      string
 	webhookURL   string
 	logPrefix    string
@@ -244,6 +255,14 @@ func uploadFaceToWebhook(tCtx transformContext, faceURL string) string {
 	if u == "" {
 		return ""
 	}
+	if tCtx.avatars != nil {
+		key, err := tCtx.avatars.Resolve(tCtx.ctx, tCtx.apiBase, tCtx.webhookURL, u)
+		if err != nil {
+			log.Printf("%s upload author face failed: %v", tCtx.logPrefix, err)
+			return ""
+		}
+		return key
+	}
 	att, err := uploadRemoteToWebhook(tCtx, u, "avatar"+path.Ext(u))
 	if err != nil {
 		log.Printf("%s upload author face failed: %v", tCtx.logPrefix, err)
@@ -252,11 +271,7 @@ func uploadFaceToWebhook(tCtx transformContext, faceURL string) string {
 	return att.Key
 }
 
-type localizedEmoji struct {
-	Text      string `json:"text"`
-	IconURL   string `json:"icon_url"`
-	S3IconURL string `json:"s3_icon_url,omitempty"`
-}
+type localizedEmoji = sdk.BilibiliEmoji
 
 func collectEmojiNodes(v *source.RichText) []localizedEmoji {
 	if v == nil || len(v.RichTextNodes) == 0 {
@@ -370,30 +385,45 @@ func majorTitleAndURL(major *source.Major) (string, string) {
 }
 
 func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.WebhookPayload, error) {
+	card, msg, err := transformItemToCard(tCtx, item)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := sdk.CardPayload(card)
+	if err != nil {
+		return nil, err
+	}
+	msg.Payload = payload
+	return msg, nil
+}
+
+// transformItemToCard builds the card of a dynamic, plus the webhook message
+// around it (without the payload).
+func transformItemToCard(tCtx transformContext, item source.APIItem) (*sdk.BilibiliCard, *sdk.WebhookPayload, error) {
 	author := item.Modules.ModuleAuthor
 	dyn := item.Modules.ModuleDynamic
 	authorFace := normalizeBiliURL(author.Face)
 	s3AuthorFace := uploadFaceToWebhook(tCtx, authorFace)
 
-	payload := map[string]any{
-		"dynamic_id":        item.IDStr,
-		"dynamic_url":       fmt.Sprintf("https://t.bilibili.com/%s", item.IDStr),
-		"author_name":       author.Name,
-		"author_face":       authorFace,
-		"author_mid":        author.ID,
-		"author_type":       author.Type,
-		"published_at":      int64(author.PubTS),
-		"published_loc":     author.PubLoc,
-		"bili_dynamic_type": item.Type,
+	card := &sdk.BilibiliCard{
+		DynamicID:       item.IDStr,
+		DynamicURL:      fmt.Sprintf("https://t.bilibili.com/%s", item.IDStr),
+		AuthorName:      author.Name,
+		AuthorFace:      authorFace,
+		AuthorMID:       author.ID,
+		AuthorType:      author.Type,
+		PublishedAt:     int64(author.PubTS),
+		PublishedLoc:    author.PubLoc,
+		BiliDynamicType: item.Type,
 	}
 	if s3AuthorFace != "" {
-		payload["s3_author_face"] = s3AuthorFace
+		card.S3AuthorFace = s3AuthorFace
 	}
 	if dyn.Major != nil {
-		payload["bili_major_type"] = dyn.Major.Type
+		card.BiliMajorType = dyn.Major.Type
 	}
 	if dyn.Additional != nil {
-		payload["additional"] = dyn.Additional
+		card.Additional = dyn.Additional
 	}
 
 	var contentParts []string
@@ -402,7 +432,7 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 	switch item.Type {
 	case "DYNAMIC_TYPE_AV": // Video
 		if dyn.Major == nil || dyn.Major.Archive == nil {
-			return nil, fmt.Errorf("missing archive for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing archive for type=%s", item.Type)
 		}
 		video := dyn.Major.Archive
 		title := fmt.Sprintf("%s 发布了新视频", author.Name)
@@ -419,20 +449,20 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 			contentParts = append(contentParts, descText)
 		}
 
-		payload["type"] = "video"
-		payload["title"] = string(video.Title)
-		payload["description"] = descText
-		payload["bvid"] = video.BVID
-		payload["cover_url"] = normalizeBiliURL(string(video.Cover))
+		card.Type = "video"
+		card.Title = string(video.Title)
+		card.Description = descText
+		card.BVID = video.BVID
+		card.CoverURL = normalizeBiliURL(string(video.Cover))
 		if u := normalizeBiliURL(string(video.JumpURL)); u != "" {
-			payload["video_url"] = u
+			card.VideoURL = u
 		} else if video.BVID != "" {
-			payload["video_url"] = "https://www.bilibili.com/video/" + video.BVID
+			card.VideoURL = "https://www.bilibili.com/video/" + video.BVID
 		}
 
 	case "DYNAMIC_TYPE_ARTICLE": // Article (often MAJOR_TYPE_OPUS)
 		if dyn.Major == nil || dyn.Major.Opus == nil {
-			return nil, fmt.Errorf("missing opus for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing opus for type=%s", item.Type)
 		}
 		opus := dyn.Major.Opus
 		title := fmt.Sprintf("%s 发布了新文章", author.Name)
@@ -444,16 +474,16 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 			contentParts = append(contentParts, summary)
 		}
 
-		payload["type"] = "article"
-		payload["title"] = string(opus.Title)
-		payload["summary"] = summary
-		payload["article_url"] = normalizeBiliURL(string(opus.JumpURL))
+		card.Type = "article"
+		card.Title = string(opus.Title)
+		card.Summary = summary
+		card.ArticleURL = normalizeBiliURL(string(opus.JumpURL))
 		origImages, s3Images := uploadPictures(tCtx, opus.Pics)
 		if len(origImages) > 0 {
-			payload["image_urls"] = origImages
+			card.ImageURLs = origImages
 		}
 		if len(s3Images) > 0 {
-			payload["s3_image_urls"] = s3Images
+			card.S3ImageURLs = s3Images
 		}
 
 	case "DYNAMIC_TYPE_DRAW", "DYNAMIC_TYPE_WORD": // Image/Text or Pure Text
@@ -479,19 +509,19 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		}
 		contentParts = append(contentParts, text)
 
-		payload["type"] = "post"
-		payload["text"] = text
+		card.Type = "post"
+		card.Text = text
 		origImages, s3Images := uploadPictures(tCtx, pics)
 		if len(origImages) > 0 {
-			payload["image_urls"] = origImages
+			card.ImageURLs = origImages
 		}
 		if len(s3Images) > 0 {
-			payload["s3_image_urls"] = s3Images
+			card.S3ImageURLs = s3Images
 		}
 
 	case "DYNAMIC_TYPE_PGC": // PGC episode update
 		if dyn.Major == nil || dyn.Major.PGC == nil {
-			return nil, fmt.Errorf("missing pgc for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing pgc for type=%s", item.Type)
 		}
 		pgc := dyn.Major.PGC
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -502,18 +532,18 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(pgc.Desc) != "" {
 			contentParts = append(contentParts, string(pgc.Desc))
 		}
-		payload["type"] = "pgc"
-		payload["title"] = string(pgc.Title)
-		payload["subtitle"] = string(pgc.SubTitle)
-		payload["description"] = string(pgc.Desc)
-		payload["season_id"] = string(pgc.SeasonID)
-		payload["ep_id"] = string(pgc.EpisodeID)
-		payload["cover_url"] = normalizeBiliURL(string(pgc.Cover))
-		payload["pgc_url"] = normalizeBiliURL(string(pgc.JumpURL))
+		card.Type = "pgc"
+		card.Title = string(pgc.Title)
+		card.Subtitle = string(pgc.SubTitle)
+		card.Description = string(pgc.Desc)
+		card.SeasonID = string(pgc.SeasonID)
+		card.EpisodeID = string(pgc.EpisodeID)
+		card.CoverURL = normalizeBiliURL(string(pgc.Cover))
+		card.PGCURL = normalizeBiliURL(string(pgc.JumpURL))
 
 	case "DYNAMIC_TYPE_MUSIC": // audio
 		if dyn.Major == nil || dyn.Major.Music == nil {
-			return nil, fmt.Errorf("missing music for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing music for type=%s", item.Type)
 		}
 		m := dyn.Major.Music
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -521,21 +551,21 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(m.Intro) != "" {
 			contentParts = append(contentParts, string(m.Intro))
 		}
-		payload["type"] = "music"
-		payload["title"] = string(m.Title)
-		payload["description"] = string(m.Intro)
-		payload["music_id"] = string(m.ID)
-		payload["author"] = string(m.Author)
-		payload["cover_url"] = normalizeBiliURL(string(m.Cover))
+		card.Type = "music"
+		card.Title = string(m.Title)
+		card.Description = string(m.Intro)
+		card.MusicID = string(m.ID)
+		card.Author = string(m.Author)
+		card.CoverURL = normalizeBiliURL(string(m.Cover))
 		if u := normalizeBiliURL(string(m.JumpURL)); u != "" {
-			payload["music_url"] = u
+			card.MusicURL = u
 		} else {
-			payload["music_url"] = string(m.Schema)
+			card.MusicURL = string(m.Schema)
 		}
 
 	case "DYNAMIC_TYPE_COMMON_SQUARE", "DYNAMIC_TYPE_COMMON_VERTICAL":
 		if dyn.Major == nil || dyn.Major.Common == nil {
-			return nil, fmt.Errorf("missing common for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing common for type=%s", item.Type)
 		}
 		c := dyn.Major.Common
 		descText := richTextToString(dyn.Desc)
@@ -551,15 +581,15 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if descText != "" {
 			contentParts = append(contentParts, descText)
 		}
-		payload["type"] = "common"
-		payload["title"] = string(c.Title)
-		payload["description"] = descText
-		payload["cover_url"] = normalizeBiliURL(string(c.Cover))
-		payload["url"] = normalizeBiliURL(string(c.JumpURL))
+		card.Type = "common"
+		card.Title = string(c.Title)
+		card.Description = descText
+		card.CoverURL = normalizeBiliURL(string(c.Cover))
+		card.URL = normalizeBiliURL(string(c.JumpURL))
 
 	case "DYNAMIC_TYPE_LIVE": // live room share
 		if dyn.Major == nil || dyn.Major.Live == nil {
-			return nil, fmt.Errorf("missing live for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing live for type=%s", item.Type)
 		}
 		l := dyn.Major.Live
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -567,16 +597,16 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(l.Desc) != "" {
 			contentParts = append(contentParts, string(l.Desc))
 		}
-		payload["type"] = "live_share"
-		payload["title"] = string(l.Title)
-		payload["description"] = string(l.Desc)
-		payload["room_id"] = string(l.RoomID)
-		payload["cover_url"] = normalizeBiliURL(string(l.Cover))
-		payload["live_url"] = normalizeBiliURL(string(l.JumpURL))
+		card.Type = "live_share"
+		card.Title = string(l.Title)
+		card.Description = string(l.Desc)
+		card.RoomID = string(l.RoomID)
+		card.CoverURL = normalizeBiliURL(string(l.Cover))
+		card.LiveURL = normalizeBiliURL(string(l.JumpURL))
 
 	case "DYNAMIC_TYPE_MEDIALIST": // favorite list
 		if dyn.Major == nil || dyn.Major.Medialist == nil {
-			return nil, fmt.Errorf("missing medialist for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing medialist for type=%s", item.Type)
 		}
 		ml := dyn.Major.Medialist
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -584,16 +614,16 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(ml.Desc) != "" {
 			contentParts = append(contentParts, string(ml.Desc))
 		}
-		payload["type"] = "medialist"
-		payload["title"] = string(ml.Title)
-		payload["description"] = string(ml.Desc)
-		payload["medialist_id"] = string(ml.ID)
-		payload["cover_url"] = normalizeBiliURL(string(ml.Cover))
-		payload["url"] = normalizeBiliURL(string(ml.JumpURL))
+		card.Type = "medialist"
+		card.Title = string(ml.Title)
+		card.Description = string(ml.Desc)
+		card.MedialistID = string(ml.ID)
+		card.CoverURL = normalizeBiliURL(string(ml.Cover))
+		card.URL = normalizeBiliURL(string(ml.JumpURL))
 
 	case "DYNAMIC_TYPE_UGC_SEASON": // series/collection update
 		if dyn.Major == nil || dyn.Major.UGCSeason == nil {
-			return nil, fmt.Errorf("missing ugc_season for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing ugc_season for type=%s", item.Type)
 		}
 		s := dyn.Major.UGCSeason
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -601,16 +631,16 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(s.Desc) != "" {
 			contentParts = append(contentParts, string(s.Desc))
 		}
-		payload["type"] = "ugc_season"
-		payload["title"] = string(s.Title)
-		payload["description"] = string(s.Desc)
-		payload["season_id"] = string(s.SeasonID)
-		payload["cover_url"] = normalizeBiliURL(string(s.Cover))
-		payload["url"] = normalizeBiliURL(string(s.JumpURL))
+		card.Type = "ugc_season"
+		card.Title = string(s.Title)
+		card.Description = string(s.Desc)
+		card.SeasonID = string(s.SeasonID)
+		card.CoverURL = normalizeBiliURL(string(s.Cover))
+		card.URL = normalizeBiliURL(string(s.JumpURL))
 
 	case "DYNAMIC_TYPE_COURSES", "DYNAMIC_TYPE_COURSES_SEASON", "DYNAMIC_TYPE_COURSES_BATCH":
 		if dyn.Major == nil || dyn.Major.Courses == nil {
-			return nil, fmt.Errorf("missing courses for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing courses for type=%s", item.Type)
 		}
 		c := dyn.Major.Courses
 		emojis = localizeEmojisToWebhook(tCtx, dyn.Desc)
@@ -618,12 +648,12 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		if string(c.Desc) != "" {
 			contentParts = append(contentParts, string(c.Desc))
 		}
-		payload["type"] = "courses"
-		payload["title"] = string(c.Title)
-		payload["description"] = string(c.Desc)
-		payload["course_id"] = string(c.ID)
-		payload["cover_url"] = normalizeBiliURL(string(c.Cover))
-		payload["url"] = normalizeBiliURL(string(c.JumpURL))
+		card.Type = "courses"
+		card.Title = string(c.Title)
+		card.Description = string(c.Desc)
+		card.CourseID = string(c.ID)
+		card.CoverURL = normalizeBiliURL(string(c.Cover))
+		card.URL = normalizeBiliURL(string(c.JumpURL))
 
 	case "DYNAMIC_TYPE_FORWARD": // Forward
 		text := strings.TrimSpace(richTextToString(dyn.Desc))
@@ -642,16 +672,16 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		}
 		contentParts = append(contentParts, text)
 
-		payload["type"] = "forward"
-		payload["text"] = text
+		card.Type = "forward"
+		card.Text = text
 
 		if item.Orig != nil {
-			origPayload, err := transformItemToWebhook(tCtx, *item.Orig)
+			origCard, origPayload, err := transformItemToCard(tCtx, *item.Orig)
 			if err != nil {
 				log.Printf("%s failed to transform original post: %v", tCtx.logPrefix, err)
 			} else {
-				payload["original_post"] = origPayload.Payload
-				payload["original_author"] = origPayload.Username
+				card.OriginalPost = origCard
+				card.OriginalAuthor = origPayload.Username
 				// Add original content to the main message for text-only clients
 				contentParts = append(contentParts, fmt.Sprintf("\n// @%s: %s", origPayload.Username, origPayload.Content))
 			}
@@ -659,20 +689,20 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 
 	case "DYNAMIC_TYPE_LIVE_RCMD": // Live
 		if dyn.Major == nil || dyn.Major.LiveRcmd == nil {
-			return nil, fmt.Errorf("missing live_rcmd for type=%s", item.Type)
+			return nil, nil, fmt.Errorf("missing live_rcmd for type=%s", item.Type)
 		}
 		var liveData source.LiveContent
 		if err := json.Unmarshal([]byte(dyn.Major.LiveRcmd.Content), &liveData); err == nil {
 			info := liveData.LivePlayInfo
 			title := fmt.Sprintf("%s 正在直播: %s", author.Name, info.Title)
 			contentParts = append(contentParts, title)
-			payload["type"] = "live"
-			payload["title"] = info.Title
-			payload["room_id"] = info.RoomID
-			payload["cover_url"] = normalizeBiliURL(info.Cover)
-			payload["live_url"] = normalizeBiliURL(info.Link)
+			card.Type = "live"
+			card.Title = info.Title
+			card.RoomID = strconv.FormatInt(info.RoomID, 10)
+			card.CoverURL = normalizeBiliURL(info.Cover)
+			card.LiveURL = normalizeBiliURL(info.Link)
 		} else {
-			return nil, fmt.Errorf("unsupported live content: %w", err)
+			return nil, nil, fmt.Errorf("unsupported live content: %w", err)
 		}
 
 	default:
@@ -692,27 +722,24 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 			if u != "" && !strings.Contains(contentParts[len(contentParts)-1], u) {
 				contentParts = append(contentParts, u)
 			}
-			payload["type"] = "unknown"
-			payload["title"] = t
-			payload["url"] = u
+			card.Type = "unknown"
+			card.Title = t
+			card.URL = u
 			break
 		}
-		return nil, fmt.Errorf("unsupported dynamic type: %s", item.Type)
+		return nil, nil, fmt.Errorf("unsupported dynamic type: %s", item.Type)
 	}
 
-	if coverURL, ok := payload["cover_url"].(string); ok && strings.TrimSpace(coverURL) != "" {
-		if key := uploadCoverToWebhook(tCtx, coverURL); key != "" {
-			payload["s3_cover_url"] = key
-		}
+	if strings.TrimSpace(card.CoverURL) != "" {
+		card.S3CoverURL = uploadCoverToWebhook(tCtx, card.CoverURL)
 	}
 	if len(emojis) > 0 {
-		payload["emojis"] = emojis
+		card.Emojis = emojis
 	}
 
 	msg := &sdk.WebhookPayload{
 		Content:   strings.Join(contentParts, "\n"),
-		Type:      "app/x-bilibili-card",
-		Payload:   payload,
+		Type:      card.CardType(),
 		Username:  author.Name,
 		AvatarURL: authorFace, // Fallback when avatar upload/localization fails
 	}
@@ -720,7 +747,7 @@ func transformItemToWebhook(tCtx transformContext, item source.APIItem) (*sdk.We
 		msg.AvatarURL = s3AuthorFace
 	}
 
-	return msg, nil
+	return card, msg, nil
 }
 
 func uploadPictures(tCtx transformContext, pics []source.Picture) (origURLs []string, s3Keys []string) {
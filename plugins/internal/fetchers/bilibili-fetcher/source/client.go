@@ -39,19 +39,42 @@ var wbiMixinKeyEncTab = []int{
 
 type Client struct {
 	httpClient *http.Client
+	// newSession, when set, opens a fresh session per FetchDynamicsRaw call.
+	newSession func() (Session, error)
+}
+
+// Session is an HTTP session that lasts one fetch (sdk.HTTPSession).
+type Session interface {
+	Client() *http.Client
+	Close()
 }
 
 func NewClient(httpClient *http.Client) *Client {
 	return &Client{httpClient: httpClient}
 }
 
+// NewSessionClient opens one HTTP session per fetch, so the buvid/SPI cookies,
+// WBI keys and the feed request all go out through the same exit IP.
+func NewSessionClient(newSession func() (Session, error)) *Client {
+	return &Client{newSession: newSession}
+}
+
 func (c *Client) FetchDynamicsRaw(ctx context.Context, uid, offset string) ([]byte, error) {
 	targetUID := strings.TrimSpace(uid)
 	if targetUID == "" {
 		return nil, fmt.Errorf("uid required")
 	}
 
-	httpClient := ensureClient(c.httpClient)
+	httpClient := c.httpClient
+	if c.newSession != nil {
+		sess, err := c.newSession()
+		if err != nil {
+			return nil, err
+		}
+		defer sess.Close()
+		httpClient = sess.Client()
+	}
+	httpClient = ensureClient(httpClient)
 	headers := map[string]string{
 		"User-Agent": BiliUserAgent,
 		"Referer":    fmt.Sprintf("https://space.bilibili.com/%s/dynamic", targetUID),
@@ -8,11 +8,13 @@ import (
 )
 
 type taskRaw struct {
-	Interval           int    `json:"interval"`
-	Webhook            string `json:"webhook"`
-	Username           string `json:"username"`
-	Enabled            *bool  `json:"enabled"`
-	SendHistoryOnStart *bool  `json:"send_history_on_start"`
+	Interval           int              `json:"interval"`
+	Webhook            string           `json:"webhook"`
+	Username           string           `json:"username"`
+	Enabled            *bool            `json:"enabled"`
+	SendHistoryOnStart *bool            `json:"send_history_on_start"`
+	Media              sdk.MediaOptions `json:"media"`
+	Routes             sdk.HTTPRoutes   `json:"routes"`
 }
 
 type TaskConfig struct {
@@ -21,6 +23,9 @@ type TaskConfig struct {
 	Username           string
 	Enabled            *bool
 	SendHistoryOnStart *bool
+	Media              sdk.MediaOptions
+	// Routes is matched before the engine's default routes.
+	Routes sdk.HTTPRoutes
 }
 
 func ParseTasks(rawConfig string) ([]TaskConfig, error) {
@@ -50,12 +55,22 @@ func ParseTasks(rawConfig string) ([]TaskConfig, error) {
 			return nil, fmt.Errorf("tasks[%d].username is required", i)
 		}
 
+		if err := t.Media.Validate(); err != nil {
+			return nil, fmt.Errorf("tasks[%d].media invalid: %w", i, err)
+		}
+
+		if err := t.Routes.Validate(); err != nil {
+			return nil, fmt.Errorf("tasks[%d].routes invalid: %w", i, err)
+		}
+
 		validated = append(validated, TaskConfig{
 			Interval:           interval,
 			Webhook:            webhook,
 			Username:           username,
 			Enabled:            t.Enabled,
 			SendHistoryOnStart: t.SendHistoryOnStart,
+			Media:              t.Media,
+			Routes:             t.Routes,
 		})
 	}
 
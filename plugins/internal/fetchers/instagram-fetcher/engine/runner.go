@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"time"
 
 	"mew/plugins/internal/fetchers/instagram-fetcher/config"
@@ -41,10 +43,8 @@ func (r *Runner) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	downloadClient, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
-		Timeout: 45 * time.Second,
-		Mode:    "proxy",
-	})
+	defaults := defaultRoutes(r.apiBase)
+	downloadClient, err := newDownloadClient(defaults)
 	if err != nil {
 		return err
 	}
@@ -56,6 +56,14 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: downloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[ig-bot] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
+
 	for i, task := range r.tasks {
 		if !sdk.IsEnabled(task.Enabled) {
 			continue
@@ -66,18 +74,26 @@ func (r *Runner) Run(ctx context.Context) error {
 		g.Go(func(ctx context.Context) {
 			logPrefix := fmt.Sprintf("[ig-bot] bot=%s task=%d user=%s", r.botID, taskIdx, taskCopy.Username)
 
-			client, err := source.NewClient(true)
+			routes := taskCopy.Routes.Merge(defaults)
+			client, err := source.NewClient(true, routes)
 			if err != nil {
 				log.Printf("%s init source client failed: %v", logPrefix, err)
 				return
 			}
+			taskDownloadClient := downloadClient
+			if !taskCopy.Routes.IsZero() {
+				if taskDownloadClient, err = newDownloadClient(routes); err != nil {
+					log.Printf("%s init download client failed: %v", logPrefix, err)
+					return
+				}
+			}
 
 			tr, err := Load(r.serviceType, r.botID, taskIdx, taskCopy.Username)
 			if err != nil {
 				log.Printf("%s load state failed: %v", logPrefix, err)
 			}
 
-			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, downloadClient, uploadClient, tr)
+			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, taskDownloadClient, uploadClient, tr).WithMedia(taskCopy.Media).WithAvatars(avatars)
 
 			w := &Worker{
 				logPrefix:    logPrefix,
@@ -101,3 +117,23 @@ func (r *Runner) Run(ctx context.Context) error {
 	g.Wait()
 	return nil
 }
+
+// defaultRoutes sends Mew API calls direct and tries the media CDNs direct
+// before the pool; scraping endpoints keep the pool.
+func defaultRoutes(apiBase string) sdk.HTTPRoutes {
+	routes := sdk.HTTPRoutes{Rules: []sdk.HTTPRoute{
+		{Hosts: []string{"cdninstagram.com", "fbcdn.net"}, Via: []string{"direct", "pool"}},
+	}}
+	if u, err := url.Parse(apiBase); err == nil && u.Hostname() != "" {
+		routes.Rules = append([]sdk.HTTPRoute{{Hosts: []string{u.Hostname()}, Via: []string{"direct"}}}, routes.Rules...)
+	}
+	return routes
+}
+
+func newDownloadClient(routes sdk.HTTPRoutes) (*http.Client, error) {
+	return sdk.NewHTTPClient(sdk.HTTPClientOptions{
+		Timeout: 45 * time.Second,
+		Mode:    "proxy",
+		Routes:  routes,
+	})
+}
@@ -36,6 +36,8 @@ type Uploader struct {
 	downloadClient *http.Client
 	uploadClient   *http.Client
 	tracker        *Manager
+	media          sdk.MediaOptions
+	avatars        *sdk.AvatarCache
 }
 
 func NewUploader(
@@ -54,13 +56,45 @@ func NewUploader(
 	}
 }
 
+// WithMedia sets the task's upload media options (size caps, re-encoding).
+// Thumbnails are not generated: the card has no field to show them.
+func (u *Uploader) WithMedia(opts sdk.MediaOptions) *Uploader {
+	opts.ThumbnailSize = 0
+	u.media = opts
+	return u
+}
+
+// WithAvatars routes avatar uploads through the bot-wide avatar cache, which
+// uploads each avatar once and refreshes it when the origin image changes.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
+}
+
+// uploadAvatar returns an attachment key for a profile picture ("" on failure).
+func (u *Uploader) uploadAvatar(ctx context.Context, remoteURL, fallbackFilename string) string {
+	if u.avatars == nil {
+		return u.UploadWithCache(ctx, remoteURL, fallbackFilename)
+	}
+	src := strings.TrimSpace(remoteURL)
+	if src == "" {
+		return ""
+	}
+	key, err := u.avatars.Resolve(ctx, u.apiBase, u.webhookURL, src)
+	if err != nil {
+		log.Printf("%s upload avatar failed: url=%s err=%v", u.logPrefix, src, err)
+		return ""
+	}
+	return key
+}
+
 func (u *Uploader) UploadWithCache(ctx context.Context, remoteURL, fallbackFilename string) string {
 	src := strings.TrimSpace(remoteURL)
 	if src == "" {
 		return ""
 	}
 
-	key, _, err := sdk.UploadRemoteToWebhookCached(
+	keys, _, err := sdk.UploadRemoteMediaToWebhookCached(
 		ctx,
 		u.tracker,
 		u.downloadClient,
@@ -69,12 +103,13 @@ func (u *Uploader) UploadWithCache(ctx context.Context, remoteURL, fallbackFilen
 		u.webhookURL,
 		src,
 		fallbackFilename,
+		u.media,
 	)
 	if err != nil {
 		log.Printf("%s upload media failed: url=%s err=%v", u.logPrefix, src, err)
 		return ""
 	}
-	return key
+	return keys.Key
 }
 
 func (u *Uploader) ProcessAndSendStory(ctx context.Context, user *source.UserProfile, story source.StoryItem) error {
@@ -117,7 +152,7 @@ func (u *Uploader) ProcessAndSendPost(ctx context.Context, user *source.UserProf
 		if ext := path.Ext(fallback); ext == "" {
 			fallback = fallback + ".jpg"
 		}
-		s3Profile = u.UploadWithCache(ctx, profilePic, fallback)
+		s3Profile = u.uploadAvatar(ctx, profilePic, fallback)
 	}
 
 	takenAt := int64(0)
@@ -221,62 +256,41 @@ func (u *Uploader) ProcessAndSendPost(ctx context.Context, user *source.UserProf
 		displayFilename = sdk.FilenameFromURL(rawDisplay, "story.jpg")
 	}
 
-	payload := map[string]any{
-		"id":                   strings.TrimSpace(postID),
-		"story_ids":            storyIDs,
-		"media_count":          len(items),
-		"taken_at":             takenAt,
-		"is_video":             rawVideo != "" || isVideoPost,
-		"like_count":           likeCount,
-		"comment_count":        commentCount,
-		"title":                storyText,
-		"content":              storyText,
-		"display_url":          rawDisplay,
-		"display_url_filename": strings.TrimSpace(displayFilename),
-		"thumbnail_src":        rawThumb,
-		"video_url":            rawVideo,
-		"images":               rawImages,
-		"user_id":              strings.TrimSpace(user.ID),
-		"username":             strings.TrimSpace(user.Username),
-		"full_name":            strings.TrimSpace(user.FullName),
-		"biography":            user.Biography,
-		"profile_pic_url":      profilePic,
-		"followers_count":      user.EdgeFollowedBy,
-		"following_count":      user.EdgeFollow,
-		"is_verified":          user.IsVerified,
-		"is_private":           user.IsPrivate,
-		"external_url":         user.ExternalURL,
-		"category_name":        user.CategoryName,
-		"business_category":    user.BusinessCategoryName,
-	}
-	if len(s3Images) > 0 {
-		payload["s3_images"] = s3Images
-	}
-	if s3Display != "" {
-		payload["s3_display_url"] = s3Display
-	}
-	if s3Thumb != "" {
-		payload["s3_thumbnail_url"] = s3Thumb
-	}
-	if s3Video != "" {
-		payload["s3_video_url"] = s3Video
-	}
-	if s3Profile != "" {
-		payload["s3_profile_pic_url"] = s3Profile
-	}
+	card := sdk.InstagramCard{
+		ID:                 strings.TrimSpace(postID),
+		StoryIDs:           storyIDs,
+		MediaCount:         len(items),
+		TakenAt:            takenAt,
+		IsVideo:            rawVideo != "" || isVideoPost,
+		LikeCount:          likeCount,
+		CommentCount:       commentCount,
+		Title:              storyText,
+		Content:            storyText,
+		DisplayURL:         rawDisplay,
+		DisplayURLFilename: strings.TrimSpace(displayFilename),
+		ThumbnailSrc:       rawThumb,
+		VideoURL:           rawVideo,
+		Images:             rawImages,
+
+		S3Images:        s3Images,
+		S3DisplayURL:    s3Display,
+		S3ThumbnailURL:  s3Thumb,
+		S3VideoURL:      s3Video,
+		S3ProfilePicURL: s3Profile,
+	}
+	fillInstagramUser(&card, user, profilePic)
 
 	content := fmt.Sprintf("@%s posted a new story", strings.TrimSpace(user.Username))
 	avatarURL := profilePic
 	if s3Profile != "" {
 		avatarURL = s3Profile
 	}
-	msg := sdk.WebhookPayload{
-		Content:   content,
-		Type:      "app/x-instagram-card",
-		Payload:   payload,
-		Username:  strings.TrimSpace(user.FullName),
-		AvatarURL: avatarURL,
+	msg, err := sdk.CardWebhook(content, card)
+	if err != nil {
+		return err
 	}
+	msg.Username = strings.TrimSpace(user.FullName)
+	msg.AvatarURL = avatarURL
 	if strings.TrimSpace(msg.Username) == "" {
 		msg.Username = "@" + strings.TrimSpace(user.Username)
 	}
@@ -294,58 +308,38 @@ func (u *Uploader) SendStory(ctx context.Context, user *source.UserProfile, stor
 	}
 	storyText := pickStoryText(story)
 
-	payload := map[string]any{
-		"id":            strings.TrimSpace(story.ID),
-		"taken_at":      story.TakenAt,
-		"is_video":      uploaded.IsVideo,
-		"like_count":    story.LikeCount,
-		"comment_count": story.CommentCount,
-		"title":         storyText,
-		"content":       storyText,
-
-		"display_url":          uploaded.DisplayURL,
-		"display_url_filename": strings.TrimSpace(story.DisplayURLFilename),
-		"thumbnail_src":        uploaded.ThumbURL,
-		"video_url":            uploaded.VideoURL,
-
-		"user_id":           strings.TrimSpace(user.ID),
-		"username":          strings.TrimSpace(user.Username),
-		"full_name":         strings.TrimSpace(user.FullName),
-		"biography":         user.Biography,
-		"profile_pic_url":   uploaded.ProfilePic,
-		"followers_count":   user.EdgeFollowedBy,
-		"following_count":   user.EdgeFollow,
-		"is_verified":       user.IsVerified,
-		"is_private":        user.IsPrivate,
-		"external_url":      user.ExternalURL,
-		"category_name":     user.CategoryName,
-		"business_category": user.BusinessCategoryName,
-	}
-	if uploaded.DisplayKey != "" {
-		payload["s3_display_url"] = uploaded.DisplayKey
-	}
-	if uploaded.ThumbKey != "" {
-		payload["s3_thumbnail_url"] = uploaded.ThumbKey
-	}
-	if uploaded.VideoKey != "" {
-		payload["s3_video_url"] = uploaded.VideoKey
-	}
-	if uploaded.ProfileKey != "" {
-		payload["s3_profile_pic_url"] = uploaded.ProfileKey
+	card := sdk.InstagramCard{
+		ID:           strings.TrimSpace(story.ID),
+		TakenAt:      story.TakenAt,
+		IsVideo:      uploaded.IsVideo,
+		LikeCount:    story.LikeCount,
+		CommentCount: story.CommentCount,
+		Title:        storyText,
+		Content:      storyText,
+
+		DisplayURL:         uploaded.DisplayURL,
+		DisplayURLFilename: strings.TrimSpace(story.DisplayURLFilename),
+		ThumbnailSrc:       uploaded.ThumbURL,
+		VideoURL:           uploaded.VideoURL,
+
+		S3DisplayURL:    uploaded.DisplayKey,
+		S3ThumbnailURL:  uploaded.ThumbKey,
+		S3VideoURL:      uploaded.VideoKey,
+		S3ProfilePicURL: uploaded.ProfileKey,
 	}
+	fillInstagramUser(&card, user, uploaded.ProfilePic)
 
 	content := fmt.Sprintf("@%s posted a new story", strings.TrimSpace(user.Username))
 	avatarURL := uploaded.ProfilePic
 	if uploaded.ProfileKey != "" {
 		avatarURL = uploaded.ProfileKey
 	}
-	msg := sdk.WebhookPayload{
-		Content:   content,
-		Type:      "app/x-instagram-card",
-		Payload:   payload,
-		Username:  strings.TrimSpace(user.FullName),
-		AvatarURL: avatarURL,
+	msg, err := sdk.CardWebhook(content, card)
+	if err != nil {
+		return err
 	}
+	msg.Username = strings.TrimSpace(user.FullName)
+	msg.AvatarURL = avatarURL
 	if strings.TrimSpace(msg.Username) == "" {
 		msg.Username = "@" + strings.TrimSpace(user.Username)
 	}
@@ -412,3 +406,18 @@ func storyIndexLocal(storyID string) int {
 	}
 	return n
 }
+
+func fillInstagramUser(card *sdk.InstagramCard, user *source.UserProfile, profilePic string) {
+	card.UserID = strings.TrimSpace(user.ID)
+	card.Username = strings.TrimSpace(user.Username)
+	card.FullName = strings.TrimSpace(user.FullName)
+	card.Biography = user.Biography
+	card.ProfilePicURL = profilePic
+	card.FollowersCount = user.EdgeFollowedBy
+	card.FollowingCount = user.EdgeFollow
+	card.IsVerified = user.IsVerified
+	card.IsPrivate = user.IsPrivate
+	card.ExternalURL = user.ExternalURL
+	card.CategoryName = user.CategoryName
+	card.BusinessCategory = user.BusinessCategoryName
+}
@@ -14,25 +14,40 @@ import (
 )
 
 type Client struct {
+	// httpClient, when set, is used for every fetch instead of a session.
 	httpClient          *http.Client
+	sessionOpts         sdk.HTTPSessionOptions
 	sourceCursor        atomic.Uint64
 	storySourceProvider func() []storySource
 }
 
-func NewClient(useProxy bool) (*Client, error) {
-	opts := sdk.HTTPClientOptions{
-		Timeout:   30 * time.Second,
-		CookieJar: true,
-		Mode:      "direct",
+// NewClient builds a source client. routes override the session mode per
+// host, e.g. to keep media CDNs off the proxy pool.
+func NewClient(useProxy bool, routes sdk.HTTPRoutes) (*Client, error) {
+	opts := defaultSessionOptions()
+	if !useProxy {
+		opts.Mode = "direct"
 	}
-	if useProxy {
-		opts.Mode = "proxy"
-	}
-	httpClient, err := sdk.NewHTTPClient(opts)
-	if err != nil {
+	opts.Routes = routes
+	// Validate the options once; each fetch cycle opens its own session.
+	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
-	return &Client{httpClient: httpClient}, nil
+	return &Client{sessionOpts: opts}, nil
+}
+
+// defaultSessionOptions keeps one exit IP, cookie jar and user agent per fetch
+// cycle: picuki's signed requests are checked against the clock skew fetched
+// earlier in the same cycle.
+func defaultSessionOptions() sdk.HTTPSessionOptions {
+	return sdk.HTTPSessionOptions{
+		ClientOptions: sdk.HTTPClientOptions{
+			Timeout:   30 * time.Second,
+			CookieJar: true,
+			Mode:      "proxy",
+		},
+		RandomUserAgent: true,
+	}
 }
 
 func (c *Client) FetchStories(ctx context.Context, username string) ([]StoryItem, *UserProfile, error) {
@@ -42,8 +57,8 @@ func (c *Client) FetchStories(ctx context.Context, username string) ([]StoryItem
 		return nil, nil, fmt.Errorf("username required")
 	}
 
-	httpClient := c.getHTTPClient()
-	ua := sdk.RandomBrowserUserAgent()
+	httpClient, ua, closeSession := c.openSession()
+	defer closeSession()
 	sources := c.storySources()
 	if len(sources) == 0 {
 		return nil, nil, fmt.Errorf("no story source configured")
@@ -83,23 +98,25 @@ func (c *Client) storySources() []storySource {
 	return sources
 }
 
-func (c *Client) getHTTPClient() *http.Client {
-	client := c.httpClient
-	if client == nil {
-		tmp, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
-			Timeout:   30 * time.Second,
-			CookieJar: true,
-			Mode:      "proxy",
-		})
-		if err != nil {
-			client = &http.Client{Timeout: 30 * time.Second, Transport: http.DefaultTransport}
-		} else {
-			client = tmp
+// openSession returns the client and user agent for one fetch cycle, and a
+// func that releases its connections.
+func (c *Client) openSession() (*http.Client, string, func()) {
+	if client := c.httpClient; client != nil {
+		if client.Jar == nil {
+			jar, _ := cookiejar.New(nil)
+			client.Jar = jar
 		}
+		return client, sdk.RandomBrowserUserAgent(), func() {}
 	}
-	if client.Jar == nil {
+
+	opts := c.sessionOpts
+	if opts.Timeout <= 0 {
+		opts = defaultSessionOptions()
+	}
+	sess, err := sdk.NewHTTPSession(opts)
+	if err != nil {
 		jar, _ := cookiejar.New(nil)
-		client.Jar = jar
+		return &http.Client{Timeout: 30 * time.Second, Transport: http.DefaultTransport, Jar: jar}, sdk.RandomBrowserUserAgent(), func() {}
 	}
-	return client
+	return sess.Client(), sess.UserAgent(), sess.Close
 }
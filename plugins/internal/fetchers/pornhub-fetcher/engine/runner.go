@@ -64,6 +64,14 @@ func (r *Runner) Run(ctx context.Context) error {
 
 	src := source.NewClient(phClient, source.DefaultBaseURL)
 
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: downloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[ph-bot] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
+
 	for i, task := range r.tasks {
 		if !sdk.IsEnabled(task.Enabled) {
 			continue
@@ -78,7 +86,7 @@ func (r *Runner) Run(ctx context.Context) error {
 				log.Printf("%s load state failed: %v", logPrefix, err)
 			}
 
-			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, downloadClient, uploadClient)
+			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, downloadClient, uploadClient).WithAvatars(avatars)
 
 			w := &Worker{
 				logPrefix:     logPrefix,
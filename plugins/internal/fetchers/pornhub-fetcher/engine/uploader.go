@@ -15,6 +15,7 @@ type Uploader struct {
 	logPrefix      string
 	downloadClient *http.Client
 	uploadClient   *http.Client
+	avatars        *sdk.AvatarCache
 }
 
 func NewUploader(apiBase, webhookURL, logPrefix string, downloadClient, uploadClient *http.Client) *Uploader {
@@ -53,11 +54,26 @@ func (u *Uploader) UploadPreview(ctx context.Context, url string) string {
 	return att.Key
 }
 
+// WithAvatars routes avatar uploads through the bot-wide avatar cache, which
+// uploads each avatar once and refreshes it when the origin image changes.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
+}
+
 func (u *Uploader) UploadAvatar(ctx context.Context, url string) string {
 	src := strings.TrimSpace(url)
 	if src == "" {
 		return ""
 	}
+	if u.avatars != nil {
+		key, err := u.avatars.Resolve(ctx, u.apiBase, u.webhookURL, src)
+		if err != nil {
+			log.Printf("%s upload avatar failed: %v", u.logPrefix, err)
+			return ""
+		}
+		return key
+	}
 	att, err := sdk.UploadRemoteToWebhook(ctx, u.downloadClient, u.uploadClient, u.apiBase, u.webhookURL, src, "avatar.jpg")
 	if err != nil {
 		log.Printf("%s upload avatar failed: %v", u.logPrefix, err)
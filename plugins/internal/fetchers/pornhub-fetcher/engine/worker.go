@@ -14,8 +14,7 @@ import (
 )
 
 const (
-	cardMessageType = "app/x-pornhub-card"
-	seenCap         = 1000
+	seenCap = 1000
 )
 
 type Worker struct {
@@ -96,35 +95,30 @@ func (w *Worker) Run(ctx context.Context) {
 }
 
 func (w *Worker) processAndSend(ctx context.Context, author source.Author, item source.Video) error {
-	payload := map[string]any{
-		"title":         item.Title,
-		"url":           item.URL,
-		"thumbnail_url": item.ThumbnailURL,
-		"preview_url":   item.PreviewURL,
+	card := sdk.PornhubCard{
+		Title:        item.Title,
+		URL:          item.URL,
+		ThumbnailURL: item.ThumbnailURL,
+		PreviewURL:   item.PreviewURL,
 	}
 
 	if strings.TrimSpace(item.ThumbnailURL) != "" {
-		if key := w.uploader.UploadThumbnail(ctx, item.ThumbnailURL); key != "" {
-			payload["s3_thumbnail_url"] = key
-		}
+		card.S3ThumbnailURL = w.uploader.UploadThumbnail(ctx, item.ThumbnailURL)
 	}
 	if strings.TrimSpace(item.PreviewURL) != "" {
-		if key := w.uploader.UploadPreview(ctx, item.PreviewURL); key != "" {
-			payload["s3_preview_url"] = key
-		}
+		card.S3PreviewURL = w.uploader.UploadPreview(ctx, item.PreviewURL)
 	}
 	avatarURL := strings.TrimSpace(author.AvatarURL)
 	if key := w.uploader.UploadAvatar(ctx, avatarURL); key != "" {
 		avatarURL = key
 	}
 
-	msg := sdk.WebhookPayload{
-		Content:   fmt.Sprintf("@%v posted a new video - %v", author.Name, item.Title),
-		Type:      cardMessageType,
-		Payload:   payload,
-		Username:  author.Name,
-		AvatarURL: avatarURL,
+	msg, err := sdk.CardWebhook(fmt.Sprintf("@%v posted a new video - %v", author.Name, item.Title), card)
+	if err != nil {
+		return err
 	}
+	msg.Username = author.Name
+	msg.AvatarURL = avatarURL
 
 	if err := sdk.PostWebhook(ctx, w.webhookClient, w.apiBase, w.task.Webhook, msg, 3); err != nil {
 		return err
@@ -48,6 +48,27 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	avatarDownloadClient, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
+		Timeout: 30 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	uploadClient, err := sdk.NewHTTPClient(sdk.HTTPClientOptions{
+		Timeout: 60 * time.Second,
+		Mode:    "direct",
+	})
+	if err != nil {
+		return err
+	}
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: avatarDownloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[rss-fetcher-bot] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
+
 	src := source.NewClient(rssHTTPClient)
 
 	for i, task := range r.tasks {
@@ -69,11 +90,11 @@ func (r *Runner) Run(ctx context.Context) error {
 				logPrefix:       logPrefix,
 				client:          src,
 				tracker:         tr,
-				uploader:        NewUploader(r.apiBase, taskCopy.Webhook, webhookHTTPClient),
+				uploader:        NewUploader(r.apiBase, taskCopy.Webhook, webhookHTTPClient).WithAvatars(avatars),
 				task:            taskCopy,
 				firstRun:        true,
 				freshState:      tr.Fresh(),
-				fetchTimeout:    30*time.Second + 15*time.Second,
+				fetchTimeout:    30*time.Second + 30*time.Second + 60*time.Second + 15*time.Second, // feed, avatar download/upload, webhook
 				sendHistory:     sdk.BoolOrDefault(taskCopy.SendHistoryOnStart, false),
 				interval:        time.Duration(taskCopy.Interval) * time.Second,
 				maxItemsPerPoll: taskCopy.MaxItemsPerPoll,
@@ -16,33 +16,53 @@ type Uploader struct {
 	apiBase    string
 	webhookURL string
 	httpClient *http.Client
+	avatars    *sdk.AvatarCache
 }
 
 func NewUploader(apiBase, webhookURL string, httpClient *http.Client) *Uploader {
 	return &Uploader{apiBase: apiBase, webhookURL: webhookURL, httpClient: httpClient}
 }
 
-func (u *Uploader) Post(ctx context.Context, msg sdk.WebhookPayload) error {
-	return sdk.PostWebhook(ctx, u.httpClient, u.apiBase, u.webhookURL, msg, 3)
+// WithAvatars self-hosts feed images used as message avatars.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
 }
 
-func (u *Uploader) BuildItemWebhook(feedTitle, feedImageURL, feedSiteURL, feedURL string, it *gofeed.Item) (sdk.WebhookPayload, bool) {
-	content, payload := FormatItemCard(feedTitle, it, feedSiteURL, feedURL)
-	if payload == nil || strings.TrimSpace(anyToString(payload["url"])) == "" {
-		return sdk.WebhookPayload{}, false
-	}
-	return sdk.WebhookPayload{
-		Content:   content,
-		Type:      "app/x-rss-card",
-		Payload:   payload,
-		Username:  strings.TrimSpace(feedTitle),
-		AvatarURL: strings.TrimSpace(feedImageURL),
-	}, true
+func (u *Uploader) Post(ctx context.Context, msg sdk.WebhookPayload) error {
+	if u.avatars != nil {
+		// On failure the feed's own image URL stays in place.
+		_ = u.avatars.Apply(ctx, u.apiBase, u.webhookURL, &msg)
+	}
+	return sdk.PostWebhook(ctx, u.httpClient, u.apiBase, u.webhookURL, msg, 3)
 }
 
-func anyToString(v any) string {
-	s, _ := v.(string)
-	return s
+// BuildItemWebhook builds the card message for an item. Items without a link
+// are skipped (ok=false). A card that fails validation (e.g. a non-http(s)
+// link) is still posted with its fields as-is, and the validation error is
+// returned alongside so the caller can log it.
+func (u *Uploader) BuildItemWebhook(feedTitle, feedImageURL, feedSiteURL, feedURL string, it *gofeed.Item) (sdk.WebhookPayload, bool, error) {
+	content, card, ok := FormatItemCard(feedTitle, it, feedSiteURL, feedURL)
+	if !ok {
+		return sdk.WebhookPayload{}, false, nil
+	}
+	msg, err := sdk.CardWebhook(content, card)
+	if err != nil {
+		if strings.TrimSpace(card.URL) == "" {
+			return sdk.WebhookPayload{}, false, err
+		}
+		msg = sdk.WebhookPayload{Content: content, Type: card.CardType(), Payload: map[string]any{
+			"title":         card.Title,
+			"summary":       card.Summary,
+			"url":           card.URL,
+			"thumbnail_url": card.ThumbnailURL,
+			"feed_title":    card.FeedTitle,
+			"published_at":  card.PublishedAt,
+		}}
+	}
+	msg.Username = strings.TrimSpace(feedTitle)
+	msg.AvatarURL = strings.TrimSpace(feedImageURL)
+	return msg, true, err
 }
 
 func itemLink(it *gofeed.Item) string {
@@ -92,9 +112,9 @@ func itemThumbnail(it *gofeed.Item) string {
 	return ""
 }
 
-func FormatItemCard(feedTitle string, it *gofeed.Item, feedSiteURL string, feedURL string) (string, map[string]any) {
+func FormatItemCard(feedTitle string, it *gofeed.Item, feedSiteURL string, feedURL string) (string, sdk.RSSCard, bool) {
 	if it == nil {
-		return "", nil
+		return "", sdk.RSSCard{}, false
 	}
 	title := sdk.CleanText(it.Title)
 	if title == "" {
@@ -127,16 +147,16 @@ func FormatItemCard(feedTitle string, it *gofeed.Item, feedSiteURL string, feedU
 	}
 	publishedAt := itemPublished(it)
 
-	payload := map[string]any{
-		"title":         title,
-		"summary":       summary,
-		"url":           link,
-		"thumbnail_url": thumb,
-		"feed_title":    strings.TrimSpace(feedTitle),
-		"published_at":  publishedAt,
+	card := sdk.RSSCard{
+		Title:        title,
+		Summary:      summary,
+		URL:          link,
+		ThumbnailURL: thumb,
+		FeedTitle:    strings.TrimSpace(feedTitle),
+		PublishedAt:  publishedAt,
 	}
 
-	return title, payload
+	return title, card, true
 }
 
 func ItemTime(it *gofeed.Item) time.Time {
@@ -108,16 +108,24 @@ func (w *Worker) Run(ctx context.Context) {
 		for _, d := range dated {
 			it := d.it
 			id := ItemIdentity(it)
-			w.tracker.MarkSeen(id)
 
-			msg, ok := w.uploader.BuildItemWebhook(feedTitle, feedImageURL, feedSiteURL, w.task.RSSURL, it)
+			msg, ok, err := w.uploader.BuildItemWebhook(feedTitle, feedImageURL, feedSiteURL, w.task.RSSURL, it)
+			if err != nil {
+				log.Printf("%s invalid item card (guid=%q posted=%v): %v", w.logPrefix, it.GUID, ok, err)
+			}
 			if !ok {
+				w.tracker.MarkSeen(id)
 				continue
 			}
 			if err := w.uploader.Post(ctx, msg); err != nil {
 				log.Printf("%s post failed: %v", w.logPrefix, err)
-				continue
+				if ctx.Err() != nil || sdk.IsWebhookRetryable(err) {
+					// Leave this item and the rest unseen so the next poll
+					// retries them in order.
+					break
+				}
 			}
+			w.tracker.MarkSeen(id)
 		}
 
 		_ = w.tracker.Save()
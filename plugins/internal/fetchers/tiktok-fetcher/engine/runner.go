@@ -56,6 +56,14 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: downloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[tt-bot] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
+
 	for i, task := range r.tasks {
 		if !sdk.IsEnabled(task.Enabled) {
 			continue
@@ -77,7 +85,7 @@ func (r *Runner) Run(ctx context.Context) error {
 				log.Printf("%s load state failed: %v", logPrefix, err)
 			}
 
-			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, downloadClient, uploadClient, tr)
+			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, downloadClient, uploadClient, tr).WithMedia(taskCopy.Media).WithAvatars(avatars)
 
 			w := &Worker{
 				logPrefix:   logPrefix,
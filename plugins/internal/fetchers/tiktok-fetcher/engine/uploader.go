@@ -20,6 +20,8 @@ type Uploader struct {
 	downloadClient *http.Client
 	uploadClient   *http.Client
 	tracker        *Manager
+	media          sdk.MediaOptions
+	avatars        *sdk.AvatarCache
 }
 
 func NewUploader(
@@ -38,13 +40,45 @@ func NewUploader(
 	}
 }
 
+// WithMedia sets the task's upload media options (size caps, re-encoding).
+// Thumbnails are not generated: the card has no field to show them.
+func (u *Uploader) WithMedia(opts sdk.MediaOptions) *Uploader {
+	opts.ThumbnailSize = 0
+	u.media = opts
+	return u
+}
+
+// WithAvatars routes avatar uploads through the bot-wide avatar cache, which
+// uploads each avatar once and refreshes it when the origin image changes.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
+}
+
+// uploadAvatar returns an attachment key for an avatar URL ("" on failure).
+func (u *Uploader) uploadAvatar(ctx context.Context, remoteURL string) string {
+	src := strings.TrimSpace(remoteURL)
+	if src == "" {
+		return ""
+	}
+	if u.avatars == nil {
+		return u.uploadWithCache(ctx, src, "avatar"+path.Ext(src))
+	}
+	key, err := u.avatars.Resolve(ctx, u.apiBase, u.webhookURL, src)
+	if err != nil {
+		log.Printf("%s upload avatar failed: url=%s err=%v", u.logPrefix, src, err)
+		return ""
+	}
+	return key
+}
+
 func (u *Uploader) uploadWithCache(ctx context.Context, remoteURL, fallbackFilename string) string {
 	src := strings.TrimSpace(remoteURL)
 	if src == "" {
 		return ""
 	}
 
-	key, _, err := sdk.UploadRemoteToWebhookCached(
+	keys, _, err := sdk.UploadRemoteMediaToWebhookCached(
 		ctx,
 		u.tracker,
 		u.downloadClient,
@@ -53,19 +87,20 @@ func (u *Uploader) uploadWithCache(ctx context.Context, remoteURL, fallbackFilen
 		u.webhookURL,
 		src,
 		fallbackFilename,
+		u.media,
 	)
 	if err != nil {
 		log.Printf("%s upload media failed: url=%s err=%v", u.logPrefix, src, err)
 		return ""
 	}
-	return key
+	return keys.Key
 }
 
 func (u *Uploader) SendVideo(ctx context.Context, feed source.Feed, v source.Video) error {
 	avatar := strings.TrimSpace(feed.Profile.AvatarURL)
 	s3Avatar := ""
 	if avatar != "" {
-		s3Avatar = u.uploadWithCache(ctx, avatar, "avatar"+path.Ext(avatar))
+		s3Avatar = u.uploadAvatar(ctx, avatar)
 	}
 
 	cover := strings.TrimSpace(v.ThumbnailURL)
@@ -80,41 +115,36 @@ func (u *Uploader) SendVideo(ctx context.Context, feed source.Feed, v source.Vid
 		s3Video = u.uploadWithCache(ctx, videoURL, "video.mp4")
 	}
 
-	payload := map[string]any{
-		"id":          strings.TrimSpace(v.ID),
-		"url":         strings.TrimSpace(v.URL),
-		"title":       strings.TrimSpace(v.Title),
-		"description": strings.TrimSpace(v.Description),
-		"upload_date": strings.TrimSpace(v.UploadDate),
-		"duration":    strings.TrimSpace(v.Duration),
-		"views":       v.Views,
-		"likes":       v.Likes,
-		"comments":    v.Comments,
-		"shares":      v.Shares,
-		"width":       v.Width,
-		"height":      v.Height,
-		"video_url":   videoURL,
-		"cover_url":   cover,
-
-		"audio_name":   strings.TrimSpace(v.AudioName),
-		"audio_author": strings.TrimSpace(v.AudioAuthor),
-
-		"profile_name":      strings.TrimSpace(feed.Profile.Name),
-		"profile_username":  strings.TrimSpace(feed.Profile.Username),
-		"profile_bio":       strings.TrimSpace(feed.Profile.Bio),
-		"profile_url":       strings.TrimSpace(feed.Profile.ProfileURL),
-		"profile_avatar":    avatar,
-		"profile_hearts":    feed.Profile.Hearts,
-		"profile_followers": feed.Profile.Followers,
-	}
-	if s3Video != "" {
-		payload["s3_video_url"] = s3Video
-	}
-	if s3Cover != "" {
-		payload["s3_cover_url"] = s3Cover
-	}
-	if s3Avatar != "" {
-		payload["s3_profile_avatar"] = s3Avatar
+	card := sdk.TikTokCard{
+		ID:          strings.TrimSpace(v.ID),
+		URL:         strings.TrimSpace(v.URL),
+		Title:       strings.TrimSpace(v.Title),
+		Description: strings.TrimSpace(v.Description),
+		UploadDate:  strings.TrimSpace(v.UploadDate),
+		Duration:    strings.TrimSpace(v.Duration),
+		Views:       v.Views,
+		Likes:       v.Likes,
+		Comments:    v.Comments,
+		Shares:      v.Shares,
+		Width:       v.Width,
+		Height:      v.Height,
+		VideoURL:    videoURL,
+		CoverURL:    cover,
+
+		AudioName:   strings.TrimSpace(v.AudioName),
+		AudioAuthor: strings.TrimSpace(v.AudioAuthor),
+
+		ProfileName:      strings.TrimSpace(feed.Profile.Name),
+		ProfileUsername:  strings.TrimSpace(feed.Profile.Username),
+		ProfileBio:       strings.TrimSpace(feed.Profile.Bio),
+		ProfileURL:       strings.TrimSpace(feed.Profile.ProfileURL),
+		ProfileAvatar:    avatar,
+		ProfileHearts:    feed.Profile.Hearts,
+		ProfileFollowers: feed.Profile.Followers,
+
+		S3VideoURL:      s3Video,
+		S3CoverURL:      s3Cover,
+		S3ProfileAvatar: s3Avatar,
 	}
 
 	content := fmt.Sprintf("@%s posted a new TikTok video", strings.TrimSpace(feed.Profile.Username))
@@ -134,13 +164,12 @@ func (u *Uploader) SendVideo(ctx context.Context, feed source.Feed, v source.Vid
 		avatarForMessage = s3Avatar
 	}
 
-	msg := sdk.WebhookPayload{
-		Content:   content,
-		Type:      "app/x-tiktok-card",
-		Payload:   payload,
-		Username:  displayName,
-		AvatarURL: avatarForMessage,
+	msg, err := sdk.CardWebhook(content, card)
+	if err != nil {
+		return err
 	}
+	msg.Username = displayName
+	msg.AvatarURL = avatarForMessage
 	if strings.TrimSpace(msg.Username) == "" {
 		msg.Username = "TikTok"
 	}
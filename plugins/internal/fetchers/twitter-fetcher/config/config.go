@@ -8,12 +8,13 @@ import (
 )
 
 type taskRaw struct {
-	Interval           int    `json:"interval"`
-	Webhook            string `json:"webhook"`
-	Username           string `json:"username"`
-	Handle             string `json:"handle"`
-	Enabled            *bool  `json:"enabled"`
-	SendHistoryOnStart *bool  `json:"send_history_on_start"`
+	Interval           int              `json:"interval"`
+	Webhook            string           `json:"webhook"`
+	Username           string           `json:"username"`
+	Handle             string           `json:"handle"`
+	Enabled            *bool            `json:"enabled"`
+	SendHistoryOnStart *bool            `json:"send_history_on_start"`
+	Media              sdk.MediaOptions `json:"media"`
 }
 
 type TaskConfig struct {
@@ -22,6 +23,7 @@ type TaskConfig struct {
 	Username           string
 	Enabled            *bool
 	SendHistoryOnStart *bool
+	Media              sdk.MediaOptions
 }
 
 func ParseTasks(rawConfig string) ([]TaskConfig, error) {
@@ -54,12 +56,17 @@ func ParseTasks(rawConfig string) ([]TaskConfig, error) {
 			return nil, fmt.Errorf("tasks[%d].username is required", i)
 		}
 
+		if err := t.Media.Validate(); err != nil {
+			return nil, fmt.Errorf("tasks[%d].media invalid: %w", i, err)
+		}
+
 		validated = append(validated, TaskConfig{
 			Interval:           interval,
 			Webhook:            webhook,
 			Username:           username,
 			Enabled:            t.Enabled,
 			SendHistoryOnStart: t.SendHistoryOnStart,
+			Media:              t.Media,
 		})
 	}
 
@@ -66,6 +66,14 @@ func (r *Runner) Run(ctx context.Context) error {
 
 	src := source.NewClient(twitterClient)
 
+	avatars, err := sdk.NewAvatarCache(sdk.AvatarCacheFile(r.serviceType, r.botID), sdk.AvatarOptions{
+		DownloadClient: downloadClient,
+		UploadClient:   uploadClient,
+	})
+	if err != nil {
+		log.Printf("[tw-bot] bot=%s load avatar cache failed: %v", r.botID, err)
+	}
+
 	for i, task := range r.tasks {
 		if !sdk.IsEnabled(task.Enabled) {
 			continue
@@ -81,7 +89,7 @@ func (r *Runner) Run(ctx context.Context) error {
 				log.Printf("%s load state failed: %v", logPrefix, err)
 			}
 
-			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, downloadClient, uploadClient, tr)
+			uploader := NewUploader(r.apiBase, taskCopy.Webhook, logPrefix, webhookClient, downloadClient, uploadClient, tr).WithMedia(taskCopy.Media).WithAvatars(avatars)
 
 			w := &Worker{
 				logPrefix:    logPrefix,
@@ -23,6 +23,8 @@ type Uploader struct {
 	downloadClient *http.Client
 	uploadClient   *http.Client
 	tracker        *Manager
+	media          sdk.MediaOptions
+	avatars        *sdk.AvatarCache
 }
 
 func NewUploader(
@@ -41,12 +43,44 @@ func NewUploader(
 	}
 }
 
+// WithMedia sets the task's upload media options (size caps, re-encoding).
+// Thumbnails are not generated: the card has no field to show them.
+func (u *Uploader) WithMedia(opts sdk.MediaOptions) *Uploader {
+	opts.ThumbnailSize = 0
+	u.media = opts
+	return u
+}
+
+// WithAvatars routes avatar uploads through the bot-wide avatar cache, which
+// uploads each avatar once and refreshes it when the origin image changes.
+func (u *Uploader) WithAvatars(c *sdk.AvatarCache) *Uploader {
+	u.avatars = c
+	return u
+}
+
+// uploadAvatar returns an attachment key for an avatar URL ("" on failure).
+func (u *Uploader) uploadAvatar(ctx context.Context, remoteURL string) string {
+	src := strings.TrimSpace(remoteURL)
+	if src == "" {
+		return ""
+	}
+	if u.avatars == nil {
+		return u.uploadWithCache(ctx, src, "avatar"+path.Ext(src))
+	}
+	key, err := u.avatars.Resolve(ctx, u.apiBase, u.webhookURL, src)
+	if err != nil {
+		log.Printf("%s upload avatar failed: url=%s err=%v", u.logPrefix, src, err)
+		return ""
+	}
+	return key
+}
+
 func (u *Uploader) uploadWithCache(ctx context.Context, remoteURL, fallbackFilename string) string {
 	src := NormalizeMediaURL(remoteURL)
 	if src == "" {
 		return ""
 	}
-	key, _, err := sdk.UploadRemoteToWebhookCached(
+	keys, _, err := sdk.UploadRemoteMediaToWebhookCached(
 		ctx,
 		u.tracker,
 		u.downloadClient,
@@ -55,12 +89,13 @@ func (u *Uploader) uploadWithCache(ctx context.Context, remoteURL, fallbackFilen
 		u.webhookURL,
 		src,
 		fallbackFilename,
+		u.media,
 	)
 	if err != nil {
 		log.Printf("%s upload media failed: url=%s err=%v", u.logPrefix, src, err)
 		return ""
 	}
-	return key
+	return keys.Key
 }
 
 func (u *Uploader) SendTweet(ctx context.Context, tl source.Timeline, wrapper source.Tweet) error {
@@ -90,7 +125,7 @@ func (u *Uploader) SendTweet(ctx context.Context, tl source.Timeline, wrapper so
 	authorAvatar := NormalizeMediaURL(author.ProfileImageURL)
 	s3AuthorAvatar := ""
 	if authorAvatar != "" {
-		s3AuthorAvatar = u.uploadWithCache(ctx, authorAvatar, "avatar"+path.Ext(authorAvatar))
+		s3AuthorAvatar = u.uploadAvatar(ctx, authorAvatar)
 	}
 
 	s3Images := make([]string, 0, len(display.Images))
@@ -121,42 +156,37 @@ func (u *Uploader) SendTweet(ctx context.Context, tl source.Timeline, wrapper so
 		}
 	}
 
-	payload := map[string]any{
-		"id":            wrapper.RestID,
-		"url":           tweetURL,
-		"text":          text,
-		"created_at":    display.CreatedAt,
-		"is_retweet":    isRetweet,
-		"author_name":   author.Name,
-		"author_handle": author.Handle,
-		"author_avatar": authorAvatar,
-		"images":        images,
-		"video_url":     videoURL,
-		"cover_url":     coverURL,
-		"like_count":    display.FavoriteCount,
-		"retweet_count": display.RetweetCount,
-		"reply_count":   display.ReplyCount,
-		"quote_count":   display.QuoteCount,
-		"view_count":    SafeInt64ToString(display.ViewCount),
-	}
-	if len(s3Images) > 0 {
-		payload["s3_images"] = s3Images
-	}
-	if s3AuthorAvatar != "" {
-		payload["s3_author_avatar"] = s3AuthorAvatar
-	}
-	if s3Cover != "" {
-		payload["s3_cover_url"] = s3Cover
+	card := sdk.TwitterCard{
+		ID:           wrapper.RestID,
+		URL:          tweetURL,
+		Text:         text,
+		CreatedAt:    display.CreatedAt,
+		IsRetweet:    &isRetweet,
+		AuthorName:   author.Name,
+		AuthorHandle: author.Handle,
+		AuthorAvatar: authorAvatar,
+		Images:       images,
+		VideoURL:     videoURL,
+		CoverURL:     coverURL,
+		LikeCount:    display.FavoriteCount,
+		RetweetCount: display.RetweetCount,
+		ReplyCount:   display.ReplyCount,
+		QuoteCount:   display.QuoteCount,
+		ViewCount:    SafeInt64ToString(display.ViewCount),
+
+		S3Images:       s3Images,
+		S3AuthorAvatar: s3AuthorAvatar,
+		S3CoverURL:     s3Cover,
 	}
 	if s3Video != "" {
-		payload["s3_video_url"] = s3Video
-		payload["video_content_type"] = videoCT
+		card.S3VideoURL = s3Video
+		card.VideoContentType = videoCT
 	}
 	if display.QuotedTweet != nil && strings.TrimSpace(display.QuotedTweet.RestID) != "" {
-		payload["quoted_tweet"] = u.buildTweetCardPayloadDepth(ctx, tl, *display.QuotedTweet, 1)
+		card.QuotedTweet = u.buildTweetCardDepth(ctx, tl, *display.QuotedTweet, 1)
 	}
 	if display.RetweetedTweet != nil && strings.TrimSpace(display.RetweetedTweet.RestID) != "" {
-		payload["retweeted_tweet"] = u.buildTweetCardPayloadDepth(ctx, tl, *display.RetweetedTweet, 1)
+		card.RetweetedTweet = u.buildTweetCardDepth(ctx, tl, *display.RetweetedTweet, 1)
 	}
 
 	content := text
@@ -168,17 +198,16 @@ func (u *Uploader) SendTweet(ctx context.Context, tl source.Timeline, wrapper so
 	}
 
 	avatarURL := NormalizeMediaURL(tl.MonitoredUser.ProfileImageURL)
-	if key := u.uploadWithCache(ctx, avatarURL, "avatar"+path.Ext(avatarURL)); key != "" {
+	if key := u.uploadAvatar(ctx, avatarURL); key != "" {
 		avatarURL = key
 	}
 
-	msg := sdk.WebhookPayload{
-		Content:   content,
-		Type:      "app/x-twitter-card",
-		Payload:   payload,
-		Username:  tl.MonitoredUser.Name,
-		AvatarURL: avatarURL,
+	msg, err := sdk.CardWebhook(content, card)
+	if err != nil {
+		return err
 	}
+	msg.Username = tl.MonitoredUser.Name
+	msg.AvatarURL = avatarURL
 
 	if err := sdk.PostWebhook(ctx, u.webhookClient, u.apiBase, u.webhookURL, msg, 3); err != nil {
 		return err
@@ -187,11 +216,7 @@ func (u *Uploader) SendTweet(ctx context.Context, tl source.Timeline, wrapper so
 	return nil
 }
 
-func (u *Uploader) buildTweetCardPayload(ctx context.Context, tl source.Timeline, t source.Tweet) map[string]any {
-	return u.buildTweetCardPayloadDepth(ctx, tl, t, 0)
-}
-
-func (u *Uploader) buildTweetCardPayloadDepth(ctx context.Context, tl source.Timeline, t source.Tweet, depth int) map[string]any {
+func (u *Uploader) buildTweetCardDepth(ctx context.Context, tl source.Timeline, t source.Tweet, depth int) *sdk.TwitterCard {
 	author := tl.Users[t.UserID]
 	author = enrichAuthorFromUserKey(author, t.UserID)
 
@@ -200,7 +225,7 @@ func (u *Uploader) buildTweetCardPayloadDepth(ctx context.Context, tl source.Tim
 	authorAvatar := NormalizeMediaURL(author.ProfileImageURL)
 	s3AuthorAvatar := ""
 	if authorAvatar != "" {
-		s3AuthorAvatar = u.uploadWithCache(ctx, authorAvatar, "avatar"+path.Ext(authorAvatar))
+		s3AuthorAvatar = u.uploadAvatar(ctx, authorAvatar)
 	}
 
 	s3Images := make([]string, 0, len(t.Images))
@@ -231,41 +256,36 @@ func (u *Uploader) buildTweetCardPayloadDepth(ctx context.Context, tl source.Tim
 		}
 	}
 
-	out := map[string]any{
-		"id":            t.RestID,
-		"url":           tweetURL,
-		"text":          text,
-		"created_at":    t.CreatedAt,
-		"author_name":   author.Name,
-		"author_handle": author.Handle,
-		"author_avatar": authorAvatar,
-		"images":        images,
-		"video_url":     videoURL,
-		"cover_url":     coverURL,
-		"like_count":    t.FavoriteCount,
-		"retweet_count": t.RetweetCount,
-		"reply_count":   t.ReplyCount,
-		"quote_count":   t.QuoteCount,
-		"view_count":    SafeInt64ToString(t.ViewCount),
-	}
-	if len(s3Images) > 0 {
-		out["s3_images"] = s3Images
-	}
-	if s3AuthorAvatar != "" {
-		out["s3_author_avatar"] = s3AuthorAvatar
-	}
-	if s3Cover != "" {
-		out["s3_cover_url"] = s3Cover
+	out := &sdk.TwitterCard{
+		ID:           t.RestID,
+		URL:          tweetURL,
+		Text:         text,
+		CreatedAt:    t.CreatedAt,
+		AuthorName:   author.Name,
+		AuthorHandle: author.Handle,
+		AuthorAvatar: authorAvatar,
+		Images:       images,
+		VideoURL:     videoURL,
+		CoverURL:     coverURL,
+		LikeCount:    t.FavoriteCount,
+		RetweetCount: t.RetweetCount,
+		ReplyCount:   t.ReplyCount,
+		QuoteCount:   t.QuoteCount,
+		ViewCount:    SafeInt64ToString(t.ViewCount),
+
+		S3Images:       s3Images,
+		S3AuthorAvatar: s3AuthorAvatar,
+		S3CoverURL:     s3Cover,
 	}
 	if s3Video != "" {
-		out["s3_video_url"] = s3Video
-		out["video_content_type"] = videoCT
+		out.S3VideoURL = s3Video
+		out.VideoContentType = videoCT
 	}
 	if depth < 3 && t.QuotedTweet != nil && strings.TrimSpace(t.QuotedTweet.RestID) != "" {
-		out["quoted_tweet"] = u.buildTweetCardPayloadDepth(ctx, tl, *t.QuotedTweet, depth+1)
+		out.QuotedTweet = u.buildTweetCardDepth(ctx, tl, *t.QuotedTweet, depth+1)
 	}
 	if depth < 3 && t.RetweetedTweet != nil && strings.TrimSpace(t.RetweetedTweet.RestID) != "" {
-		out["retweeted_tweet"] = u.buildTweetCardPayloadDepth(ctx, tl, *t.RetweetedTweet, depth+1)
+		out.RetweetedTweet = u.buildTweetCardDepth(ctx, tl, *t.RetweetedTweet, depth+1)
 	}
 	return out
 }
@@ -294,7 +314,7 @@ func (u *Uploader) cacheTweetAuthorAvatars(
 	author := enrichAuthorFromUserKey(tl.Users[t.UserID], t.UserID)
 	avatar := NormalizeMediaURL(author.ProfileImageURL)
 	if avatar != "" {
-		_ = u.uploadWithCache(ctx, avatar, "avatar"+path.Ext(avatar))
+		_ = u.uploadAvatar(ctx, avatar)
 	}
 
 	if t.QuotedTweet != nil {
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type AddressReason string
+
+const (
+	AddressedByMention AddressReason = "mention"
+	AddressedByReply   AddressReason = "reply"
+	AddressedByName    AddressReason = "name"
+	AddressedByPrefix  AddressReason = "prefix"
+	AddressedByDM      AddressReason = "dm"
+)
+
+// Addressing is the routing decision for one inbound message.
+type Addressing struct {
+	Addressed bool
+	Reason    AddressReason
+
+	// Text is the message's ContextText with bot mentions, a leading bot
+	// name or a leading prefix removed. Only meaningful when Addressed.
+	Text string
+
+	// ReplyTo is the message the bot should thread its answer under: the
+	// triggering message outside DMs, empty in DMs where replies are implied.
+	ReplyTo string
+}
+
+// AddressingPolicy decides whether a message is addressed to the bot.
+//
+// Checks run in order: mention (anywhere in the text or in the mentions list),
+// reply to a bot message, leading name, leading prefix, DM. The first match
+// sets Reason; cleaning is applied regardless of which check matched.
+type AddressingPolicy struct {
+	BotUserID string
+
+	// Names are case-insensitive aliases that address the bot when they lead
+	// the message, e.g. "jpdict, 猫" or "hey jpdict 猫".
+	Names []string
+
+	// Prefixes address the bot when the message starts with one of them,
+	// e.g. "!" for "!echo hi". The prefix is stripped from Text.
+	Prefixes []string
+}
+
+// AddressingConfig is the "addressing" block of an agent's Bot.config. It
+// configures the name and prefix triggers of AddressingPolicy.
+type AddressingConfig struct {
+	Names    []string `json:"names,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Policy returns the policy for botUserID with c's names and prefixes. Blank
+// entries are dropped.
+func (c AddressingConfig) Policy(botUserID string) AddressingPolicy {
+	return AddressingPolicy{
+		BotUserID: botUserID,
+		Names:     nonBlank(c.Names),
+		Prefixes:  nonBlank(c.Prefixes),
+	}
+}
+
+func nonBlank(in []string) []string {
+	var out []string
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+var mentionTokenRE = regexp.MustCompile(`<@!?(\w+)>`)
+
+var leadingGreetings = []string{"hey", "hi", "hello", "yo", "ok", "okay"}
+
+// Resolve applies the policy. isDM and repliedToBot come from state the
+// caller owns (DM channel cache, known bot message IDs).
+func (p AddressingPolicy) Resolve(msg ChannelMessage, isDM, repliedToBot bool) Addressing {
+	text, mentioned := p.stripMentions(msg.ContextText())
+	if !mentioned && p.BotUserID != "" && slices.Contains(MentionIDs(msg.MentionsRaw), p.BotUserID) {
+		mentioned = true
+	}
+
+	rest, named := p.stripLeadingName(text)
+	if named {
+		text = rest
+	}
+	rest, prefixed := p.stripPrefix(text)
+	if prefixed {
+		text = rest
+	}
+
+	out := Addressing{Addressed: true, Text: text}
+	switch {
+	case mentioned:
+		out.Reason = AddressedByMention
+	case repliedToBot:
+		out.Reason = AddressedByReply
+	case named:
+		out.Reason = AddressedByName
+	case prefixed:
+		out.Reason = AddressedByPrefix
+	case isDM:
+		out.Reason = AddressedByDM
+	default:
+		return Addressing{}
+	}
+	if !isDM {
+		out.ReplyTo = strings.TrimSpace(msg.ID)
+	}
+	return out
+}
+
+// stripMentions removes every bot mention token and collapses the whitespace
+// left behind. Mentions of other users are kept.
+func (p AddressingPolicy) stripMentions(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if p.BotUserID == "" {
+		return trimmed, false
+	}
+	found := false
+	out := mentionTokenRE.ReplaceAllStringFunc(trimmed, func(tok string) string {
+		m := mentionTokenRE.FindStringSubmatch(tok)
+		if len(m) == 2 && m[1] == p.BotUserID {
+			found = true
+			return " "
+		}
+		return tok
+	})
+	if !found {
+		return trimmed, false
+	}
+	return strings.Join(strings.Fields(out), " "), true
+}
+
+func (p AddressingPolicy) stripLeadingName(text string) (string, bool) {
+	if len(p.Names) == 0 {
+		return text, false
+	}
+	s := text
+	if w, rest := leadingWord(s); w != "" && slices.Contains(leadingGreetings, strings.ToLower(w)) {
+		s = rest
+	}
+	s = strings.TrimPrefix(s, "@")
+	for _, name := range p.Names {
+		name = strings.TrimSpace(name)
+		if name == "" || len(s) < len(name) || !strings.EqualFold(s[:len(name)], name) {
+			continue
+		}
+		rest := s[len(name):]
+		if rest != "" {
+			r, _ := utf8.DecodeRuneInString(rest)
+			if !isNameBoundary(r) {
+				continue
+			}
+		}
+		return strings.TrimLeftFunc(rest, isNameBoundary), true
+	}
+	return text, false
+}
+
+func (p AddressingPolicy) stripPrefix(text string) (string, bool) {
+	for _, prefix := range p.Prefixes {
+		if prefix == "" || !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		return strings.TrimSpace(text[len(prefix):]), true
+	}
+	return text, false
+}
+
+func leadingWord(s string) (word, rest string) {
+	end := strings.IndexFunc(s, isNameBoundary)
+	if end <= 0 {
+		return "", s
+	}
+	return s[:end], strings.TrimLeftFunc(s[end:], isNameBoundary)
+}
+
+func isNameBoundary(r rune) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+	switch r {
+	case ',', ':', '，', '：', '、':
+		return true
+	}
+	return false
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"strings"
 
 	sdkapi "mew/plugins/pkg/api"
+	"mew/plugins/pkg/api/rest"
 )
 
 func DownloadAttachmentBytes(ctx context.Context, mewHTTPClient, externalHTTPClient *http.Client, apiBase, userToken string, att sdkapi.AttachmentRef, limit int64) ([]byte, error) {
@@ -19,19 +19,9 @@ func DownloadAttachmentBytes(ctx context.Context, mewHTTPClient, externalHTTPCli
 	key := strings.TrimSpace(att.Key)
 	channelID := strings.TrimSpace(att.ChannelID)
 	if key != "" && channelID != "" && mewHTTPClient != nil {
-		u := fmt.Sprintf("%s/channels/%s/uploads/%s", strings.TrimRight(strings.TrimSpace(apiBase), "/"), url.PathEscape(channelID), url.PathEscape(key))
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-		if err != nil {
-			return nil, err
-		}
-		if strings.TrimSpace(userToken) != "" {
-			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(userToken))
-		}
-		resp, err := mewHTTPClient.Do(req)
-		if err == nil {
-			defer resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				return io.ReadAll(io.LimitReader(resp.Body, limit))
+		if c, err := rest.Shared(apiBase, mewHTTPClient, userToken); err == nil {
+			if data, err := c.Uploads.Download(ctx, channelID, key, limit); err == nil {
+				return data, nil
 			}
 		}
 	}
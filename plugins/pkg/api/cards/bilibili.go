@@ -0,0 +1,125 @@
+package cards
+
+import "encoding/json"
+
+const TypeBilibili = "app/x-bilibili-card"
+
+// Bilibili dynamic kinds (BilibiliCard.Type).
+const (
+	BilibiliVideo     = "video"
+	BilibiliArticle   = "article"
+	BilibiliPost      = "post"
+	BilibiliPGC       = "pgc"
+	BilibiliMusic     = "music"
+	BilibiliCommon    = "common"
+	BilibiliLiveShare = "live_share"
+	BilibiliMedialist = "medialist"
+	BilibiliUGCSeason = "ugc_season"
+	BilibiliCourses   = "courses"
+	BilibiliForward   = "forward"
+	BilibiliLive      = "live"
+	BilibiliUnknown   = "unknown"
+)
+
+// BilibiliCard is a Bilibili dynamic. Which optional fields are set depends
+// on Type; forwards nest the original dynamic in OriginalPost.
+type BilibiliCard struct {
+	DynamicID       string `json:"dynamic_id"`
+	DynamicURL      string `json:"dynamic_url"`
+	AuthorName      string `json:"author_name"`
+	AuthorFace      string `json:"author_face"`
+	AuthorMID       int64  `json:"author_mid"`
+	AuthorType      string `json:"author_type"`
+	PublishedAt     int64  `json:"published_at"`
+	PublishedLoc    string `json:"published_loc"`
+	BiliDynamicType string `json:"bili_dynamic_type"`
+	BiliMajorType   string `json:"bili_major_type,omitempty"`
+	// Additional is Bilibili's raw module_dynamic.additional block.
+	Additional map[string]any `json:"additional,omitempty"`
+
+	Type        string   `json:"type"`
+	Title       string   `json:"title,omitempty"`
+	Subtitle    string   `json:"subtitle,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	ImageURLs   []string `json:"image_urls,omitempty"`
+
+	BVID        string `json:"bvid,omitempty"`
+	VideoURL    string `json:"video_url,omitempty"`
+	ArticleURL  string `json:"article_url,omitempty"`
+	SeasonID    string `json:"season_id,omitempty"`
+	EpisodeID   string `json:"ep_id,omitempty"`
+	PGCURL      string `json:"pgc_url,omitempty"`
+	MusicID     string `json:"music_id,omitempty"`
+	MusicURL    string `json:"music_url,omitempty"`
+	RoomID      string `json:"room_id,omitempty"`
+	LiveURL     string `json:"live_url,omitempty"`
+	MedialistID string `json:"medialist_id,omitempty"`
+	CourseID    string `json:"course_id,omitempty"`
+
+	OriginalPost   *BilibiliCard `json:"original_post,omitempty"`
+	OriginalAuthor string        `json:"original_author,omitempty"`
+
+	Emojis []BilibiliEmoji `json:"emojis,omitempty"`
+
+	S3AuthorFace string   `json:"s3_author_face,omitempty"`
+	S3CoverURL   string   `json:"s3_cover_url,omitempty"`
+	S3ImageURLs  []string `json:"s3_image_urls,omitempty"`
+}
+
+// BilibiliEmoji is an inline emoji referenced as Text (e.g. "[doge]").
+type BilibiliEmoji struct {
+	Text      string `json:"text"`
+	IconURL   string `json:"icon_url"`
+	S3IconURL string `json:"s3_icon_url,omitempty"`
+}
+
+// bilibiliTypeFields lists the string fields each Type always carries, even
+// when empty; the remaining optional fields are only sent when set.
+var bilibiliTypeFields = map[string][]string{
+	BilibiliVideo:     {"title", "description", "bvid", "cover_url", "video_url"},
+	BilibiliArticle:   {"title", "summary", "article_url"},
+	BilibiliPost:      {"text"},
+	BilibiliPGC:       {"title", "subtitle", "description", "season_id", "ep_id", "cover_url", "pgc_url"},
+	BilibiliMusic:     {"title", "description", "music_id", "author", "cover_url", "music_url"},
+	BilibiliCommon:    {"title", "description", "cover_url", "url"},
+	BilibiliLiveShare: {"title", "description", "room_id", "cover_url", "live_url"},
+	BilibiliMedialist: {"title", "description", "medialist_id", "cover_url", "url"},
+	BilibiliUGCSeason: {"title", "description", "season_id", "cover_url", "url"},
+	BilibiliCourses:   {"title", "description", "course_id", "cover_url", "url"},
+	BilibiliForward:   {"text"},
+	BilibiliLive:      {"title", "room_id", "cover_url", "live_url"},
+	BilibiliUnknown:   {"title", "url"},
+}
+
+func (c BilibiliCard) MarshalJSON() ([]byte, error) {
+	type plain BilibiliCard
+	b, err := json.Marshal(plain(c))
+	if err != nil {
+		return nil, err
+	}
+	fields := bilibiliTypeFields[c.Type]
+	if c.OriginalPost != nil {
+		fields = append(fields[:len(fields):len(fields)], "original_author")
+	}
+	return withDefaults(b, `""`, fields...)
+}
+
+func (BilibiliCard) CardType() string { return TypeBilibili }
+
+func (c BilibiliCard) Validate() error {
+	if err := required(TypeBilibili, "type", c.Type); err != nil {
+		return err
+	}
+	if c.DynamicURL == "" && c.Title == "" && c.Text == "" && c.CoverURL == "" && len(c.ImageURLs) == 0 {
+		return &ValidationError{Type: TypeBilibili, Field: "dynamic_url", Reason: "or a title, text or media is required"}
+	}
+	if c.OriginalPost != nil {
+		return c.OriginalPost.Validate()
+	}
+	return nil
+}
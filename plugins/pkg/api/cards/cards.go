@@ -0,0 +1,117 @@
+// Package cards defines typed payloads for the app/x-*-card message types the
+// client renders, plus a registry that validates and versions them.
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"mew/plugins/pkg/api/webhook"
+)
+
+// VersionKey is the payload key carrying the card schema version. Payloads
+// without it are treated as version 1.
+const VersionKey = "card_version"
+
+// Card is a typed message payload.
+type Card interface {
+	// CardType returns the message type, e.g. "app/x-rss-card".
+	CardType() string
+	// Validate reports whether the client can render the card.
+	Validate() error
+}
+
+// ValidationError describes a card field the client cannot render.
+type ValidationError struct {
+	Type   string
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Type, e.Field, e.Reason)
+}
+
+// Payload validates card and encodes it as a message payload stamped with its
+// registered schema version.
+func Payload(card Card) (map[string]any, error) {
+	if card == nil {
+		return nil, fmt.Errorf("card is nil")
+	}
+	if err := card.Validate(); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(card)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", card.CardType(), err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", card.CardType(), err)
+	}
+	if s, ok := Lookup(card.CardType()); ok {
+		out[VersionKey] = s.Version
+	}
+	return out, nil
+}
+
+// Webhook builds a webhook message for card. content is the plain-text
+// fallback shown by clients that do not render the card type.
+func Webhook(content string, card Card) (webhook.Payload, error) {
+	payload, err := Payload(card)
+	if err != nil {
+		return webhook.Payload{}, err
+	}
+	return webhook.Payload{Content: content, Type: card.CardType(), Payload: payload}, nil
+}
+
+// withDefaults adds keys missing from the JSON object b with the raw JSON
+// value def, for cards whose optional fields are always present in some
+// variants.
+func withDefaults(b []byte, def string, keys ...string) ([]byte, error) {
+	if len(keys) == 0 {
+		return b, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if _, ok := obj[k]; !ok {
+			obj[k] = json.RawMessage(def)
+		}
+	}
+	return json.Marshal(obj)
+}
+
+func required(cardType, field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return &ValidationError{Type: cardType, Field: field, Reason: "is required"}
+	}
+	return nil
+}
+
+// httpURL checks an optional http(s) URL. s3_* fields hold upload keys and are
+// not checked.
+func httpURL(cardType, field, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Type: cardType, Field: field, Reason: "must be an http(s) URL"}
+	}
+	return nil
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cards
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPayload_StampsVersionAndValidates(t *testing.T) {
+	p, err := Payload(RSSCard{Title: "t", URL: "https://example.com/a"})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+	if p["url"] != "https://example.com/a" || p[VersionKey] != 1 {
+		t.Fatalf("unexpected payload: %#v", p)
+	}
+	if _, ok := p["summary"]; !ok {
+		t.Fatalf("always-present fields should stay in the payload: %#v", p)
+	}
+
+	_, err = Payload(RSSCard{Title: "no link"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "url" {
+		t.Fatalf("expected a url validation error, got %v", err)
+	}
+}
+
+func TestPayload_KeepsVariantFields(t *testing.T) {
+	has := func(p map[string]any, keys ...string) bool {
+		for _, k := range keys {
+			if _, ok := p[k]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	video, err := Payload(BilibiliCard{Type: BilibiliVideo, DynamicURL: "https://t.bilibili.com/1", Title: "v"})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+	if !has(video, "description", "bvid", "cover_url", "video_url") || has(video, "text") || has(video, "room_id") {
+		t.Fatalf("video card should carry exactly its own fields: %#v", video)
+	}
+
+	tw, err := Payload(TwitterCard{URL: "https://x.com/a/status/1", IsRetweet: new(bool), QuotedTweet: &TwitterCard{URL: "https://x.com/b/status/2"}})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+	if tw["is_retweet"] != false || has(tw["quoted_tweet"].(map[string]any), "is_retweet") {
+		t.Fatalf("is_retweet should be sent on the top-level tweet only: %#v", tw)
+	}
+
+	post, err := Payload(InstagramCard{Username: "u", StoryIDs: []string{"1"}, MediaCount: 1})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+	story, err := Payload(InstagramCard{Username: "u"})
+	if err != nil {
+		t.Fatalf("Payload: %v", err)
+	}
+	if !has(post, "story_ids", "media_count", "images") || has(story, "story_ids") || has(story, "images") {
+		t.Fatalf("merged posts should always carry story fields: post=%#v story=%#v", post, story)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	card, err := Decode(TypeTwitter, []byte(`{"url":"https://x.com/a/status/1","text":"hi","quoted_tweet":{"url":"https://x.com/b/status/2","text":"q"}}`))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	tw, ok := card.(*TwitterCard)
+	if !ok || tw.QuotedTweet == nil || tw.QuotedTweet.Text != "q" {
+		t.Fatalf("unexpected card: %#v", card)
+	}
+
+	if _, err := Decode(TypeTwitter, []byte(`{"url":"https://x.com/a","card_version":2}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+	}
+	if err := Validate("app/x-nope-card", map[string]any{}); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+	if err := Validate(TypeJpdict, map[string]any{"content": ""}); err == nil {
+		t.Fatalf("empty jpdict content should not validate")
+	}
+}
+
+func TestRichCard_Validate(t *testing.T) {
+	ok := RichCard{
+		Title:  "Deploy finished",
+		Body:   "main → prod",
+		Color:  "#2ecc71",
+		Media:  []RichMedia{{S3URL: "shot.png"}},
+		Fields: []RichField{{Name: "Duration", Value: "42s", Inline: true}},
+		Links:  []RichLink{{Label: "Logs", URL: "https://ci.example.com/1"}},
+		Footer: &RichFooter{Text: "ci-bot"},
+	}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid card rejected: %v", err)
+	}
+
+	cases := map[string]RichCard{
+		"empty":      {},
+		"color":      {Title: "x", Color: "green"},
+		"media":      {Title: "x", Media: []RichMedia{{Type: "image"}}},
+		"media type": {Title: "x", Media: []RichMedia{{Type: "audio", URL: "https://a/b"}}},
+		"field":      {Title: "x", Fields: []RichField{{Name: "n"}}},
+		"link":       {Title: "x", Links: []RichLink{{Label: "l", URL: "javascript:alert(1)"}}},
+	}
+	for name, c := range cases {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestRegister_RejectsDuplicates(t *testing.T) {
+	if err := Register(Schema{Type: TypeRSS, New: func() Card { return &RSSCard{} }}); err == nil {
+		t.Fatalf("registering a built-in type twice should fail")
+	}
+	if _, ok := Lookup(TypeRich); !ok {
+		t.Fatalf("rich card should be registered")
+	}
+}
@@ -0,0 +1,65 @@
+package cards
+
+import "encoding/json"
+
+const TypeInstagram = "app/x-instagram-card"
+
+// InstagramCard is a story or a post merged from several story items. Merged
+// posts set StoryIDs and always carry story_ids, media_count and images.
+type InstagramCard struct {
+	ID                 string   `json:"id"`
+	StoryIDs           []string `json:"story_ids,omitempty"`
+	MediaCount         int      `json:"media_count,omitempty"`
+	TakenAt            int64    `json:"taken_at"`
+	IsVideo            bool     `json:"is_video"`
+	LikeCount          int64    `json:"like_count"`
+	CommentCount       int64    `json:"comment_count"`
+	Title              string   `json:"title"`
+	Content            string   `json:"content"`
+	DisplayURL         string   `json:"display_url"`
+	DisplayURLFilename string   `json:"display_url_filename"`
+	ThumbnailSrc       string   `json:"thumbnail_src"`
+	VideoURL           string   `json:"video_url"`
+	Images             []string `json:"images,omitempty"`
+
+	UserID           string `json:"user_id"`
+	Username         string `json:"username"`
+	FullName         string `json:"full_name"`
+	Biography        string `json:"biography"`
+	ProfilePicURL    string `json:"profile_pic_url"`
+	FollowersCount   int64  `json:"followers_count"`
+	FollowingCount   int64  `json:"following_count"`
+	IsVerified       bool   `json:"is_verified"`
+	IsPrivate        bool   `json:"is_private"`
+	ExternalURL      string `json:"external_url"`
+	CategoryName     string `json:"category_name"`
+	BusinessCategory string `json:"business_category"`
+
+	S3Images        []string `json:"s3_images,omitempty"`
+	S3DisplayURL    string   `json:"s3_display_url,omitempty"`
+	S3ThumbnailURL  string   `json:"s3_thumbnail_url,omitempty"`
+	S3VideoURL      string   `json:"s3_video_url,omitempty"`
+	S3ProfilePicURL string   `json:"s3_profile_pic_url,omitempty"`
+}
+
+func (c InstagramCard) MarshalJSON() ([]byte, error) {
+	type plain InstagramCard
+	b, err := json.Marshal(plain(c))
+	if err != nil || c.StoryIDs == nil {
+		return b, err
+	}
+	if b, err = withDefaults(b, `[]`, "story_ids", "images"); err != nil {
+		return nil, err
+	}
+	return withDefaults(b, `0`, "media_count")
+}
+
+func (InstagramCard) CardType() string { return TypeInstagram }
+
+func (c InstagramCard) Validate() error {
+	if c.Username == "" && c.FullName == "" && c.DisplayURL == "" && c.S3DisplayURL == "" &&
+		c.VideoURL == "" && c.S3VideoURL == "" && len(c.Images) == 0 && len(c.S3Images) == 0 && c.Content == "" {
+		return &ValidationError{Type: TypeInstagram, Field: "username", Reason: "or some media or content is required"}
+	}
+	return nil
+}
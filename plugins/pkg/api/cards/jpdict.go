@@ -0,0 +1,12 @@
+package cards
+
+const TypeJpdict = "app/x-jpdict-card"
+
+// JpdictCard is a dictionary/translation answer rendered as Markdown.
+type JpdictCard struct {
+	Content string `json:"content"`
+}
+
+func (JpdictCard) CardType() string { return TypeJpdict }
+
+func (c JpdictCard) Validate() error { return required(TypeJpdict, "content", c.Content) }
@@ -0,0 +1,18 @@
+package cards
+
+const TypePornhub = "app/x-pornhub-card"
+
+type PornhubCard struct {
+	Title          string `json:"title"`
+	URL            string `json:"url"`
+	ThumbnailURL   string `json:"thumbnail_url"`
+	PreviewURL     string `json:"preview_url"`
+	S3ThumbnailURL string `json:"s3_thumbnail_url,omitempty"`
+	S3PreviewURL   string `json:"s3_preview_url,omitempty"`
+}
+
+func (PornhubCard) CardType() string { return TypePornhub }
+
+func (c PornhubCard) Validate() error {
+	return firstErr(required(TypePornhub, "url", c.URL), httpURL(TypePornhub, "url", c.URL))
+}
@@ -0,0 +1,124 @@
+package cards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownType        = errors.New("unknown card type")
+	ErrUnsupportedVersion = errors.New("unsupported card version")
+)
+
+// Schema registers a card type. Version is bumped whenever a field is
+// renamed or its meaning changes; adding optional fields keeps the version.
+type Schema struct {
+	Type    string
+	Version int
+	New     func() Card
+}
+
+var registry = struct {
+	sync.RWMutex
+	m map[string]Schema
+}{m: map[string]Schema{}}
+
+// Register adds a card schema. Plugins may register their own types; the
+// built-in ones are registered by this package.
+func Register(s Schema) error {
+	s.Type = strings.TrimSpace(s.Type)
+	if s.Type == "" || s.New == nil {
+		return fmt.Errorf("card schema needs a type and constructor")
+	}
+	if s.Version <= 0 {
+		s.Version = 1
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.m[s.Type]; ok {
+		return fmt.Errorf("card type %s already registered", s.Type)
+	}
+	registry.m[s.Type] = s
+	return nil
+}
+
+func MustRegister(s Schema) {
+	if err := Register(s); err != nil {
+		panic(err)
+	}
+}
+
+func Lookup(cardType string) (Schema, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	s, ok := registry.m[strings.TrimSpace(cardType)]
+	return s, ok
+}
+
+// Types returns the registered card types, sorted.
+func Types() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	out := make([]string, 0, len(registry.m))
+	for t := range registry.m {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Decode parses a payload of the given card type and validates it.
+// Payloads from a newer schema version than the registered one are rejected.
+func Decode(cardType string, payload []byte) (Card, error) {
+	s, ok := Lookup(cardType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, cardType)
+	}
+	var meta struct {
+		Version int `json:"card_version"`
+	}
+	if err := json.Unmarshal(payload, &meta); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", cardType, err)
+	}
+	if meta.Version > s.Version {
+		return nil, fmt.Errorf("%w: %s v%d (supported: v%d)", ErrUnsupportedVersion, cardType, meta.Version, s.Version)
+	}
+	card := s.New()
+	if err := json.Unmarshal(payload, card); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", cardType, err)
+	}
+	if err := card.Validate(); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// Validate checks an untyped payload against the registered schema. Unknown
+// types return ErrUnknownType.
+func Validate(cardType string, payload map[string]any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = Decode(cardType, b)
+	return err
+}
+
+func init() {
+	for _, s := range []Schema{
+		{Type: TypeRSS, Version: 1, New: func() Card { return &RSSCard{} }},
+		{Type: TypeBilibili, Version: 1, New: func() Card { return &BilibiliCard{} }},
+		{Type: TypeTwitter, Version: 1, New: func() Card { return &TwitterCard{} }},
+		{Type: TypeInstagram, Version: 1, New: func() Card { return &InstagramCard{} }},
+		{Type: TypeTikTok, Version: 1, New: func() Card { return &TikTokCard{} }},
+		{Type: TypePornhub, Version: 1, New: func() Card { return &PornhubCard{} }},
+		{Type: TypeJpdict, Version: 1, New: func() Card { return &JpdictCard{} }},
+		{Type: TypeRich, Version: 1, New: func() Card { return &RichCard{} }},
+	} {
+		MustRegister(s)
+	}
+}
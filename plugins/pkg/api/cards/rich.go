@@ -0,0 +1,136 @@
+package cards
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TypeRich is a generic card any plugin can post without client changes.
+const TypeRich = "app/x-rich-card"
+
+// Rich card limits enforced by Validate.
+const (
+	MaxRichMedia  = 10
+	MaxRichFields = 25
+	MaxRichLinks  = 5
+)
+
+var richColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// RichCard renders as: author line, linked title, plain-text body, a media
+// grid, name/value fields, link buttons and a footer with the timestamp.
+type RichCard struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// Color is an optional "#RRGGBB" accent.
+	Color     string      `json:"color,omitempty"`
+	Author    *RichAuthor `json:"author,omitempty"`
+	Media     []RichMedia `json:"media,omitempty"`
+	Fields    []RichField `json:"fields,omitempty"`
+	Links     []RichLink  `json:"links,omitempty"`
+	Footer    *RichFooter `json:"footer,omitempty"`
+	Timestamp *time.Time  `json:"timestamp,omitempty"`
+}
+
+type RichAuthor struct {
+	Name      string `json:"name"`
+	URL       string `json:"url,omitempty"`
+	IconURL   string `json:"icon_url,omitempty"`
+	S3IconURL string `json:"s3_icon_url,omitempty"`
+}
+
+// RichMedia is an image or video. S3URL holds a webhook upload key and takes
+// precedence over URL.
+type RichMedia struct {
+	// Type is "image" (default) or "video".
+	Type  string `json:"type,omitempty"`
+	URL   string `json:"url,omitempty"`
+	S3URL string `json:"s3_url,omitempty"`
+	Alt   string `json:"alt,omitempty"`
+}
+
+type RichField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type RichLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+type RichFooter struct {
+	Text      string `json:"text"`
+	IconURL   string `json:"icon_url,omitempty"`
+	S3IconURL string `json:"s3_icon_url,omitempty"`
+}
+
+func (RichCard) CardType() string { return TypeRich }
+
+func (c RichCard) Validate() error {
+	if strings.TrimSpace(c.Title) == "" && strings.TrimSpace(c.Body) == "" {
+		return &ValidationError{Type: TypeRich, Field: "title", Reason: "or body is required"}
+	}
+	if err := httpURL(TypeRich, "url", c.URL); err != nil {
+		return err
+	}
+	if c.Color != "" && !richColor.MatchString(c.Color) {
+		return &ValidationError{Type: TypeRich, Field: "color", Reason: "must look like #RRGGBB"}
+	}
+	if c.Author != nil {
+		if err := firstErr(
+			required(TypeRich, "author.name", c.Author.Name),
+			httpURL(TypeRich, "author.url", c.Author.URL),
+			httpURL(TypeRich, "author.icon_url", c.Author.IconURL),
+		); err != nil {
+			return err
+		}
+	}
+	if len(c.Media) > MaxRichMedia {
+		return &ValidationError{Type: TypeRich, Field: "media", Reason: fmt.Sprintf("has more than %d items", MaxRichMedia)}
+	}
+	for i, m := range c.Media {
+		field := fmt.Sprintf("media[%d]", i)
+		if m.Type != "" && m.Type != "image" && m.Type != "video" {
+			return &ValidationError{Type: TypeRich, Field: field + ".type", Reason: "must be image or video"}
+		}
+		if strings.TrimSpace(m.URL) == "" && strings.TrimSpace(m.S3URL) == "" {
+			return &ValidationError{Type: TypeRich, Field: field + ".url", Reason: "or s3_url is required"}
+		}
+		if err := httpURL(TypeRich, field+".url", m.URL); err != nil {
+			return err
+		}
+	}
+	if len(c.Fields) > MaxRichFields {
+		return &ValidationError{Type: TypeRich, Field: "fields", Reason: fmt.Sprintf("has more than %d items", MaxRichFields)}
+	}
+	for i, f := range c.Fields {
+		field := fmt.Sprintf("fields[%d]", i)
+		if err := firstErr(required(TypeRich, field+".name", f.Name), required(TypeRich, field+".value", f.Value)); err != nil {
+			return err
+		}
+	}
+	if len(c.Links) > MaxRichLinks {
+		return &ValidationError{Type: TypeRich, Field: "links", Reason: fmt.Sprintf("has more than %d items", MaxRichLinks)}
+	}
+	for i, l := range c.Links {
+		field := fmt.Sprintf("links[%d]", i)
+		if err := firstErr(
+			required(TypeRich, field+".label", l.Label),
+			required(TypeRich, field+".url", l.URL),
+			httpURL(TypeRich, field+".url", l.URL),
+		); err != nil {
+			return err
+		}
+	}
+	if c.Footer != nil {
+		if err := firstErr(required(TypeRich, "footer.text", c.Footer.Text), httpURL(TypeRich, "footer.icon_url", c.Footer.IconURL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package cards
+
+const TypeRSS = "app/x-rss-card"
+
+// RSSCard is a feed item posted by the RSS fetcher.
+type RSSCard struct {
+	Title        string `json:"title"`
+	Summary      string `json:"summary"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	FeedTitle    string `json:"feed_title"`
+	// PublishedAt is an RFC 3339 timestamp (or the feed's raw date string).
+	PublishedAt string `json:"published_at"`
+}
+
+func (RSSCard) CardType() string { return TypeRSS }
+
+func (c RSSCard) Validate() error {
+	return firstErr(required(TypeRSS, "url", c.URL), httpURL(TypeRSS, "url", c.URL))
+}
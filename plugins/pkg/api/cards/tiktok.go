@@ -0,0 +1,44 @@
+package cards
+
+const TypeTikTok = "app/x-tiktok-card"
+
+type TikTokCard struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	UploadDate  string `json:"upload_date"`
+	Duration    string `json:"duration"`
+	Views       int64  `json:"views"`
+	Likes       int64  `json:"likes"`
+	Comments    int64  `json:"comments"`
+	Shares      int64  `json:"shares"`
+	Width       int64  `json:"width"`
+	Height      int64  `json:"height"`
+	VideoURL    string `json:"video_url"`
+	CoverURL    string `json:"cover_url"`
+
+	AudioName   string `json:"audio_name"`
+	AudioAuthor string `json:"audio_author"`
+
+	ProfileName      string `json:"profile_name"`
+	ProfileUsername  string `json:"profile_username"`
+	ProfileBio       string `json:"profile_bio"`
+	ProfileURL       string `json:"profile_url"`
+	ProfileAvatar    string `json:"profile_avatar"`
+	ProfileHearts    int64  `json:"profile_hearts"`
+	ProfileFollowers int64  `json:"profile_followers"`
+
+	S3VideoURL      string `json:"s3_video_url,omitempty"`
+	S3CoverURL      string `json:"s3_cover_url,omitempty"`
+	S3ProfileAvatar string `json:"s3_profile_avatar,omitempty"`
+}
+
+func (TikTokCard) CardType() string { return TypeTikTok }
+
+func (c TikTokCard) Validate() error {
+	if c.URL == "" && c.VideoURL == "" && c.S3VideoURL == "" && c.CoverURL == "" && c.S3CoverURL == "" && c.Title == "" {
+		return &ValidationError{Type: TypeTikTok, Field: "url", Reason: "or a video, cover or title is required"}
+	}
+	return httpURL(TypeTikTok, "url", c.URL)
+}
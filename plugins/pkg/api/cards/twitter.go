@@ -0,0 +1,41 @@
+package cards
+
+const TypeTwitter = "app/x-twitter-card"
+
+// TwitterCard is a tweet. Quoted and retweeted tweets nest as TwitterCards;
+// the server keeps one level of quoted_tweet. Only the top-level tweet sets
+// IsRetweet.
+type TwitterCard struct {
+	ID           string   `json:"id"`
+	URL          string   `json:"url"`
+	Text         string   `json:"text"`
+	CreatedAt    string   `json:"created_at"`
+	IsRetweet    *bool    `json:"is_retweet,omitempty"`
+	AuthorName   string   `json:"author_name"`
+	AuthorHandle string   `json:"author_handle"`
+	AuthorAvatar string   `json:"author_avatar"`
+	Images       []string `json:"images"`
+	VideoURL     string   `json:"video_url"`
+	CoverURL     string   `json:"cover_url"`
+	LikeCount    int64    `json:"like_count"`
+	RetweetCount int64    `json:"retweet_count"`
+	ReplyCount   int64    `json:"reply_count"`
+	QuoteCount   int64    `json:"quote_count"`
+	// ViewCount is a decimal string ("" when unknown).
+	ViewCount string `json:"view_count"`
+
+	S3Images         []string `json:"s3_images,omitempty"`
+	S3AuthorAvatar   string   `json:"s3_author_avatar,omitempty"`
+	S3CoverURL       string   `json:"s3_cover_url,omitempty"`
+	S3VideoURL       string   `json:"s3_video_url,omitempty"`
+	VideoContentType string   `json:"video_content_type,omitempty"`
+
+	QuotedTweet    *TwitterCard `json:"quoted_tweet,omitempty"`
+	RetweetedTweet *TwitterCard `json:"retweeted_tweet,omitempty"`
+}
+
+func (TwitterCard) CardType() string { return TypeTwitter }
+
+func (c TwitterCard) Validate() error {
+	return firstErr(required(TypeTwitter, "url", c.URL), httpURL(TypeTwitter, "url", c.URL))
+}
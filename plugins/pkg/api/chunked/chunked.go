@@ -0,0 +1,303 @@
+// Package chunked uploads large files as presigned multipart PUTs with
+// per-part retries, progress reporting and resume across restarts.
+//
+// The server protocol (create / sign parts / list parts / complete / abort)
+// is the same for webhook and channel uploads; each client implements
+// Protocol against its own base path.
+package chunked
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUploadNotFound is returned by Protocol.ListParts when the server no
+// longer knows the upload (completed, aborted or expired).
+var ErrUploadNotFound = errors.New("multipart upload not found")
+
+// ErrUnsupported is returned by Protocol.Create when the server has no
+// multipart endpoints; callers fall back to a single-request upload.
+var ErrUnsupported = errors.New("multipart upload not supported by server")
+
+// Session identifies a server-side multipart upload.
+type Session struct {
+	Key       string `json:"key"`
+	UploadID  string `json:"uploadId"`
+	PartSize  int64  `json:"partSize"`
+	PartCount int    `json:"partCount"`
+}
+
+// Part is an uploaded part as reported by the server.
+type Part struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// Result is the completed upload.
+type Result struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+}
+
+type Protocol interface {
+	Create(ctx context.Context, filename, contentType string, size int64) (Session, error)
+	SignParts(ctx context.Context, s Session, partNumbers []int) (map[int]string, error)
+	ListParts(ctx context.Context, s Session) ([]Part, error)
+	Complete(ctx context.Context, s Session, filename, contentType string) (Result, error)
+	Abort(ctx context.Context, s Session) error
+}
+
+// Progress reports bytes sent so far out of total. It is called from the
+// uploading goroutine after each part (and once at start when resuming).
+type Progress func(sent, total int64)
+
+// Options tunes Upload. The zero value uploads without resume state.
+type Options struct {
+	Progress Progress
+	// PartRetries is the number of attempts per part (default 3).
+	PartRetries int
+	// Store and ResumeID persist the session so a later Upload with the
+	// same ResumeID (and the same file size and Fingerprint) continues where
+	// it stopped.
+	Store    StateStore
+	ResumeID string
+	// Fingerprint identifies the file content for resume, e.g. a content
+	// hash or the file's mtime. When empty, Upload hashes r (SHA-256).
+	Fingerprint string
+	// PutClient sends the part PUTs (default: 5 minute timeout client).
+	PutClient *http.Client
+}
+
+// Upload sends size bytes from r using proto. Parts already on the server
+// (from a resumed session) are skipped. Without resume state a failed upload
+// is aborted so the server can drop the parts already stored.
+func Upload(ctx context.Context, proto Protocol, r io.ReaderAt, size int64, filename, contentType string, opts Options) (res Result, err error) {
+	if size <= 0 {
+		return Result{}, fmt.Errorf("size must be > 0")
+	}
+	if opts.PartRetries <= 0 {
+		opts.PartRetries = 3
+	}
+	if opts.PutClient == nil {
+		opts.PutClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resumeID := strings.TrimSpace(opts.ResumeID)
+	if opts.Store == nil {
+		resumeID = ""
+	}
+
+	fingerprint := strings.TrimSpace(opts.Fingerprint)
+	if resumeID != "" && fingerprint == "" {
+		if fingerprint, err = hashContent(r, size); err != nil {
+			return Result{}, err
+		}
+	}
+
+	sess, done, err := resumeOrCreate(ctx, proto, opts.Store, resumeID, fingerprint, size, filename, contentType)
+	if err != nil {
+		return Result{}, err
+	}
+	if resumeID == "" {
+		defer func() {
+			if err != nil {
+				abort(ctx, proto, sess)
+			}
+		}()
+	}
+
+	var sent int64
+	for n := range done {
+		sent += partLen(sess, size, n)
+	}
+	if opts.Progress != nil && sent > 0 {
+		opts.Progress(sent, size)
+	}
+
+	var pending []int
+	for n := 1; n <= sess.PartCount; n++ {
+		if !done[n] {
+			pending = append(pending, n)
+		}
+	}
+	for len(pending) > 0 {
+		batch := pending[:min(len(pending), 20)]
+		pending = pending[len(batch):]
+		urls, err := proto.SignParts(ctx, sess, batch)
+		if err != nil {
+			return Result{}, err
+		}
+		for _, n := range batch {
+			u := strings.TrimSpace(urls[n])
+			if u == "" {
+				return Result{}, fmt.Errorf("server did not sign part %d", n)
+			}
+			off := int64(n-1) * sess.PartSize
+			l := partLen(sess, size, n)
+			body := io.NewSectionReader(r, off, l)
+			err := putPart(ctx, opts.PutClient, u, body, l, opts.PartRetries)
+			var se *statusError
+			if errors.As(err, &se) && se.code == http.StatusForbidden {
+				// The signature likely expired while earlier parts were sent.
+				if again, serr := proto.SignParts(ctx, sess, []int{n}); serr == nil && strings.TrimSpace(again[n]) != "" {
+					err = putPart(ctx, opts.PutClient, again[n], body, l, opts.PartRetries)
+				}
+			}
+			if err != nil {
+				return Result{}, fmt.Errorf("part %d/%d: %w", n, sess.PartCount, err)
+			}
+			done[n] = true
+			sent += l
+			if resumeID != "" {
+				opts.Store.SaveUpload(resumeID, newState(sess, size, fingerprint, filename, contentType, done))
+			}
+			if opts.Progress != nil {
+				opts.Progress(sent, size)
+			}
+		}
+	}
+
+	res, err = proto.Complete(ctx, sess, filename, contentType)
+	if err != nil {
+		return Result{}, err
+	}
+	if resumeID != "" {
+		opts.Store.DeleteUpload(resumeID)
+	}
+	if res.Filename == "" {
+		res.Filename = filename
+	}
+	if res.ContentType == "" {
+		res.ContentType = contentType
+	}
+	if res.Size == 0 {
+		res.Size = size
+	}
+	return res, nil
+}
+
+// resumeOrCreate returns the session and the set of parts already uploaded.
+// A saved session for different content (size or fingerprint changed) is
+// aborted and replaced.
+func resumeOrCreate(ctx context.Context, proto Protocol, store StateStore, resumeID, fingerprint string, size int64, filename, contentType string) (Session, map[int]bool, error) {
+	done := map[int]bool{}
+	if resumeID != "" {
+		st, ok := store.LoadUpload(resumeID)
+		if ok && (st.Size != size || st.Fingerprint != fingerprint) {
+			if st.Session.UploadID != "" {
+				abort(ctx, proto, st.Session)
+			}
+			store.DeleteUpload(resumeID)
+			ok = false
+		}
+		if ok && st.Session.UploadID != "" && st.Session.PartSize > 0 {
+			parts, err := proto.ListParts(ctx, st.Session)
+			switch {
+			case err == nil:
+				for _, p := range parts {
+					if p.PartNumber >= 1 && p.PartNumber <= st.Session.PartCount && p.Size == partLen(st.Session, size, p.PartNumber) {
+						done[p.PartNumber] = true
+					}
+				}
+				return st.Session, done, nil
+			case errors.Is(err, ErrUploadNotFound):
+				store.DeleteUpload(resumeID)
+			default:
+				return Session{}, nil, err
+			}
+		}
+	}
+
+	sess, err := proto.Create(ctx, filename, contentType, size)
+	if err != nil {
+		return Session{}, nil, err
+	}
+	if sess.PartSize <= 0 {
+		return Session{}, nil, fmt.Errorf("server returned invalid part size %d", sess.PartSize)
+	}
+	if want := int((size + sess.PartSize - 1) / sess.PartSize); sess.PartCount != want {
+		sess.PartCount = want
+	}
+	if resumeID != "" {
+		store.SaveUpload(resumeID, newState(sess, size, fingerprint, filename, contentType, done))
+	}
+	return sess, done, nil
+}
+
+// abort releases sess on the server. It runs even when ctx is already
+// cancelled, since cancellation is the usual reason to get here.
+func abort(ctx context.Context, proto Protocol, sess Session) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	_ = proto.Abort(ctx, sess)
+}
+
+// hashContent returns the hex SHA-256 of the first size bytes of r.
+func hashContent(r io.ReaderAt, size int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+		return "", fmt.Errorf("hash upload content: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func partLen(s Session, size int64, n int) int64 {
+	off := int64(n-1) * s.PartSize
+	return min(s.PartSize, size-off)
+}
+
+func putPart(ctx context.Context, client *http.Client, url string, body *io.SectionReader, size int64, attempts int) error {
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(500*i*i) * time.Millisecond):
+			}
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+		if err != nil {
+			return err
+		}
+		req.ContentLength = size
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(b))}
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			// An expired signature (403) won't heal by retrying the same URL.
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("status=%d body=%s", e.code, e.body) }
@@ -0,0 +1,258 @@
+package chunked
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer implements Protocol and accepts part PUTs at /part/<n>.
+type fakeServer struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	partSize int64
+	parts    map[int][]byte
+	fail     map[int]int // part -> remaining 500 responses
+	puts     int
+	created  int
+	aborted  int
+	gone     bool
+}
+
+func newFakeServer(t *testing.T, partSize int64) *fakeServer {
+	t.Helper()
+	f := &fakeServer{partSize: partSize, parts: map[int][]byte{}, fail: map[int]int{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/part/"))
+		if r.Method != http.MethodPut || err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.puts++
+		if f.fail[n] > 0 {
+			f.fail[n]--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.parts[n] = body
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeServer) Create(_ context.Context, _, _ string, size int64) (Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	f.gone = false
+	return Session{Key: "k", UploadID: fmt.Sprintf("up%d", f.created), PartSize: f.partSize, PartCount: int((size + f.partSize - 1) / f.partSize)}, nil
+}
+
+func (f *fakeServer) SignParts(_ context.Context, _ Session, partNumbers []int) (map[int]string, error) {
+	out := map[int]string{}
+	for _, n := range partNumbers {
+		out[n] = fmt.Sprintf("%s/part/%d", f.srv.URL, n)
+	}
+	return out, nil
+}
+
+func (f *fakeServer) ListParts(_ context.Context, _ Session) ([]Part, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.gone {
+		return nil, ErrUploadNotFound
+	}
+	var out []Part
+	for n, b := range f.parts {
+		out = append(out, Part{PartNumber: n, ETag: "e", Size: int64(len(b))})
+	}
+	return out, nil
+}
+
+func (f *fakeServer) Complete(_ context.Context, s Session, filename, contentType string) (Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gone = true
+	return Result{Filename: filename, ContentType: contentType, Key: s.Key}, nil
+}
+
+func (f *fakeServer) Abort(context.Context, Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.aborted++
+	f.gone = true
+	return nil
+}
+
+func (f *fakeServer) assembled() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buf bytes.Buffer
+	for n := 1; n <= len(f.parts); n++ {
+		buf.Write(f.parts[n])
+	}
+	return buf.Bytes()
+}
+
+func TestUpload_SendsAllPartsAndReportsProgress(t *testing.T) {
+	f := newFakeServer(t, 4)
+	data := []byte("0123456789")
+	var last int64
+	calls := 0
+	res, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "application/octet-stream", Options{
+		Progress: func(sent, total int64) {
+			calls++
+			last = sent
+			if total != int64(len(data)) {
+				t.Fatalf("total=%d", total)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if res.Key != "k" || res.Size != int64(len(data)) || res.Filename != "a.bin" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if got := f.assembled(); !bytes.Equal(got, data) {
+		t.Fatalf("assembled=%q", got)
+	}
+	if calls != 3 || last != int64(len(data)) {
+		t.Fatalf("progress calls=%d last=%d", calls, last)
+	}
+}
+
+func TestUpload_RetriesFailedPart(t *testing.T) {
+	f := newFakeServer(t, 4)
+	f.fail[2] = 1
+	data := []byte("0123456789")
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", Options{}); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if f.puts != 4 {
+		t.Fatalf("expected 4 PUTs (one retry), got %d", f.puts)
+	}
+	if got := f.assembled(); !bytes.Equal(got, data) {
+		t.Fatalf("assembled=%q", got)
+	}
+}
+
+func TestUpload_AbortsFailedUploadWithoutStore(t *testing.T) {
+	f := newFakeServer(t, 4)
+	f.fail[2] = 10
+	data := []byte("0123456789")
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", Options{PartRetries: 1}); err == nil {
+		t.Fatalf("expected upload to fail")
+	}
+	if f.aborted != 1 {
+		t.Fatalf("expected the failed upload to be aborted once, got %d", f.aborted)
+	}
+}
+
+func TestUpload_ResumesFromStore(t *testing.T) {
+	f := newFakeServer(t, 4)
+	f.fail[3] = 10
+	data := []byte("0123456789")
+	store := NewMemoryStore()
+	opts := Options{Store: store, ResumeID: "file", PartRetries: 1}
+
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", opts); err == nil {
+		t.Fatalf("expected first upload to fail")
+	}
+	if st, ok := store.LoadUpload("file"); !ok || len(st.Done) != 2 {
+		t.Fatalf("expected saved state with 2 parts, got %+v ok=%v", st, ok)
+	}
+	if f.aborted != 0 {
+		t.Fatalf("expected a resumable upload to be kept, aborted=%d", f.aborted)
+	}
+
+	f.fail[3] = 0
+	putsBefore := f.puts
+	var first int64 = -1
+	opts.Progress = func(sent, _ int64) {
+		if first < 0 {
+			first = sent
+		}
+	}
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", opts); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if f.created != 1 {
+		t.Fatalf("expected the session to be reused, created=%d", f.created)
+	}
+	if f.puts-putsBefore != 1 {
+		t.Fatalf("expected only the missing part to be sent, got %d PUTs", f.puts-putsBefore)
+	}
+	if first != 8 {
+		t.Fatalf("expected resume progress to start at 8, got %d", first)
+	}
+	if _, ok := store.LoadUpload("file"); ok {
+		t.Fatalf("expected state to be deleted after completion")
+	}
+	if got := f.assembled(); !bytes.Equal(got, data) {
+		t.Fatalf("assembled=%q", got)
+	}
+}
+
+func TestUpload_StartsOverWhenServerForgotUpload(t *testing.T) {
+	f := newFakeServer(t, 4)
+	store := NewMemoryStore()
+	store.SaveUpload("file", State{Session: Session{Key: "k", UploadID: "old", PartSize: 4, PartCount: 3}, Size: 10, Fingerprint: "v1", Done: []int{1}})
+	f.gone = true
+
+	data := []byte("0123456789")
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", Options{Store: store, ResumeID: "file", Fingerprint: "v1"}); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if f.created != 1 || f.puts != 3 {
+		t.Fatalf("expected a fresh session, created=%d puts=%d", f.created, f.puts)
+	}
+}
+
+func TestUpload_StartsOverWhenContentChanged(t *testing.T) {
+	f := newFakeServer(t, 4)
+	f.fail[3] = 10
+	store := NewMemoryStore()
+	opts := Options{Store: store, ResumeID: "file", PartRetries: 1}
+	if _, err := Upload(context.Background(), f, bytes.NewReader([]byte("0123456789")), 10, "a.bin", "", opts); err == nil {
+		t.Fatalf("expected first upload to fail")
+	}
+
+	// Same ResumeID and size, different bytes: the old parts must not be reused.
+	f.fail[3] = 0
+	data := []byte("abcdefghij")
+	if _, err := Upload(context.Background(), f, bytes.NewReader(data), int64(len(data)), "a.bin", "", opts); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if f.created != 2 || f.aborted != 1 {
+		t.Fatalf("expected the stale session to be aborted and replaced, created=%d aborted=%d", f.created, f.aborted)
+	}
+	if got := f.assembled(); !bytes.Equal(got, data) {
+		t.Fatalf("assembled=%q", got)
+	}
+}
+
+func TestUpload_PassesThroughUnsupported(t *testing.T) {
+	_, err := Upload(context.Background(), unsupported{}, bytes.NewReader([]byte("x")), 1, "a", "", Options{})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+type unsupported struct{ Protocol }
+
+func (unsupported) Create(context.Context, string, string, int64) (Session, error) {
+	return Session{}, ErrUnsupported
+}
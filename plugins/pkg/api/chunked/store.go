@@ -0,0 +1,102 @@
+package chunked
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"mew/plugins/pkg/state"
+)
+
+// State is the persisted resume record for one upload.
+type State struct {
+	Session     Session `json:"session"`
+	Filename    string  `json:"filename"`
+	ContentType string  `json:"contentType"`
+	Size        int64   `json:"size"`
+	// Fingerprint identifies the content the parts were cut from; see
+	// Options.Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Done lists part numbers known to be uploaded; the server's part list
+	// is still checked on resume.
+	Done []int `json:"done,omitempty"`
+}
+
+// StateStore persists resume records by caller-chosen ID (e.g. a file path
+// or remote URL).
+type StateStore interface {
+	LoadUpload(id string) (State, bool)
+	SaveUpload(id string, st State)
+	DeleteUpload(id string)
+}
+
+func newState(s Session, size int64, fingerprint, filename, contentType string, done map[int]bool) State {
+	st := State{Session: s, Filename: filename, ContentType: contentType, Size: size, Fingerprint: fingerprint}
+	for n := range done {
+		st.Done = append(st.Done, n)
+	}
+	sort.Ints(st.Done)
+	return st
+}
+
+// FileStore keeps one JSON file per upload under Dir.
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore stores resume records under dir (default:
+// state.BaseDir()/uploads).
+func NewFileStore(dir string) *FileStore {
+	if dir == "" {
+		dir = filepath.Join(state.BaseDir(), "uploads")
+	}
+	return &FileStore{Dir: dir}
+}
+
+func (f *FileStore) path(id string) string {
+	sum := sha1.Sum([]byte(id))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (f *FileStore) LoadUpload(id string) (State, bool) {
+	st, err := state.LoadJSONFile[State](f.path(id))
+	if err != nil || st.Session.UploadID == "" {
+		return State{}, false
+	}
+	return st, true
+}
+
+func (f *FileStore) SaveUpload(id string, st State) { _ = state.SaveJSONFile(f.path(id), st) }
+
+func (f *FileStore) DeleteUpload(id string) { _ = os.Remove(f.path(id)) }
+
+// MemoryStore keeps resume records in memory (useful for retries within one
+// process, and in tests).
+type MemoryStore struct {
+	mu sync.Mutex
+	m  map[string]State
+}
+
+func NewMemoryStore() *MemoryStore { return &MemoryStore{m: map[string]State{}} }
+
+func (s *MemoryStore) LoadUpload(id string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.m[id]
+	return st, ok
+}
+
+func (s *MemoryStore) SaveUpload(id string, st State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[id] = st
+}
+
+func (s *MemoryStore) DeleteUpload(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, id)
+}
@@ -42,6 +42,7 @@ type BootstrapBot struct {
 	AccessToken string `json:"accessToken"`
 	ServiceType string `json:"serviceType"`
 	DmEnabled   bool   `json:"dmEnabled"`
+	OwnerID     string `json:"ownerId"`
 }
 
 func (c *Client) BootstrapBots(ctx context.Context, serviceType string) ([]BootstrapBot, error) {
@@ -78,11 +79,30 @@ func (c *Client) BootstrapBots(ctx context.Context, serviceType string) ([]Boots
 }
 
 type ServiceTypeRegistration struct {
-	ServiceType    string `json:"serviceType"`
-	ServerName     string `json:"serverName"`
-	Icon           string `json:"icon"`
-	Description    string `json:"description"`
-	ConfigTemplate string `json:"configTemplate"`
+	ServiceType    string        `json:"serviceType"`
+	ServerName     string        `json:"serverName"`
+	Icon           string        `json:"icon"`
+	Description    string        `json:"description"`
+	ConfigTemplate string        `json:"configTemplate"`
+	Commands       []CommandInfo `json:"commands,omitempty"`
+}
+
+// CommandInfo describes a slash command for client-side autocomplete.
+type CommandInfo struct {
+	Name        string           `json:"name"`
+	Aliases     []string         `json:"aliases,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Usage       string           `json:"usage,omitempty"`
+	Args        []CommandArgInfo `json:"args,omitempty"`
+	DMOnly      bool             `json:"dmOnly,omitempty"`
+	OwnerOnly   bool             `json:"ownerOnly,omitempty"`
+}
+
+type CommandArgInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 func (c *Client) RegisterServiceType(ctx context.Context, serviceType string) error {
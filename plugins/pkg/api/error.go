@@ -1,15 +1,77 @@
 package api
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
-type HTTPStatusError struct {
+// Error is returned for non-2xx MEW API responses.
+type Error struct {
 	StatusCode int
 	Body       string
+
+	// Message is the server's JSON `message` field, when present.
+	Message string
+
+	// RetryAfter is parsed from the Retry-After header (rate limits).
+	RetryAfter time.Duration
 }
 
-func (e *HTTPStatusError) Error() string {
+// HTTPStatusError is the historical name of Error.
+type HTTPStatusError = Error
+
+func (e *Error) Error() string {
 	if e == nil {
 		return "http status error"
 	}
 	return fmt.Sprintf("status=%d body=%s", e.StatusCode, e.Body)
 }
+
+// NewError builds an Error from a response and its (already read) body.
+func NewError(resp *http.Response, body []byte) *Error {
+	e := &Error{Body: strings.TrimSpace(string(body))}
+	if resp == nil {
+		return e
+	}
+	e.StatusCode = resp.StatusCode
+	e.RetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"))
+
+	var parsed struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		e.Message = strings.TrimSpace(parsed.Message)
+	}
+	return e
+}
+
+// IsStatus reports whether err is an *Error with the given status code.
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// ParseRetryAfter accepts delta-seconds or an HTTP date.
+func ParseRetryAfter(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(raw); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(raw); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
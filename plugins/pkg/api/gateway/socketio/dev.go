@@ -0,0 +1,92 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mew/plugins/pkg/x/devmode"
+)
+
+// Dev hooks of RunGatewayWithReconnect*:
+//
+//   - DEV_MODE records every frame to devmode.Dir()/gateway/<serviceType>-<id>.jsonl
+//     unless GatewayOptions.Recorder is already set.
+//   - MEW_GATEWAY_REPLAY=<recording.jsonl> replays that recording into the
+//     handler instead of connecting, writes the frames the handler emitted to
+//     devmode.Dir()/gateway/<serviceType>-replay-<id>.jsonl and then idles until
+//     the context ends. MEW_GATEWAY_REPLAY_SPEED scales the recorded delays
+//     (1 = real time; unset or 0 = no delay). REST calls made by the handler
+//     still go to the configured server.
+
+// devRecorder opens a recording when DEV_MODE is on and the caller did not
+// bring its own recorder. close is always safe to call.
+func devRecorder(opts GatewayOptions) (GatewayOptions, func()) {
+	if opts.Recorder != nil || !devmode.Enabled() {
+		return opts, func() {}
+	}
+	rec, err := OpenDevRecorder()
+	if err != nil {
+		log.Printf("[gateway] DEV_MODE recorder disabled: %v", err)
+		return opts, func() {}
+	}
+	opts.Recorder = rec
+	return opts, func() { _ = rec.Close() }
+}
+
+// replayFromEnv runs the MEW_GATEWAY_REPLAY hook. ok reports whether it was
+// configured.
+func replayFromEnv(ctx context.Context, handler EventHandler) (ok bool, err error) {
+	path := strings.TrimSpace(os.Getenv("MEW_GATEWAY_REPLAY"))
+	if path == "" {
+		return false, nil
+	}
+	speed := 0.0
+	if raw := strings.TrimSpace(os.Getenv("MEW_GATEWAY_REPLAY_SPEED")); raw != "" {
+		if speed, err = strconv.ParseFloat(raw, 64); err != nil {
+			return true, fmt.Errorf("invalid MEW_GATEWAY_REPLAY_SPEED %q: %w", raw, err)
+		}
+	}
+
+	frames, err := LoadRecording(path)
+	if err != nil {
+		return true, fmt.Errorf("load replay %s: %w", path, err)
+	}
+	emitted, replayErr := Replay(ctx, frames, handler, ReplayOptions{Speed: speed})
+
+	out := filepath.Join(devmode.Dir(), "gateway", devmode.ServiceTypeFromCaller()+"-replay-"+devmode.TimestampID()+".jsonl")
+	if err := writeRecording(out, emitted); err != nil {
+		return true, fmt.Errorf("write replay output: %w", err)
+	}
+	log.Printf("[gateway] replayed %s: %d frames emitted -> %s", path, len(emitted), out)
+	if replayErr != nil {
+		return true, replayErr
+	}
+
+	// Keep the runner alive so the bot manager does not restart (and replay) it.
+	<-ctx.Done()
+	return true, ctx.Err()
+}
+
+func writeRecording(path string, frames []RecordedFrame) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, fr := range frames {
+		if err := enc.Encode(fr); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -22,6 +22,10 @@ type GatewayOptions struct {
 	HandshakeTimeout time.Duration
 	ReadTimeout      time.Duration
 	WriteTimeout     time.Duration
+
+	// Recorder, when set, receives every inbound and outbound frame. Recording
+	// is best-effort: write failures never break the connection.
+	Recorder *Recorder
 }
 
 func directWebsocketProxy(*http.Request) (*url.URL, error) { return nil, nil }
@@ -64,7 +68,11 @@ func RunGatewayOnce(ctx context.Context, wsURL, token string, handler EventHandl
 		writeMu.Lock()
 		defer writeMu.Unlock()
 		_ = conn.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
-		return conn.WriteMessage(websocket.TextMessage, []byte(payload))
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(payload)); err != nil {
+			return err
+		}
+		_ = opts.Recorder.Record(FrameOutbound, payload)
+		return nil
 	}
 
 	emit := func(event string, payload any) error {
@@ -101,6 +109,7 @@ func RunGatewayOnce(ctx context.Context, wsURL, token string, handler EventHandl
 			if s == "" {
 				continue
 			}
+			_ = opts.Recorder.Record(FrameInbound, s)
 
 			switch s[0] {
 			case '0': // Engine.IO open
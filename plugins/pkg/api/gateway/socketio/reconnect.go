@@ -57,6 +57,12 @@ func RunGatewayWithReconnectTokenProvider(
 	gatewayOpts GatewayOptions,
 	reconnectOpts ReconnectOptions,
 ) error {
+	if ok, err := replayFromEnv(ctx, handler); ok {
+		return err
+	}
+	gatewayOpts, closeRecorder := devRecorder(gatewayOpts)
+	defer closeRecorder()
+
 	backoff := reconnectOpts.InitialBackoff
 	if backoff <= 0 {
 		backoff = 500 * time.Millisecond
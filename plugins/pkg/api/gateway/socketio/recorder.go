@@ -0,0 +1,161 @@
+package socketio
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mew/plugins/pkg/x/devmode"
+)
+
+type FrameDirection string
+
+const (
+	FrameInbound  FrameDirection = "in"
+	FrameOutbound FrameDirection = "out"
+)
+
+// RecordedFrame is one line of a gateway recording (JSONL).
+type RecordedFrame struct {
+	Time  string         `json:"time"`
+	Dir   FrameDirection `json:"dir"`
+	Frame string         `json:"frame"`
+}
+
+func (f RecordedFrame) At() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, f.Time)
+}
+
+// Recorder appends raw Engine.IO/Socket.IO frames to a JSONL stream.
+// It is safe for concurrent use and can be shared across reconnects.
+type Recorder struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	now    func() time.Time
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w, now: time.Now}
+}
+
+// OpenRecorder appends to the JSONL file at path, creating parent directories as needed.
+func OpenRecorder(path string) (*Recorder, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f)
+	r.closer = f
+	return r, nil
+}
+
+// OpenDevRecorder opens a new recording under devmode.Dir()/gateway, named
+// after the calling plugin's serviceType.
+func OpenDevRecorder() (*Recorder, error) {
+	id := devmode.TimestampID()
+	st := devmode.ServiceTypeFromCaller()
+	return OpenRecorder(filepath.Join(devmode.Dir(), "gateway", st+"-"+id+".jsonl"))
+}
+
+func (r *Recorder) Record(dir FrameDirection, frame string) error {
+	if r == nil || r.w == nil {
+		return nil
+	}
+	b, err := json.Marshal(RecordedFrame{
+		Time:  r.now().UTC().Format(time.RFC3339Nano),
+		Dir:   dir,
+		Frame: redactAuthFrame(dir, frame),
+	})
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(b)
+	return err
+}
+
+func (r *Recorder) Close() error {
+	if r == nil || r.closer == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.closer.Close()
+	r.closer = nil
+	r.w = nil
+	return err
+}
+
+// redactAuthFrame keeps bot tokens out of recordings that may end up in bug reports.
+func redactAuthFrame(dir FrameDirection, frame string) string {
+	if dir != FrameOutbound || !strings.HasPrefix(frame, "40{") {
+		return frame
+	}
+	var auth map[string]any
+	if err := json.Unmarshal([]byte(frame[2:]), &auth); err != nil {
+		return frame
+	}
+	if _, ok := auth["token"]; !ok {
+		return frame
+	}
+	auth["token"] = "[redacted]"
+	b, err := json.Marshal(auth)
+	if err != nil {
+		return frame
+	}
+	return "40" + string(b)
+}
+
+// ReadRecording parses a JSONL gateway recording. Blank lines are skipped.
+func ReadRecording(r io.Reader) ([]RecordedFrame, error) {
+	if r == nil {
+		return nil, errors.New("nil reader")
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var out []RecordedFrame
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var f RecordedFrame
+		if err := json.Unmarshal([]byte(raw), &f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func LoadRecording(path string) ([]RecordedFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
@@ -0,0 +1,154 @@
+package socketio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_WritesJSONLAndRedactsToken(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	if err := rec.Record(FrameOutbound, `40{"token":"secret"}`); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := rec.Record(FrameInbound, `42["MESSAGE_CREATE",{"content":"hi"}]`); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("token leaked into recording: %s", buf.String())
+	}
+
+	frames, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+	if frames[0].Dir != FrameOutbound || frames[0].Frame != `40{"token":"[redacted]"}` {
+		t.Fatalf("unexpected auth frame: %+v", frames[0])
+	}
+	if frames[1].Dir != FrameInbound {
+		t.Fatalf("unexpected dir: %+v", frames[1])
+	}
+	if _, err := frames[1].At(); err != nil {
+		t.Fatalf("At: %v", err)
+	}
+}
+
+func TestRecorder_NilIsNoop(t *testing.T) {
+	t.Parallel()
+
+	var rec *Recorder
+	if err := rec.Record(FrameInbound, "2"); err != nil {
+		t.Fatalf("Record on nil recorder: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close on nil recorder: %v", err)
+	}
+}
+
+func TestReplay_FeedsInboundEventsAndCapturesEmits(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return base.Add(d).Format(time.RFC3339Nano) }
+	frames := []RecordedFrame{
+		{Time: at(0), Dir: FrameInbound, Frame: `0{"sid":"x"}`},
+		{Time: at(0), Dir: FrameOutbound, Frame: `40{"token":"[redacted]"}`},
+		{Time: at(time.Second), Dir: FrameInbound, Frame: `42["MESSAGE_CREATE",{"channelId":"c1","content":"ping"}]`},
+		{Time: at(2 * time.Second), Dir: FrameInbound, Frame: `2`},
+		{Time: at(3 * time.Second), Dir: FrameInbound, Frame: `42["PRESENCE_UPDATE",{}]`},
+	}
+
+	var seen []string
+	handler := func(ctx context.Context, eventName string, payload json.RawMessage, emit EmitFunc) error {
+		seen = append(seen, eventName)
+		if eventName != "MESSAGE_CREATE" {
+			return nil
+		}
+		var msg struct {
+			ChannelID string `json:"channelId"`
+		}
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return err
+		}
+		return emit("message/create", map[string]any{"channelId": msg.ChannelID, "content": "pong"})
+	}
+
+	start := time.Now()
+	out, err := Replay(context.Background(), frames, handler, ReplayOptions{Speed: 1000})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("accelerated replay took too long: %s", elapsed)
+	}
+
+	if strings.Join(seen, ",") != "MESSAGE_CREATE,PRESENCE_UPDATE" {
+		t.Fatalf("unexpected events: %v", seen)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected 1 emitted frame, got %d", len(out))
+	}
+	if out[0].Dir != FrameOutbound || out[0].Frame != `42["message/create",{"channelId":"c1","content":"pong"}]` {
+		t.Fatalf("unexpected emitted frame: %+v", out[0])
+	}
+}
+
+func TestReplay_StopsOnContextCancel(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	frames := []RecordedFrame{
+		{Time: base.Format(time.RFC3339Nano), Dir: FrameInbound, Frame: `42["A",{}]`},
+		{Time: base.Add(time.Hour).Format(time.RFC3339Nano), Dir: FrameInbound, Frame: `42["B",{}]`},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := Replay(ctx, frames, func(context.Context, string, json.RawMessage, EmitFunc) error { return nil }, ReplayOptions{Speed: 1})
+	if err == nil {
+		t.Fatalf("expected context error")
+	}
+}
+
+func TestRunGatewayWithReconnect_ReplaysFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MEW_DEV_DIR", dir)
+	rec := filepath.Join(dir, "in.jsonl")
+	if err := os.WriteFile(rec, []byte(`{"time":"2025-01-01T00:00:00Z","dir":"in","frame":"42[\"MESSAGE_CREATE\",{\"content\":\"ping\"}]"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MEW_GATEWAY_REPLAY", rec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := func(ctx context.Context, eventName string, payload json.RawMessage, emit EmitFunc) error {
+		defer cancel()
+		return emit("message/create", map[string]any{"content": "pong"})
+	}
+	err := RunGatewayWithReconnect(ctx, "ws://unused.invalid", "token", handler, GatewayOptions{}, ReconnectOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v, want context.Canceled", err)
+	}
+
+	outs, _ := filepath.Glob(filepath.Join(dir, "gateway", "*-replay-*.jsonl"))
+	if len(outs) != 1 {
+		t.Fatalf("expected one replay output, got %v", outs)
+	}
+	frames, err := LoadRecording(outs[0])
+	if err != nil || len(frames) != 1 || !strings.Contains(frames[0].Frame, "pong") {
+		t.Fatalf("replay output=%+v err=%v", frames, err)
+	}
+}
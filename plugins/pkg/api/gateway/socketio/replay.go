@@ -0,0 +1,99 @@
+package socketio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ReplayOptions struct {
+	// Speed scales the recorded inter-frame delays: 1 replays in real time,
+	// 2 twice as fast. Zero or negative replays without any delay.
+	Speed float64
+}
+
+// Replay feeds the inbound Socket.IO events of a recording into handler and
+// returns the frames the handler emitted, in order. Outbound frames in the
+// recording and non-event frames (open, ping, ...) are skipped.
+func Replay(ctx context.Context, frames []RecordedFrame, handler EventHandler, opts ReplayOptions) ([]RecordedFrame, error) {
+	if handler == nil {
+		return nil, fmt.Errorf("handler is required")
+	}
+
+	var (
+		mu      sync.Mutex
+		emitted []RecordedFrame
+	)
+	emit := func(event string, payload any) error {
+		frame, err := EmitFrame(event, payload)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, RecordedFrame{
+			Time:  time.Now().UTC().Format(time.RFC3339Nano),
+			Dir:   FrameOutbound,
+			Frame: frame,
+		})
+		return nil
+	}
+	snapshot := func() []RecordedFrame {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]RecordedFrame(nil), emitted...)
+	}
+
+	var prev time.Time
+	for i, f := range frames {
+		if f.Dir != FrameInbound {
+			continue
+		}
+
+		if opts.Speed > 0 {
+			at, err := f.At()
+			if err == nil {
+				if !prev.IsZero() && at.After(prev) {
+					if err := sleepContext(ctx, time.Duration(float64(at.Sub(prev))/opts.Speed)); err != nil {
+						return snapshot(), err
+					}
+				}
+				prev = at
+			}
+		}
+		if ctx.Err() != nil {
+			return snapshot(), ctx.Err()
+		}
+
+		if !strings.HasPrefix(f.Frame, "42") {
+			continue
+		}
+		eventName, payload, ok, err := decodeEventPayload([]byte(f.Frame[2:]))
+		if err != nil {
+			return snapshot(), fmt.Errorf("frame %d: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := handler(ctx, eventName, payload, emit); err != nil {
+			return snapshot(), fmt.Errorf("frame %d (%s): %w", i, eventName, err)
+		}
+	}
+	return snapshot(), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	sdkapi "mew/plugins/pkg/api"
 	"mew/plugins/pkg/api/messages"
+	"mew/plugins/pkg/api/rest"
 	"mew/plugins/pkg/runtime"
 )
 
@@ -162,18 +163,38 @@ func (f *Fetcher) RecordIDForMessage(ctx context.Context, channelID, messageID s
 }
 
 func (f *Fetcher) SearchHistory(ctx context.Context, channelID, keyword string, limit int) ([]sdkapi.ChannelMessage, error) {
-	keyword = strings.TrimSpace(keyword)
-	if keyword == "" {
+	return f.Search(ctx, rest.SearchScope{ChannelID: channelID}, rest.SearchQuery{Query: keyword, Limit: limit})
+}
+
+// Search runs a filtered keyword search (see rest.SearchService) and returns
+// up to q.Limit (default 10) non-retracted matches, newest first, following
+// result pages as needed.
+func (f *Fetcher) Search(ctx context.Context, scope rest.SearchScope, q rest.SearchQuery) ([]sdkapi.ChannelMessage, error) {
+	q.Query = strings.TrimSpace(q.Query)
+	if q.Query == "" {
 		return []sdkapi.ChannelMessage{}, nil
 	}
+	limit := q.Limit
 	if limit <= 0 {
 		limit = 10
 	}
-	msgs, err := messages.SearchChannelMessages(ctx, f.HTTPClient, f.APIBase, f.UserToken, channelID, keyword, limit, 1)
+	q.Limit = limit
+	c, err := rest.Shared(f.APIBase, f.HTTPClient, f.UserToken)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]sdkapi.ChannelMessage, 0, limit)
+	err = c.Search.Each(ctx, scope, q, func(m sdkapi.ChannelMessage) (bool, error) {
+		if m.RetractedAt != nil {
+			return true, nil
+		}
+		out = append(out, m)
+		return len(out) < limit, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return filterRetractedMessages(msgs), nil
+	return out, nil
 }
 
 func (f *Fetcher) UserActivityFrequency(ctx context.Context, channelID, userID string, asOf time.Time) (string, error) {
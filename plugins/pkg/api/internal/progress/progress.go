@@ -0,0 +1,22 @@
+// Package progress reports how much of an upload body has been sent.
+package progress
+
+import "io"
+
+// Reader reports bytes read from R through Fn (Total is -1 when unknown).
+type Reader struct {
+	R     io.Reader
+	Total int64
+	Fn    func(sent, total int64)
+
+	sent int64
+}
+
+func (p *Reader) Read(b []byte) (int, error) {
+	n, err := p.R.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.Fn(p.sent, p.Total)
+	}
+	return n, err
+}
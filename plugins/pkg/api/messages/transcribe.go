@@ -0,0 +1,26 @@
+package messages
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+// Transcribe uploads a voice message's audio to
+// `/api/channels/:channelId/messages/:messageId/transcribe` and returns the
+// transcript. The server also stores it as the message's plain text.
+func Transcribe(
+	ctx context.Context,
+	httpClient *http.Client,
+	apiBase, userToken, channelID, messageID string,
+	filename, contentType string,
+	data []byte,
+) (string, error) {
+	c, err := rest.Shared(apiBase, httpClient, userToken)
+	if err != nil {
+		return "", err
+	}
+	return c.Messages.Transcribe(ctx, channelID, messageID, filename, contentType, bytes.NewReader(data))
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxSpeechBytes caps the synthesized audio read back from the server; larger
+// responses fail instead of being truncated.
+const MaxSpeechBytes = 20 << 20
+
+type AudioService struct{ c *Client }
+
+// SpeechRequest is the body of POST /v1/audio/speech (OpenAI-compatible).
+// Empty Model/Voice use the server defaults (namiai/doubao).
+type SpeechRequest struct {
+	Input string `json:"input"`
+	Model string `json:"model,omitempty"`
+	Voice string `json:"voice,omitempty"`
+}
+
+// Speech synthesizes text with the server's TTS module and returns the audio
+// bytes and their content type (audio/mpeg).
+func (s *AudioService) Speech(ctx context.Context, req SpeechRequest) ([]byte, string, error) {
+	req.Input = strings.TrimSpace(req.Input)
+	if req.Input == "" {
+		return nil, "", fmt.Errorf("input is required")
+	}
+	req.Model = strings.TrimSpace(req.Model)
+	req.Voice = strings.TrimSpace(req.Voice)
+
+	resp, err := s.c.send(ctx, request{method: http.MethodPost, path: "/v1/audio/speech", body: req, accept: "audio/*", idempotent: true})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxSpeechBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxSpeechBytes {
+		return nil, "", fmt.Errorf("speech response exceeds %d bytes", MaxSpeechBytes)
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("speech response is empty")
+	}
+	ct := strings.TrimSpace(resp.Header.Get("Content-Type"))
+	if ct == "" {
+		ct = "audio/mpeg"
+	}
+	return data, ct, nil
+}
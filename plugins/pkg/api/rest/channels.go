@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+const ChannelTypeDM = "DM"
+
+type ChannelsService struct{ c *Client }
+
+type Channel struct {
+	ID         string `json:"_id"`
+	Type       string `json:"type"`
+	Name       string `json:"name,omitempty"`
+	Topic      string `json:"topic,omitempty"`
+	ServerID   string `json:"serverId,omitempty"`
+	CategoryID string `json:"categoryId,omitempty"`
+	Position   int    `json:"position,omitempty"`
+
+	PermissionOverrides []PermissionOverride `json:"permissionOverrides,omitempty"`
+
+	// LastReadMessageID is the bot's read marker, as returned by channel
+	// lists; use Channels.LastRead for a single channel.
+	LastReadMessageID string `json:"lastReadMessageId,omitempty"`
+
+	// RecipientsRaw holds DM recipients; items may be IDs or populated users.
+	RecipientsRaw []json.RawMessage `json:"recipients,omitempty"`
+}
+
+// PermissionOverride allows or denies permissions for a role or member in
+// one channel.
+type PermissionOverride struct {
+	TargetType string   `json:"targetType"` // "role" or "member"
+	TargetID   string   `json:"targetId"`
+	Allow      []string `json:"allow,omitempty"`
+	Deny       []string `json:"deny,omitempty"`
+}
+
+func (ch Channel) IsDM() bool { return ch.Type == ChannelTypeDM }
+
+// RecipientIDs returns the DM recipient user IDs.
+func (ch Channel) RecipientIDs() []string {
+	out := make([]string, 0, len(ch.RecipientsRaw))
+	for _, raw := range ch.RecipientsRaw {
+		if id := sdkapi.AuthorID(bytes.TrimSpace(raw)); id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// ListDMs returns the bot's DM channels (GET /users/@me/channels).
+func (s *ChannelsService) ListDMs(ctx context.Context) ([]Channel, error) {
+	var channels []Channel
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: "/users/@me/channels"}, &channels); err != nil {
+		return nil, err
+	}
+	out := channels[:0]
+	for _, ch := range channels {
+		if strings.TrimSpace(ch.ID) == "" || !ch.IsDM() {
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out, nil
+}
+
+// DMChannelIDs returns the set of the bot's DM channel IDs.
+func (s *ChannelsService) DMChannelIDs(ctx context.Context) (map[string]struct{}, error) {
+	channels, err := s.ListDMs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	next := make(map[string]struct{}, len(channels))
+	for _, ch := range channels {
+		next[ch.ID] = struct{}{}
+	}
+	return next, nil
+}
+
+// CreateDM opens (or returns the existing) DM channel with a user.
+func (s *ChannelsService) CreateDM(ctx context.Context, recipientID string) (Channel, error) {
+	recipientID = strings.TrimSpace(recipientID)
+	if recipientID == "" {
+		return Channel{}, fmt.Errorf("recipientID is required")
+	}
+	var ch Channel
+	err := s.c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/users/@me/channels",
+		body:   map[string]string{"recipientId": recipientID},
+	}, &ch)
+	return ch, err
+}
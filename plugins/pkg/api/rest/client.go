@@ -0,0 +1,299 @@
+// Package rest is a typed client for the MEW REST API as seen by bots.
+//
+// Requests are grouped by resource (Messages, Channels, Users, Stickers,
+// Uploads, Servers) and share one transport, error type (*api.Error) and
+// rate-limit handling. Authentication is left to the http.Client; a
+// BotSession's HTTPClient already injects and refreshes the bot JWT.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+const maxResponseBytes = 2 * 1024 * 1024
+
+type Options struct {
+	// Token is sent as a bearer token when set. Leave empty when the
+	// http.Client already authenticates (e.g. BotSession.HTTPClient()).
+	Token string
+
+	// MaxRetries bounds retries of rate-limited (429) or unavailable (503)
+	// requests. Zero means the default (2); negative disables retries. A 503
+	// is only retried for requests that are safe to repeat (GET, PUT, DELETE
+	// and side-effect-free POSTs such as speech synthesis), so a message is
+	// never posted twice.
+	MaxRetries int
+
+	// MaxRetryWait caps a single rate-limit wait. Defaults to 30s.
+	MaxRetryWait time.Duration
+}
+
+type Client struct {
+	apiBase    string
+	httpClient *http.Client
+	opts       Options
+	limit      *rateLimit
+
+	Messages *MessagesService
+	Channels *ChannelsService
+	Users    *UsersService
+	Stickers *StickersService
+	Uploads  *UploadsService
+	Servers  *ServersService
+	Audio    *AudioService
+	Search   *SearchService
+}
+
+// New builds a client for apiBase (e.g. http://localhost:3000/api).
+func New(apiBase string, httpClient *http.Client, opts Options) (*Client, error) {
+	apiBase = strings.TrimRight(strings.TrimSpace(apiBase), "/")
+	if apiBase == "" {
+		return nil, fmt.Errorf("apiBase is required")
+	}
+	if httpClient == nil {
+		return nil, fmt.Errorf("httpClient is required")
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 2
+	}
+	if opts.MaxRetryWait <= 0 {
+		opts.MaxRetryWait = 30 * time.Second
+	}
+	opts.Token = strings.TrimSpace(opts.Token)
+
+	c := &Client{apiBase: apiBase, httpClient: httpClient, opts: opts, limit: &rateLimit{}}
+	c.Messages = &MessagesService{c: c}
+	c.Channels = &ChannelsService{c: c}
+	c.Users = &UsersService{c: c}
+	c.Stickers = &StickersService{c: c}
+	c.Uploads = &UploadsService{c: c}
+	c.Servers = &ServersService{c: c}
+	c.Search = &SearchService{c: c}
+	c.Audio = &AudioService{c: c}
+	return c, nil
+}
+
+func (c *Client) APIBase() string { return c.apiBase }
+
+func (c *Client) HTTPClient() *http.Client { return c.httpClient }
+
+// request describes one API call. Body is JSON-encoded unless it is a
+// streamBody; only JSON (and empty) bodies are retried.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	accept string
+
+	// idempotent marks a POST/PATCH as safe to send twice.
+	idempotent bool
+}
+
+func (r request) safeToRepeat() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.idempotent
+}
+
+type streamBody struct {
+	contentType string
+	r           io.Reader
+}
+
+// do performs req and decodes a JSON response into out (when non-nil).
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// send performs req and returns the 2xx response; the caller closes the body.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var (
+		payload     []byte
+		stream      *streamBody
+		contentType string
+	)
+	switch b := req.body.(type) {
+	case nil:
+	case streamBody:
+		stream = &b
+		contentType = b.contentType
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		payload = encoded
+		contentType = "application/json"
+	}
+
+	target := c.apiBase + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.waitRateLimit(ctx); err != nil {
+			return nil, err
+		}
+
+		var body io.Reader
+		if stream != nil {
+			body = stream.r
+		} else if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			httpReq.Header.Set("Content-Type", contentType)
+		}
+		accept := req.accept
+		if accept == "" {
+			accept = "application/json"
+		}
+		httpReq.Header.Set("Accept", accept)
+		if c.opts.Token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.opts.Token)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		c.observeRateLimit(resp.Header)
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+		_ = resp.Body.Close()
+		apiErr := sdkapi.NewError(resp, raw)
+
+		// 429 is answered by the rate limiter before the request is handled;
+		// a 503 may come from a proxy after the server already acted on it.
+		retryable := resp.StatusCode == http.StatusTooManyRequests ||
+			(resp.StatusCode == http.StatusServiceUnavailable && req.safeToRepeat())
+		if !retryable || stream != nil || c.opts.MaxRetries < 0 || attempt >= c.opts.MaxRetries {
+			return nil, apiErr
+		}
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = time.Duration(attempt+1) * 500 * time.Millisecond
+		}
+		if wait > c.opts.MaxRetryWait {
+			return nil, apiErr
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// observeRateLimit records the IETF draft RateLimit-* headers the server sends
+// (express-rate-limit standardHeaders). Once the window is exhausted, later
+// requests wait for the reset instead of burning a 429.
+func (c *Client) observeRateLimit(h http.Header) {
+	remaining, err := strconv.Atoi(strings.TrimSpace(h.Get("RateLimit-Remaining")))
+	if err != nil || remaining > 0 {
+		return
+	}
+	reset, err := strconv.Atoi(strings.TrimSpace(h.Get("RateLimit-Reset")))
+	if err != nil || reset <= 0 {
+		return
+	}
+	until := time.Now().Add(time.Duration(reset) * time.Second)
+	c.limit.mu.Lock()
+	if until.After(c.limit.blockedUntil) {
+		c.limit.blockedUntil = until
+	}
+	c.limit.mu.Unlock()
+}
+
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	c.limit.mu.Lock()
+	wait := time.Until(c.limit.blockedUntil)
+	c.limit.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	if wait > c.opts.MaxRetryWait {
+		wait = c.opts.MaxRetryWait
+	}
+	return sleepContext(ctx, wait)
+}
+
+// rateLimit is the rate-limit window of one API identity; clients built by
+// Shared for the same identity share it.
+type rateLimit struct {
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func pathID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", fmt.Errorf("id is required")
+	}
+	return url.PathEscape(id), nil
+}
+
+func channelPath(channelID string) (string, error) {
+	id, err := pathID(channelID)
+	if err != nil {
+		return "", fmt.Errorf("channelID is required")
+	}
+	return "/channels/" + id, nil
+}
+
+func serverPath(serverID string) (string, error) {
+	id, err := pathID(serverID)
+	if err != nil {
+		return "", fmt.Errorf("serverID is required")
+	}
+	return "/servers/" + id, nil
+}
@@ -0,0 +1,497 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+func newTestClient(t *testing.T, h http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL+"/api", srv.Client(), Options{Token: "tok"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestMessagesEach_FollowsBeforeCursor(t *testing.T) {
+	t.Parallel()
+
+	// Five messages, newest first: m5..m1.
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/channels/ch1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("unexpected auth header %q", got)
+		}
+		start := 5
+		if before := r.URL.Query().Get("before"); before != "" {
+			fmt.Sscanf(before, "m%d", &start)
+			start--
+		}
+		var page []map[string]any
+		for i := start; i > 0 && len(page) < 2; i-- {
+			page = append(page, map[string]any{
+				"_id":         fmt.Sprintf("m%d", i),
+				"channelId":   "ch1",
+				"attachments": []map[string]any{{"key": "k"}},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+
+	var ids []string
+	err := c.Messages.Each(context.Background(), "ch1", 2, func(m sdkapi.ChannelMessage) (bool, error) {
+		if len(m.Attachments) != 1 || m.Attachments[0].ChannelID != "ch1" {
+			t.Fatalf("attachment channel not filled: %+v", m.Attachments)
+		}
+		ids = append(ids, m.ID)
+		return m.ID != "m2", nil
+	})
+	if err != nil {
+		t.Fatalf("Each: %v", err)
+	}
+	if strings.Join(ids, ",") != "m5,m4,m3,m2" {
+		t.Fatalf("unexpected order: %v", ids)
+	}
+}
+
+func TestClient_RetriesRateLimitedRequests(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"content":"hi"`) {
+			t.Errorf("body not replayed: %q", body)
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message":"slow down"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"_id":"m1","channelId":"ch1"}`))
+	}))
+
+	msg, err := c.Messages.SendText(context.Background(), "ch1", "hi")
+	if err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if msg.ID != "m1" || calls.Load() != 2 {
+		t.Fatalf("unexpected result: msg=%+v calls=%d", msg, calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryUnavailablePosts(t *testing.T) {
+	t.Parallel()
+
+	var posts, gets atomic.Int32
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := &gets
+		if r.Method == http.MethodPost {
+			n = &posts
+		}
+		if n.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"_id":"u1"}`))
+	}))
+
+	if _, err := c.Messages.SendText(context.Background(), "ch1", "hi"); !sdkapi.IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("SendText err=%v, want 503", err)
+	}
+	if posts.Load() != 1 {
+		t.Fatalf("message POST sent %d times", posts.Load())
+	}
+	if _, err := c.Users.Me(context.Background()); err != nil || gets.Load() != 2 {
+		t.Fatalf("GET should be retried: err=%v calls=%d", err, gets.Load())
+	}
+}
+
+func TestShared_ReusesClient(t *testing.T) {
+	hc := &http.Client{}
+	a, err := Shared("http://mew.test/api/", hc, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := Shared("http://mew.test/api", hc, "tok")
+	other, _ := Shared("http://mew.test/api", hc, "tok2")
+	if a != b || a == other {
+		t.Fatalf("expected one client per (apiBase, httpClient, token)")
+	}
+
+	shared.mu.Lock()
+	before := len(shared.clients)
+	shared.mu.Unlock()
+	for i := 0; i < 10; i++ {
+		c, err := Shared("http://mew.test/api", &http.Client{}, "tok")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.limit != a.limit {
+			t.Fatalf("clones of the http.Client should share the token's rate-limit state")
+		}
+	}
+	shared.mu.Lock()
+	after := len(shared.clients)
+	shared.mu.Unlock()
+	if after != before {
+		t.Fatalf("cloned http.Clients grew the cache from %d to %d entries", before, after)
+	}
+}
+
+func TestClient_ErrorsAreTyped(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Missing permission"}`))
+	}))
+
+	_, err := c.Users.Get(context.Background(), "u1")
+	var apiErr *sdkapi.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *api.Error, got %T %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "Missing permission" {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+	if !sdkapi.IsStatus(err, http.StatusForbidden) {
+		t.Fatalf("IsStatus should match")
+	}
+}
+
+func TestSendFile_UploadsThenReferencesAttachment(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/channels/ch1/uploads", func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Fatalf("multipart: %v", err)
+		}
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		if ct := p.Header.Get("Content-Type"); ct != "image/png" {
+			t.Errorf("content type not inferred from extension: %q", ct)
+		}
+		_, _ = w.Write([]byte(`{"key":"k1","size":3}`))
+	})
+	mux.HandleFunc("/api/channels/ch1/messages", func(w http.ResponseWriter, r *http.Request) {
+		var body CreateMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		want := Attachment{Filename: "a.png", ContentType: "image/png", Key: "k1", Size: 3}
+		if len(body.Attachments) != 1 || body.Attachments[0] != want {
+			t.Errorf("unexpected attachments: %+v", body.Attachments)
+		}
+		_, _ = w.Write([]byte(`{"_id":"m1","channelId":"ch1"}`))
+	})
+	c := newTestClient(t, mux)
+
+	if _, err := c.Messages.SendFile(context.Background(), "ch1", "a.png", "", strings.NewReader("png")); err != nil {
+		t.Fatalf("SendFile: %v", err)
+	}
+}
+
+func TestChannels_ListDMs(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"_id":"dm1","type":"DM","recipients":["u1",{"_id":"u2"}]},{"_id":"g1","type":"GUILD_TEXT"}]`))
+	}))
+
+	dms, err := c.Channels.ListDMs(context.Background())
+	if err != nil {
+		t.Fatalf("ListDMs: %v", err)
+	}
+	if len(dms) != 1 || strings.Join(dms[0].RecipientIDs(), ",") != "u1,u2" {
+		t.Fatalf("unexpected DMs: %+v", dms)
+	}
+}
+
+func TestMessages_EditDeleteReact(t *testing.T) {
+	t.Parallel()
+
+	var seen []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.URL.EscapedPath())
+		if r.Method == http.MethodPatch {
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"content":"fixed"}` {
+				t.Errorf("unexpected edit body: %s", body)
+			}
+		}
+		_, _ = w.Write([]byte(`{"_id":"m1","channelId":"ch1"}`))
+	}))
+
+	ctx := context.Background()
+	if _, err := c.Messages.Edit(ctx, "ch1", "m1", "fixed"); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if _, err := c.Messages.Delete(ctx, "ch1", "m1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := c.Messages.React(ctx, "ch1", "m1", "👍"); err != nil {
+		t.Fatalf("React: %v", err)
+	}
+	if err := c.Messages.Unreact(ctx, "ch1", "m1", "👍"); err != nil {
+		t.Fatalf("Unreact: %v", err)
+	}
+	if err := c.Messages.React(ctx, "ch1", "m1", " "); err == nil {
+		t.Fatalf("expected error for empty emoji")
+	}
+
+	want := []string{
+		"PATCH /api/channels/ch1/messages/m1",
+		"DELETE /api/channels/ch1/messages/m1",
+		"PUT /api/channels/ch1/messages/m1/reactions/%F0%9F%91%8D/@me",
+		"DELETE /api/channels/ch1/messages/m1/reactions/%F0%9F%91%8D/@me",
+	}
+	if strings.Join(seen, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected requests:\n%s", strings.Join(seen, "\n"))
+	}
+}
+
+func TestMessages_TranscribeVoice(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/channels/ch1/uploads/v.webm":
+			_, _ = w.Write([]byte("audio"))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/channels/ch1/messages/m1/transcribe":
+			f, hdr, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("FormFile: %v", err)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			if string(data) != "audio" || hdr.Header.Get("Content-Type") != "audio/webm" {
+				t.Errorf("unexpected upload: %q %q", data, hdr.Header.Get("Content-Type"))
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(" 你好 \n"))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	msg := sdkapi.ChannelMessage{
+		ID:        "m1",
+		ChannelID: "ch1",
+		Type:      "message/voice",
+		Payload:   json.RawMessage(`{"voice":{"key":"v.webm","contentType":"audio/webm","size":5}}`),
+	}
+	text, err := c.Messages.TranscribeVoice(context.Background(), msg, 1024)
+	if err != nil || text != "你好" {
+		t.Fatalf("TranscribeVoice = %q, %v", text, err)
+	}
+
+	msg.PlainText = "cached"
+	if text, err := c.Messages.TranscribeVoice(context.Background(), msg, 1024); err != nil || text != "cached" {
+		t.Fatalf("expected stored transcript, got %q, %v", text, err)
+	}
+}
+
+func TestAudio_SpeechRejectsOversizedResponses(t *testing.T) {
+	t.Parallel()
+
+	size := 4
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write(make([]byte, size))
+	}))
+
+	data, ct, err := c.Audio.Speech(context.Background(), SpeechRequest{Input: "hi"})
+	if err != nil || len(data) != 4 || ct != "audio/mpeg" {
+		t.Fatalf("Speech = %d bytes, %q, %v", len(data), ct, err)
+	}
+
+	size = MaxSpeechBytes + 1
+	if _, _, err := c.Audio.Speech(context.Background(), SpeechRequest{Input: "hi"}); err == nil {
+		t.Fatalf("expected an oversized response to fail")
+	}
+}
+
+func TestMessages_UnreadAfterAck(t *testing.T) {
+	t.Parallel()
+
+	var acked atomic.Value
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/channels/ch1/read-state":
+			_, _ = w.Write([]byte(`{"lastReadMessageId":"m2"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/channels/ch1/messages":
+			// Five messages, newest first: m5..m1.
+			limit := 0
+			fmt.Sscanf(r.URL.Query().Get("limit"), "%d", &limit)
+			start := 5
+			if before := r.URL.Query().Get("before"); before != "" {
+				fmt.Sscanf(before, "m%d", &start)
+				start--
+			}
+			var page []map[string]any
+			for i := start; i > 0 && len(page) < limit; i-- {
+				page = append(page, map[string]any{"_id": fmt.Sprintf("m%d", i), "channelId": "ch1"})
+			}
+			_ = json.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/channels/ch1/ack":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			acked.Store(body["lastMessageId"])
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	msgs, err := c.Messages.Unread(context.Background(), "ch1", 0)
+	if err != nil {
+		t.Fatalf("Unread: %v", err)
+	}
+	var ids []string
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	if strings.Join(ids, ",") != "m3,m4,m5" {
+		t.Fatalf("unexpected unread messages: %v", ids)
+	}
+
+	if msgs, err := c.Messages.Since(context.Background(), "ch1", "m2", 2); err != nil || len(msgs) != 2 || msgs[0].ID != "m4" {
+		t.Fatalf("capped Since should keep the newest messages, got %+v, %v", msgs, err)
+	}
+
+	if err := c.Channels.Ack(context.Background(), "ch1", "m5"); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if got, _ := acked.Load().(string); got != "m5" {
+		t.Fatalf("unexpected ack body %q", got)
+	}
+}
+
+func TestSearch_ServerWideFiltersAndPages(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/servers/s1/search" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("q") != "cat" || q.Get("channelId") != "c1" || q.Get("authorId") != "u1" ||
+			q.Get("hasAttachment") != "true" || q.Get("after") != "2024-01-01T00:00:00Z" || q.Get("before") != "" {
+			t.Errorf("unexpected query %v", q)
+		}
+		page := q.Get("page")
+		_, _ = fmt.Fprintf(w, `{"messages":[{"_id":"m%s","channelId":"c1"}],"pagination":{"page":%s,"limit":1,"total":3,"totalPages":3}}`, page, page)
+	}))
+
+	hasAttachment := true
+	var ids []string
+	err := c.Search.Each(context.Background(), SearchScope{ServerID: "s1", ChannelID: "c1"}, SearchQuery{
+		Query:         "cat",
+		AuthorID:      "u1",
+		HasAttachment: &hasAttachment,
+		After:         time.Date(2024, 1, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*3600)),
+		Limit:         1,
+	}, func(m sdkapi.ChannelMessage) (bool, error) {
+		ids = append(ids, m.ID)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Each: %v", err)
+	}
+	if strings.Join(ids, ",") != "m1,m2,m3" {
+		t.Fatalf("unexpected pages: %v", ids)
+	}
+
+	if _, err := c.Search.Messages(context.Background(), SearchScope{}, SearchQuery{Query: "x"}); err == nil {
+		t.Fatalf("expected an error for an empty scope")
+	}
+}
+
+func TestStickers_AdoptFromMessage(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/channels/dm1/uploads/s.gif", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("GIF89a"))
+	})
+	mux.HandleFunc("/api/users/@me/stickers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s", r.Method)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("multipart: %v", err)
+		}
+		if got := r.FormValue("name"); got != "cat" {
+			t.Errorf("name = %q", got)
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("file: %v", err)
+		}
+		data, _ := io.ReadAll(f)
+		if string(data) != "GIF89a" || h.Header.Get("Content-Type") != "image/gif" {
+			t.Errorf("unexpected file %q (%s)", data, h.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"_id":"st2","scope":"user","name":"cat","url":"u"}`))
+	})
+	mux.HandleFunc("/api/users/@me/stickers/st2", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if _, ok := body["name"]; ok || body["description"] != "" {
+				t.Errorf("patch should only clear the description: %v", body)
+			}
+			_, _ = w.Write([]byte(`{"_id":"st2","name":"cat"}`))
+		case http.MethodDelete:
+			_, _ = w.Write([]byte(`{"stickerId":"st2"}`))
+		default:
+			t.Errorf("method = %s", r.Method)
+		}
+	})
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+
+	msg := sdkapi.ChannelMessage{
+		ID: "m1", ChannelID: "dm1", Type: "message/sticker",
+		Payload: json.RawMessage(`{"stickerId":"st1","sticker":{"_id":"st1","name":"cat","format":"gif","contentType":"image/gif","key":"s.gif","size":6}}`),
+	}
+	st, err := c.Stickers.AdoptFromMessage(ctx, msg, "")
+	if err != nil || st.ID != "st2" {
+		t.Fatalf("AdoptFromMessage: %+v %v", st, err)
+	}
+	empty := ""
+	if _, err := c.Stickers.UpdateMine(ctx, "st2", StickerUpdate{Description: &empty}); err != nil {
+		t.Fatalf("UpdateMine: %v", err)
+	}
+	if err := c.Stickers.DeleteMine(ctx, "st2"); err != nil {
+		t.Fatalf("DeleteMine: %v", err)
+	}
+	if _, ok := StickerFromMessage(sdkapi.ChannelMessage{Type: "message/default"}); ok {
+		t.Fatalf("plain messages carry no sticker")
+	}
+}
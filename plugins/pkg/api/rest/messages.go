@@ -0,0 +1,361 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+// MaxPageSize is the server-side cap for message list pages.
+const MaxPageSize = 100
+
+type MessagesService struct{ c *Client }
+
+type ListMessagesOptions struct {
+	// Limit defaults to (and is capped at) MaxPageSize.
+	Limit int
+	// Before returns messages older than this message ID.
+	Before string
+}
+
+type SearchMessagesOptions struct {
+	Query string
+	Limit int
+	Page  int
+}
+
+// CreateMessage is the body of POST /channels/:id/messages.
+type CreateMessage struct {
+	Content     string       `json:"content,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Payload     any          `json:"payload,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// PlainText is the bot-provided plain-text form for non-text types.
+	PlainText string `json:"plain-text,omitempty"`
+
+	// ReferencedMessageID threads the message as a reply. The referenced
+	// message must be in the same channel.
+	ReferencedMessageID string `json:"referencedMessageId,omitempty"`
+}
+
+type VoiceOptions struct {
+	// PlainText is optional sender-provided transcript for bots.
+	PlainText string
+
+	// DurationMs is optional audio duration (milliseconds).
+	DurationMs int
+
+	// ReplyTo optionally threads the voice message under another message.
+	ReplyTo string
+}
+
+// List returns one page of messages, newest first.
+func (s *MessagesService) List(ctx context.Context, channelID string, opts ListMessagesOptions) ([]sdkapi.ChannelMessage, error) {
+	base, err := channelPath(channelID)
+	if err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	if before := strings.TrimSpace(opts.Before); before != "" {
+		q.Set("before", before)
+	}
+
+	var msgs []sdkapi.ChannelMessage
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: base + "/messages", query: q}, &msgs); err != nil {
+		return nil, err
+	}
+	return fillAttachmentChannels(msgs), nil
+}
+
+// Each walks channel history from newest to oldest, following the before
+// cursor page by page. It stops when fn returns false or an error, or when
+// history is exhausted.
+func (s *MessagesService) Each(ctx context.Context, channelID string, pageSize int, fn func(sdkapi.ChannelMessage) (bool, error)) error {
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	before := ""
+	for {
+		page, err := s.List(ctx, channelID, ListMessagesOptions{Limit: pageSize, Before: before})
+		if err != nil {
+			return err
+		}
+		for _, m := range page {
+			more, err := fn(m)
+			if err != nil || !more {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		next := strings.TrimSpace(page[len(page)-1].ID)
+		if next == "" || next == before {
+			return nil
+		}
+		before = next
+	}
+}
+
+// Search runs the channel keyword search. Use Client.Search for filters,
+// server-wide search and pagination metadata.
+func (s *MessagesService) Search(ctx context.Context, channelID string, opts SearchMessagesOptions) ([]sdkapi.ChannelMessage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	res, err := s.c.Search.Messages(ctx, SearchScope{ChannelID: channelID}, SearchQuery{Query: opts.Query, Limit: opts.Limit, Page: opts.Page})
+	if err != nil {
+		return nil, err
+	}
+	return res.Messages, nil
+}
+
+// Create posts a message over REST (as opposed to the gateway's message/create).
+func (s *MessagesService) Create(ctx context.Context, channelID string, msg CreateMessage) (sdkapi.ChannelMessage, error) {
+	base, err := channelPath(channelID)
+	if err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+	var out sdkapi.ChannelMessage
+	if err := s.c.do(ctx, request{method: http.MethodPost, path: base + "/messages", body: msg}, &out); err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+	for i := range out.Attachments {
+		out.Attachments[i].ChannelID = out.ChannelID
+	}
+	return out, nil
+}
+
+// SendText is a shortcut for a plain text message.
+func (s *MessagesService) SendText(ctx context.Context, channelID, content string) (sdkapi.ChannelMessage, error) {
+	return s.Create(ctx, channelID, CreateMessage{Content: content})
+}
+
+// Reply posts a plain text message threaded under messageID.
+func (s *MessagesService) Reply(ctx context.Context, channelID, messageID, content string) (sdkapi.ChannelMessage, error) {
+	return s.Create(ctx, channelID, CreateMessage{Content: content, ReferencedMessageID: strings.TrimSpace(messageID)})
+}
+
+// SendSticker posts a message/sticker message for one of the bot's stickers.
+func (s *MessagesService) SendSticker(ctx context.Context, channelID, stickerID string) (sdkapi.ChannelMessage, error) {
+	stickerID = strings.TrimSpace(stickerID)
+	if stickerID == "" {
+		return sdkapi.ChannelMessage{}, fmt.Errorf("stickerID is required")
+	}
+	return s.Create(ctx, channelID, CreateMessage{
+		Type: "message/sticker",
+		Payload: map[string]any{
+			"stickerId":    stickerID,
+			"stickerScope": "user",
+		},
+	})
+}
+
+// SendFile uploads data and posts it as an attachment message.
+func (s *MessagesService) SendFile(ctx context.Context, channelID, filename, contentType string, r io.Reader) (sdkapi.ChannelMessage, error) {
+	uploaded, err := s.c.Uploads.Upload(ctx, channelID, filename, contentType, r)
+	if err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+	return s.Create(ctx, channelID, CreateMessage{Attachments: []Attachment{uploaded}})
+}
+
+// SendVoice uploads an audio file then creates a message/voice message
+// referencing the uploaded key.
+func (s *MessagesService) SendVoice(ctx context.Context, channelID, filename, contentType string, r io.Reader, opts VoiceOptions) (sdkapi.ChannelMessage, error) {
+	ct := strings.TrimSpace(contentType)
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	uploaded, err := s.c.Uploads.Upload(ctx, channelID, filename, ct, r)
+	if err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+
+	voice := map[string]any{
+		"key":         uploaded.Key,
+		"contentType": uploaded.ContentType,
+		"size":        uploaded.Size,
+	}
+	if opts.DurationMs > 0 {
+		voice["durationMs"] = opts.DurationMs
+	}
+	return s.Create(ctx, channelID, CreateMessage{
+		Type:      "message/voice",
+		Payload:   map[string]any{"voice": voice},
+		PlainText: strings.TrimSpace(opts.PlainText),
+
+		ReferencedMessageID: strings.TrimSpace(opts.ReplyTo),
+	})
+}
+
+// EditMessage is the body of PATCH /channels/:id/messages/:messageId. At
+// least one field must be set; card messages that render from payload need
+// Payload updated alongside Content.
+type EditMessage struct {
+	Content string `json:"content,omitempty"`
+	Payload any    `json:"payload,omitempty"`
+}
+
+// Edit replaces the text of one of the bot's own messages.
+func (s *MessagesService) Edit(ctx context.Context, channelID, messageID, content string) (sdkapi.ChannelMessage, error) {
+	if strings.TrimSpace(content) == "" {
+		return sdkapi.ChannelMessage{}, fmt.Errorf("content is required")
+	}
+	return s.Update(ctx, channelID, messageID, EditMessage{Content: content})
+}
+
+// Update edits one of the bot's own messages.
+func (s *MessagesService) Update(ctx context.Context, channelID, messageID string, edit EditMessage) (sdkapi.ChannelMessage, error) {
+	p, err := messagePath(channelID, messageID)
+	if err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+	if edit.Content == "" && edit.Payload == nil {
+		return sdkapi.ChannelMessage{}, fmt.Errorf("content or payload is required")
+	}
+	var out sdkapi.ChannelMessage
+	err = s.c.do(ctx, request{method: http.MethodPatch, path: p, body: edit}, &out)
+	return out, err
+}
+
+// Delete retracts one of the bot's own messages. The server keeps the
+// message as a retracted placeholder and returns it.
+func (s *MessagesService) Delete(ctx context.Context, channelID, messageID string) (sdkapi.ChannelMessage, error) {
+	p, err := messagePath(channelID, messageID)
+	if err != nil {
+		return sdkapi.ChannelMessage{}, err
+	}
+	var out sdkapi.ChannelMessage
+	err = s.c.do(ctx, request{method: http.MethodDelete, path: p}, &out)
+	return out, err
+}
+
+// React adds the bot's reaction to a message. Adding an existing reaction
+// is a no-op on the server.
+func (s *MessagesService) React(ctx context.Context, channelID, messageID, emoji string) error {
+	p, err := reactionPath(channelID, messageID, emoji)
+	if err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: http.MethodPut, path: p}, nil)
+}
+
+// Unreact removes the bot's reaction from a message.
+func (s *MessagesService) Unreact(ctx context.Context, channelID, messageID, emoji string) error {
+	p, err := reactionPath(channelID, messageID, emoji)
+	if err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: http.MethodDelete, path: p}, nil)
+}
+
+// MaxTranscriptBytes caps the transcript read back from the server.
+const MaxTranscriptBytes = 64 << 10
+
+// Transcribe runs server-side speech-to-text on a voice message's audio. The
+// server stores the transcript as the message's plain text, so later fetches
+// carry it in PlainText/Context.
+func (s *MessagesService) Transcribe(ctx context.Context, channelID, messageID, filename, contentType string, r io.Reader) (string, error) {
+	p, err := messagePath(channelID, messageID)
+	if err != nil {
+		return "", err
+	}
+	if r == nil {
+		return "", fmt.Errorf("audio reader is required")
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		filename = "voice"
+	}
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	body, done := multipartFile(filename, contentType, r)
+	resp, err := s.c.send(ctx, request{method: http.MethodPost, path: p + "/transcribe", body: body, accept: "text/plain"})
+	done()
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	text, err := io.ReadAll(io.LimitReader(resp.Body, MaxTranscriptBytes))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(text)), nil
+}
+
+// TranscribeVoice returns the transcript of a message/voice message,
+// downloading its audio (up to maxBytes) and transcribing it unless the
+// message already carries plain text.
+func (s *MessagesService) TranscribeVoice(ctx context.Context, msg sdkapi.ChannelMessage, maxBytes int64) (string, error) {
+	if t := strings.TrimSpace(msg.PlainText); t != "" {
+		return t, nil
+	}
+	voice, ok := msg.Voice()
+	if !ok {
+		return "", fmt.Errorf("message %s is not a voice message", msg.ID)
+	}
+	if voice.Key == "" {
+		return "", fmt.Errorf("voice message %s has no upload key", msg.ID)
+	}
+	if maxBytes > 0 && voice.Size > maxBytes {
+		return "", fmt.Errorf("voice message too large: %d bytes", voice.Size)
+	}
+	if maxBytes <= 0 {
+		maxBytes = 10 << 20
+	}
+	audio, err := s.c.Uploads.Download(ctx, msg.ChannelID, voice.Key, maxBytes)
+	if err != nil {
+		return "", err
+	}
+	return s.Transcribe(ctx, msg.ChannelID, msg.ID, voice.Filename, voice.ContentType, bytes.NewReader(audio))
+}
+
+func messagePath(channelID, messageID string) (string, error) {
+	base, err := channelPath(channelID)
+	if err != nil {
+		return "", err
+	}
+	id, err := pathID(messageID)
+	if err != nil {
+		return "", fmt.Errorf("messageID is required")
+	}
+	return base + "/messages/" + id, nil
+}
+
+func reactionPath(channelID, messageID, emoji string) (string, error) {
+	p, err := messagePath(channelID, messageID)
+	if err != nil {
+		return "", err
+	}
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return "", fmt.Errorf("emoji is required")
+	}
+	return p + "/reactions/" + url.PathEscape(emoji) + "/@me", nil
+}
+
+func fillAttachmentChannels(msgs []sdkapi.ChannelMessage) []sdkapi.ChannelMessage {
+	for i := range msgs {
+		for j := range msgs[i].Attachments {
+			msgs[i].Attachments[j].ChannelID = msgs[i].ChannelID
+		}
+	}
+	return msgs
+}
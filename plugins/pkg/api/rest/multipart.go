@@ -0,0 +1,165 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+	"mew/plugins/pkg/api/chunked"
+)
+
+// ChunkedUploadThreshold is the size above which uploads with a known size
+// use resumable multipart uploads.
+const ChunkedUploadThreshold = 8 * 1024 * 1024
+
+// UploadOptions adds progress reporting and resume state to uploads.
+type UploadOptions struct {
+	Progress chunked.Progress
+	// PartRetries is the number of attempts per part (default 3).
+	PartRetries int
+	// Store and ResumeID let a failed chunked upload continue from the last
+	// finished part on the next call with the same ResumeID, as long as the
+	// content is unchanged (see chunked.Options.Fingerprint).
+	Store       chunked.StateStore
+	ResumeID    string
+	Fingerprint string
+}
+
+func (o UploadOptions) chunkedOptions(putClient *http.Client) chunked.Options {
+	return chunked.Options{
+		Progress:    o.Progress,
+		PartRetries: o.PartRetries,
+		Store:       o.Store,
+		ResumeID:    o.ResumeID,
+		Fingerprint: o.Fingerprint,
+		PutClient:   putClient,
+	}
+}
+
+// UploadFile uploads a local file. Large files are chunked and, with
+// opts.Store set, resumable (ResumeID defaults to the file path and
+// Fingerprint to its modification time).
+func (s *UploadsService) UploadFile(ctx context.Context, channelID, filePath, contentType string, opts UploadOptions) (Attachment, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer f.Close()
+	if opts.Store != nil && strings.TrimSpace(opts.ResumeID) == "" {
+		opts.ResumeID = filePath
+	}
+	if opts.Store != nil && strings.TrimSpace(opts.Fingerprint) == "" {
+		if st, err := f.Stat(); err == nil {
+			opts.Fingerprint = "mtime:" + st.ModTime().UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return s.UploadWithOptions(ctx, channelID, baseName(filePath), contentType, f, opts)
+}
+
+func baseName(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
+
+// uploadProtocol speaks the multipart upload protocol under
+// /channels/:id/uploads/multipart.
+type uploadProtocol struct {
+	c    *Client
+	base string
+}
+
+func (p *uploadProtocol) Create(ctx context.Context, filename, contentType string, size int64) (chunked.Session, error) {
+	var out chunked.Session
+	err := p.c.do(ctx, request{method: http.MethodPost, path: p.base, body: map[string]any{
+		"filename":    filename,
+		"contentType": contentType,
+		"size":        size,
+	}}, &out)
+	if sdkapi.IsStatus(err, http.StatusNotFound) {
+		return chunked.Session{}, chunked.ErrUnsupported
+	}
+	return out, err
+}
+
+func (p *uploadProtocol) SignParts(ctx context.Context, s chunked.Session, partNumbers []int) (map[int]string, error) {
+	var out struct {
+		Parts []struct {
+			PartNumber int    `json:"partNumber"`
+			URL        string `json:"url"`
+		} `json:"parts"`
+	}
+	if err := p.c.do(ctx, request{method: http.MethodPost, path: p.base + "/parts", body: map[string]any{
+		"key":         s.Key,
+		"uploadId":    s.UploadID,
+		"partNumbers": partNumbers,
+	}}, &out); err != nil {
+		return nil, err
+	}
+	urls := make(map[int]string, len(out.Parts))
+	for _, part := range out.Parts {
+		urls[part.PartNumber] = part.URL
+	}
+	return urls, nil
+}
+
+func (p *uploadProtocol) ListParts(ctx context.Context, s chunked.Session) ([]chunked.Part, error) {
+	var out struct {
+		Parts []chunked.Part `json:"parts"`
+	}
+	q := url.Values{"key": {s.Key}, "uploadId": {s.UploadID}}
+	err := p.c.do(ctx, request{method: http.MethodGet, path: p.base + "/parts", query: q}, &out)
+	if sdkapi.IsStatus(err, http.StatusNotFound) {
+		return nil, chunked.ErrUploadNotFound
+	}
+	return out.Parts, err
+}
+
+func (p *uploadProtocol) Complete(ctx context.Context, s chunked.Session, filename, contentType string) (chunked.Result, error) {
+	var out chunked.Result
+	err := p.c.do(ctx, request{method: http.MethodPost, path: p.base + "/complete", body: map[string]any{
+		"key":         s.Key,
+		"uploadId":    s.UploadID,
+		"filename":    filename,
+		"contentType": contentType,
+	}}, &out)
+	if err == nil && strings.TrimSpace(out.Key) == "" {
+		err = fmt.Errorf("upload response missing key")
+	}
+	return out, err
+}
+
+func (p *uploadProtocol) Abort(ctx context.Context, s chunked.Session) error {
+	return p.c.do(ctx, request{method: http.MethodPost, path: p.base + "/abort", body: map[string]any{
+		"key":      s.Key,
+		"uploadId": s.UploadID,
+	}}, nil)
+}
+
+// readerSize reports the remaining size of readers that know it.
+func readerSize(r io.Reader) (int64, bool) {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len()), v.Len() > 0
+	case interface{ Size() int64 }:
+		return v.Size(), v.Size() > 0
+	case *os.File:
+		st, err := v.Stat()
+		if err != nil || !st.Mode().IsRegular() {
+			return 0, false
+		}
+		if off, err := v.Seek(0, io.SeekCurrent); err != nil || off != 0 {
+			return 0, false
+		}
+		return st.Size(), st.Size() > 0
+	}
+	return 0, false
+}
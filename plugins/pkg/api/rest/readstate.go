@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+// Channel notification levels (GET/PUT /channels/:id/notification-settings).
+// NotificationDefault follows the server/user level.
+const (
+	NotificationDefault      = "DEFAULT"
+	NotificationAllMessages  = "ALL_MESSAGES"
+	NotificationMentionsOnly = "MENTIONS_ONLY"
+	NotificationMute         = "MUTE"
+)
+
+// Ack marks the channel as read up to lastMessageID.
+func (s *ChannelsService) Ack(ctx context.Context, channelID, lastMessageID string) error {
+	p, err := channelPath(channelID)
+	if err != nil {
+		return err
+	}
+	lastMessageID = strings.TrimSpace(lastMessageID)
+	if lastMessageID == "" {
+		return fmt.Errorf("lastMessageID is required")
+	}
+	return s.c.do(ctx, request{
+		method: http.MethodPost,
+		path:   p + "/ack",
+		body:   map[string]string{"lastMessageId": lastMessageID},
+	}, nil)
+}
+
+// LastRead returns the last acked message ID ("" if the channel was never
+// acked).
+func (s *ChannelsService) LastRead(ctx context.Context, channelID string) (string, error) {
+	p, err := channelPath(channelID)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		LastReadMessageID *string `json:"lastReadMessageId"`
+	}
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: p + "/read-state"}, &out); err != nil {
+		return "", err
+	}
+	if out.LastReadMessageID == nil {
+		return "", nil
+	}
+	return strings.TrimSpace(*out.LastReadMessageID), nil
+}
+
+func (s *ChannelsService) NotificationLevel(ctx context.Context, channelID string) (string, error) {
+	p, err := channelPath(channelID)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		Level string `json:"level"`
+	}
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: p + "/notification-settings"}, &out); err != nil {
+		return "", err
+	}
+	return out.Level, nil
+}
+
+// SetNotificationLevel updates the bot's own notification level for the
+// channel; NotificationDefault removes the override.
+func (s *ChannelsService) SetNotificationLevel(ctx context.Context, channelID, level string) error {
+	p, err := channelPath(channelID)
+	if err != nil {
+		return err
+	}
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level == "" {
+		return fmt.Errorf("level is required")
+	}
+	return s.c.do(ctx, request{
+		method: http.MethodPut,
+		path:   p + "/notification-settings",
+		body:   map[string]string{"level": level},
+	}, nil)
+}
+
+// Unread returns the messages posted after the bot's last ack, oldest first,
+// capped at max (<= 0 means MaxPageSize). A channel that was never acked has
+// no unread backlog.
+func (s *MessagesService) Unread(ctx context.Context, channelID string, max int) ([]sdkapi.ChannelMessage, error) {
+	lastRead, err := s.c.Channels.LastRead(ctx, channelID)
+	if err != nil || lastRead == "" {
+		return nil, err
+	}
+	return s.Since(ctx, channelID, lastRead, max)
+}
+
+// Since returns the messages newer than afterID, oldest first, capped at
+// max (<= 0 means MaxPageSize). When the cap is hit the newest messages are
+// kept.
+func (s *MessagesService) Since(ctx context.Context, channelID, afterID string, max int) ([]sdkapi.ChannelMessage, error) {
+	afterID = strings.TrimSpace(afterID)
+	if afterID == "" {
+		return nil, fmt.Errorf("afterID is required")
+	}
+	if max <= 0 {
+		max = MaxPageSize
+	}
+	var out []sdkapi.ChannelMessage
+	err := s.Each(ctx, channelID, max, func(m sdkapi.ChannelMessage) (bool, error) {
+		if !isNewerID(m.ID, afterID) {
+			return false, nil
+		}
+		out = append(out, m)
+		return len(out) < max, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// isNewerID compares message IDs. Mongo ObjectIDs start with a big-endian
+// timestamp, so same-length hex IDs sort chronologically.
+func isNewerID(id, than string) bool {
+	id = strings.ToLower(strings.TrimSpace(id))
+	than = strings.ToLower(strings.TrimSpace(than))
+	if len(id) != len(than) {
+		return id != than
+	}
+	return id > than
+}
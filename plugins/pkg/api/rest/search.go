@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+// MaxSearchPageSize is the server-side cap for search result pages.
+const MaxSearchPageSize = 50
+
+type SearchService struct{ c *Client }
+
+// SearchScope picks where to search: a single channel (DM or server
+// channel), or a whole server when ServerID is set (narrowed to ChannelID if
+// both are set).
+type SearchScope struct {
+	ServerID  string
+	ChannelID string
+}
+
+// SearchQuery is a keyword search with optional filters. Zero values mean
+// "no filter".
+type SearchQuery struct {
+	// Query is a case-insensitive substring; the server requires it.
+	Query string
+
+	AuthorID      string
+	Type          string
+	HasAttachment *bool
+	After         time.Time
+	Before        time.Time
+
+	// Limit defaults to 20 and is capped at MaxSearchPageSize.
+	Limit int
+	Page  int
+}
+
+// SearchResult is one page of matches, newest first.
+type SearchResult struct {
+	Messages   []sdkapi.ChannelMessage
+	Page       int
+	Limit      int
+	Total      int
+	TotalPages int
+}
+
+func (r SearchResult) HasMore() bool { return r.Page < r.TotalPages }
+
+// Messages runs one search page.
+func (s *SearchService) Messages(ctx context.Context, scope SearchScope, q SearchQuery) (SearchResult, error) {
+	path, err := scope.path()
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return SearchResult{}, fmt.Errorf("search query is required")
+	}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Limit > MaxSearchPageSize {
+		q.Limit = MaxSearchPageSize
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+
+	var raw json.RawMessage
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: path, query: q.values(scope)}, &raw); err != nil {
+		return SearchResult{}, err
+	}
+	var parsed struct {
+		Messages   []sdkapi.ChannelMessage `json:"messages"`
+		Pagination struct {
+			Page       int `json:"page"`
+			Limit      int `json:"limit"`
+			Total      int `json:"total"`
+			TotalPages int `json:"totalPages"`
+		} `json:"pagination"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		// Some deployments might return a plain array.
+		var msgs []sdkapi.ChannelMessage
+		if err2 := json.Unmarshal(raw, &msgs); err2 != nil {
+			return SearchResult{}, err
+		}
+		parsed.Messages = msgs
+		parsed.Pagination.Page = q.Page
+	}
+	res := SearchResult{
+		Messages:   fillAttachmentChannels(parsed.Messages),
+		Page:       parsed.Pagination.Page,
+		Limit:      parsed.Pagination.Limit,
+		Total:      parsed.Pagination.Total,
+		TotalPages: parsed.Pagination.TotalPages,
+	}
+	if res.Page == 0 {
+		res.Page = q.Page
+	}
+	if res.Limit == 0 {
+		res.Limit = q.Limit
+	}
+	return res, nil
+}
+
+// Each walks search results page by page, newest first, starting at q.Page.
+// It stops when fn returns false or an error, or when results run out.
+func (s *SearchService) Each(ctx context.Context, scope SearchScope, q SearchQuery, fn func(sdkapi.ChannelMessage) (bool, error)) error {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	for {
+		res, err := s.Messages(ctx, scope, q)
+		if err != nil {
+			return err
+		}
+		for _, m := range res.Messages {
+			more, err := fn(m)
+			if err != nil || !more {
+				return err
+			}
+		}
+		if len(res.Messages) == 0 || !res.HasMore() {
+			return nil
+		}
+		q.Page = res.Page + 1
+	}
+}
+
+func (sc SearchScope) path() (string, error) {
+	if strings.TrimSpace(sc.ServerID) != "" {
+		p, err := serverPath(sc.ServerID)
+		if err != nil {
+			return "", err
+		}
+		return p + "/search", nil
+	}
+	p, err := channelPath(sc.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("search scope needs a serverID or channelID")
+	}
+	return p + "/search", nil
+}
+
+func (q SearchQuery) values(scope SearchScope) url.Values {
+	v := url.Values{}
+	v.Set("q", strings.TrimSpace(q.Query))
+	v.Set("limit", strconv.Itoa(q.Limit))
+	v.Set("page", strconv.Itoa(q.Page))
+	if strings.TrimSpace(scope.ServerID) != "" && strings.TrimSpace(scope.ChannelID) != "" {
+		v.Set("channelId", strings.TrimSpace(scope.ChannelID))
+	}
+	if id := strings.TrimSpace(q.AuthorID); id != "" {
+		v.Set("authorId", id)
+	}
+	if t := strings.TrimSpace(q.Type); t != "" {
+		v.Set("type", t)
+	}
+	if q.HasAttachment != nil {
+		v.Set("hasAttachment", strconv.FormatBool(*q.HasAttachment))
+	}
+	if !q.After.IsZero() {
+		v.Set("after", q.After.UTC().Format(time.RFC3339))
+	}
+	if !q.Before.IsZero() {
+		v.Set("before", q.Before.UTC().Format(time.RFC3339))
+	}
+	return v
+}
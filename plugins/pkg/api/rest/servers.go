@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+type ServersService struct{ c *Client }
+
+type Server struct {
+	ID             string `json:"_id"`
+	Name           string `json:"name"`
+	AvatarURL      string `json:"avatarUrl,omitempty"`
+	EveryoneRoleID string `json:"everyoneRoleId,omitempty"`
+}
+
+type Category struct {
+	ID       string `json:"_id"`
+	Name     string `json:"name"`
+	ServerID string `json:"serverId"`
+	Position int    `json:"position,omitempty"`
+}
+
+type Role struct {
+	ID          string   `json:"_id"`
+	Name        string   `json:"name"`
+	ServerID    string   `json:"serverId"`
+	Permissions []string `json:"permissions"`
+	Color       string   `json:"color,omitempty"`
+	Position    int      `json:"position"`
+	IsDefault   bool     `json:"isDefault"`
+}
+
+func (r Role) Has(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission || p == "ADMINISTRATOR" {
+			return true
+		}
+	}
+	return false
+}
+
+// Member is a server membership. Webhooks are listed as bot members whose
+// ChannelID is set.
+type Member struct {
+	ID        string   `json:"_id"`
+	ServerID  string   `json:"serverId"`
+	ChannelID string   `json:"channelId,omitempty"`
+	RoleIDs   []string `json:"roleIds"`
+	IsOwner   bool     `json:"isOwner"`
+	Nickname  string   `json:"nickname,omitempty"`
+
+	// UserRaw is the populated user (or a bare user ID).
+	UserRaw json.RawMessage `json:"userId"`
+}
+
+func (m Member) UserID() string { return sdkapi.AuthorID(m.UserRaw) }
+
+func (m Member) Username() string { return sdkapi.AuthorUsername(m.UserRaw) }
+
+// DisplayName returns the nickname, falling back to the username.
+func (m Member) DisplayName() string {
+	if m.Nickname != "" {
+		return m.Nickname
+	}
+	return m.Username()
+}
+
+func (m Member) IsWebhook() bool { return m.ChannelID != "" }
+
+// ListMine returns the servers the bot is a member of.
+func (s *ServersService) ListMine(ctx context.Context) ([]Server, error) {
+	var servers []Server
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: "/users/@me/servers"}, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+func (s *ServersService) Get(ctx context.Context, serverID string) (Server, error) {
+	p, err := serverPath(serverID)
+	if err != nil {
+		return Server{}, err
+	}
+	var srv Server
+	err = s.c.do(ctx, request{method: http.MethodGet, path: p}, &srv)
+	return srv, err
+}
+
+// ListChannels returns the server channels visible to the bot, in position
+// order.
+func (s *ServersService) ListChannels(ctx context.Context, serverID string) ([]Channel, error) {
+	var channels []Channel
+	if err := s.list(ctx, serverID, "/channels", &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (s *ServersService) ListCategories(ctx context.Context, serverID string) ([]Category, error) {
+	var categories []Category
+	if err := s.list(ctx, serverID, "/categories", &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (s *ServersService) ListMembers(ctx context.Context, serverID string) ([]Member, error) {
+	var members []Member
+	if err := s.list(ctx, serverID, "/members", &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (s *ServersService) ListRoles(ctx context.Context, serverID string) ([]Role, error) {
+	var roles []Role
+	if err := s.list(ctx, serverID, "/roles", &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *ServersService) list(ctx context.Context, serverID, sub string, out any) error {
+	p, err := serverPath(serverID)
+	if err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: http.MethodGet, path: p + sub}, out)
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxSharedClients bounds the Shared cache; bot tokens rotate, so old keys
+// would otherwise pile up.
+const maxSharedClients = 64
+
+// sharedKey identifies an API identity: the token, or the http.Client itself
+// when the client carries the credentials and token is empty.
+type sharedKey struct {
+	apiBase    string
+	token      string
+	httpClient *http.Client
+}
+
+var shared struct {
+	mu      sync.Mutex
+	clients map[sharedKey]*Client
+	order   []sharedKey
+}
+
+// Shared returns a client for (apiBase, httpClient, token). Helpers that take
+// those three values per call use it so their calls share rate-limit state
+// instead of each starting from scratch. Clients are cached per apiBase and
+// token, so callers passing a new http.Client each time get a client for it
+// that still shares the cached rate-limit state.
+func Shared(apiBase string, httpClient *http.Client, token string) (*Client, error) {
+	key := sharedKey{
+		apiBase: strings.TrimRight(strings.TrimSpace(apiBase), "/"),
+		token:   strings.TrimSpace(token),
+	}
+	if key.token == "" {
+		key.httpClient = httpClient
+	}
+
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if c, ok := shared.clients[key]; ok {
+		if c.httpClient == httpClient {
+			return c, nil
+		}
+		other, err := New(key.apiBase, httpClient, Options{Token: key.token})
+		if err != nil {
+			return nil, err
+		}
+		other.limit = c.limit
+		return other, nil
+	}
+	c, err := New(key.apiBase, httpClient, Options{Token: key.token})
+	if err != nil {
+		return nil, err
+	}
+	if shared.clients == nil {
+		shared.clients = make(map[sharedKey]*Client)
+	}
+	if len(shared.order) >= maxSharedClients {
+		delete(shared.clients, shared.order[0])
+		shared.order = shared.order[1:]
+	}
+	shared.clients[key] = c
+	shared.order = append(shared.order, key)
+	return c, nil
+}
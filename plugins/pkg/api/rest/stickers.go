@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+// MaxStickerBytes bounds the sticker images AdoptFromMessage downloads.
+const MaxStickerBytes = 10 << 20
+
+type StickersService struct{ c *Client }
+
+type Sticker struct {
+	ID          string `json:"_id"`
+	Scope       string `json:"scope"`
+	OwnerID     string `json:"ownerId,omitempty"`
+	ServerID    string `json:"serverId,omitempty"`
+	Name        string `json:"name"`
+	Group       string `json:"group,omitempty"`
+	Description string `json:"description,omitempty"`
+	Format      string `json:"format,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	URL         string `json:"url"`
+}
+
+// StickerUpdate is the body of PATCH /users/@me/stickers/:id. Nil fields are
+// left unchanged; an empty Description clears it.
+type StickerUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ListMine returns the bot's own (user-scope) stickers.
+func (s *StickersService) ListMine(ctx context.Context) ([]Sticker, error) {
+	var stickers []Sticker
+	if err := s.c.do(ctx, request{method: http.MethodGet, path: "/users/@me/stickers"}, &stickers); err != nil {
+		return nil, err
+	}
+	return stickers, nil
+}
+
+// CreateMine uploads r as a new user-scope sticker. The server accepts
+// common image formats only; an empty contentType is inferred from the
+// filename extension.
+func (s *StickersService) CreateMine(ctx context.Context, name, description, filename, contentType string, r io.Reader) (Sticker, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Sticker{}, fmt.Errorf("sticker name is required")
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		return Sticker{}, fmt.Errorf("filename is required")
+	}
+	if r == nil {
+		return Sticker{}, fmt.Errorf("file reader is required")
+	}
+	fields := map[string]string{"name": name}
+	if d := strings.TrimSpace(description); d != "" {
+		fields["description"] = d
+	}
+
+	body, done := multipartForm(fields, filename, fileContentType(filename, contentType), r)
+	var out Sticker
+	err := s.c.do(ctx, request{method: http.MethodPost, path: "/users/@me/stickers", body: body}, &out)
+	done()
+	if err != nil {
+		return Sticker{}, err
+	}
+	return out, nil
+}
+
+func (s *StickersService) CreateMineBytes(ctx context.Context, name, description, filename, contentType string, data []byte) (Sticker, error) {
+	return s.CreateMine(ctx, name, description, filename, contentType, bytes.NewReader(data))
+}
+
+func (s *StickersService) UpdateMine(ctx context.Context, stickerID string, update StickerUpdate) (Sticker, error) {
+	p, err := myStickerPath(stickerID)
+	if err != nil {
+		return Sticker{}, err
+	}
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return Sticker{}, fmt.Errorf("sticker name cannot be empty")
+	}
+	var out Sticker
+	err = s.c.do(ctx, request{method: http.MethodPatch, path: p, body: update}, &out)
+	return out, err
+}
+
+func (s *StickersService) DeleteMine(ctx context.Context, stickerID string) error {
+	p, err := myStickerPath(stickerID)
+	if err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: http.MethodDelete, path: p}, nil)
+}
+
+// AdoptFromMessage copies the sticker of a message/sticker message into the
+// bot's own stickers. An empty name keeps the original one.
+func (s *StickersService) AdoptFromMessage(ctx context.Context, msg sdkapi.ChannelMessage, name string) (Sticker, error) {
+	src, ok := StickerFromMessage(msg)
+	if !ok {
+		return Sticker{}, fmt.Errorf("message %s is not a sticker message", msg.ID)
+	}
+	if src.Key == "" {
+		return Sticker{}, fmt.Errorf("sticker message %s has no upload key", msg.ID)
+	}
+	if src.Size > MaxStickerBytes {
+		return Sticker{}, fmt.Errorf("sticker too large: %d bytes", src.Size)
+	}
+	data, err := s.c.Uploads.Download(ctx, msg.ChannelID, src.Key, MaxStickerBytes)
+	if err != nil {
+		return Sticker{}, err
+	}
+	if strings.TrimSpace(name) == "" {
+		name = src.Name
+	}
+	filename := "sticker" + path.Ext(src.Key)
+	if src.Format != "" && path.Ext(src.Key) == "" {
+		filename = "sticker." + src.Format
+	}
+	return s.CreateMineBytes(ctx, name, src.Description, filename, src.ContentType, data)
+}
+
+// StickerFromMessage returns the sticker a message/sticker message carries.
+func StickerFromMessage(msg sdkapi.ChannelMessage) (Sticker, bool) {
+	if strings.TrimSpace(msg.Type) != "message/sticker" || len(msg.Payload) == 0 {
+		return Sticker{}, false
+	}
+	var p struct {
+		Sticker *Sticker `json:"sticker"`
+	}
+	if err := json.Unmarshal(msg.Payload, &p); err != nil || p.Sticker == nil || strings.TrimSpace(p.Sticker.ID) == "" {
+		return Sticker{}, false
+	}
+	return *p.Sticker, true
+}
+
+func myStickerPath(stickerID string) (string, error) {
+	id, err := pathID(stickerID)
+	if err != nil {
+		return "", fmt.Errorf("stickerID is required")
+	}
+	return "/users/@me/stickers/" + id, nil
+}
@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"mew/plugins/pkg/api/chunked"
+	"mew/plugins/pkg/api/internal/progress"
+)
+
+type UploadsService struct{ c *Client }
+
+// Attachment is an uploaded file as returned by the upload endpoint and as
+// referenced in CreateMessage.Attachments.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+}
+
+// Upload streams r to POST /channels/:id/uploads. An empty contentType is
+// inferred from the filename extension.
+func (s *UploadsService) Upload(ctx context.Context, channelID, filename, contentType string, r io.Reader) (Attachment, error) {
+	return s.UploadWithOptions(ctx, channelID, filename, contentType, r, UploadOptions{})
+}
+
+// UploadWithOptions is Upload with progress reporting. Files over
+// ChunkedUploadThreshold whose size is known (bytes, *os.File,
+// io.SectionReader) go through resumable multipart uploads.
+func (s *UploadsService) UploadWithOptions(ctx context.Context, channelID, filename, contentType string, r io.Reader, opts UploadOptions) (Attachment, error) {
+	base, err := channelPath(channelID)
+	if err != nil {
+		return Attachment{}, err
+	}
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		return Attachment{}, fmt.Errorf("filename is required")
+	}
+	if r == nil {
+		return Attachment{}, fmt.Errorf("file reader is required")
+	}
+	contentType = fileContentType(filename, contentType)
+
+	size, sized := readerSize(r)
+	if ra, ok := r.(io.ReaderAt); ok && sized && size > ChunkedUploadThreshold {
+		proto := &uploadProtocol{c: s.c, base: base + "/uploads/multipart"}
+		res, err := chunked.Upload(ctx, proto, ra, size, filename, contentType, opts.chunkedOptions(s.c.httpClient))
+		if !errors.Is(err, chunked.ErrUnsupported) {
+			if err != nil {
+				return Attachment{}, err
+			}
+			return Attachment(res), nil
+		}
+		r = io.NewSectionReader(ra, 0, size)
+	}
+	if opts.Progress != nil {
+		total := int64(-1)
+		if sized {
+			total = size
+		}
+		r = &progress.Reader{R: r, Total: total, Fn: opts.Progress}
+	}
+
+	body, done := multipartFile(filename, contentType, r)
+	var out Attachment
+	err = s.c.do(ctx, request{method: http.MethodPost, path: base + "/uploads", body: body}, &out)
+	done()
+	if err != nil {
+		return Attachment{}, err
+	}
+	if strings.TrimSpace(out.Key) == "" {
+		return Attachment{}, fmt.Errorf("upload response missing key")
+	}
+	if strings.TrimSpace(out.Filename) == "" {
+		out.Filename = filename
+	}
+	if strings.TrimSpace(out.ContentType) == "" {
+		out.ContentType = contentType
+	}
+	return out, nil
+}
+
+// multipartFile streams r as the "file" field of a multipart body. Call done
+// once the request has finished to unblock the writer if it ended early.
+func multipartFile(filename, contentType string, r io.Reader) (body streamBody, done func()) {
+	return multipartForm(nil, filename, contentType, r)
+}
+
+// multipartForm is multipartFile with extra text fields written ahead of the
+// file, in key order.
+func multipartForm(fields map[string]string, filename, contentType string, r io.Reader) (body streamBody, done func()) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		var err error
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err = writer.WriteField(k, fields[k]); err != nil {
+				break
+			}
+		}
+		var part io.Writer
+		if err == nil {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+			h.Set("Content-Type", contentType)
+			part, err = writer.CreatePart(h)
+		}
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return streamBody{contentType: writer.FormDataContentType(), r: pr}, func() { _ = pr.CloseWithError(io.ErrClosedPipe) }
+}
+
+// fileContentType returns contentType, or a type inferred from the filename
+// extension.
+func fileContentType(filename, contentType string) string {
+	contentType = strings.TrimSpace(contentType)
+	if contentType == "" {
+		contentType = mime.TypeByExtension(strings.ToLower(path.Ext(filename)))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType
+}
+
+func (s *UploadsService) UploadBytes(ctx context.Context, channelID, filename, contentType string, data []byte) (Attachment, error) {
+	return s.Upload(ctx, channelID, filename, contentType, bytes.NewReader(data))
+}
+
+// Download reads up to limit bytes of an uploaded file by key.
+func (s *UploadsService) Download(ctx context.Context, channelID, key string, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be > 0")
+	}
+	base, err := channelPath(channelID)
+	if err != nil {
+		return nil, err
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	resp, err := s.c.send(ctx, request{method: http.MethodGet, path: base + "/uploads/" + url.PathEscape(key), accept: "*/*"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+type UsersService struct{ c *Client }
+
+func (s *UsersService) Me(ctx context.Context) (sdkapi.User, error) {
+	var u sdkapi.User
+	err := s.c.do(ctx, request{method: http.MethodGet, path: "/users/@me"}, &u)
+	return u, err
+}
+
+func (s *UsersService) Get(ctx context.Context, userID string) (sdkapi.User, error) {
+	id, err := pathID(userID)
+	if err != nil {
+		return sdkapi.User{}, fmt.Errorf("userID is required")
+	}
+	var u sdkapi.User
+	err = s.c.do(ctx, request{method: http.MethodGet, path: "/users/" + id}, &u)
+	return u, err
+}
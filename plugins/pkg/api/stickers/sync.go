@@ -0,0 +1,173 @@
+package stickers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+// imageExts are the sticker formats the server accepts.
+var imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+type SyncOptions struct {
+	// Prune deletes the bot's stickers that have no matching file.
+	Prune bool
+}
+
+// SyncResult lists the sticker names touched by a sync.
+type SyncResult struct {
+	Created  []string
+	Updated  []string
+	Replaced []string
+	Deleted  []string
+}
+
+// SyncDir makes the bot's own stickers match the images in dir. See SyncFS.
+func SyncDir(ctx context.Context, c *rest.Client, dir string, opts SyncOptions) (SyncResult, error) {
+	return SyncFS(ctx, c, os.DirFS(dir), opts)
+}
+
+// SyncFS makes the bot's own stickers match the images at the root of fsys:
+// "<name>.png" (or jpg/gif/webp) becomes the sticker <name>, and an optional
+// "<name>.txt" next to it holds the description. Stickers whose image content
+// changed (compared by SHA-256 against the uploaded image) are re-uploaded;
+// description changes are patched in place.
+//
+// Sticker names are unique per owner, so a replacement is uploaded under a
+// temporary name, the old sticker is deleted and the new one renamed. A
+// failed upload leaves the old sticker untouched.
+func SyncFS(ctx context.Context, c *rest.Client, fsys fs.FS, opts SyncOptions) (SyncResult, error) {
+	var res SyncResult
+	if c == nil {
+		return res, fmt.Errorf("rest client is required")
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return res, err
+	}
+	existing, err := c.Stickers.ListMine(ctx)
+	if err != nil {
+		return res, err
+	}
+	byName := make(map[string]rest.Sticker, len(existing))
+	for _, s := range existing {
+		byName[strings.TrimSpace(s.Name)] = s
+	}
+
+	wanted := map[string]bool{}
+	for _, e := range entries {
+		if e.IsDir() || !imageExts[strings.ToLower(path.Ext(e.Name()))] {
+			continue
+		}
+		name := strings.TrimSpace(strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+		if name == "" || wanted[name] {
+			continue
+		}
+		wanted[name] = true
+
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return res, err
+		}
+		description := ""
+		if b, err := fs.ReadFile(fsys, name+".txt"); err == nil {
+			description = strings.TrimSpace(string(b))
+		}
+
+		cur, ok := byName[name]
+		switch {
+		case !ok:
+			if _, err := c.Stickers.CreateMine(ctx, name, description, e.Name(), "", bytes.NewReader(data)); err != nil {
+				return res, fmt.Errorf("create sticker %q: %w", name, err)
+			}
+			res.Created = append(res.Created, name)
+		case changed(ctx, c, cur, data):
+			if err := replace(ctx, c, cur, name, description, e.Name(), data); err != nil {
+				return res, fmt.Errorf("replace sticker %q: %w", name, err)
+			}
+			res.Replaced = append(res.Replaced, name)
+		case strings.TrimSpace(cur.Description) != description:
+			if _, err := c.Stickers.UpdateMine(ctx, cur.ID, rest.StickerUpdate{Description: &description}); err != nil {
+				return res, fmt.Errorf("update sticker %q: %w", name, err)
+			}
+			res.Updated = append(res.Updated, name)
+		}
+	}
+
+	if opts.Prune {
+		names := make([]string, 0, len(byName))
+		for name := range byName {
+			if !wanted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := c.Stickers.DeleteMine(ctx, byName[name].ID); err != nil {
+				return res, fmt.Errorf("delete sticker %q: %w", name, err)
+			}
+			res.Deleted = append(res.Deleted, name)
+		}
+	}
+	return res, nil
+}
+
+// changed reports whether data differs from the uploaded image of cur. Sizes
+// are compared first; equal sizes fall back to hashing the stored image. An
+// image that cannot be fetched counts as changed, since replacing is safe.
+func changed(ctx context.Context, c *rest.Client, cur rest.Sticker, data []byte) bool {
+	if cur.Size != int64(len(data)) {
+		return true
+	}
+	if strings.TrimSpace(cur.URL) == "" {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cur.URL, nil)
+	if err != nil {
+		return true
+	}
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		return true
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return true
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, int64(len(data))+1)); err != nil {
+		return true
+	}
+	want := sha256.Sum256(data)
+	return !bytes.Equal(h.Sum(nil), want[:])
+}
+
+// replace swaps cur for a new upload of data named name, uploading before
+// deleting so a failed upload keeps the old sticker.
+func replace(ctx context.Context, c *rest.Client, cur rest.Sticker, name, description, filename string, data []byte) error {
+	tmpName := name + "~" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	next, err := c.Stickers.CreateMine(ctx, tmpName, description, filename, "", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if err := c.Stickers.DeleteMine(ctx, cur.ID); err != nil {
+		_ = c.Stickers.DeleteMine(ctx, next.ID)
+		return err
+	}
+	if _, err := c.Stickers.UpdateMine(ctx, next.ID, rest.StickerUpdate{Name: &name}); err != nil {
+		return fmt.Errorf("rename %q: %w", tmpName, err)
+	}
+	return nil
+}
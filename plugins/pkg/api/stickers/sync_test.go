@@ -0,0 +1,120 @@
+package stickers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+func TestSyncFS(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users/@me/stickers":
+			base := "http://" + r.Host + "/img/"
+			_, _ = w.Write([]byte(`[
+				{"_id":"1","name":"same","size":3,"description":"hi","url":"` + base + `abc"},
+				{"_id":"2","name":"grown","size":1},
+				{"_id":"3","name":"retitled","size":3,"description":"old","url":"` + base + `abc"},
+				{"_id":"4","name":"gone","size":3},
+				{"_id":"5","name":"recoded","size":3,"url":"` + base + `xyz"}]`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/img/"):
+			_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/img/")))
+		case r.Method == http.MethodPost:
+			_ = r.ParseMultipartForm(1 << 20)
+			name, _, _ := strings.Cut(r.FormValue("name"), "~")
+			calls = append(calls, "create "+name+" "+r.FormValue("description"))
+			_, _ = w.Write([]byte(`{"_id":"new-` + name + `"}`))
+		case r.Method == http.MethodPatch:
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			calls = append(calls, "patch "+r.URL.Path+" "+body["name"]+body["description"])
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete:
+			calls = append(calls, "delete "+r.URL.Path)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c, err := rest.New(srv.URL, srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"same.png":     {Data: []byte("abc")},
+		"same.txt":     {Data: []byte("hi\n")},
+		"grown.gif":    {Data: []byte("abc")},
+		"retitled.png": {Data: []byte("abc")},
+		"retitled.txt": {Data: []byte("new")},
+		"fresh.webp":   {Data: []byte("abc")},
+		"recoded.png":  {Data: []byte("abc")},
+		"notes.md":     {Data: []byte("ignored")},
+	}
+	res, err := SyncFS(context.Background(), c, fsys, SyncOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("SyncFS: %v", err)
+	}
+	if len(res.Created) != 1 || res.Created[0] != "fresh" ||
+		len(res.Replaced) != 2 || res.Replaced[0] != "grown" || res.Replaced[1] != "recoded" ||
+		len(res.Updated) != 1 || res.Updated[0] != "retitled" ||
+		len(res.Deleted) != 1 || res.Deleted[0] != "gone" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	want := []string{
+		"create fresh ",
+		"create grown ",
+		"delete /users/@me/stickers/2",
+		"patch /users/@me/stickers/new-grown grown",
+		"create recoded ",
+		"delete /users/@me/stickers/5",
+		"patch /users/@me/stickers/new-recoded recoded",
+		"patch /users/@me/stickers/3 new",
+		"delete /users/@me/stickers/4",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %q", calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("call %d = %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestSyncFS_FailedReplacementKeepsOldSticker(t *testing.T) {
+	var deletes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"_id":"1","name":"grown","size":1}]`))
+		case http.MethodPost:
+			http.Error(w, `{"message":"bad image"}`, http.StatusBadRequest)
+		case http.MethodDelete:
+			deletes++
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	c, err := rest.New(srv.URL, srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+
+	_, err = SyncFS(context.Background(), c, fstest.MapFS{"grown.png": {Data: []byte("abc")}}, SyncOptions{})
+	if err == nil || deletes != 0 {
+		t.Fatalf("expected the failed upload to keep the old sticker: err=%v deletes=%d", err, deletes)
+	}
+}
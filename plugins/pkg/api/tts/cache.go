@@ -0,0 +1,80 @@
+package tts
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+)
+
+// DefaultCacheBytes bounds NewCache when maxBytes <= 0.
+const DefaultCacheBytes = 32 << 20
+
+type cacheEntry struct {
+	key   [sha256.Size]byte
+	audio Audio
+}
+
+// Cache is an in-memory LRU in front of a VoiceSynthesizer. Agents tend to
+// repeat short phrases (greetings, acknowledgements), and TTS upstreams are
+// slow and rate limited.
+type Cache struct {
+	inner    VoiceSynthesizer
+	maxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	order *list.List
+	items map[[sha256.Size]byte]*list.Element
+}
+
+func NewCache(inner VoiceSynthesizer, maxBytes int64) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheBytes
+	}
+	return &Cache{
+		inner:    inner,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+func (c *Cache) Synthesize(ctx context.Context, text string) (Audio, error) {
+	key := sha256.Sum256([]byte(strings.TrimSpace(text)))
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		a := el.Value.(*cacheEntry).audio
+		c.mu.Unlock()
+		return a, nil
+	}
+	c.mu.Unlock()
+
+	a, err := c.inner.Synthesize(ctx, text)
+	if err != nil {
+		return Audio{}, err
+	}
+	if int64(len(a.Data)) > c.maxBytes {
+		return a, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return a, nil
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, audio: a})
+	c.size += int64(len(a.Data))
+	for c.size > c.maxBytes {
+		el := c.order.Back()
+		e := el.Value.(*cacheEntry)
+		c.order.Remove(el)
+		delete(c.items, e.key)
+		c.size -= int64(len(e.audio.Data))
+	}
+	return a, nil
+}
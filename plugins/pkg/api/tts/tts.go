@@ -0,0 +1,80 @@
+// Package tts turns text into voice audio for message/voice replies.
+//
+// Agents depend on the VoiceSynthesizer interface; the backend (the Mew
+// server's TTS module, a third-party endpoint, ...) is picked by the operator
+// and can be wrapped with NewCache.
+package tts
+
+import (
+	"context"
+	"mime"
+	"strings"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+// Audio is synthesized speech ready to upload.
+type Audio struct {
+	Data        []byte
+	ContentType string
+	// Filename is the upload filename; Synthesize implementations may leave
+	// it empty and let callers use DefaultFilename.
+	Filename string
+}
+
+// DefaultFilename returns a.Filename or "voice" plus an extension guessed
+// from the content type.
+func (a Audio) DefaultFilename() string {
+	if f := strings.TrimSpace(a.Filename); f != "" {
+		return f
+	}
+	switch strings.ToLower(strings.TrimSpace(strings.Split(a.ContentType, ";")[0])) {
+	case "audio/mpeg", "audio/mp3":
+		return "voice.mp3"
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "voice.wav"
+	case "audio/ogg":
+		return "voice.ogg"
+	}
+	if exts, _ := mime.ExtensionsByType(a.ContentType); len(exts) > 0 {
+		return "voice" + exts[0]
+	}
+	return "voice"
+}
+
+type VoiceSynthesizer interface {
+	Synthesize(ctx context.Context, text string) (Audio, error)
+}
+
+// SynthesizerFunc adapts a function to VoiceSynthesizer.
+type SynthesizerFunc func(ctx context.Context, text string) (Audio, error)
+
+func (f SynthesizerFunc) Synthesize(ctx context.Context, text string) (Audio, error) {
+	return f(ctx, text)
+}
+
+// ServerOptions selects the server TTS upstream. Empty values use the server
+// defaults.
+type ServerOptions struct {
+	// Model is "namiai" or "qwen3-tts".
+	Model string
+	Voice string
+}
+
+// ServerSynthesizer uses the Mew server's /v1/audio/speech endpoint.
+type ServerSynthesizer struct {
+	api  *rest.Client
+	opts ServerOptions
+}
+
+func NewServerSynthesizer(api *rest.Client, opts ServerOptions) *ServerSynthesizer {
+	return &ServerSynthesizer{api: api, opts: opts}
+}
+
+func (s *ServerSynthesizer) Synthesize(ctx context.Context, text string) (Audio, error) {
+	data, ct, err := s.api.Audio.Speech(ctx, rest.SpeechRequest{Input: text, Model: s.opts.Model, Voice: s.opts.Voice})
+	if err != nil {
+		return Audio{}, err
+	}
+	return Audio{Data: data, ContentType: ct}, nil
+}
@@ -0,0 +1,54 @@
+package tts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+func TestCache_ReusesAndEvicts(t *testing.T) {
+	calls := map[string]int{}
+	inner := SynthesizerFunc(func(ctx context.Context, text string) (Audio, error) {
+		calls[text]++
+		return Audio{Data: make([]byte, 4), ContentType: "audio/mpeg"}, nil
+	})
+	c := NewCache(inner, 8)
+	ctx := context.Background()
+
+	for _, text := range []string{"a", "a", "b", "c", "a"} {
+		if _, err := c.Synthesize(ctx, text); err != nil {
+			t.Fatalf("Synthesize(%q): %v", text, err)
+		}
+	}
+	// "a" is cached, then evicted by "b"+"c" (8 bytes total), so it is
+	// synthesized twice.
+	if calls["a"] != 2 || calls["b"] != 1 || calls["c"] != 1 {
+		t.Fatalf("unexpected upstream calls: %v", calls)
+	}
+}
+
+func TestServerSynthesizer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/audio/speech" || r.Method != http.MethodPost {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write([]byte("mp3"))
+	}))
+	defer srv.Close()
+	api, err := rest.New(srv.URL+"/api", srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+
+	a, err := NewServerSynthesizer(api, ServerOptions{Model: "qwen3-tts"}).Synthesize(context.Background(), "你好")
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if string(a.Data) != "mp3" || a.DefaultFilename() != "voice.mp3" {
+		t.Fatalf("unexpected audio: %+v %q", a, a.DefaultFilename())
+	}
+}
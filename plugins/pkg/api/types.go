@@ -40,6 +40,10 @@ type ChannelMessage struct {
 	// Payload is message type-specific data used by the frontend to render cards/embeds.
 	Payload json.RawMessage `json:"payload,omitempty"`
 
+	// PlainText is the sender-provided (or server-transcribed, for voice)
+	// plain-text form of a non-text message.
+	PlainText string `json:"plainText,omitempty"`
+
 	Attachments []AttachmentRef `json:"attachments"`
 
 	// Mentions is an array of mentioned user IDs. Depending on backend populate behavior, items may be strings or objects.
@@ -58,6 +62,40 @@ func (m ChannelMessage) AuthorID() string { return AuthorID(m.AuthorRaw) }
 
 func (m ChannelMessage) AuthorUsername() string { return AuthorUsername(m.AuthorRaw) }
 
+// Voice returns the audio of a message/voice message as an attachment ref.
+func (m ChannelMessage) Voice() (AttachmentRef, bool) {
+	if strings.TrimSpace(m.Type) != "message/voice" || len(m.Payload) == 0 {
+		return AttachmentRef{}, false
+	}
+	var p struct {
+		Voice *struct {
+			Key         string `json:"key"`
+			URL         string `json:"url"`
+			ContentType string `json:"contentType"`
+			Size        int64  `json:"size"`
+		} `json:"voice"`
+	}
+	if err := json.Unmarshal(m.Payload, &p); err != nil || p.Voice == nil {
+		return AttachmentRef{}, false
+	}
+	key, url := strings.TrimSpace(p.Voice.Key), strings.TrimSpace(p.Voice.URL)
+	if key == "" && url == "" {
+		return AttachmentRef{}, false
+	}
+	ct := strings.TrimSpace(p.Voice.ContentType)
+	if ct == "" {
+		ct = "audio/webm"
+	}
+	return AttachmentRef{
+		ChannelID:   strings.TrimSpace(m.ChannelID),
+		Filename:    "voice",
+		ContentType: ct,
+		Key:         key,
+		Size:        p.Voice.Size,
+		URL:         url,
+	}, true
+}
+
 func (m ChannelMessage) ContextText() string {
 	if strings.TrimSpace(m.Context) != "" {
 		return strings.TrimSpace(m.Context)
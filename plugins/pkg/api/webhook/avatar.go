@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mew/plugins/pkg/state"
+)
+
+// AvatarOptions tunes an AvatarCache. Zero values use the defaults.
+type AvatarOptions struct {
+	DownloadClient *http.Client
+	UploadClient   *http.Client
+	// UserAgent is sent to the avatar origin.
+	UserAgent string
+	// RefreshAfter is how long a cached key is trusted before the origin is
+	// revalidated (default 24h).
+	RefreshAfter time.Duration
+	// Media is applied before upload (default: max 256px, sniffed type).
+	Media MediaOptions
+	// MaxEntries bounds the cache; the least recently used entries are
+	// dropped first (default 2000).
+	MaxEntries int
+}
+
+func (o AvatarOptions) withDefaults() AvatarOptions {
+	if o.RefreshAfter <= 0 {
+		o.RefreshAfter = 24 * time.Hour
+	}
+	if o.Media == (MediaOptions{}) {
+		o.Media = MediaOptions{MaxDimension: 256}
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 2000
+	}
+	return o
+}
+
+// maxAvatarBytes caps avatar downloads; anything bigger isn't an avatar.
+const maxAvatarBytes = 10 * 1024 * 1024
+
+// AvatarEntry is the cached upload of one remote avatar.
+type AvatarEntry struct {
+	Key          string    `json:"key"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Hash         string    `json:"hash,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+	UsedAt       time.Time `json:"used_at"`
+}
+
+// AvatarCache uploads each distinct remote avatar once and reuses the
+// attachment key in webhook payloads, so avatars stay up when the origin CDN
+// blocks hotlinking. After RefreshAfter the origin is revalidated with a
+// conditional GET (ETag/Last-Modified); if it has changed, or sends no
+// validators and the content hash differs, the new image is uploaded.
+//
+// Entries persist to a JSON file (path "" keeps them in memory). Keys are
+// plain attachment keys, so one cache can serve every webhook of a bot.
+type AvatarCache struct {
+	path string
+	opts AvatarOptions
+
+	mu      sync.Mutex
+	entries map[string]AvatarEntry
+	locks   map[string]*sync.Mutex
+
+	now func() time.Time
+}
+
+// NewAvatarCache loads the cache stored at path (created on first save).
+func NewAvatarCache(path string, opts AvatarOptions) (*AvatarCache, error) {
+	c := &AvatarCache{
+		path:    strings.TrimSpace(path),
+		opts:    opts.withDefaults(),
+		entries: map[string]AvatarEntry{},
+		locks:   map[string]*sync.Mutex{},
+		now:     time.Now,
+	}
+	if c.path != "" {
+		loaded, err := state.LoadJSONFile[map[string]AvatarEntry](c.path)
+		if err != nil {
+			return c, err
+		}
+		for k, v := range loaded {
+			if strings.TrimSpace(v.Key) != "" {
+				c.entries[k] = v
+			}
+		}
+	}
+	return c, nil
+}
+
+// Lookup returns the cached key for remoteURL without touching the network.
+func (c *AvatarCache) Lookup(remoteURL string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[strings.TrimSpace(remoteURL)]
+	return e.Key, ok
+}
+
+// Resolve returns an attachment key for remoteURL, uploading through
+// webhookURL when the avatar is new or has changed. Non-http(s) values
+// (already a key) are returned as-is. When a refresh fails the previous key
+// is kept.
+func (c *AvatarCache) Resolve(ctx context.Context, apiBase, webhookURL, remoteURL string) (string, error) {
+	src := strings.TrimSpace(remoteURL)
+	if src == "" || !(strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")) {
+		return src, nil
+	}
+
+	lock := c.lockFor(src)
+	lock.Lock()
+	defer lock.Unlock()
+
+	c.mu.Lock()
+	e, ok := c.entries[src]
+	c.mu.Unlock()
+	now := c.now()
+
+	if ok && now.Sub(e.CheckedAt) < c.opts.RefreshAfter {
+		e.UsedAt = now
+		c.put(src, e, false)
+		return e.Key, nil
+	}
+
+	next, err := c.fetchAndUpload(ctx, apiBase, webhookURL, src, e, ok)
+	if err != nil {
+		if ok {
+			// Keep serving the old key; try again after another RefreshAfter.
+			e.CheckedAt, e.UsedAt = now, now
+			c.put(src, e, true)
+			return e.Key, nil
+		}
+		return "", err
+	}
+	next.CheckedAt, next.UsedAt = now, now
+	c.put(src, next, true)
+	return next.Key, nil
+}
+
+// Apply replaces an http(s) p.AvatarURL with its cached key. On failure the
+// remote URL is left in place so the message still carries an avatar.
+func (c *AvatarCache) Apply(ctx context.Context, apiBase, webhookURL string, p *Payload) error {
+	if p == nil || strings.TrimSpace(p.AvatarURL) == "" {
+		return nil
+	}
+	key, err := c.Resolve(ctx, apiBase, webhookURL, p.AvatarURL)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		p.AvatarURL = key
+	}
+	return nil
+}
+
+func (c *AvatarCache) lockFor(src string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := c.locks[src]
+	if l == nil {
+		l = &sync.Mutex{}
+		c.locks[src] = l
+	}
+	return l
+}
+
+// fetchAndUpload downloads src (conditionally when prev has validators) and
+// uploads it unless the origin reports or hashes to the same image.
+func (c *AvatarCache) fetchAndUpload(ctx context.Context, apiBase, webhookURL, src string, prev AvatarEntry, havePrev bool) (AvatarEntry, error) {
+	resp, err := c.get(ctx, src, prev, havePrev)
+	if err != nil {
+		return AvatarEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && havePrev {
+		return prev, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarBytes+1))
+	if err != nil {
+		return AvatarEntry{}, err
+	}
+	if len(data) > maxAvatarBytes {
+		return AvatarEntry{}, fmt.Errorf("%s: %w: over %d bytes", src, ErrMediaTooLarge, maxAvatarBytes)
+	}
+
+	sum := sha256.Sum256(data)
+	next := AvatarEntry{
+		Key:          prev.Key,
+		ETag:         strings.TrimSpace(resp.Header.Get("ETag")),
+		LastModified: strings.TrimSpace(resp.Header.Get("Last-Modified")),
+		Hash:         hex.EncodeToString(sum[:]),
+	}
+	if havePrev && prev.Hash == next.Hash && prev.Key != "" {
+		return next, nil
+	}
+
+	filename, contentType := remoteFileMeta(src, "avatar"+path.Ext(src), resp)
+	m, err := NormalizeMedia(data, filename, contentType, c.opts.Media)
+	if err != nil {
+		return AvatarEntry{}, err
+	}
+	att, err := UploadBytes(ctx, c.opts.UploadClient, apiBase, webhookURL, m.Filename, m.ContentType, m.Data)
+	if err != nil {
+		return AvatarEntry{}, err
+	}
+	next.Key = strings.TrimSpace(att.Key)
+	if next.Key == "" {
+		return AvatarEntry{}, fmt.Errorf("upload response missing key")
+	}
+	return next, nil
+}
+
+// get revalidates with a conditional GET when possible; first fetches go
+// through downloadRemote for its retries and hotlink fallback.
+func (c *AvatarCache) get(ctx context.Context, src string, prev AvatarEntry, havePrev bool) (*http.Response, error) {
+	if !havePrev || (prev.ETag == "" && prev.LastModified == "") {
+		return downloadRemote(ctx, c.opts.DownloadClient, src, "avatar.jpg", c.opts.UserAgent)
+	}
+	client := c.opts.DownloadClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	// The origin may now block direct requests; retry with the fallbacks.
+	return downloadRemote(ctx, c.opts.DownloadClient, src, "avatar.jpg", c.opts.UserAgent)
+}
+
+// put stores e and, when persist is set, writes the cache file.
+func (c *AvatarCache) put(src string, e AvatarEntry, persist bool) {
+	c.mu.Lock()
+	c.entries[src] = e
+	c.evictLocked()
+	var snapshot map[string]AvatarEntry
+	if persist && c.path != "" {
+		snapshot = make(map[string]AvatarEntry, len(c.entries))
+		for k, v := range c.entries {
+			snapshot[k] = v
+		}
+	}
+	c.mu.Unlock()
+	if snapshot != nil {
+		_ = state.SaveJSONFile(c.path, snapshot)
+	}
+}
+
+func (c *AvatarCache) evictLocked() {
+	over := len(c.entries) - c.opts.MaxEntries
+	if over <= 0 {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].UsedAt.Before(c.entries[keys[j]].UsedAt) })
+	for _, k := range keys[:over] {
+		delete(c.entries, k)
+		delete(c.locks, k)
+	}
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// avatarServers serves one avatar (with an ETag when etag is set) and a
+// webhook upload endpoint that hands out numbered keys.
+func avatarServers(t *testing.T, etag bool) (origin, api *httptest.Server, img *atomic.Value, gets, uploads *int32) {
+	t.Helper()
+	img = &atomic.Value{}
+	gets, uploads = new(int32), new(int32)
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(gets, 1)
+		data := img.Load().([]byte)
+		if etag {
+			tag := fmt.Sprintf(`"%d"`, len(data))
+			if r.Header.Get("If-None-Match") == tag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", tag)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(origin.Close)
+	api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/webhooks/1/token/upload" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(uploads, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"filename":"avatar.png","contentType":"image/png","key":"avatar-%d","size":1}`, n)
+	}))
+	t.Cleanup(api.Close)
+	return origin, api, img, gets, uploads
+}
+
+func TestAvatarCache_UploadsOnceAndRevalidates(t *testing.T) {
+	origin, api, img, gets, uploads := avatarServers(t, true)
+	img.Store(testPNG(t, 8, 8))
+
+	c, err := NewAvatarCache(filepath.Join(t.TempDir(), "avatars.json"), AvatarOptions{RefreshAfter: time.Hour})
+	if err != nil {
+		t.Fatalf("NewAvatarCache: %v", err)
+	}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	webhookURL := api.URL + "/api/webhooks/1/token"
+	src := origin.URL + "/face.png"
+
+	for i := 0; i < 2; i++ {
+		key, err := c.Resolve(context.Background(), "", webhookURL, src)
+		if err != nil || key != "avatar-1" {
+			t.Fatalf("Resolve #%d: key=%q err=%v", i, key, err)
+		}
+	}
+	if *gets != 1 || *uploads != 1 {
+		t.Fatalf("expected one download and upload, got gets=%d uploads=%d", *gets, *uploads)
+	}
+
+	// Unchanged origin: 304, no upload.
+	now = now.Add(2 * time.Hour)
+	if key, _ := c.Resolve(context.Background(), "", webhookURL, src); key != "avatar-1" || *uploads != 1 {
+		t.Fatalf("expected cached key after 304, got key=%q uploads=%d", key, *uploads)
+	}
+
+	// Changed origin: re-uploaded.
+	img.Store(testPNG(t, 16, 16))
+	now = now.Add(2 * time.Hour)
+	if key, _ := c.Resolve(context.Background(), "", webhookURL, src); key != "avatar-2" {
+		t.Fatalf("expected new key after change, got %q", key)
+	}
+
+	// Persisted across instances.
+	c2, err := NewAvatarCache(c.path, AvatarOptions{})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if key, ok := c2.Lookup(src); !ok || key != "avatar-2" {
+		t.Fatalf("expected persisted key, got %q ok=%v", key, ok)
+	}
+}
+
+func TestAvatarCache_UsesHashWithoutValidators(t *testing.T) {
+	origin, api, img, _, uploads := avatarServers(t, false)
+	img.Store(testPNG(t, 8, 8))
+
+	c, _ := NewAvatarCache("", AvatarOptions{RefreshAfter: time.Minute})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	webhookURL := api.URL + "/api/webhooks/1/token"
+	src := origin.URL + "/face.png"
+
+	if _, err := c.Resolve(context.Background(), "", webhookURL, src); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if key, _ := c.Resolve(context.Background(), "", webhookURL, src); key != "avatar-1" || *uploads != 1 {
+		t.Fatalf("expected same bytes to keep the key, got key=%q uploads=%d", key, *uploads)
+	}
+}
+
+func TestAvatarCache_ApplyKeepsRemoteURLOnFailure(t *testing.T) {
+	c, _ := NewAvatarCache("", AvatarOptions{})
+	p := Payload{Content: "x", AvatarURL: "http://127.0.0.1:0/face.png"}
+	if err := c.Apply(context.Background(), "", "http://127.0.0.1:0/api/webhooks/1/token", &p); err == nil {
+		t.Fatalf("expected error")
+	}
+	if p.AvatarURL != "http://127.0.0.1:0/face.png" {
+		t.Fatalf("expected avatar url to be kept, got %q", p.AvatarURL)
+	}
+
+	p.AvatarURL = "already-a-key.png"
+	if err := c.Apply(context.Background(), "", "", &p); err != nil || p.AvatarURL != "already-a-key.png" {
+		t.Fatalf("expected keys to pass through, got %q err=%v", p.AvatarURL, err)
+	}
+}
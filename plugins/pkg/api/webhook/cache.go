@@ -45,3 +45,57 @@ func UploadRemoteKeyCached(
 	}
 	return key, false, nil
 }
+
+// MediaKeys are the attachment keys of an UploadRemoteMediaKeyCached upload.
+type MediaKeys struct {
+	Key string
+	// ThumbnailKey is set when MediaOptions.ThumbnailSize produced a thumbnail.
+	ThumbnailKey string
+}
+
+// thumbnailCacheKey is the MediaCache entry that holds the thumbnail key of
+// remoteURL.
+func thumbnailCacheKey(remoteURL string) string { return remoteURL + "#thumbnail" }
+
+// UploadRemoteMediaKeyCached is UploadRemoteKeyCached with the media pipeline
+// applied. The thumbnail key, if any, is cached next to the main key so a
+// cache hit returns both.
+func UploadRemoteMediaKeyCached(
+	ctx context.Context,
+	cache MediaCache,
+	downloadClient *http.Client,
+	uploadClient *http.Client,
+	apiBase, webhookURL, remoteURL, fallbackFilename, userAgent string,
+	opts MediaOptions,
+) (MediaKeys, bool, error) {
+	src := strings.TrimSpace(remoteURL)
+	if src == "" {
+		return MediaKeys{}, false, nil
+	}
+
+	if cache != nil {
+		if key, ok := cache.GetCachedMedia(src); ok && strings.TrimSpace(key) != "" {
+			keys := MediaKeys{Key: strings.TrimSpace(key)}
+			if thumb, ok := cache.GetCachedMedia(thumbnailCacheKey(src)); ok {
+				keys.ThumbnailKey = strings.TrimSpace(thumb)
+			}
+			return keys, true, nil
+		}
+	}
+
+	up, err := UploadRemoteMedia(ctx, downloadClient, uploadClient, apiBase, webhookURL, src, fallbackFilename, userAgent, opts)
+	if err != nil {
+		return MediaKeys{}, false, err
+	}
+	keys := MediaKeys{Key: strings.TrimSpace(up.Key)}
+	if up.Thumbnail != nil {
+		keys.ThumbnailKey = strings.TrimSpace(up.Thumbnail.Key)
+	}
+	if cache != nil && keys.Key != "" {
+		if keys.ThumbnailKey != "" {
+			cache.CacheMedia(thumbnailCacheKey(src), keys.ThumbnailKey)
+		}
+		cache.CacheMedia(src, keys.Key)
+	}
+	return keys, false, nil
+}
@@ -2,6 +2,9 @@ package webhook
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 )
 
@@ -59,3 +62,31 @@ func TestUploadRemoteKeyCached_EmptyURL(t *testing.T) {
 		t.Fatalf("expected no writes, got %d", c.writes)
 	}
 }
+
+func TestUploadRemoteMediaKeyCached_CachesThumbnail(t *testing.T) {
+	t.Setenv("DEV_MODE", "1")
+	t.Setenv("MEW_DEV_DIR", t.TempDir())
+
+	var downloads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(testPNG(t, 64, 32))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := &testCache{}
+	opts := MediaOptions{ThumbnailSize: 8}
+	keys, used, err := UploadRemoteMediaKeyCached(context.Background(), c, srv.Client(), nil, "", "invalid-webhook-url", srv.URL+"/pic.png", "pic.png", "ua", opts)
+	if err != nil || used || keys.Key == "" || keys.ThumbnailKey == "" {
+		t.Fatalf("first upload: keys=%+v used=%v err=%v", keys, used, err)
+	}
+
+	again, used, err := UploadRemoteMediaKeyCached(context.Background(), c, srv.Client(), nil, "", "invalid-webhook-url", srv.URL+"/pic.png", "pic.png", "ua", opts)
+	if err != nil || !used || again != keys {
+		t.Fatalf("cache hit: keys=%+v used=%v err=%v, want %+v", again, used, err, keys)
+	}
+	if n := atomic.LoadInt32(&downloads); n != 1 {
+		t.Fatalf("expected one download, got %d", n)
+	}
+}
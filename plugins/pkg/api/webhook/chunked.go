@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"mew/plugins/pkg/api/chunked"
+)
+
+// webhookProtocol speaks the multipart upload protocol under
+// <webhookURL>/multipart.
+type webhookProtocol struct {
+	client     *http.Client
+	apiBase    string
+	webhookURL string
+}
+
+func (p *webhookProtocol) endpoint(sub string) (string, error) {
+	// Drop the "#secret=" fragment before it can end up in an error.
+	raw := stripFragment(strings.TrimSpace(p.webhookURL))
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid webhook url (missing scheme/host): %q", raw)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/multipart" + sub
+	u.RawPath = ""
+	u.Fragment, u.RawFragment = "", ""
+	target := u.String()
+	if strings.TrimSpace(p.apiBase) != "" {
+		return RewriteLoopbackURL(target, p.apiBase)
+	}
+	return target, nil
+}
+
+func (p *webhookProtocol) call(ctx context.Context, method, sub string, query url.Values, in, out any) (int, error) {
+	target, err := p.endpoint(sub)
+	if err != nil {
+		return 0, err
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var b []byte
+	if in != nil {
+		if b, err = json.Marshal(in); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(b))
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	signWebhookRequest(req, p.webhookURL, "/multipart"+sub, b)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, decodeAPIError(raw, resp.StatusCode)
+	}
+	if out != nil && len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode multipart response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func (p *webhookProtocol) Create(ctx context.Context, filename, contentType string, size int64) (chunked.Session, error) {
+	var out chunked.Session
+	status, err := p.call(ctx, http.MethodPost, "", nil, map[string]any{
+		"filename":    filename,
+		"contentType": contentType,
+		"size":        size,
+	}, &out)
+	if status == http.StatusNotFound {
+		return chunked.Session{}, chunked.ErrUnsupported
+	}
+	return out, err
+}
+
+func (p *webhookProtocol) SignParts(ctx context.Context, s chunked.Session, partNumbers []int) (map[int]string, error) {
+	var out struct {
+		Parts []struct {
+			PartNumber int    `json:"partNumber"`
+			URL        string `json:"url"`
+		} `json:"parts"`
+	}
+	if _, err := p.call(ctx, http.MethodPost, "/parts", nil, map[string]any{
+		"key":         s.Key,
+		"uploadId":    s.UploadID,
+		"partNumbers": partNumbers,
+	}, &out); err != nil {
+		return nil, err
+	}
+	urls := make(map[int]string, len(out.Parts))
+	for _, part := range out.Parts {
+		urls[part.PartNumber] = part.URL
+	}
+	return urls, nil
+}
+
+func (p *webhookProtocol) ListParts(ctx context.Context, s chunked.Session) ([]chunked.Part, error) {
+	var out struct {
+		Parts []chunked.Part `json:"parts"`
+	}
+	q := url.Values{"key": {s.Key}, "uploadId": {s.UploadID}}
+	status, err := p.call(ctx, http.MethodGet, "/parts", q, nil, &out)
+	if status == http.StatusNotFound {
+		return nil, chunked.ErrUploadNotFound
+	}
+	return out.Parts, err
+}
+
+func (p *webhookProtocol) Complete(ctx context.Context, s chunked.Session, filename, contentType string) (chunked.Result, error) {
+	var out chunked.Result
+	_, err := p.call(ctx, http.MethodPost, "/complete", nil, map[string]any{
+		"key":         s.Key,
+		"uploadId":    s.UploadID,
+		"filename":    filename,
+		"contentType": contentType,
+	}, &out)
+	if err == nil && strings.TrimSpace(out.Key) == "" {
+		err = fmt.Errorf("upload response missing key")
+	}
+	return out, err
+}
+
+func (p *webhookProtocol) Abort(ctx context.Context, s chunked.Session) error {
+	_, err := p.call(ctx, http.MethodPost, "/abort", nil, map[string]any{
+		"key":      s.Key,
+		"uploadId": s.UploadID,
+	}, nil)
+	return err
+}
@@ -0,0 +1,352 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"mew/plugins/pkg/x/httpx"
+)
+
+// DownloadRequest is what a DownloadStrategy fetches.
+type DownloadRequest struct {
+	URL string
+	// Filename is the caller's fallback name; strategies use it to tell
+	// images from other media.
+	Filename  string
+	UserAgent string
+	// Header carries the matched rule's headers (Referer, Cookie, ...).
+	Header http.Header
+	// Client is the caller's download client (never nil).
+	Client *http.Client
+}
+
+// DownloadStrategy is one way of fetching a remote file. Download returns a
+// 2xx response whose body the caller closes, or ErrStrategySkipped when it
+// doesn't apply to the request.
+type DownloadStrategy interface {
+	Name() string
+	Download(ctx context.Context, req DownloadRequest) (*http.Response, error)
+}
+
+// ErrStrategySkipped is returned by strategies that don't apply (e.g. an
+// image proxy asked for a video). Skips aren't counted in the stats.
+var ErrStrategySkipped = errors.New("download strategy not applicable")
+
+// DownloadRule configures downloads from matching hosts.
+type DownloadRule struct {
+	// Hosts match the URL host exactly or as a parent domain ("hdslb.com"
+	// matches "i0.hdslb.com"); "*" matches every host.
+	Hosts []string
+	// Header is sent by the strategies that talk to the origin.
+	Header http.Header
+	// Strategies replaces the downloader's default chain when non-empty.
+	Strategies []DownloadStrategy
+}
+
+func (r DownloadRule) matches(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, h := range r.Hosts {
+		h = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "*."))
+		if h == "*" || h == host || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadStats are the counters of one strategy.
+type DownloadStats struct {
+	Attempts      int64     `json:"attempts"`
+	Successes     int64     `json:"successes"`
+	Failures      int64     `json:"failures"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at,omitempty"`
+	LastSuccessAt time.Time `json:"last_success_at,omitempty"`
+}
+
+// Downloader fetches remote files through a chain of strategies, tried in
+// order until one succeeds. The first rule matching the URL host adds
+// headers and may replace the chain. A 404/410 from the origin ends the
+// chain early: no fallback will find a file that doesn't exist.
+//
+// A Downloader is safe for concurrent use.
+type Downloader struct {
+	chain []DownloadStrategy
+
+	mu    sync.Mutex
+	rules []DownloadRule
+	stats map[string]*DownloadStats
+}
+
+// NewDownloader uses chain for every host not overridden by a rule.
+func NewDownloader(chain []DownloadStrategy, rules ...DownloadRule) *Downloader {
+	return &Downloader{chain: chain, rules: rules, stats: map[string]*DownloadStats{}}
+}
+
+// DefaultDownloader is used by UploadRemote and friends.
+var DefaultDownloader = NewDownloader(DefaultDownloadChain(), DefaultDownloadRules()...)
+
+// DefaultDownloadChain tries the caller's client, then wsrv.nl for images,
+// then a FlareSolverr instance when FLARESOLVERR_URL is set.
+func DefaultDownloadChain() []DownloadStrategy {
+	return []DownloadStrategy{DirectDownload(), WsrvDownload(), FlareSolverrFromEnv()}
+}
+
+// DefaultDownloadRules send the Referer that hotlink-protected CDNs of the
+// built-in fetchers expect.
+func DefaultDownloadRules() []DownloadRule {
+	referer := func(v string) http.Header { return http.Header{"Referer": []string{v}} }
+	return []DownloadRule{
+		{Hosts: []string{"hdslb.com", "bilivideo.com", "bilivideo.cn"}, Header: referer("https://www.bilibili.com/")},
+		{Hosts: []string{"twimg.com"}, Header: referer("https://x.com/")},
+		{Hosts: []string{"cdninstagram.com", "fbcdn.net"}, Header: referer("https://www.instagram.com/")},
+		{Hosts: []string{"tiktokcdn.com", "tiktokcdn-us.com"}, Header: referer("https://www.tiktok.com/")},
+	}
+}
+
+// AddRule puts rule ahead of the existing ones.
+func (d *Downloader) AddRule(rule DownloadRule) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rules = append([]DownloadRule{rule}, d.rules...)
+}
+
+// Stats returns the counters of every strategy used, keyed by name.
+func (d *Downloader) Stats() map[string]DownloadStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make(map[string]DownloadStats, len(d.stats))
+	for k, s := range d.stats {
+		out[k] = *s
+	}
+	return out
+}
+
+func (d *Downloader) plan(host string) (http.Header, []DownloadStrategy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, r := range d.rules {
+		if r.matches(host) {
+			if len(r.Strategies) > 0 {
+				return r.Header, r.Strategies
+			}
+			return r.Header, d.chain
+		}
+	}
+	return nil, d.chain
+}
+
+func (d *Downloader) record(name string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.stats[name]
+	if s == nil {
+		s = &DownloadStats{}
+		d.stats[name] = s
+	}
+	now := time.Now()
+	s.Attempts++
+	if err == nil {
+		s.Successes++
+		s.LastSuccessAt = now
+		return
+	}
+	s.Failures++
+	s.LastError = err.Error()
+	s.LastErrorAt = now
+}
+
+// Download GETs src through the chain. The caller closes the body of the
+// returned 2xx response.
+func (d *Downloader) Download(ctx context.Context, client *http.Client, src, fallbackFilename, userAgent string) (*http.Response, error) {
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("unsupported url: %q", src)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	header, chain := d.plan(u.Hostname())
+	req := DownloadRequest{URL: src, Filename: fallbackFilename, UserAgent: userAgent, Header: header, Client: client}
+
+	var errs []string
+	var lastErr error
+	for _, s := range chain {
+		resp, err := s.Download(ctx, req)
+		if errors.Is(err, ErrStrategySkipped) {
+			continue
+		}
+		d.record(s.Name(), err)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, s.Name()+"="+err.Error())
+		lastErr = err
+		if ctx.Err() != nil || isDownloadGone(err) {
+			break
+		}
+	}
+	if lastErr == nil {
+		return nil, fmt.Errorf("download failed: no strategy for %s", u.Hostname())
+	}
+	if len(errs) == 1 {
+		return nil, fmt.Errorf("download failed: %w", lastErr)
+	}
+	return nil, fmt.Errorf("download failed: %s: %w", strings.Join(errs[:len(errs)-1], " "), lastErr)
+}
+
+// DownloadStatusError is a non-2xx response from a download.
+type DownloadStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *DownloadStatusError) Error() string { return "download failed: " + e.Status }
+
+func isDownloadGone(err error) bool {
+	var se *DownloadStatusError
+	return errors.As(err, &se) && (se.StatusCode == http.StatusNotFound || se.StatusCode == http.StatusGone)
+}
+
+// ---- strategies ----
+
+// fetchStrategy GETs a (possibly rewritten) URL with a fixed client or the
+// caller's, retrying transient failures.
+type fetchStrategy struct {
+	name     string
+	attempts int
+	// client overrides the caller's client when set.
+	client func() (*http.Client, error)
+	// rewrite maps the source URL to the one fetched; "" skips.
+	rewrite func(req DownloadRequest) string
+	// originHeaders sends the rule headers (false for third-party proxies).
+	originHeaders bool
+}
+
+func (s *fetchStrategy) Name() string { return s.name }
+
+func (s *fetchStrategy) Download(ctx context.Context, req DownloadRequest) (*http.Response, error) {
+	target := req.URL
+	if s.rewrite != nil {
+		if target = s.rewrite(req); target == "" {
+			return nil, ErrStrategySkipped
+		}
+	}
+	client := req.Client
+	if s.client != nil {
+		c, err := s.client()
+		if err != nil {
+			return nil, err
+		}
+		client = c
+	}
+	var header http.Header
+	if s.originHeaders {
+		header = req.Header
+	}
+	return fetchWithRetry(ctx, client, target, req.UserAgent, header, s.attempts)
+}
+
+// DirectDownload fetches through the caller's client, which already follows
+// the SDK proxy mode, with up to three attempts.
+func DirectDownload() DownloadStrategy {
+	return &fetchStrategy{name: "direct", attempts: 3, originHeaders: true}
+}
+
+// ProxyPoolDownload fetches through the built-in proxy pool (falling back to
+// the environment proxy and direct), regardless of the caller's client mode.
+func ProxyPoolDownload() DownloadStrategy {
+	var (
+		once   sync.Once
+		client *http.Client
+		err    error
+	)
+	return &fetchStrategy{
+		name:          "proxy",
+		attempts:      2,
+		originHeaders: true,
+		client: func() (*http.Client, error) {
+			once.Do(func() {
+				client, err = httpx.NewClient(httpx.ClientOptions{Mode: httpx.ModeProxy, Timeout: 60 * time.Second})
+			})
+			return client, err
+		},
+	}
+}
+
+// ImageProxyDownload fetches images through a third-party image proxy;
+// rewrite maps the source URL to the proxy URL. Non-images are skipped.
+func ImageProxyDownload(name string, rewrite func(src string) string) DownloadStrategy {
+	return &fetchStrategy{
+		name:     name,
+		attempts: 1,
+		rewrite: func(req DownloadRequest) string {
+			if !isLikelyImageURL(req.URL, req.Filename) {
+				return ""
+			}
+			return strings.TrimSpace(rewrite(req.URL))
+		},
+	}
+}
+
+// WsrvDownload is ImageProxyDownload through wsrv.nl.
+func WsrvDownload() DownloadStrategy { return ImageProxyDownload("wsrv", wsrvFallbackURL) }
+
+// fetchWithRetry GETs target, retrying timeouts, resets, 408/429 and 5xx.
+func fetchWithRetry(ctx context.Context, client *http.Client, target, userAgent string, header http.Header, attempts int) (*http.Response, error) {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if ua := strings.TrimSpace(userAgent); ua != "" {
+			req.Header.Set("User-Agent", ua)
+		}
+		req.Header.Set("Accept", "*/*")
+		for k, vs := range header {
+			req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+		}
+		// Force new connection per attempt to avoid getting stuck on a bad keep-alive proxy connection.
+		req.Close = true
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+			if err == nil {
+				se := &DownloadStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+				if resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+					// Non-retryable status (likely permanent).
+					return nil, se
+				}
+				err = se
+			}
+		}
+
+		lastErr = err
+		var se *DownloadStatusError
+		if !errors.As(err, &se) && !isRetryableDownloadErr(err) {
+			return nil, err
+		}
+
+		// Small backoff to avoid hammering proxies or origin.
+		if i+1 < attempts {
+			if err := sleepCtx(ctx, time.Duration(150*(i+1))*time.Millisecond); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, lastErr
+}
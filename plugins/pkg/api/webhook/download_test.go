@@ -0,0 +1,187 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func okResponse(r *http.Request, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"image/jpeg"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		Request:    r,
+	}
+}
+
+func statusResponse(r *http.Request, code int) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    r,
+	}
+}
+
+type namedStrategy struct {
+	name  string
+	calls int
+	fn    func(req DownloadRequest) (*http.Response, error)
+}
+
+func (s *namedStrategy) Name() string { return s.name }
+
+func (s *namedStrategy) Download(_ context.Context, req DownloadRequest) (*http.Response, error) {
+	s.calls++
+	return s.fn(req)
+}
+
+func TestDownloader_AppliesHostRuleHeaders(t *testing.T) {
+	var referer string
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		referer = r.Header.Get("Referer")
+		return okResponse(r, "img"), nil
+	})}
+
+	d := NewDownloader([]DownloadStrategy{DirectDownload()}, DefaultDownloadRules()...)
+	resp, err := d.Download(context.Background(), client, "https://i0.hdslb.com/bfs/face/a.jpg", "a.jpg", "ua")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	resp.Body.Close()
+	if referer != "https://www.bilibili.com/" {
+		t.Fatalf("expected bilibili referer, got %q", referer)
+	}
+
+	resp, err = d.Download(context.Background(), client, "https://example.com/a.jpg", "a.jpg", "ua")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	resp.Body.Close()
+	if referer != "" {
+		t.Fatalf("expected no referer for unmatched host, got %q", referer)
+	}
+}
+
+func TestDownloader_FallsThroughOnBlockedAndRecordsStats(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "wsrv.nl" {
+			return okResponse(r, "img"), nil
+		}
+		return statusResponse(r, http.StatusForbidden), nil
+	})}
+	video := &namedStrategy{name: "video-only", fn: func(req DownloadRequest) (*http.Response, error) {
+		return nil, ErrStrategySkipped
+	}}
+
+	d := NewDownloader([]DownloadStrategy{DirectDownload(), video, WsrvDownload()})
+	resp, err := d.Download(context.Background(), client, "https://pbs.twimg.com/media/a.jpg", "a.jpg", "ua")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	resp.Body.Close()
+
+	stats := d.Stats()
+	if s := stats["direct"]; s.Attempts != 1 || s.Failures != 1 || !strings.Contains(s.LastError, "Forbidden") {
+		t.Fatalf("direct stats: %+v", s)
+	}
+	if s := stats["wsrv"]; s.Attempts != 1 || s.Successes != 1 {
+		t.Fatalf("wsrv stats: %+v", s)
+	}
+	if _, ok := stats["video-only"]; ok || video.calls != 1 {
+		t.Fatalf("skipped strategy should be consulted but not counted: %+v calls=%d", stats, video.calls)
+	}
+}
+
+func TestDownloader_StopsOnNotFound(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return statusResponse(r, http.StatusNotFound), nil
+	})}
+	next := &namedStrategy{name: "next", fn: func(DownloadRequest) (*http.Response, error) {
+		return nil, errors.New("should not run")
+	}}
+
+	d := NewDownloader([]DownloadStrategy{DirectDownload(), next})
+	_, err := d.Download(context.Background(), client, "https://example.com/a.jpg", "a.jpg", "ua")
+	var se *DownloadStatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 status error, got %v", err)
+	}
+	if next.calls != 0 {
+		t.Fatalf("expected chain to stop after 404")
+	}
+}
+
+func TestDownloader_RuleOverridesChain(t *testing.T) {
+	special := &namedStrategy{name: "special", fn: func(req DownloadRequest) (*http.Response, error) {
+		if req.Header.Get("Cookie") != "sid=1" {
+			t.Errorf("expected rule cookie, got %q", req.Header.Get("Cookie"))
+		}
+		return okResponse(nil, "x"), nil
+	}}
+	d := NewDownloader([]DownloadStrategy{DirectDownload()})
+	d.AddRule(DownloadRule{Hosts: []string{"*.media.example"}, Header: http.Header{"Cookie": {"sid=1"}}, Strategies: []DownloadStrategy{special}})
+
+	resp, err := d.Download(context.Background(), nil, "https://cdn.media.example/v.mp4", "v.mp4", "ua")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	resp.Body.Close()
+	if special.calls != 1 {
+		t.Fatalf("expected the rule's chain to be used")
+	}
+}
+
+func TestFlareSolverrDownload_UsesSolvedCookiesAndCaches(t *testing.T) {
+	var solves atomic.Int32
+	solver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if r.URL.Path != "/v1" || in["cmd"] != "request.get" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		solves.Add(1)
+		_, _ = w.Write([]byte(`{"status":"ok","solution":{"userAgent":"solved-ua","cookies":[{"name":"cf_clearance","value":"abc"}]}}`))
+	}))
+	t.Cleanup(solver.Close)
+
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("Cookie") != "cf_clearance=abc" || r.Header.Get("User-Agent") != "solved-ua" {
+			return statusResponse(r, http.StatusForbidden), nil
+		}
+		return okResponse(r, "ok"), nil
+	})}
+
+	d := NewDownloader([]DownloadStrategy{DirectDownload(), FlareSolverrDownload(solver.URL)})
+	for i := 0; i < 2; i++ {
+		resp, err := d.Download(context.Background(), client, "https://protected.example/v.mp4", "v.mp4", "ua")
+		if err != nil {
+			t.Fatalf("Download %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if n := solves.Load(); n != 1 {
+		t.Fatalf("expected one solve (cached), got %d", n)
+	}
+	if s := d.Stats()["flaresolverr"]; s.Successes != 2 {
+		t.Fatalf("solver stats: %+v", s)
+	}
+}
+
+func TestFlareSolverrFromEnv_SkippedWhenUnset(t *testing.T) {
+	t.Setenv("FLARESOLVERR_URL", "")
+	_, err := FlareSolverrFromEnv().Download(context.Background(), DownloadRequest{URL: "https://example.com/a"})
+	if !errors.Is(err, ErrStrategySkipped) {
+		t.Fatalf("expected skip, got %v", err)
+	}
+}
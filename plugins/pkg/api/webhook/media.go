@@ -0,0 +1,299 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"mew/plugins/pkg/x/imagex"
+)
+
+// ErrMediaTooLarge is returned when media exceeds MediaOptions.MaxBytes and
+// can't be shrunk under it. Callers usually fall back to linking the remote URL.
+var ErrMediaTooLarge = errors.New("media exceeds size limit")
+
+// Image output formats for MediaOptions.ImageFormat.
+const (
+	ImageFormatKeep = ""
+	ImageFormatWebP = imagex.FormatWebP
+	ImageFormatJPEG = imagex.FormatJPEG
+)
+
+// MediaOptions configures NormalizeMedia and UploadRemoteMedia. The zero
+// value only sniffs the content type and fixes the filename. The JSON tags
+// let fetchers accept it as a per-task "media" block.
+type MediaOptions struct {
+	// MaxBytes caps the uploaded size (0 = no cap). Oversized images are
+	// re-encoded first; anything still too large fails with ErrMediaTooLarge.
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+	// MaxDimension scales images down so the longer side fits (0 = keep).
+	MaxDimension int `json:"max_dimension,omitempty"`
+	// ImageFormat re-encodes still images to "webp" or "jpeg". WebP needs
+	// cwebp in PATH and falls back to JPEG without it. GIFs are never
+	// re-encoded so animations survive. Formats without a Go decoder
+	// (HEIC, AVIF) are uploaded unchanged: MaxDimension, ImageFormat and
+	// ThumbnailSize do not apply to them.
+	ImageFormat string `json:"image_format,omitempty"`
+	// ImageQuality applies to JPEG/WebP output (default 82).
+	ImageQuality int `json:"image_quality,omitempty"`
+	// ThumbnailSize also produces a JPEG thumbnail whose longer side is this
+	// many pixels (0 = none). Only decodable images get thumbnails.
+	ThumbnailSize int `json:"thumbnail_size,omitempty"`
+}
+
+func (o MediaOptions) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(o.ImageFormat)) {
+	case ImageFormatKeep, ImageFormatWebP, ImageFormatJPEG, "jpg":
+	default:
+		return fmt.Errorf("image_format must be webp or jpeg, got %q", o.ImageFormat)
+	}
+	if o.MaxBytes < 0 || o.MaxDimension < 0 || o.ThumbnailSize < 0 {
+		return fmt.Errorf("media limits must not be negative")
+	}
+	if o.ImageQuality < 0 || o.ImageQuality > 100 {
+		return fmt.Errorf("image_quality must be between 1 and 100")
+	}
+	return nil
+}
+
+func (o MediaOptions) imageFormat() string {
+	f := strings.ToLower(strings.TrimSpace(o.ImageFormat))
+	if f == "jpg" {
+		return ImageFormatJPEG
+	}
+	return f
+}
+
+// Media is a normalized file ready for upload.
+type Media struct {
+	Data        []byte
+	Filename    string
+	ContentType string
+	// Width and Height are set for decodable images.
+	Width, Height int
+	Thumbnail     *Media
+}
+
+// MediaUpload is the result of UploadRemoteMedia.
+type MediaUpload struct {
+	Attachment
+	Thumbnail *Attachment
+}
+
+// NormalizeMedia sniffs the real content type (fixing the filename
+// extension to match), then applies opts to images. The input slice is not
+// modified.
+func NormalizeMedia(data []byte, filename, contentType string, opts MediaOptions) (Media, error) {
+	if err := opts.Validate(); err != nil {
+		return Media{}, err
+	}
+	m := Media{Data: data, Filename: filename, ContentType: strings.TrimSpace(contentType)}
+	if sniffed := imagex.Sniff(data); sniffed != "" {
+		m.ContentType = sniffed
+	}
+	if m.ContentType == "" {
+		m.ContentType = "application/octet-stream"
+	}
+	m.Filename = fixFilenameExt(m.Filename, m.ContentType)
+
+	if strings.HasPrefix(m.ContentType, "image/") {
+		if err := normalizeImage(&m, opts); err != nil {
+			return Media{}, err
+		}
+	}
+	if opts.MaxBytes > 0 && int64(len(m.Data)) > opts.MaxBytes {
+		return Media{}, fmt.Errorf("%w: %s is %d bytes (max %d)", ErrMediaTooLarge, m.Filename, len(m.Data), opts.MaxBytes)
+	}
+	return m, nil
+}
+
+func normalizeImage(m *Media, opts MediaOptions) error {
+	w, h, srcFormat, ok := imagex.DecodeConfig(m.Data)
+	if !ok {
+		// HEIC/AVIF and friends: nothing to decode with, upload as-is.
+		return nil
+	}
+	m.Width, m.Height = w, h
+
+	target := opts.imageFormat()
+	nw, nh := imagex.FitSize(w, h, opts.MaxDimension)
+	resize := nw != w || nh != h
+	overCap := opts.MaxBytes > 0 && int64(len(m.Data)) > opts.MaxBytes
+	convert := target != ImageFormatKeep && target != srcFormat
+	// Re-encoding would drop GIF animation frames.
+	reencode := srcFormat != "gif" && (resize || overCap || convert)
+	if !reencode && opts.ThumbnailSize <= 0 {
+		return nil
+	}
+
+	img, _, err := imagex.Decode(m.Data)
+	if err != nil {
+		return nil
+	}
+	if opts.ThumbnailSize > 0 {
+		small := imagex.Resize(img, opts.ThumbnailSize)
+		if thumb, err := imagex.Encode(small, imagex.FormatJPEG, opts.ImageQuality); err == nil {
+			base := strings.TrimSuffix(m.Filename, path.Ext(m.Filename))
+			m.Thumbnail = &Media{
+				Data:        thumb,
+				Filename:    base + ".thumb.jpg",
+				ContentType: "image/jpeg",
+				Width:       small.Bounds().Dx(),
+				Height:      small.Bounds().Dy(),
+			}
+		}
+	}
+	if !reencode {
+		return nil
+	}
+
+	if target == ImageFormatKeep {
+		target = srcFormat
+		if target != imagex.FormatJPEG && target != imagex.FormatWebP {
+			// PNG/BMP/TIFF re-encoded losslessly rarely shrink.
+			target = imagex.FormatJPEG
+		}
+	}
+	resized := imagex.Resize(img, opts.MaxDimension)
+	out, err := imagex.Encode(resized, target, opts.ImageQuality)
+	if errors.Is(err, imagex.ErrNoWebPEncoder) {
+		target = imagex.FormatJPEG
+		out, err = imagex.Encode(resized, target, opts.ImageQuality)
+	}
+	if err != nil {
+		return fmt.Errorf("re-encode %s: %w", m.Filename, err)
+	}
+	if !resize && !convert && len(out) >= len(m.Data) {
+		// Only re-encoded for size and it didn't help.
+		return nil
+	}
+	m.Data = out
+	m.ContentType = imagex.ContentType(target)
+	m.Filename = forceFilenameExt(m.Filename, imagex.Ext(m.ContentType))
+	m.Width, m.Height = resized.Bounds().Dx(), resized.Bounds().Dy()
+	return nil
+}
+
+// fixFilenameExt replaces a missing or mismatched extension with the one for
+// contentType. Unknown types keep the filename unchanged.
+func fixFilenameExt(filename, contentType string) string {
+	want := imagex.Ext(contentType)
+	if want == "" {
+		return filename
+	}
+	cur := strings.ToLower(path.Ext(filename))
+	if cur == want || (want == ".jpg" && cur == ".jpeg") || (want == ".tiff" && cur == ".tif") {
+		return filename
+	}
+	return forceFilenameExt(filename, want)
+}
+
+// UploadRemoteMedia downloads remoteURL, normalizes it with opts and uploads
+// the result (plus its thumbnail, when one was generated). Still images are
+// buffered so they can be re-encoded; everything else is streamed and cut
+// off with ErrMediaTooLarge once it passes opts.MaxBytes.
+func UploadRemoteMedia(
+	ctx context.Context,
+	downloadClient *http.Client,
+	uploadClient *http.Client,
+	apiBase, webhookURL, remoteURL, fallbackFilename, userAgent string,
+	opts MediaOptions,
+) (MediaUpload, error) {
+	src := strings.TrimSpace(remoteURL)
+	if src == "" {
+		return MediaUpload{}, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return MediaUpload{}, err
+	}
+	resp, err := downloadRemote(ctx, downloadClient, src, fallbackFilename, userAgent)
+	if err != nil {
+		return MediaUpload{}, err
+	}
+	defer resp.Body.Close()
+
+	filename, contentType := remoteFileMeta(src, fallbackFilename, resp)
+	body := bufio.NewReaderSize(resp.Body, 512)
+	head, _ := body.Peek(512)
+	if sniffed := imagex.Sniff(head); sniffed != "" {
+		contentType = sniffed
+	}
+	filename = fixFilenameExt(filename, contentType)
+
+	if !isReencodableImage(contentType) {
+		if opts.MaxBytes > 0 && resp.ContentLength > opts.MaxBytes {
+			return MediaUpload{}, fmt.Errorf("%s: %w: %d bytes (max %d)", src, ErrMediaTooLarge, resp.ContentLength, opts.MaxBytes)
+		}
+		cr := &capReader{r: body, n: opts.MaxBytes}
+		att, err := uploadDownloaded(ctx, uploadClient, apiBase, webhookURL, filename, contentType, cr, resp.ContentLength)
+		if cr.exceeded {
+			return MediaUpload{}, fmt.Errorf("%s: %w: over %d bytes", src, ErrMediaTooLarge, opts.MaxBytes)
+		}
+		if err != nil {
+			return MediaUpload{}, err
+		}
+		return MediaUpload{Attachment: att}, nil
+	}
+
+	// Images may be up to 4x the cap since re-encoding can bring them under.
+	limit := opts.MaxBytes * 4
+	if limit > 0 && resp.ContentLength > limit {
+		return MediaUpload{}, fmt.Errorf("%s: %w: %d bytes (max %d)", src, ErrMediaTooLarge, resp.ContentLength, opts.MaxBytes)
+	}
+	data, err := io.ReadAll(&capReader{r: body, n: limit})
+	if err != nil {
+		return MediaUpload{}, fmt.Errorf("%s: %w", src, err)
+	}
+	m, err := NormalizeMedia(data, filename, contentType, opts)
+	if err != nil {
+		return MediaUpload{}, err
+	}
+	return uploadMedia(ctx, uploadClient, apiBase, webhookURL, m)
+}
+
+func isReencodableImage(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp", "image/bmp", "image/tiff":
+		return true
+	}
+	return false
+}
+
+// capReader fails with ErrMediaTooLarge once more than n bytes were read
+// (n <= 0 means no cap).
+type capReader struct {
+	r        io.Reader
+	n        int64
+	read     int64
+	exceeded bool
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	if c.n > 0 && c.read > c.n {
+		c.exceeded = true
+		return n, ErrMediaTooLarge
+	}
+	return n, err
+}
+
+func uploadMedia(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, m Media) (MediaUpload, error) {
+	att, err := UploadBytes(ctx, httpClient, apiBase, webhookURL, m.Filename, m.ContentType, m.Data)
+	if err != nil {
+		return MediaUpload{}, err
+	}
+	out := MediaUpload{Attachment: att}
+	if m.Thumbnail != nil {
+		// A missing thumbnail shouldn't fail the main upload.
+		if thumb, err := UploadBytes(ctx, httpClient, apiBase, webhookURL, m.Thumbnail.Filename, m.Thumbnail.ContentType, m.Thumbnail.Data); err == nil {
+			out.Thumbnail = &thumb
+		}
+	}
+	return out, nil
+}
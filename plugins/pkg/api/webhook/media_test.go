@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNormalizeMedia_SniffsContentTypeAndFixesFilename(t *testing.T) {
+	data := testPNG(t, 4, 4)
+	m, err := NormalizeMedia(data, "photo.php", "application/octet-stream", MediaOptions{})
+	if err != nil {
+		t.Fatalf("NormalizeMedia: %v", err)
+	}
+	if m.ContentType != "image/png" || m.Filename != "photo.png" {
+		t.Fatalf("got %q %q", m.ContentType, m.Filename)
+	}
+	if !bytes.Equal(m.Data, data) {
+		t.Fatalf("zero options must not re-encode")
+	}
+
+	heic := append([]byte{0, 0, 0, 24}, []byte("ftypheic\x00\x00\x00\x00mif1heic")...)
+	m, err = NormalizeMedia(heic, "IMG_1.jpg", "image/jpeg", MediaOptions{ImageFormat: "jpeg"})
+	if err != nil {
+		t.Fatalf("NormalizeMedia heic: %v", err)
+	}
+	if m.ContentType != "image/heic" || m.Filename != "IMG_1.heic" {
+		t.Fatalf("heic got %q %q", m.ContentType, m.Filename)
+	}
+}
+
+func TestNormalizeMedia_ResizesReencodesAndThumbnails(t *testing.T) {
+	m, err := NormalizeMedia(testPNG(t, 200, 100), "a.png", "image/png", MediaOptions{
+		MaxDimension:  100,
+		ImageFormat:   "jpeg",
+		ThumbnailSize: 20,
+	})
+	if err != nil {
+		t.Fatalf("NormalizeMedia: %v", err)
+	}
+	if m.ContentType != "image/jpeg" || m.Filename != "a.jpg" || m.Width != 100 || m.Height != 50 {
+		t.Fatalf("unexpected media: %q %q %dx%d", m.ContentType, m.Filename, m.Width, m.Height)
+	}
+	if m.Thumbnail == nil || m.Thumbnail.Filename != "a.thumb.jpg" || m.Thumbnail.Width != 20 || m.Thumbnail.Height != 10 {
+		t.Fatalf("unexpected thumbnail: %+v", m.Thumbnail)
+	}
+}
+
+func TestNormalizeMedia_TooLarge(t *testing.T) {
+	_, err := NormalizeMedia(bytes.Repeat([]byte{0}, 64), "clip.mp4", "video/mp4", MediaOptions{MaxBytes: 10})
+	if !errors.Is(err, ErrMediaTooLarge) {
+		t.Fatalf("expected ErrMediaTooLarge, got %v", err)
+	}
+	if _, err := NormalizeMedia(nil, "x", "", MediaOptions{ImageFormat: "gif"}); err == nil {
+		t.Fatalf("expected invalid image_format error")
+	}
+}
+
+func TestUploadRemoteMedia_CapsNonImageDownloads(t *testing.T) {
+	t.Setenv("DEV_MODE", "1")
+	t.Setenv("MEW_DEV_DIR", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			_, _ = w.Write(bytes.Repeat([]byte{1}, 4096))
+		case "/chunked.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			for i := 0; i < 4; i++ {
+				_, _ = w.Write(bytes.Repeat([]byte{1}, 1024))
+				w.(http.Flusher).Flush()
+			}
+		case "/pic":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(testPNG(t, 64, 32))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, p := range []string{"/big.mp4", "/chunked.mp4"} {
+		_, err := UploadRemoteMedia(context.Background(), srv.Client(), nil, "", "invalid-webhook-url", srv.URL+p, "video.mp4", "ua", MediaOptions{MaxBytes: 1024})
+		if !errors.Is(err, ErrMediaTooLarge) {
+			t.Fatalf("%s: expected ErrMediaTooLarge, got %v", p, err)
+		}
+	}
+
+	up, err := UploadRemoteMedia(context.Background(), srv.Client(), nil, "", "invalid-webhook-url", srv.URL+"/pic", "image", "ua", MediaOptions{MaxDimension: 16, ThumbnailSize: 8})
+	if err != nil {
+		t.Fatalf("UploadRemoteMedia: %v", err)
+	}
+	if up.Filename != "pic.jpg" || up.ContentType != "image/jpeg" || up.Thumbnail == nil || up.Thumbnail.Key == "" {
+		t.Fatalf("unexpected upload: %+v thumb=%+v", up.Attachment, up.Thumbnail)
+	}
+}
@@ -9,13 +9,17 @@ import (
 	"strings"
 	"time"
 
+	sdkapi "mew/plugins/pkg/api"
 	"mew/plugins/pkg/x/devmode"
 )
 
 // PostJSONWithRetry posts JSON to webhookURL, automatically rewriting loopback
 // URLs (localhost/127.0.0.1/::1) to match apiBase's origin (useful in Docker).
 //
-// If attempts <= 0, it defaults to 3.
+// Transient failures (network errors, 408/429/5xx) are retried with
+// exponential backoff, waiting at least as long as the server's Retry-After;
+// other 4xx responses are returned immediately. If attempts <= 0, it
+// defaults to 3.
 func PostJSONWithRetry(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, body []byte, attempts int) error {
 	if attempts <= 0 {
 		attempts = 3
@@ -23,17 +27,19 @@ func PostJSONWithRetry(ctx context.Context, httpClient *http.Client, apiBase, we
 
 	var lastErr error
 	for attempt := 1; attempt <= attempts; attempt++ {
-		if err := PostJSON(ctx, httpClient, apiBase, webhookURL, body); err != nil {
-			lastErr = err
-			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-				continue
-			}
+		err := PostJSON(ctx, httpClient, apiBase, webhookURL, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !Retryable(err) || attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryDelay(err, attempt, defaultMaxRetryAfter)):
 		}
-		return nil
 	}
 	return lastErr
 }
@@ -43,7 +49,7 @@ func PostJSON(ctx context.Context, httpClient *http.Client, apiBase, webhookURL
 		httpClient = &http.Client{Timeout: 15 * time.Second}
 	}
 
-	target := strings.TrimSpace(webhookURL)
+	target := stripFragment(strings.TrimSpace(webhookURL))
 	if strings.TrimSpace(apiBase) != "" && strings.TrimSpace(target) != "" {
 		rewritten, err := RewriteLoopbackURL(target, apiBase)
 		if err != nil {
@@ -53,7 +59,7 @@ func PostJSON(ctx context.Context, httpClient *http.Client, apiBase, webhookURL
 	}
 
 	if devmode.Enabled() {
-		return recordWebhookJSON(apiBase, webhookURL, target, body)
+		return recordWebhookJSON(apiBase, stripFragment(webhookURL), target, body)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
@@ -61,6 +67,7 @@ func PostJSON(ctx context.Context, httpClient *http.Client, apiBase, webhookURL
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	signWebhookRequest(req, webhookURL, "", body)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -70,11 +77,81 @@ func PostJSON(ctx context.Context, httpClient *http.Client, apiBase, webhookURL
 
 	respBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		msg := strings.TrimSpace(string(respBody))
-		if msg == "" {
-			msg = http.StatusText(resp.StatusCode)
-		}
-		return errors.New(msg)
+		return newStatusError(resp, respBody)
 	}
 	return nil
 }
+
+// defaultMaxRetryAfter caps how long a single Retry-After is honoured.
+const defaultMaxRetryAfter = 2 * time.Minute
+
+// StatusError is a non-2xx webhook response. Error() is the server's message;
+// it unwraps to *api.Error so api.IsStatus works on it.
+type StatusError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is parsed from the Retry-After header (429/503).
+	RetryAfter time.Duration
+
+	api *sdkapi.Error
+}
+
+func newStatusError(resp *http.Response, body []byte) *StatusError {
+	apiErr := sdkapi.NewError(resp, body)
+	msg := apiErr.Body
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Message: msg, RetryAfter: apiErr.RetryAfter, api: apiErr}
+}
+
+func (e *StatusError) Error() string { return e.Message }
+
+func (e *StatusError) Unwrap() error {
+	if e.api == nil {
+		return nil
+	}
+	return e.api
+}
+
+// Temporary reports whether retrying the same request may succeed.
+func (e *StatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return e.StatusCode >= 500
+}
+
+// Retryable classifies a delivery error: network errors and 408/425/429/5xx
+// responses are transient; other 4xx responses and context cancellation are
+// permanent.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Temporary()
+	}
+	return true
+}
+
+// RetryAfter returns the server-requested delay carried by err, if any.
+func RetryAfter(err error) time.Duration {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.RetryAfter
+	}
+	return 0
+}
+
+// retryDelay is 1s, 2s, 4s... or the server's Retry-After (capped), whichever
+// is longer.
+func retryDelay(err error, attempt int, maxRetryAfter time.Duration) time.Duration {
+	backoff := time.Duration(1<<uint(min(attempt-1, 6))) * time.Second
+	if ra := min(RetryAfter(err), maxRetryAfter); ra > backoff {
+		return ra
+	}
+	return backoff
+}
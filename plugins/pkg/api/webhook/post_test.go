@@ -86,3 +86,22 @@ func TestPostJSONWithRetry_CancelDuringBackoff(t *testing.T) {
 		t.Fatalf("expected exactly 1 attempt before cancel, got %d", n)
 	}
 }
+
+func TestPostJSONWithRetry_StopsOnPermanentError(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := PostJSONWithRetry(context.Background(), srv.Client(), "", srv.URL, []byte(`{}`), 3)
+	if err == nil || err.Error() != "gone" {
+		t.Fatalf("expected error %q, got %v", "gone", err)
+	}
+	if atomic.LoadInt32(&n) != 1 {
+		t.Fatalf("expected a single attempt, got %d", n)
+	}
+}
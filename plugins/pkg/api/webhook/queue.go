@@ -0,0 +1,358 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mew/plugins/pkg/x/devmode"
+)
+
+// QueueOptions tunes a Queue. Zero values use the defaults.
+type QueueOptions struct {
+	// Interval is the steady-state gap between posts to one webhook
+	// (default 300ms).
+	Interval time.Duration
+	// Burst is how many posts may go out back to back before pacing kicks
+	// in (default 5).
+	Burst int
+	// MaxAttempts bounds retries of transient failures (default 5). 429
+	// responses don't count against it.
+	MaxAttempts int
+	// MaxRetryAfter caps a single server-requested wait (default 2m).
+	MaxRetryAfter time.Duration
+	// IdleTTL is how long a webhook that has nothing queued keeps its
+	// pacing state and stats before the queue forgets it (default 10m).
+	IdleTTL time.Duration
+}
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	if o.Interval <= 0 {
+		o.Interval = 300 * time.Millisecond
+	}
+	if o.Burst <= 0 {
+		o.Burst = 5
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.MaxRetryAfter <= 0 {
+		o.MaxRetryAfter = defaultMaxRetryAfter
+	}
+	if o.IdleTTL <= 0 {
+		o.IdleTTL = 10 * time.Minute
+	}
+	return o
+}
+
+// maxRateLimitRetries bounds 429 retries, which don't consume attempts.
+const maxRateLimitRetries = 10
+
+// QueueStats are delivery counters for one webhook.
+type QueueStats struct {
+	// Pending counts posts waiting for (or in) delivery.
+	Pending     int       `json:"pending"`
+	Delivered   int64     `json:"delivered"`
+	Failed      int64     `json:"failed"`
+	Retries     int64     `json:"retries"`
+	RateLimited int64     `json:"rate_limited"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+	LastPostAt  time.Time `json:"last_post_at,omitempty"`
+}
+
+// Queue delivers webhook posts one at a time per webhook, in call order,
+// pacing bursts and pausing the whole webhook while the server asks for a
+// Retry-After. Posts are synchronous: they return once delivered or failed,
+// so callers can keep marking items as sent only on success.
+//
+// A Queue is safe for concurrent use; different webhooks don't block each
+// other. Webhooks idle for longer than IdleTTL are forgotten, stats included.
+type Queue struct {
+	opts QueueOptions
+
+	mu        sync.Mutex
+	lanes     map[string]*lane
+	lastSweep time.Time
+}
+
+// lane is the per-webhook state. busy and waiters (a FIFO of posts waiting
+// for their turn) are guarded by Queue.mu, as are stats; the pacing fields
+// are only touched by the post that holds the turn.
+type lane struct {
+	busy      bool
+	waiters   []chan struct{}
+	idleSince time.Time
+
+	tokens       float64
+	refilledAt   time.Time
+	blockedUntil time.Time
+
+	stats QueueStats
+}
+
+func NewQueue(opts QueueOptions) *Queue {
+	return &Queue{opts: opts.withDefaults(), lanes: map[string]*lane{}}
+}
+
+// DefaultQueue is the process-wide queue used by Post.
+var DefaultQueue = NewQueue(QueueOptions{})
+
+// laneKey drops the query so the same webhook shares one lane.
+func laneKey(webhookURL string) string {
+	key := strings.TrimSpace(webhookURL)
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		key = key[:i]
+	}
+	return strings.TrimRight(key, "/")
+}
+
+// acquire waits until it is webhookURL's turn, in call order. On success the
+// caller owns the lane until release.
+func (q *Queue) acquire(ctx context.Context, webhookURL string) (*lane, error) {
+	key := laneKey(webhookURL)
+	q.mu.Lock()
+	q.sweepLocked(time.Now())
+	l := q.lanes[key]
+	if l == nil {
+		l = &lane{tokens: float64(q.opts.Burst)}
+		q.lanes[key] = l
+	}
+	l.stats.Pending++
+	if !l.busy && len(l.waiters) == 0 {
+		l.busy = true
+		q.mu.Unlock()
+		return l, nil
+	}
+	turn := make(chan struct{})
+	l.waiters = append(l.waiters, turn)
+	q.mu.Unlock()
+
+	select {
+	case <-turn:
+		return l, nil
+	case <-ctx.Done():
+	}
+	q.mu.Lock()
+	for i, w := range l.waiters {
+		if w == turn {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			l.stats.Pending--
+			q.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	q.mu.Unlock()
+	// The turn was handed over just as ctx ended; pass it on.
+	q.release(l)
+	return nil, ctx.Err()
+}
+
+// release hands the lane to the next waiter, if any.
+func (q *Queue) release(l *lane) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	l.stats.Pending--
+	if len(l.waiters) > 0 {
+		next := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		close(next)
+		return
+	}
+	l.busy = false
+	l.idleSince = time.Now()
+}
+
+// sweepLocked drops lanes that have been idle for IdleTTL, at most once per
+// IdleTTL. A lane still inside a Retry-After pause is kept.
+func (q *Queue) sweepLocked(now time.Time) {
+	if now.Sub(q.lastSweep) < q.opts.IdleTTL {
+		return
+	}
+	q.lastSweep = now
+	for k, l := range q.lanes {
+		if !l.busy && len(l.waiters) == 0 && now.Sub(l.idleSince) >= q.opts.IdleTTL && now.After(l.blockedUntil) {
+			delete(q.lanes, k)
+		}
+	}
+}
+
+// Post marshals payload and delivers it through the queue.
+func (q *Queue) Post(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payload Payload) error {
+	return q.post(ctx, httpClient, apiBase, webhookURL, payload, q.opts.MaxAttempts)
+}
+
+func (q *Queue) post(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payload Payload, attempts int) error {
+	if payload.Content == "" {
+		return fmt.Errorf("payload content is required")
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	return q.deliver(ctx, httpClient, apiBase, webhookURL, b, attempts)
+}
+
+// PostJSON delivers a pre-encoded body through the queue.
+func (q *Queue) PostJSON(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, body []byte) error {
+	return q.deliver(ctx, httpClient, apiBase, webhookURL, body, q.opts.MaxAttempts)
+}
+
+// PostBatch delivers payloads in order and stops at the first failure. It
+// returns how many were delivered, so callers can mark exactly those as sent
+// (e.g. an RSS catch-up after downtime).
+func (q *Queue) PostBatch(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payloads []Payload) (int, error) {
+	for i, p := range payloads {
+		if err := q.Post(ctx, httpClient, apiBase, webhookURL, p); err != nil {
+			return i, fmt.Errorf("batch item %d/%d: %w", i+1, len(payloads), err)
+		}
+	}
+	return len(payloads), nil
+}
+
+// Stats returns the counters for webhookURL (zero if never used or
+// forgotten after IdleTTL).
+func (q *Queue) Stats(webhookURL string) QueueStats {
+	key := laneKey(webhookURL)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if l := q.lanes[key]; l != nil {
+		return l.stats
+	}
+	return QueueStats{}
+}
+
+// AllStats returns the counters of every webhook seen, keyed by webhook URL
+// without its query string. The keys contain webhook tokens; don't log them.
+func (q *Queue) AllStats() map[string]QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make(map[string]QueueStats, len(q.lanes))
+	for k, l := range q.lanes {
+		out[k] = l.stats
+	}
+	return out
+}
+
+func (q *Queue) update(l *lane, fn func(s *QueueStats)) {
+	q.mu.Lock()
+	fn(&l.stats)
+	q.mu.Unlock()
+}
+
+func (q *Queue) deliver(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, body []byte, attempts int) error {
+	if attempts <= 0 {
+		attempts = q.opts.MaxAttempts
+	}
+	l, err := q.acquire(ctx, webhookURL)
+	if err != nil {
+		return err
+	}
+	defer q.release(l)
+
+	attempt, rateLimited := 1, 0
+	for {
+		if err := q.waitTurn(ctx, l); err != nil {
+			return err
+		}
+		err := PostJSON(ctx, httpClient, apiBase, webhookURL, body)
+		now := time.Now()
+		if err == nil {
+			q.update(l, func(s *QueueStats) {
+				s.Delivered++
+				s.LastPostAt = now
+			})
+			return nil
+		}
+
+		is429 := isRateLimited(err)
+		if is429 {
+			rateLimited++
+		} else {
+			attempt++
+		}
+		retry := Retryable(err) && attempt <= attempts && rateLimited <= maxRateLimitRetries
+		q.update(l, func(s *QueueStats) {
+			s.LastPostAt = now
+			s.LastError = err.Error()
+			s.LastErrorAt = now
+			if is429 {
+				s.RateLimited++
+			}
+			if retry {
+				s.Retries++
+			} else {
+				s.Failed++
+			}
+		})
+		if !retry {
+			return err
+		}
+
+		n := attempt - 1
+		if is429 {
+			n = rateLimited
+		}
+		delay := retryDelay(err, n, q.opts.MaxRetryAfter)
+		if RetryAfter(err) > 0 || is429 {
+			// The server throttles the whole webhook, not this one post.
+			l.blockedUntil = now.Add(delay)
+			l.tokens = 0
+			continue
+		}
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// waitTurn blocks until the lane is unblocked and a pacing token is free.
+// Dev mode only records to disk, so it skips pacing.
+func (q *Queue) waitTurn(ctx context.Context, l *lane) error {
+	if devmode.Enabled() {
+		return nil
+	}
+	if err := sleepCtx(ctx, time.Until(l.blockedUntil)); err != nil {
+		return err
+	}
+	now := time.Now()
+	if !l.refilledAt.IsZero() {
+		l.tokens += float64(now.Sub(l.refilledAt)) / float64(q.opts.Interval)
+		l.tokens = min(l.tokens, float64(q.opts.Burst))
+	}
+	l.refilledAt = now
+	if l.tokens < 1 {
+		wait := time.Duration((1 - l.tokens) * float64(q.opts.Interval))
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+		l.tokens = 1
+		l.refilledAt = time.Now()
+	}
+	l.tokens--
+	return nil
+}
+
+func isRateLimited(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusTooManyRequests
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
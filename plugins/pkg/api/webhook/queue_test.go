@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+func TestQueue_RetriesAfterRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{MaxAttempts: 1})
+	start := time.Now()
+	if err := q.Post(context.Background(), srv.Client(), "", srv.URL, Payload{Content: "hi"}); err != nil {
+		t.Fatalf("Post error: %v", err)
+	}
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Fatalf("expected Retry-After to be honoured, waited %s", waited)
+	}
+	st := q.Stats(srv.URL)
+	if st.Delivered != 1 || st.RateLimited != 1 || st.Retries != 1 || st.Failed != 0 || st.Pending != 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestQueue_DoesNotRetryPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		http.Error(w, `{"message":"bad payload"}`, http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{})
+	err := q.Post(context.Background(), srv.Client(), "", srv.URL, Payload{Content: "hi"})
+	if err == nil || Retryable(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+	if !sdkapi.IsStatus(err, http.StatusBadRequest) {
+		t.Fatalf("expected error to unwrap to a 400 api error, got %#v", err)
+	}
+	if atomic.LoadInt32(&n) != 1 {
+		t.Fatalf("expected a single attempt, got %d", n)
+	}
+	if st := q.Stats(srv.URL + "?x=1"); st.Failed != 1 || st.LastError == "" {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestQueue_PacesBursts(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{Burst: 2, Interval: 50 * time.Millisecond})
+	payloads := make([]Payload, 5)
+	for i := range payloads {
+		payloads[i] = Payload{Content: "item"}
+	}
+	start := time.Now()
+	sent, err := q.PostBatch(context.Background(), srv.Client(), "", srv.URL, payloads)
+	if err != nil || sent != 5 {
+		t.Fatalf("PostBatch sent=%d err=%v", sent, err)
+	}
+	// 2 go out immediately, the other 3 wait ~50ms each.
+	if waited := time.Since(start); waited < 140*time.Millisecond {
+		t.Fatalf("expected pacing, finished in %s", waited)
+	}
+	if st := q.Stats(srv.URL); st.Delivered != 5 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestQueue_PostBatchStopsAtFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 2 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{})
+	sent, err := q.PostBatch(context.Background(), srv.Client(), "", srv.URL, []Payload{{Content: "a"}, {Content: "b"}, {Content: "c"}})
+	if err == nil || sent != 1 {
+		t.Fatalf("expected 1 sent and an error, got sent=%d err=%v", sent, err)
+	}
+	if atomic.LoadInt32(&n) != 2 {
+		t.Fatalf("expected the batch to stop after the failure, got %d requests", n)
+	}
+}
+
+func TestQueue_DeliversInCallOrder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if p.Content == "0" {
+			<-release
+		}
+		mu.Lock()
+		got = append(got, p.Content)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{Burst: 100})
+	var wg sync.WaitGroup
+	want := make([]string, 8)
+	for i := range want {
+		want[i] = fmt.Sprint(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = q.Post(context.Background(), srv.Client(), "", srv.URL, Payload{Content: want[i]})
+		}()
+		// Queue the posts one after another.
+		for q.Stats(srv.URL).Pending != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	wg.Wait()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delivery order=%v, want %v", got, want)
+	}
+}
+
+func TestQueue_ForgetsIdleWebhooks(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := NewQueue(QueueOptions{IdleTTL: 20 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		if err := q.Post(context.Background(), srv.Client(), "", fmt.Sprintf("%s/hook%d", srv.URL, i), Payload{Content: "hi"}); err != nil {
+			t.Fatalf("Post: %v", err)
+		}
+	}
+	if n := len(q.AllStats()); n != 3 {
+		t.Fatalf("expected 3 lanes, got %d", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := q.Post(context.Background(), srv.Client(), "", srv.URL+"/hook0", Payload{Content: "hi"}); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	all := q.AllStats()
+	if len(all) != 1 || all[srv.URL+"/hook0"].Delivered != 1 {
+		t.Fatalf("expected only the fresh lane to remain, got %+v", all)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{&StatusError{StatusCode: http.StatusBadGateway}, true},
+		{&StatusError{StatusCode: http.StatusNotFound}, false},
+		{context.Canceled, false},
+		{http.ErrHandlerTimeout, true},
+	}
+	for _, tc := range cases {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Fatalf("Retryable(%v)=%v want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -3,14 +3,12 @@ package webhook
 import (
 	"context"
 	"errors"
-	"fmt"
 	"mime"
 	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
-	"time"
 )
 
 func forceFilenameExt(filename, ext string) string {
@@ -110,99 +108,32 @@ func UploadRemote(
 	if src == "" {
 		return Attachment{}, nil
 	}
-	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
-		return Attachment{}, fmt.Errorf("unsupported url: %q", src)
-	}
-
-	if downloadClient == nil {
-		downloadClient = &http.Client{Timeout: 30 * time.Second}
-	}
-
-	doDownload := func(client *http.Client, downloadURL string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
-		if err != nil {
-			return nil, err
-		}
-		if ua := strings.TrimSpace(userAgent); ua != "" {
-			req.Header.Set("User-Agent", ua)
-		}
-		req.Header.Set("Accept", "*/*")
-		// Force new connection per attempt to avoid getting stuck on a bad keep-alive proxy connection.
-		req.Close = true
-		return client.Do(req)
-	}
-
-	tryClient := func(client *http.Client, downloadURL string, attempts int) (*http.Response, error) {
-		var lastErr error
-		for i := 0; i < attempts; i++ {
-			resp, err := doDownload(client, downloadURL)
-			if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				return resp, nil
-			}
-
-			// Non-2xx response: close body before retrying.
-			if resp != nil {
-				_ = resp.Body.Close()
-				if err == nil {
-					// Retry on transient HTTP statuses.
-					switch resp.StatusCode {
-					case http.StatusRequestTimeout, http.StatusTooManyRequests:
-						err = fmt.Errorf("download failed: %s", resp.Status)
-					default:
-						if resp.StatusCode >= 500 && resp.StatusCode <= 599 {
-							err = fmt.Errorf("download failed: %s", resp.Status)
-						} else {
-							// Non-retryable status (likely permanent).
-							return nil, fmt.Errorf("download failed: %s", resp.Status)
-						}
-					}
-				}
-			}
-
-			lastErr = err
-			if !isRetryableDownloadErr(err) {
-				return nil, err
-			}
-
-			// Small backoff to avoid hammering proxies or origin.
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(time.Duration(150*(i+1)) * time.Millisecond):
-			}
-		}
-		return nil, lastErr
-	}
-
-	// Only use the provided download client, so transport behavior follows SDK strategy.
-	resp, err := tryClient(downloadClient, src, 3)
+	resp, err := downloadRemote(ctx, downloadClient, src, fallbackFilename, userAgent)
 	if err != nil {
-		// Image-only last resort via wsrv.nl, still using the same download client.
-		if isRetryableDownloadErr(err) && isLikelyImageURL(src, fallbackFilename) {
-			fallbackURL := wsrvFallbackURL(src)
-			if strings.TrimSpace(fallbackURL) != "" {
-				if r2, err2 := tryClient(downloadClient, fallbackURL, 1); err2 == nil {
-					resp = r2
-					err = nil
-				} else {
-					return Attachment{}, fmt.Errorf("download failed: primary=%v fallback=%w", err, err2)
-				}
-			} else {
-				return Attachment{}, fmt.Errorf("download failed: %w", err)
-			}
-		} else {
-			return Attachment{}, fmt.Errorf("download failed: %w", err)
-		}
+		return Attachment{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return Attachment{}, fmt.Errorf("download failed: %s", resp.Status)
+	filename, contentType := remoteFileMeta(src, fallbackFilename, resp)
+	att, err := uploadDownloaded(ctx, uploadClient, apiBase, webhookURL, filename, contentType, resp.Body, resp.ContentLength)
+	if err != nil {
+		return Attachment{}, err
 	}
+	return att, nil
+}
 
+// downloadRemote GETs src through DefaultDownloader. The caller closes the
+// body of the returned 2xx response.
+func downloadRemote(ctx context.Context, downloadClient *http.Client, src, fallbackFilename, userAgent string) (*http.Response, error) {
+	return DefaultDownloader.Download(ctx, downloadClient, src, fallbackFilename, userAgent)
+}
+
+// remoteFileMeta picks the upload filename and content type from the source
+// URL and response headers.
+func remoteFileMeta(src, fallbackFilename string, resp *http.Response) (filename, contentType string) {
 	// Keep the original filename even if we downloaded through a proxy.
-	filename := FilenameFromURL(src, fallbackFilename)
-	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
+	filename = FilenameFromURL(src, fallbackFilename)
+	contentType = strings.TrimSpace(resp.Header.Get("Content-Type"))
 	if contentType == "" {
 		contentType = mime.TypeByExtension(path.Ext(filename))
 	}
@@ -215,12 +146,7 @@ func UploadRemote(
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
-
-	att, err := UploadReader(ctx, uploadClient, apiBase, webhookURL, filename, contentType, resp.Body)
-	if err != nil {
-		return Attachment{}, err
-	}
-	return att, nil
+	return filename, contentType
 }
 
 func FilenameFromURL(rawURL, fallback string) string {
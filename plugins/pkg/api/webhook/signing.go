@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signature headers. A signed request carries the unix timestamp, the hex
+// SHA-256 of its body and "v1=<hex HMAC-SHA256>" over SignatureBase; several
+// comma-separated v1 values are accepted so secrets can be rotated. On the
+// Mew server's /upload route the hash covers the uploaded file's content
+// rather than the multipart envelope; the server checks it after storing.
+const (
+	SignatureHeader     = "X-Mew-Signature"
+	TimestampHeader     = "X-Mew-Timestamp"
+	ContentSHA256Header = "X-Mew-Content-SHA256"
+
+	// DefaultSignatureTolerance bounds clock skew and how long a captured
+	// request can be replayed.
+	DefaultSignatureTolerance = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.New("webhook signature missing")
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	ErrSignatureExpired = errors.New("webhook signature timestamp outside tolerance")
+)
+
+// signingNow is replaced in tests.
+var signingNow = time.Now
+
+// SignatureBase is the string that gets signed. route is the path below the
+// webhook URL ("" for posts, "/upload", "/presign", "/multipart/parts", ...)
+// followed by "?" and the raw query when there is one, so a signature can't
+// be replayed against another endpoint or with other parameters.
+func SignatureBase(timestamp int64, method, route, contentSHA256 string) string {
+	return "v1\n" + strconv.FormatInt(timestamp, 10) + "\n" + strings.ToUpper(method) + "\n" + route + "\n" + contentSHA256
+}
+
+// ComputeSignature returns the hex HMAC-SHA256 of SignatureBase.
+func ComputeSignature(secret string, timestamp int64, method, route, contentSHA256 string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SignatureBase(timestamp, method, route, contentSHA256)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func bodySHA256(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignRequest sets the signature headers on req for body. route is the path
+// below the webhook URL; req's query is appended to it.
+func SignRequest(req *http.Request, secret, route string, body []byte) {
+	signHeaders(req, secret, route, bodySHA256(body))
+}
+
+func signHeaders(req *http.Request, secret, route, contentSHA256 string) {
+	ts := signingNow().Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(ContentSHA256Header, contentSHA256)
+	req.Header.Set(SignatureHeader, "v1="+ComputeSignature(secret, ts, req.Method, withQuery(route, req.URL), contentSHA256))
+}
+
+// withQuery appends u's raw query to route.
+func withQuery(route string, u *url.URL) string {
+	if u == nil || u.RawQuery == "" {
+		return route
+	}
+	return route + "?" + u.RawQuery
+}
+
+// VerifySignature checks the signature headers in h against body. route must
+// include the query (see SignatureBase). tolerance <= 0 uses
+// DefaultSignatureTolerance.
+func VerifySignature(h http.Header, secret, method, route string, body []byte, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+	rawTS := strings.TrimSpace(h.Get(TimestampHeader))
+	rawSig := strings.TrimSpace(h.Get(SignatureHeader))
+	if rawTS == "" || rawSig == "" {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(rawTS, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	if d := signingNow().Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrSignatureExpired
+	}
+	sum := bodySHA256(body)
+	if claimed := strings.TrimSpace(h.Get(ContentSHA256Header)); claimed != "" && !strings.EqualFold(claimed, sum) {
+		return fmt.Errorf("%w: body hash mismatch", ErrInvalidSignature)
+	}
+	want := []byte(ComputeSignature(secret, ts, method, route, sum))
+	for _, part := range strings.Split(rawSig, ",") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(part), "v1=")
+		if ok && hmac.Equal([]byte(strings.ToLower(v)), want) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// VerifyRequest reads r's body, verifies it and puts the body back so later
+// handlers can still decode it. r's query is appended to route.
+func VerifyRequest(r *http.Request, secret, route string, tolerance time.Duration) ([]byte, error) {
+	var body []byte
+	if r.Body != nil {
+		b, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		r.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	return body, VerifySignature(r.Header, secret, r.Method, withQuery(route, r.URL), body, tolerance)
+}
+
+// ---- per-webhook secrets ----
+
+var signingSecrets sync.Map // laneKey -> secret
+
+// SetSigningSecret makes requests to webhookURL (posts, uploads, multipart)
+// carry signatures made with secret; "" turns signing off again.
+func SetSigningSecret(webhookURL, secret string) {
+	key := laneKey(webhookURL)
+	if s := strings.TrimSpace(secret); s != "" {
+		signingSecrets.Store(key, s)
+		return
+	}
+	signingSecrets.Delete(key)
+}
+
+// signingSecret returns the secret registered for webhookURL, or the one in
+// its "#secret=..." fragment. Fragments are never sent over the wire, so a
+// configured URL can carry its secret.
+func signingSecret(webhookURL string) string {
+	if v, ok := signingSecrets.Load(laneKey(webhookURL)); ok {
+		return v.(string)
+	}
+	_, frag, ok := strings.Cut(webhookURL, "#")
+	if !ok {
+		return ""
+	}
+	q, err := url.ParseQuery(frag)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(q.Get("secret"))
+}
+
+// signWebhookRequest signs req with webhookURL's secret, if any.
+func signWebhookRequest(req *http.Request, webhookURL, route string, body []byte) {
+	signWebhookRequestSHA256(req, webhookURL, route, bodySHA256(body))
+}
+
+// signWebhookRequestSHA256 is signWebhookRequest for a body that was hashed
+// up front (the file of a streamed /upload).
+func signWebhookRequestSHA256(req *http.Request, webhookURL, route, contentSHA256 string) {
+	if secret := signingSecret(webhookURL); secret != "" {
+		signHeaders(req, secret, route, contentSHA256)
+	}
+}
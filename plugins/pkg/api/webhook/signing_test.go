@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature_RoundTrip(t *testing.T) {
+	body := []byte(`{"content":"hi"}`)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/w1/tok", bytes.NewReader(body))
+	SignRequest(req, "s3cret", "", body)
+
+	got, err := VerifyRequest(req, "s3cret", "", 0)
+	if err != nil {
+		t.Fatalf("VerifyRequest: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("body=%q", got)
+	}
+	if rest, _ := io.ReadAll(req.Body); !bytes.Equal(rest, body) {
+		t.Fatalf("expected body to be restored, got %q", rest)
+	}
+
+	cases := map[string]struct {
+		secret, method, route string
+		body                  []byte
+		want                  error
+	}{
+		"wrong secret": {"other", http.MethodPost, "", body, ErrInvalidSignature},
+		"other route":  {"s3cret", http.MethodPost, "/presign", body, ErrInvalidSignature},
+		"other method": {"s3cret", http.MethodPut, "", body, ErrInvalidSignature},
+		"edited body":  {"s3cret", http.MethodPost, "", []byte(`{"content":"ho"}`), ErrInvalidSignature},
+	}
+	for name, c := range cases {
+		if err := VerifySignature(req.Header, c.secret, c.method, c.route, c.body, 0); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", name, err, c.want)
+		}
+	}
+	if err := VerifySignature(http.Header{}, "s3cret", http.MethodPost, "", body, 0); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("unsigned: got %v", err)
+	}
+}
+
+// The server's verifier (server/src/utils/webhookSignature.test.ts) pins the
+// same vector.
+func TestComputeSignature_KnownVector(t *testing.T) {
+	got := ComputeSignature("secret", 1700000000, "post", "", bodySHA256(nil))
+	if got != "717645404240efa154cb7b54c70e4b4e539f22b8c051539cecbb02f9a532a18a" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestVerifySignature_RejectsStaleTimestampsAndAcceptsRotation(t *testing.T) {
+	body := []byte("x")
+	now := time.Unix(1_700_000_000, 0)
+	signingNow = func() time.Time { return now }
+	t.Cleanup(func() { signingNow = time.Now })
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	SignRequest(req, "new", "", body)
+	req.Header.Set(SignatureHeader, "v1=deadbeef, "+req.Header.Get(SignatureHeader))
+	if err := VerifySignature(req.Header, "new", http.MethodPost, "", body, time.Minute); err != nil {
+		t.Fatalf("expected one of several signatures to match: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := VerifySignature(req.Header, "new", http.MethodPost, "", body, time.Minute); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expected expiry, got %v", err)
+	}
+}
+
+func TestPostJSON_SignsWithFragmentSecret(t *testing.T) {
+	var verifyErr error
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.String()
+		_, verifyErr = VerifyRequest(r, "frag-secret", "", 0)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := PostJSON(context.Background(), srv.Client(), "", srv.URL+"/api/webhooks/w1/tok#secret=frag-secret", []byte(`{"content":"hi"}`))
+	if err != nil {
+		t.Fatalf("PostJSON: %v", err)
+	}
+	if verifyErr != nil {
+		t.Fatalf("server-side verification: %v", verifyErr)
+	}
+	if strings.Contains(gotPath, "secret") {
+		t.Fatalf("secret leaked into request: %q", gotPath)
+	}
+}
+
+func TestUploadBytes_SignsPresignAndUsesRegisteredSecret(t *testing.T) {
+	var routes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := strings.TrimPrefix(r.URL.Path, "/api/webhooks/w1/tok")
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if _, err := VerifyRequest(r, "reg-secret", route, 0); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		routes = append(routes, route)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"key":"k1","url":"` + "http://" + r.Host + `/put","method":"PUT"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	webhookURL := srv.URL + "/api/webhooks/w1/tok"
+	SetSigningSecret(webhookURL, "reg-secret")
+	t.Cleanup(func() { SetSigningSecret(webhookURL, "") })
+
+	att, err := UploadBytes(context.Background(), srv.Client(), "", webhookURL+"?thread=1", "a.txt", "text/plain", []byte("hello"))
+	if err != nil {
+		t.Fatalf("UploadBytes: %v", err)
+	}
+	if att.Key != "k1" || len(routes) != 1 || routes[0] != "/presign" {
+		t.Fatalf("att=%+v routes=%v", att, routes)
+	}
+}
+
+func TestSignRequest_CoversQuery(t *testing.T) {
+	body := []byte("{}")
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/w1/tok/multipart/parts?key=k&uploadId=u1", nil)
+	SignRequest(req, "s3cret", "/multipart/parts", body)
+	if err := VerifySignature(req.Header, "s3cret", http.MethodGet, "/multipart/parts?key=k&uploadId=u1", body, 0); err != nil {
+		t.Fatalf("same query: %v", err)
+	}
+	if err := VerifySignature(req.Header, "s3cret", http.MethodGet, "/multipart/parts?key=k&uploadId=u2", body, 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("other query: got %v", err)
+	}
+}
+
+func TestUploadReader_SignsFileHash(t *testing.T) {
+	file := []byte("streamed file body")
+	sum := sha256.Sum256(file)
+	var verifyErr error
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyErr = func() error {
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				return err
+			}
+			got, _ := io.ReadAll(f)
+			claimed := r.Header.Get(ContentSHA256Header)
+			if !bytes.Equal(got, file) || claimed != hex.EncodeToString(sum[:]) {
+				return fmt.Errorf("file=%q hash=%s", got, claimed)
+			}
+			ts, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+			if want := "v1=" + ComputeSignature("up-secret", ts, r.Method, "/upload?thread=1", claimed); r.Header.Get(SignatureHeader) != want {
+				return ErrInvalidSignature
+			}
+			return nil
+		}()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"key":"k1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var sent int64
+	// Not sized, so it skips presign and is spooled before the streamed POST.
+	r := io.MultiReader(bytes.NewReader(file))
+	_, err := UploadReaderWithOptions(context.Background(), srv.Client(), "", srv.URL+"/api/webhooks/w1/tok?thread=1#secret=up-secret", "a.txt", "text/plain", r, UploadOptions{
+		Progress: func(n, _ int64) { sent = n },
+	})
+	if err != nil {
+		t.Fatalf("UploadReaderWithOptions: %v", err)
+	}
+	if verifyErr != nil {
+		t.Fatalf("server-side verification: %v", verifyErr)
+	}
+	if sent != int64(len(file)) {
+		t.Fatalf("expected progress to count the file once, got %d", sent)
+	}
+}
+
+func TestWebhookProtocol_ErrorsOmitSecret(t *testing.T) {
+	p := &webhookProtocol{webhookURL: "/api/webhooks/w1/tok#secret=frag-secret"}
+	_, err := p.endpoint("")
+	if err == nil || strings.Contains(err.Error(), "frag-secret") {
+		t.Fatalf("expected an error without the secret, got %v", err)
+	}
+	if _, err := buildUploadURL("::bad#secret=frag-secret"); err == nil || strings.Contains(err.Error(), "frag-secret") {
+		t.Fatalf("expected an error without the secret, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// solverTTL is how long solved cookies are reused for a host; challenge
+// clearances usually last longer, and a stale one just triggers a re-solve.
+const solverTTL = 10 * time.Minute
+
+// FlareSolverrDownload solves anti-bot challenges with a FlareSolverr-
+// compatible service at endpoint (e.g. http://localhost:8191), then fetches
+// the file through the caller's client with the solved cookies and
+// User-Agent. Solutions are cached per host.
+func FlareSolverrDownload(endpoint string) DownloadStrategy {
+	return &solverStrategy{endpoint: func() string { return endpoint }, cache: map[string]solution{}}
+}
+
+// FlareSolverrFromEnv is FlareSolverrDownload at FLARESOLVERR_URL, read on
+// each download; it is skipped while the variable is unset.
+func FlareSolverrFromEnv() DownloadStrategy {
+	return &solverStrategy{endpoint: func() string { return os.Getenv("FLARESOLVERR_URL") }, cache: map[string]solution{}}
+}
+
+type solution struct {
+	cookies   string
+	userAgent string
+	at        time.Time
+}
+
+type solverStrategy struct {
+	endpoint func() string
+	client   *http.Client // talks to the solver; nil uses a 90s client
+
+	mu    sync.Mutex
+	cache map[string]solution
+}
+
+func (s *solverStrategy) Name() string { return "flaresolverr" }
+
+func (s *solverStrategy) Download(ctx context.Context, req DownloadRequest) (*http.Response, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(s.endpoint()), "/")
+	if endpoint == "" {
+		return nil, ErrStrategySkipped
+	}
+	if !strings.HasSuffix(endpoint, "/v1") {
+		endpoint += "/v1"
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Hostname()
+
+	sol, cached := s.cached(host)
+	if !cached {
+		if sol, err = s.solve(ctx, endpoint, req.URL); err != nil {
+			return nil, err
+		}
+		s.store(host, sol)
+	}
+	resp, err := fetchWithRetry(ctx, req.Client, req.URL, sol.agent(req.UserAgent), solvedHeader(req.Header, sol), 1)
+	if err != nil && cached {
+		// The clearance may have expired; solve once more.
+		s.forget(host)
+		if sol, err = s.solve(ctx, endpoint, req.URL); err != nil {
+			return nil, err
+		}
+		s.store(host, sol)
+		return fetchWithRetry(ctx, req.Client, req.URL, sol.agent(req.UserAgent), solvedHeader(req.Header, sol), 1)
+	}
+	return resp, err
+}
+
+func (s solution) agent(fallback string) string {
+	if s.userAgent != "" {
+		return s.userAgent
+	}
+	return fallback
+}
+
+func solvedHeader(base http.Header, sol solution) http.Header {
+	h := base.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	if sol.cookies != "" {
+		if prev := h.Get("Cookie"); prev != "" {
+			h.Set("Cookie", prev+"; "+sol.cookies)
+		} else {
+			h.Set("Cookie", sol.cookies)
+		}
+	}
+	return h
+}
+
+func (s *solverStrategy) cached(host string) (solution, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sol, ok := s.cache[host]
+	if !ok || time.Since(sol.at) > solverTTL {
+		return solution{}, false
+	}
+	return sol, true
+}
+
+func (s *solverStrategy) store(host string, sol solution) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[host] = sol
+}
+
+func (s *solverStrategy) forget(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, host)
+}
+
+// solve runs a request.get through the solver and keeps the cookies and
+// User-Agent it ended up with.
+func (s *solverStrategy) solve(ctx context.Context, endpoint, target string) (solution, error) {
+	body, _ := json.Marshal(map[string]any{"cmd": "request.get", "url": target, "maxTimeout": 60000})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return solution{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.client
+	if client == nil {
+		client = &http.Client{Timeout: 90 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return solution{}, fmt.Errorf("solver: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		Solution struct {
+			UserAgent string `json:"userAgent"`
+			Cookies   []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"cookies"`
+		} `json:"solution"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return solution{}, fmt.Errorf("solver: %s: %w", resp.Status, err)
+	}
+	if out.Status != "ok" {
+		return solution{}, fmt.Errorf("solver: %s %s", out.Status, out.Message)
+	}
+	cookies := make([]string, 0, len(out.Solution.Cookies))
+	for _, c := range out.Solution.Cookies {
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+	return solution{cookies: strings.Join(cookies, "; "), userAgent: out.Solution.UserAgent, at: time.Now()}, nil
+}
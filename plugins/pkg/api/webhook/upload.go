@@ -3,6 +3,8 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,10 +13,13 @@ import (
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"mew/plugins/pkg/api/chunked"
+	"mew/plugins/pkg/api/internal/progress"
 	"mew/plugins/pkg/x/devmode"
 )
 
@@ -25,26 +30,32 @@ type Attachment struct {
 	Size        int64  `json:"size"`
 }
 
-func UploadBytes(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, data []byte) (Attachment, error) {
-	ct := strings.TrimSpace(contentType)
-	if ct == "" {
-		ct = "application/octet-stream"
-	}
-
-	// Prefer pre-signed PUT when size is known.
-	if !devmode.Enabled() {
-		if att, ok, err := uploadViaPresign(ctx, httpClient, apiBase, webhookURL, filename, ct, int64(len(data)), bytes.NewReader(data)); err != nil {
-			return Attachment{}, err
-		} else if ok {
-			return att, nil
-		}
-	}
+// presignMaxBytes is the largest file sent as a single presigned PUT;
+// anything bigger goes through chunked (multipart) uploads when possible.
+const presignMaxBytes = 8 * 1024 * 1024
+
+// UploadOptions adds progress reporting and resumable chunked uploads.
+// Files over 8MB with a known size (bytes, *os.File, io.SectionReader) are
+// sent as presigned multipart parts; Store and ResumeID let a failed upload
+// continue from the last finished part while the content (Fingerprint, see
+// chunked.Options) is unchanged.
+type UploadOptions struct {
+	Progress    chunked.Progress
+	PartRetries int
+	Store       chunked.StateStore
+	ResumeID    string
+	Fingerprint string
+}
 
-	// Prevent a double presign attempt inside UploadReader (bytes.Reader exposes Len()).
-	return UploadReader(ctx, httpClient, apiBase, webhookURL, filename, ct, hideLen(bytes.NewReader(data)))
+func UploadBytes(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, data []byte) (Attachment, error) {
+	return UploadReaderWithOptions(ctx, httpClient, apiBase, webhookURL, filename, contentType, bytes.NewReader(data), UploadOptions{})
 }
 
 func UploadReader(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, r io.Reader) (Attachment, error) {
+	return UploadReaderWithOptions(ctx, httpClient, apiBase, webhookURL, filename, contentType, r, UploadOptions{})
+}
+
+func UploadReaderWithOptions(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, r io.Reader, opts UploadOptions) (Attachment, error) {
 	if strings.TrimSpace(filename) == "" {
 		return Attachment{}, fmt.Errorf("filename is required")
 	}
@@ -76,9 +87,39 @@ func UploadReader(ctx context.Context, httpClient *http.Client, apiBase, webhook
 		ct = "application/octet-stream"
 	}
 
+	size, sized := inferReaderSize(r)
+	if !devmode.Enabled() && sized && size > presignMaxBytes {
+		if ra, ok := r.(io.ReaderAt); ok {
+			proto := &webhookProtocol{client: httpClient, apiBase: apiBase, webhookURL: webhookURL}
+			res, err := chunked.Upload(ctx, proto, ra, size, filename, ct, chunked.Options{
+				Progress:    opts.Progress,
+				PartRetries: opts.PartRetries,
+				Store:       opts.Store,
+				ResumeID:    opts.ResumeID,
+				Fingerprint: opts.Fingerprint,
+				PutClient:   httpClient,
+			})
+			if !errors.Is(err, chunked.ErrUnsupported) {
+				if err != nil {
+					return Attachment{}, err
+				}
+				return Attachment(res), nil
+			}
+			// Older servers: fall through to the streamed POST.
+			r = io.NewSectionReader(ra, 0, size)
+		}
+	}
+	if opts.Progress != nil {
+		total := int64(-1)
+		if sized {
+			total = size
+		}
+		r = &progress.Reader{R: r, Total: total, Fn: opts.Progress}
+	}
+
 	// Prefer pre-signed PUT when we can infer a stable size.
 	if !devmode.Enabled() {
-		if size, ok := inferReaderSize(r); ok {
+		if sized {
 			if att, ok2, err := uploadViaPresign(ctx, httpClient, apiBase, webhookURL, filename, ct, size, r); err != nil {
 				return Attachment{}, err
 			} else if ok2 {
@@ -98,12 +139,23 @@ func UploadReader(ctx context.Context, httpClient *http.Client, apiBase, webhook
 			return Attachment{}, err
 		}
 		key := filepath.ToSlash(filepath.Join("dev", "webhook", "upload", filepath.Base(dataPath)))
-		if err := recordUpload(apiBase, webhookURL, target, filename, ct, dataPath, size, key); err != nil {
+		if err := recordUpload(apiBase, stripFragment(webhookURL), target, filename, ct, dataPath, size, key); err != nil {
 			return Attachment{}, err
 		}
 		return Attachment{Filename: filename, ContentType: ct, Key: key, Size: size}, nil
 	}
 
+	// Signed uploads cover the file's hash, so it is read once up front.
+	var contentSHA256 string
+	if signingSecret(webhookURL) != "" {
+		sum, cleanup, err := hashUploadFile(&r)
+		if err != nil {
+			return Attachment{}, err
+		}
+		defer cleanup()
+		contentSHA256 = sum
+	}
+
 	pr, pw := io.Pipe()
 	writer := multipart.NewWriter(pw)
 
@@ -142,6 +194,9 @@ func UploadReader(ctx context.Context, httpClient *http.Client, apiBase, webhook
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Accept", "application/json")
+	if contentSHA256 != "" {
+		signWebhookRequestSHA256(req, webhookURL, "/upload", contentSHA256)
+	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -169,7 +224,7 @@ func UploadReader(ctx context.Context, httpClient *http.Client, apiBase, webhook
 }
 
 func buildUploadURL(webhookURL string) (string, error) {
-	raw := strings.TrimSpace(webhookURL)
+	raw := stripFragment(strings.TrimSpace(webhookURL))
 	if raw == "" {
 		return "", fmt.Errorf("empty webhook url")
 	}
@@ -188,11 +243,12 @@ func buildUploadURL(webhookURL string) (string, error) {
 	}
 	u.Path = p
 	u.RawPath = ""
+	u.Fragment, u.RawFragment = "", ""
 	return u.String(), nil
 }
 
 func buildPresignURL(webhookURL string) (string, error) {
-	raw := strings.TrimSpace(webhookURL)
+	raw := stripFragment(strings.TrimSpace(webhookURL))
 	if raw == "" {
 		return "", fmt.Errorf("empty webhook url")
 	}
@@ -210,6 +266,7 @@ func buildPresignURL(webhookURL string) (string, error) {
 	}
 	u.Path = p
 	u.RawPath = ""
+	u.Fragment, u.RawFragment = "", ""
 	return u.String(), nil
 }
 
@@ -220,18 +277,89 @@ type presignResponse struct {
 	Headers map[string]string `json:"headers"`
 }
 
-type noLenReader struct{ io.Reader }
+// uploadDownloaded uploads a downloaded body. Bodies known to exceed
+// presignMaxBytes are spooled to a temp file first so they go up in
+// resumable parts instead of one long request.
+func uploadDownloaded(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, r io.Reader, contentLength int64) (Attachment, error) {
+	if devmode.Enabled() || contentLength <= presignMaxBytes {
+		return UploadReader(ctx, httpClient, apiBase, webhookURL, filename, contentType, r)
+	}
+	f, err := os.CreateTemp("", "mew-upload-*")
+	if err != nil {
+		return UploadReader(ctx, httpClient, apiBase, webhookURL, filename, contentType, r)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return Attachment{}, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return Attachment{}, err
+	}
+	return UploadReader(ctx, httpClient, apiBase, webhookURL, filename, contentType, f)
+}
 
-func hideLen(r io.Reader) io.Reader { return noLenReader{Reader: r} }
+// hashUploadFile returns the hex SHA-256 of what *r yields and replaces *r
+// with a reader that yields the same bytes again: seekable readers are
+// rewound, anything else is spooled to a temp file that cleanup removes.
+// Progress is reported on the second read only.
+func hashUploadFile(r *io.Reader) (string, func(), error) {
+	target := r
+	if p, ok := (*r).(*progress.Reader); ok {
+		target = &p.R
+	}
+	h := sha256.New()
+	if rs, ok := (*target).(io.ReadSeeker); ok {
+		pos, err := rs.Seek(0, io.SeekCurrent)
+		if err == nil {
+			if _, err := io.Copy(h, rs); err != nil {
+				return "", nil, err
+			}
+			if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+				return "", nil, err
+			}
+			return hex.EncodeToString(h.Sum(nil)), func() {}, nil
+		}
+	}
+	f, err := os.CreateTemp("", "mew-upload-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+	if _, err := io.Copy(io.MultiWriter(f, h), *target); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	*target = f
+	return hex.EncodeToString(h.Sum(nil)), cleanup, nil
+}
 
 func inferReaderSize(r io.Reader) (int64, bool) {
-	// Common readers in the SDK path expose Len(). For non-buffered streams, size is unknown.
+	// Common readers in the SDK path expose Len() or Size(). For non-buffered streams, size is unknown.
 	type lenner interface{ Len() int }
-	if v, ok := r.(lenner); ok {
-		n := v.Len()
-		if n > 0 {
+	type sizer interface{ Size() int64 }
+	switch v := r.(type) {
+	case lenner:
+		if n := v.Len(); n > 0 {
 			return int64(n), true
 		}
+	case sizer:
+		if n := v.Size(); n > 0 {
+			return n, true
+		}
+	case *os.File:
+		if st, err := v.Stat(); err == nil && st.Mode().IsRegular() && st.Size() > 0 {
+			if off, err := v.Seek(0, io.SeekCurrent); err == nil && off == 0 {
+				return st.Size(), true
+			}
+		}
 	}
 	return 0, false
 }
@@ -243,7 +371,7 @@ func uploadViaPresign(
 	size int64,
 	r io.Reader,
 ) (Attachment, bool, error) {
-	if size <= 0 || size > 8*1024*1024 {
+	if size <= 0 || size > presignMaxBytes {
 		return Attachment{}, false, nil
 	}
 
@@ -278,6 +406,7 @@ func uploadViaPresign(
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	signWebhookRequest(req, webhookURL, "/presign", reqBody)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -302,6 +431,7 @@ func uploadViaPresign(
 	if err != nil {
 		return Attachment{}, false, err
 	}
+	putReq.ContentLength = size
 	for k, v := range parsed.Headers {
 		if strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
 			continue
@@ -197,3 +197,52 @@ func TestUploadReader_ResponseMissingKey(t *testing.T) {
 		t.Fatalf("expected missing key error, got %v", err)
 	}
 }
+
+func TestUploadReaderWithOptions_UsesMultipartForLargeFiles(t *testing.T) {
+	t.Parallel()
+
+	const partSize = presignMaxBytes
+	size := int64(presignMaxBytes + 10)
+	var received int64
+	var signed, completed int
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/webhooks/1/token/multipart":
+			_, _ = fmt.Fprintf(w, `{"key":"big.bin","uploadId":"up1","partSize":%d,"partCount":2}`, partSize)
+		case r.URL.Path == "/api/webhooks/1/token/multipart/parts" && r.Method == http.MethodPost:
+			signed++
+			_, _ = fmt.Fprintf(w, `{"parts":[{"partNumber":1,"url":"%s/s3/1"},{"partNumber":2,"url":"%s/s3/2"}]}`, srv.URL, srv.URL)
+		case strings.HasPrefix(r.URL.Path, "/s3/"):
+			n, _ := io.Copy(io.Discard, r.Body)
+			received += n
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/api/webhooks/1/token/multipart/complete":
+			completed++
+			_, _ = fmt.Fprintf(w, `{"filename":"big.bin","contentType":"application/octet-stream","key":"big.bin","size":%d}`, size)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var lastSent int64
+	out, err := UploadReaderWithOptions(context.Background(), srv.Client(), "", srv.URL+"/api/webhooks/1/token",
+		"big.bin", "", io.NewSectionReader(strings.NewReader(strings.Repeat("x", int(size))), 0, size),
+		UploadOptions{Progress: func(sent, _ int64) { lastSent = sent }})
+	if err != nil {
+		t.Fatalf("UploadReaderWithOptions error: %v", err)
+	}
+	if out.Key != "big.bin" || out.Size != size {
+		t.Fatalf("unexpected response: %#v", out)
+	}
+	if received != size || lastSent != size {
+		t.Fatalf("expected %d bytes sent, got received=%d progress=%d", size, received, lastSent)
+	}
+	if signed != 1 || completed != 1 {
+		t.Fatalf("expected one sign and one complete call, got %d/%d", signed, completed)
+	}
+}
@@ -47,6 +47,14 @@ func RewriteLoopbackURL(rawURL, apiBase string) (string, error) {
 	return u.String(), nil
 }
 
+// stripFragment drops a "#..." suffix, which may hold the signing secret.
+func stripFragment(rawURL string) string {
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		return rawURL[:i]
+	}
+	return rawURL
+}
+
 func isLoopbackHost(host string) bool {
 	host = strings.TrimSpace(strings.ToLower(host))
 	if host == "localhost" {
@@ -2,8 +2,6 @@ package webhook
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
 )
 
@@ -15,16 +13,19 @@ type Payload struct {
 	Payload   map[string]any `json:"payload,omitempty"`
 	Username  string         `json:"username,omitempty"`
 	AvatarURL string         `json:"avatar_url,omitempty"`
+
+	// ReferencedMessageID threads the message as a reply to a message in the
+	// webhook's channel.
+	ReferencedMessageID string `json:"referencedMessageId,omitempty"`
 }
 
-// Post sends a JSON payload to the specified webhook URL with retry logic.
+// Post sends a JSON payload to the specified webhook URL through
+// DefaultQueue: posts to one webhook are paced and ordered, 429/Retry-After
+// pauses the webhook, and transient failures are retried up to maxRetries
+// attempts (default 3).
 func Post(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payload Payload, maxRetries int) error {
-	if payload.Content == "" {
-		return fmt.Errorf("payload content is required")
-	}
-	b, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	if maxRetries <= 0 {
+		maxRetries = 3
 	}
-	return PostJSONWithRetry(ctx, httpClient, apiBase, webhookURL, b, maxRetries)
+	return DefaultQueue.post(ctx, httpClient, apiBase, webhookURL, payload, maxRetries)
 }
@@ -32,6 +32,12 @@ func (c *DMChannelCache) Has(channelID string) bool {
 	return ok
 }
 
+func (c *DMChannelCache) add(channelID string) {
+	c.mu.Lock()
+	c.channels[channelID] = struct{}{}
+	c.mu.Unlock()
+}
+
 func (c *DMChannelCache) Refresh(ctx context.Context, httpClient *http.Client, apiBase, userToken string) error {
 	if c == nil {
 		return nil
@@ -54,12 +60,12 @@ func (c *DMChannelCache) RefreshWithBotSession(ctx context.Context, session *Bot
 		return nil
 	}
 
-	httpClient := session.HTTPClient()
-	if httpClient == nil {
+	api := session.API()
+	if api == nil {
 		return nil
 	}
 
-	dm, err := channels.FetchDMChannels(ctx, httpClient, session.apiBase, "")
+	dm, err := api.Channels.DMChannelIDs(ctx)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,449 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	apiclient "mew/plugins/pkg/api/client"
+)
+
+type CommandArgType string
+
+const (
+	CommandArgString CommandArgType = "string"
+	CommandArgInt    CommandArgType = "int"
+	CommandArgBool   CommandArgType = "bool"
+	// CommandArgRest consumes the remaining raw text; it must be the last arg.
+	CommandArgRest CommandArgType = "rest"
+)
+
+type CommandArg struct {
+	Name        string
+	Type        CommandArgType
+	Required    bool
+	Description string
+}
+
+// CommandSpec declares a slash command. Specs are static per service type and
+// are also sent to the server at registration for client autocomplete.
+type CommandSpec struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Args        []CommandArg
+
+	// DMOnly rejects the command outside DM channels.
+	DMOnly bool
+	// OwnerOnly rejects the command unless CommandContext.IsOwner is set.
+	OwnerOnly bool
+}
+
+func (s CommandSpec) Usage() string {
+	var b strings.Builder
+	b.WriteString("/" + s.Name)
+	for _, a := range s.Args {
+		name := a.Name
+		if a.Type == CommandArgRest {
+			name += "..."
+		}
+		if a.Required {
+			b.WriteString(" <" + name + ">")
+		} else {
+			b.WriteString(" [" + name + "]")
+		}
+	}
+	return b.String()
+}
+
+// CommandContext carries the caller-side facts needed for permission checks.
+// IsOwner should be UserID == BotOwnerID(ctx).
+type CommandContext struct {
+	ChannelID string
+	UserID    string
+	IsDM      bool
+	IsOwner   bool
+}
+
+type CommandInvocation struct {
+	CommandContext
+
+	Spec CommandSpec
+	// Name is the command name as typed (may be an alias).
+	Name string
+	// Raw is the text after the command name.
+	Raw string
+
+	values map[string]any
+}
+
+func (inv CommandInvocation) String(name string) string {
+	v, _ := inv.values[name].(string)
+	return v
+}
+
+func (inv CommandInvocation) Int(name string) int {
+	v, _ := inv.values[name].(int)
+	return v
+}
+
+func (inv CommandInvocation) Bool(name string) bool {
+	v, _ := inv.values[name].(bool)
+	return v
+}
+
+func (inv CommandInvocation) Has(name string) bool {
+	_, ok := inv.values[name]
+	return ok
+}
+
+// CommandHandler runs a command and returns the reply text (may be empty).
+type CommandHandler func(ctx context.Context, inv CommandInvocation) (reply string, err error)
+
+type registeredCommand struct {
+	spec    CommandSpec
+	handler CommandHandler
+}
+
+// CommandRegistry dispatches "/name args..." messages to handlers and serves
+// a built-in /help (see SetHelpCommand).
+type CommandRegistry struct {
+	// ReplyUnknown makes Dispatch answer unknown commands with a suggestion
+	// instead of reporting them as unhandled (so callers can fall through).
+	ReplyUnknown bool
+
+	commands []*registeredCommand
+	byName   map[string]*registeredCommand
+	helpName string
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{byName: map[string]*registeredCommand{}, helpName: "help"}
+}
+
+// SetHelpCommand renames the built-in help command; "" disables it, so /help
+// is dispatched like any other name (agents that forward unknown commands to
+// a backend with its own /help want this). It panics if a registered command
+// already uses name.
+func (r *CommandRegistry) SetHelpCommand(name string) {
+	name = normalizeCommandName(name)
+	if _, dup := r.byName[name]; name != "" && dup {
+		panic("runtime: duplicate command /" + name)
+	}
+	r.helpName = name
+}
+
+// Handle registers a command. It panics on invalid specs or duplicate names,
+// like http.ServeMux.
+func (r *CommandRegistry) Handle(spec CommandSpec, handler CommandHandler) {
+	spec.Name = normalizeCommandName(spec.Name)
+	if spec.Name == "" {
+		panic("runtime: command name is required")
+	}
+	if handler == nil {
+		panic("runtime: nil handler for /" + spec.Name)
+	}
+	spec.Args = append([]CommandArg(nil), spec.Args...)
+	for i, a := range spec.Args {
+		if a.Type == "" {
+			spec.Args[i].Type = CommandArgString
+		}
+		if a.Type == CommandArgRest && i != len(spec.Args)-1 {
+			panic("runtime: rest arg must be last in /" + spec.Name)
+		}
+	}
+
+	cmd := &registeredCommand{spec: spec, handler: handler}
+	for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+		name = normalizeCommandName(name)
+		if name == "" {
+			continue
+		}
+		if name == r.helpName {
+			panic("runtime: /" + name + " is reserved for help")
+		}
+		if _, dup := r.byName[name]; dup {
+			panic("runtime: duplicate command /" + name)
+		}
+		r.byName[name] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+}
+
+func (r *CommandRegistry) Specs() []CommandSpec {
+	out := make([]CommandSpec, 0, len(r.commands))
+	for _, c := range r.commands {
+		out = append(out, c.spec)
+	}
+	return out
+}
+
+// Dispatch handles text when it starts with "/". ok reports whether the text
+// was consumed (including permission/usage errors and /help); callers should
+// treat ok=false as a normal message.
+func (r *CommandRegistry) Dispatch(ctx context.Context, cc CommandContext, text string) (reply string, ok bool, err error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", false, nil
+	}
+	name, raw := splitCommand(text[1:])
+	name = normalizeCommandName(name)
+	if name == "" {
+		return "", false, nil
+	}
+
+	if r.helpName != "" && name == r.helpName {
+		return r.Help(cc, raw), true, nil
+	}
+
+	cmd, found := r.byName[name]
+	if !found {
+		if !r.ReplyUnknown {
+			return "", false, nil
+		}
+		msg := fmt.Sprintf("未知命令 /%s。", name)
+		if s := r.Suggest(name); s != "" {
+			msg += fmt.Sprintf("你是不是想输入 /%s？", s)
+		}
+		if r.helpName != "" {
+			msg += fmt.Sprintf("输入 /%s 查看可用命令。", r.helpName)
+		}
+		return msg, true, nil
+	}
+
+	if cmd.spec.DMOnly && !cc.IsDM {
+		return fmt.Sprintf("/%s 只能在私聊中使用。", cmd.spec.Name), true, nil
+	}
+	if cmd.spec.OwnerOnly && !cc.IsOwner {
+		return fmt.Sprintf("/%s 仅限 Bot 所有者使用。", cmd.spec.Name), true, nil
+	}
+
+	values, perr := parseCommandArgs(cmd.spec.Args, raw)
+	if perr != nil {
+		return fmt.Sprintf("%v\n用法：%s", perr, cmd.spec.Usage()), true, nil
+	}
+
+	reply, err = cmd.handler(ctx, CommandInvocation{
+		CommandContext: cc,
+		Spec:           cmd.spec,
+		Name:           name,
+		Raw:            raw,
+		values:         values,
+	})
+	return reply, true, err
+}
+
+// Help renders the command list, or details for one command when topic is set.
+// Commands the caller cannot use in this context are omitted from the list.
+func (r *CommandRegistry) Help(cc CommandContext, topic string) string {
+	if topic = normalizeCommandName(strings.TrimPrefix(strings.TrimSpace(topic), "/")); topic != "" {
+		cmd, ok := r.byName[topic]
+		if !ok {
+			return fmt.Sprintf("未知命令 /%s。", topic)
+		}
+		return commandDetail(cmd.spec)
+	}
+
+	var b strings.Builder
+	b.WriteString("可用命令：")
+	for _, c := range r.commands {
+		if (c.spec.DMOnly && !cc.IsDM) || (c.spec.OwnerOnly && !cc.IsOwner) {
+			continue
+		}
+		b.WriteString("\n" + c.spec.Usage())
+		if d := strings.TrimSpace(c.spec.Description); d != "" {
+			b.WriteString(" - " + d)
+		}
+	}
+	if r.helpName != "" {
+		b.WriteString(fmt.Sprintf("\n/%s [command] - 查看命令说明", r.helpName))
+	}
+	return b.String()
+}
+
+func commandDetail(s CommandSpec) string {
+	var b strings.Builder
+	b.WriteString("用法：" + s.Usage())
+	if d := strings.TrimSpace(s.Description); d != "" {
+		b.WriteString("\n" + d)
+	}
+	if len(s.Aliases) > 0 {
+		b.WriteString("\n别名：/" + strings.Join(s.Aliases, ", /"))
+	}
+	for _, a := range s.Args {
+		b.WriteString(fmt.Sprintf("\n  %s (%s)", a.Name, a.Type))
+		if d := strings.TrimSpace(a.Description); d != "" {
+			b.WriteString(" " + d)
+		}
+	}
+	return b.String()
+}
+
+// Suggest returns the closest known command name, or "" if nothing is close.
+func (r *CommandRegistry) Suggest(name string) string {
+	name = normalizeCommandName(name)
+	names := make([]string, 0, len(r.byName)+1)
+	for n := range r.byName {
+		names = append(names, n)
+	}
+	if r.helpName != "" {
+		names = append(names, r.helpName)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", -1
+	for _, n := range names {
+		d := levenshtein(name, n)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	if bestDist < 0 || bestDist > max(1, len([]rune(name))/3) {
+		return ""
+	}
+	if cmd, ok := r.byName[best]; ok {
+		return cmd.spec.Name
+	}
+	return best
+}
+
+// CommandInfos converts specs to the wire format used at registration.
+func CommandInfos(specs []CommandSpec) []apiclient.CommandInfo {
+	if len(specs) == 0 {
+		return nil
+	}
+	out := make([]apiclient.CommandInfo, 0, len(specs))
+	for _, s := range specs {
+		info := apiclient.CommandInfo{
+			Name:        normalizeCommandName(s.Name),
+			Aliases:     s.Aliases,
+			Description: strings.TrimSpace(s.Description),
+			Usage:       s.Usage(),
+			DMOnly:      s.DMOnly,
+			OwnerOnly:   s.OwnerOnly,
+		}
+		for _, a := range s.Args {
+			typ := a.Type
+			if typ == "" {
+				typ = CommandArgString
+			}
+			info.Args = append(info.Args, apiclient.CommandArgInfo{
+				Name:        a.Name,
+				Type:        string(typ),
+				Required:    a.Required,
+				Description: strings.TrimSpace(a.Description),
+			})
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+func normalizeCommandName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func splitCommand(s string) (name, rest string) {
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+func parseCommandArgs(args []CommandArg, raw string) (map[string]any, error) {
+	values := make(map[string]any, len(args))
+	rest := strings.TrimSpace(raw)
+	for _, a := range args {
+		if a.Type == CommandArgRest {
+			if rest == "" {
+				if a.Required {
+					return nil, fmt.Errorf("缺少参数 %s", a.Name)
+				}
+				return values, nil
+			}
+			values[a.Name] = rest
+			return values, nil
+		}
+
+		var tok string
+		tok, rest = nextCommandToken(rest)
+		if tok == "" {
+			if a.Required {
+				return nil, fmt.Errorf("缺少参数 %s", a.Name)
+			}
+			continue
+		}
+
+		switch a.Type {
+		case CommandArgInt:
+			n, err := strconv.Atoi(tok)
+			if err != nil {
+				return nil, fmt.Errorf("参数 %s 需要整数，收到 %q", a.Name, tok)
+			}
+			values[a.Name] = n
+		case CommandArgBool:
+			b, err := strconv.ParseBool(strings.ToLower(tok))
+			if err != nil {
+				switch strings.ToLower(tok) {
+				case "on", "yes", "y":
+					b = true
+				case "off", "no", "n":
+					b = false
+				default:
+					return nil, fmt.Errorf("参数 %s 需要 true/false，收到 %q", a.Name, tok)
+				}
+			}
+			values[a.Name] = b
+		default:
+			values[a.Name] = tok
+		}
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("多余的参数 %q", rest)
+	}
+	return values, nil
+}
+
+// nextCommandToken splits off one whitespace-delimited token; double quotes
+// group words into a single token.
+func nextCommandToken(s string) (tok, rest string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ""
+	}
+	if s[0] == '"' {
+		if end := strings.IndexByte(s[1:], '"'); end >= 0 {
+			return s[1 : end+1], strings.TrimSpace(s[end+2:])
+		}
+	}
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
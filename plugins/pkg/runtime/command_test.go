@@ -0,0 +1,147 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestCommandRegistry() *CommandRegistry {
+	reg := NewCommandRegistry()
+	reg.Handle(CommandSpec{
+		Name:    "remind",
+		Aliases: []string{"r"},
+		Args: []CommandArg{
+			{Name: "minutes", Type: CommandArgInt, Required: true},
+			{Name: "loud", Type: CommandArgBool},
+			{Name: "text", Type: CommandArgRest, Required: true},
+		},
+	}, func(ctx context.Context, inv CommandInvocation) (string, error) {
+		return strings.Join([]string{inv.Name, inv.String("text"), strings.Repeat("!", inv.Int("minutes"))}, "|"), nil
+	})
+	reg.Handle(CommandSpec{Name: "secret", OwnerOnly: true, DMOnly: true}, func(ctx context.Context, inv CommandInvocation) (string, error) {
+		return "ok", nil
+	})
+	reg.Handle(CommandSpec{Name: "fail"}, func(ctx context.Context, inv CommandInvocation) (string, error) {
+		return "", errors.New("boom")
+	})
+	return reg
+}
+
+func TestCommandRegistry_DispatchParsesTypedArgs(t *testing.T) {
+	reg := newTestCommandRegistry()
+
+	reply, ok, err := reg.Dispatch(context.Background(), CommandContext{}, `/R 3 yes "buy milk" now`)
+	if err != nil || !ok {
+		t.Fatalf("Dispatch: ok=%v err=%v", ok, err)
+	}
+	if reply != `r|"buy milk" now|!!!` {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+
+	reply, ok, _ = reg.Dispatch(context.Background(), CommandContext{}, "/remind soon hi")
+	if !ok || !strings.Contains(reply, "minutes") || !strings.Contains(reply, "/remind <minutes> [loud] <text...>") {
+		t.Fatalf("expected usage error, got ok=%v reply=%q", ok, reply)
+	}
+
+	if _, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "remind 3 hi"); ok {
+		t.Fatalf("text without leading slash must not be handled")
+	}
+
+	if _, ok, err := reg.Dispatch(context.Background(), CommandContext{}, "/fail"); !ok || err == nil {
+		t.Fatalf("expected handler error to propagate: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestCommandRegistry_Permissions(t *testing.T) {
+	reg := newTestCommandRegistry()
+
+	reply, ok, _ := reg.Dispatch(context.Background(), CommandContext{IsOwner: true}, "/secret")
+	if !ok || !strings.Contains(reply, "私聊") {
+		t.Fatalf("expected DM-only rejection, got %q", reply)
+	}
+	reply, ok, _ = reg.Dispatch(context.Background(), CommandContext{IsDM: true}, "/secret")
+	if !ok || !strings.Contains(reply, "所有者") {
+		t.Fatalf("expected owner-only rejection, got %q", reply)
+	}
+	reply, _, _ = reg.Dispatch(context.Background(), CommandContext{IsDM: true, IsOwner: true}, "/secret")
+	if reply != "ok" {
+		t.Fatalf("expected command to run, got %q", reply)
+	}
+}
+
+func TestCommandRegistry_HelpAndUnknown(t *testing.T) {
+	reg := newTestCommandRegistry()
+
+	help, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/help")
+	if !ok || !strings.Contains(help, "/remind") || strings.Contains(help, "/secret") {
+		t.Fatalf("unexpected help: %q", help)
+	}
+	detail, _, _ := reg.Dispatch(context.Background(), CommandContext{}, "/help r")
+	if !strings.Contains(detail, "别名：/r") || !strings.Contains(detail, "minutes (int)") {
+		t.Fatalf("unexpected help detail: %q", detail)
+	}
+
+	if _, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/remnid 1 x"); ok {
+		t.Fatalf("unknown commands fall through unless ReplyUnknown is set")
+	}
+	reg.ReplyUnknown = true
+	reply, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/remnid 1 x")
+	if !ok || !strings.Contains(reply, "/remind？") {
+		t.Fatalf("expected suggestion, got %q", reply)
+	}
+	if s := reg.Suggest("zzzzzz"); s != "" {
+		t.Fatalf("unexpected suggestion for unrelated name: %q", s)
+	}
+}
+
+func TestCommandRegistry_SetHelpCommand(t *testing.T) {
+	reg := NewCommandRegistry()
+	reg.SetHelpCommand("")
+	reg.Handle(CommandSpec{Name: "help"}, func(context.Context, CommandInvocation) (string, error) { return "own help", nil })
+	if reply, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/help"); !ok || reply != "own help" {
+		t.Fatalf("expected the agent's /help, got ok=%v reply=%q", ok, reply)
+	}
+
+	reg = newTestCommandRegistry()
+	reg.SetHelpCommand("commands")
+	if _, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/help"); ok {
+		t.Fatalf("/help must fall through once help is renamed")
+	}
+	if help, ok, _ := reg.Dispatch(context.Background(), CommandContext{}, "/commands"); !ok || !strings.Contains(help, "/commands [command]") {
+		t.Fatalf("unexpected help: ok=%v %q", ok, help)
+	}
+}
+
+func TestBotOwnerID(t *testing.T) {
+	if id := BotOwnerID(context.Background()); id != "" {
+		t.Fatalf("BotOwnerID without owner=%q", id)
+	}
+	if id := BotOwnerID(withBotOwnerID(context.Background(), " u1 ")); id != "u1" {
+		t.Fatalf("BotOwnerID=%q", id)
+	}
+}
+
+func TestCommandRegistry_HandlePanicsOnDuplicate(t *testing.T) {
+	reg := newTestCommandRegistry()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on duplicate alias")
+		}
+	}()
+	reg.Handle(CommandSpec{Name: "other", Aliases: []string{"r"}}, func(context.Context, CommandInvocation) (string, error) { return "", nil })
+}
+
+func TestCommandInfos(t *testing.T) {
+	infos := CommandInfos(newTestCommandRegistry().Specs())
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 infos, got %d", len(infos))
+	}
+	if infos[0].Name != "remind" || infos[0].Usage != "/remind <minutes> [loud] <text...>" || len(infos[0].Args) != 3 || infos[0].Args[0].Type != "int" {
+		t.Fatalf("unexpected info: %+v", infos[0])
+	}
+	if !infos[1].OwnerOnly || !infos[1].DMOnly {
+		t.Fatalf("expected permission flags: %+v", infos[1])
+	}
+}
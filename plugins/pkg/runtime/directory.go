@@ -0,0 +1,609 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+// Gateway events that Directory.HandleEvent consumes.
+const (
+	EventDMChannelCreate   = "DM_CHANNEL_CREATE"
+	EventChannelUpdate     = "CHANNEL_UPDATE"
+	EventChannelDelete     = "CHANNEL_DELETE"
+	EventCategoryUpdate    = "CATEGORY_UPDATE"
+	EventCategoryDelete    = "CATEGORY_DELETE"
+	EventServerUpdate      = "SERVER_UPDATE"
+	EventServerDelete      = "SERVER_DELETE"
+	EventMemberJoin        = "MEMBER_JOIN"
+	EventMemberLeave       = "MEMBER_LEAVE"
+	EventPermissionsUpdate = "PERMISSIONS_UPDATE"
+)
+
+// DefaultDirectoryRefreshInterval is the minimum gap between full refreshes
+// triggered by lookups of unknown channels.
+const DefaultDirectoryRefreshInterval = 30 * time.Second
+
+// maxDirectoryRefreshBackoff caps the gap between refresh attempts while
+// refreshes keep failing.
+const maxDirectoryRefreshBackoff = 5 * time.Minute
+
+// Directory caches the servers, channels and categories the bot can see,
+// plus members and roles of servers it asks about. It is loaded once with
+// Refresh and then kept current by feeding gateway events to HandleEvent,
+// so agents can look up channel types and member roles without a REST
+// round-trip per message.
+//
+// The server does not broadcast channel creation; a lookup of an unknown
+// channel triggers a (throttled) refresh instead. Failed refreshes back off
+// and lookups keep answering from the last good snapshot.
+type Directory struct {
+	session         *BotSession
+	refreshInterval time.Duration
+
+	refreshMu   sync.Mutex
+	lastRefresh time.Time // last attempt, successful or not
+	failures    int       // consecutive failed attempts
+	// stale forces the next lookup-triggered refresh regardless of the gap.
+	// It is set from HandleEvent, which must not wait on refreshMu while a
+	// refresh holds it across the network.
+	stale atomic.Bool
+
+	mu         sync.RWMutex
+	loaded     bool // a refresh has succeeded at least once
+	servers    map[string]rest.Server
+	channels   map[string]rest.Channel
+	categories map[string]map[string]rest.Category
+	members    map[string]map[string]rest.Member
+	roles      map[string][]rest.Role
+}
+
+func NewDirectory(session *BotSession) *Directory {
+	return &Directory{
+		session:         session,
+		refreshInterval: DefaultDirectoryRefreshInterval,
+		servers:         map[string]rest.Server{},
+		channels:        map[string]rest.Channel{},
+		categories:      map[string]map[string]rest.Category{},
+		members:         map[string]map[string]rest.Member{},
+		roles:           map[string][]rest.Role{},
+	}
+}
+
+func (d *Directory) api() (*rest.Client, error) {
+	if d == nil || d.session == nil || d.session.API() == nil {
+		return nil, fmt.Errorf("directory has no bot session")
+	}
+	return d.session.API(), nil
+}
+
+// Refresh reloads servers, their channels and categories, and DM channels.
+// Member and role caches are dropped and reloaded on next use.
+func (d *Directory) Refresh(ctx context.Context) error {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	return d.refreshLocked(ctx)
+}
+
+func (d *Directory) refreshLocked(ctx context.Context) error {
+	// Invalidations arriving during the load may not be in what it fetched,
+	// so they stay set for the next refresh.
+	d.stale.Store(false)
+	err := d.load(ctx)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	d.lastRefresh = time.Now()
+	if err != nil {
+		d.failures++
+		return err
+	}
+	d.failures = 0
+	return nil
+}
+
+func (d *Directory) load(ctx context.Context) error {
+	api, err := d.api()
+	if err != nil {
+		return err
+	}
+
+	servers, err := api.Servers.ListMine(ctx)
+	if err != nil {
+		return err
+	}
+	dms, err := api.Channels.ListDMs(ctx)
+	if err != nil {
+		return err
+	}
+
+	nextServers := make(map[string]rest.Server, len(servers))
+	nextChannels := make(map[string]rest.Channel, len(dms))
+	nextCategories := make(map[string]map[string]rest.Category, len(servers))
+	for _, ch := range dms {
+		nextChannels[ch.ID] = ch
+	}
+	for _, srv := range servers {
+		nextServers[srv.ID] = srv
+		channels, err := api.Servers.ListChannels(ctx, srv.ID)
+		if err != nil {
+			return fmt.Errorf("list channels of server %s: %w", srv.ID, err)
+		}
+		for _, ch := range channels {
+			if ch.ServerID == "" {
+				ch.ServerID = srv.ID
+			}
+			nextChannels[ch.ID] = ch
+		}
+		categories, err := api.Servers.ListCategories(ctx, srv.ID)
+		if err != nil {
+			return fmt.Errorf("list categories of server %s: %w", srv.ID, err)
+		}
+		byID := make(map[string]rest.Category, len(categories))
+		for _, cat := range categories {
+			byID[cat.ID] = cat
+		}
+		nextCategories[srv.ID] = byID
+	}
+
+	d.mu.Lock()
+	d.servers = nextServers
+	d.channels = nextChannels
+	d.categories = nextCategories
+	d.members = map[string]map[string]rest.Member{}
+	d.roles = map[string][]rest.Role{}
+	d.loaded = true
+	d.mu.Unlock()
+	return nil
+}
+
+// refreshIfStale runs Refresh unless one was attempted within the current
+// refresh gap (refreshInterval after a success, doubling per consecutive
+// failure up to maxDirectoryRefreshBackoff) and nothing invalidated it since.
+func (d *Directory) refreshIfStale(ctx context.Context) error {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	if !d.stale.Load() && !d.lastRefresh.IsZero() && time.Since(d.lastRefresh) < d.refreshGapLocked() {
+		return nil
+	}
+	return d.refreshLocked(ctx)
+}
+
+func (d *Directory) refreshGapLocked() time.Duration {
+	gap := d.refreshInterval
+	for i := 1; i < d.failures; i++ {
+		if gap *= 2; gap >= maxDirectoryRefreshBackoff {
+			return maxDirectoryRefreshBackoff
+		}
+	}
+	return gap
+}
+
+// hasSnapshot reports whether a refresh has ever succeeded.
+func (d *Directory) hasSnapshot() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.loaded
+}
+
+func (d *Directory) Servers() []rest.Server {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]rest.Server, 0, len(d.servers))
+	for _, srv := range d.servers {
+		out = append(out, srv)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (d *Directory) Server(serverID string) (rest.Server, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	srv, ok := d.servers[strings.TrimSpace(serverID)]
+	return srv, ok
+}
+
+// Channel looks up a channel, refreshing the directory once (throttled) when
+// it is unknown. A failed refresh is only reported when there is no earlier
+// snapshot to answer from.
+func (d *Directory) Channel(ctx context.Context, channelID string) (rest.Channel, bool, error) {
+	channelID = strings.TrimSpace(channelID)
+	if ch, ok := d.cachedChannel(channelID); ok {
+		return ch, true, nil
+	}
+	if err := d.refreshIfStale(ctx); err != nil && !d.hasSnapshot() {
+		return rest.Channel{}, false, err
+	}
+	ch, ok := d.cachedChannel(channelID)
+	return ch, ok, nil
+}
+
+func (d *Directory) cachedChannel(channelID string) (rest.Channel, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ch, ok := d.channels[channelID]
+	return ch, ok
+}
+
+// ServerChannels returns the cached channels of a server in position order.
+func (d *Directory) ServerChannels(serverID string) []rest.Channel {
+	serverID = strings.TrimSpace(serverID)
+	d.mu.RLock()
+	out := make([]rest.Channel, 0)
+	for _, ch := range d.channels {
+		if ch.ServerID == serverID {
+			out = append(out, ch)
+		}
+	}
+	d.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Position != out[j].Position {
+			return out[i].Position < out[j].Position
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Categories returns the cached categories of a server in position order.
+func (d *Directory) Categories(serverID string) []rest.Category {
+	d.mu.RLock()
+	byID := d.categories[strings.TrimSpace(serverID)]
+	out := make([]rest.Category, 0, len(byID))
+	for _, cat := range byID {
+		out = append(out, cat)
+	}
+	d.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Position != out[j].Position {
+			return out[i].Position < out[j].Position
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Members returns the members of a server, loading them on first use.
+func (d *Directory) Members(ctx context.Context, serverID string) ([]rest.Member, error) {
+	byUser, err := d.loadMembers(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]rest.Member, 0, len(byUser))
+	for _, m := range byUser {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID() < out[j].UserID() })
+	return out, nil
+}
+
+func (d *Directory) Member(ctx context.Context, serverID, userID string) (rest.Member, bool, error) {
+	byUser, err := d.loadMembers(ctx, serverID)
+	if err != nil {
+		return rest.Member{}, false, err
+	}
+	m, ok := byUser[strings.TrimSpace(userID)]
+	return m, ok, nil
+}
+
+func (d *Directory) loadMembers(ctx context.Context, serverID string) (map[string]rest.Member, error) {
+	serverID = strings.TrimSpace(serverID)
+	d.mu.RLock()
+	byUser, ok := d.members[serverID]
+	d.mu.RUnlock()
+	if ok {
+		return byUser, nil
+	}
+
+	api, err := d.api()
+	if err != nil {
+		return nil, err
+	}
+	members, err := api.Servers.ListMembers(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	byUser = make(map[string]rest.Member, len(members))
+	for _, m := range members {
+		if id := m.UserID(); id != "" && !m.IsWebhook() {
+			byUser[id] = m
+		}
+	}
+	d.mu.Lock()
+	d.members[serverID] = byUser
+	d.mu.Unlock()
+	return byUser, nil
+}
+
+// Roles returns the roles of a server in position order, loading them on
+// first use.
+func (d *Directory) Roles(ctx context.Context, serverID string) ([]rest.Role, error) {
+	serverID = strings.TrimSpace(serverID)
+	d.mu.RLock()
+	roles, ok := d.roles[serverID]
+	d.mu.RUnlock()
+	if ok {
+		return roles, nil
+	}
+
+	api, err := d.api()
+	if err != nil {
+		return nil, err
+	}
+	roles, err = api.Servers.ListRoles(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Position < roles[j].Position })
+	d.mu.Lock()
+	d.roles[serverID] = roles
+	d.mu.Unlock()
+	return roles, nil
+}
+
+// MemberRoles returns the roles held by a user in a server, including the
+// default (@everyone) role. A non-member gets no roles.
+func (d *Directory) MemberRoles(ctx context.Context, serverID, userID string) ([]rest.Role, error) {
+	m, ok, err := d.Member(ctx, serverID, userID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	roles, err := d.Roles(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]struct{}, len(m.RoleIDs))
+	for _, id := range m.RoleIDs {
+		held[id] = struct{}{}
+	}
+	out := make([]rest.Role, 0, len(m.RoleIDs)+1)
+	for _, role := range roles {
+		if _, ok := held[role.ID]; ok || role.IsDefault {
+			out = append(out, role)
+		}
+	}
+	return out, nil
+}
+
+// CanView reports whether userID may read channelID, following the server's
+// rules: DMs are visible to their recipients; in a server the owner and
+// ADMINISTRATOR roles see everything, channels are visible by default, and
+// VIEW_CHANNEL overrides apply for @everyone, then the member's roles by
+// position, then the member. Unknown channels and non-members are not
+// visible.
+func (d *Directory) CanView(ctx context.Context, channelID, userID string) (bool, error) {
+	userID = strings.TrimSpace(userID)
+	ch, ok, err := d.Channel(ctx, channelID)
+	if err != nil || !ok || userID == "" {
+		return false, err
+	}
+	if ch.IsDM() {
+		for _, id := range ch.RecipientIDs() {
+			if id == userID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	m, ok, err := d.Member(ctx, ch.ServerID, userID)
+	if err != nil || !ok {
+		return false, err
+	}
+	if m.IsOwner {
+		return true, nil
+	}
+	roles, err := d.Roles(ctx, ch.ServerID)
+	if err != nil {
+		return false, err
+	}
+	held := make(map[string]struct{}, len(m.RoleIDs))
+	for _, id := range m.RoleIDs {
+		held[id] = struct{}{}
+	}
+	everyoneID := ""
+	memberRoles := make([]rest.Role, 0, len(m.RoleIDs))
+	for _, role := range roles {
+		_, isHeld := held[role.ID]
+		if role.IsDefault {
+			everyoneID = role.ID
+		}
+		if (isHeld || role.IsDefault) && role.Has(permissionAdministrator) {
+			return true, nil
+		}
+		if isHeld {
+			memberRoles = append(memberRoles, role)
+		}
+	}
+
+	byRole := map[string]rest.PermissionOverride{}
+	var memberOverride *rest.PermissionOverride
+	for i, o := range ch.PermissionOverrides {
+		switch o.TargetType {
+		case "role":
+			byRole[o.TargetID] = o
+		case "member":
+			if o.TargetID == userID {
+				memberOverride = &ch.PermissionOverrides[i]
+			}
+		}
+	}
+	view := true
+	apply := func(o rest.PermissionOverride) {
+		if containsString(o.Allow, permissionViewChannel) {
+			view = true
+		}
+		if containsString(o.Deny, permissionViewChannel) {
+			view = false
+		}
+	}
+	if o, ok := byRole[everyoneID]; ok && everyoneID != "" {
+		apply(o)
+	}
+	for _, role := range memberRoles {
+		if o, ok := byRole[role.ID]; ok {
+			apply(o)
+		}
+	}
+	if memberOverride != nil {
+		apply(*memberOverride)
+	}
+	return view, nil
+}
+
+const (
+	permissionViewChannel   = "VIEW_CHANNEL"
+	permissionAdministrator = "ADMINISTRATOR"
+)
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleEvent applies a gateway event to the cache and reports whether the
+// event was one the directory tracks. Call it from the gateway handler for
+// every event; it never blocks on the network.
+func (d *Directory) HandleEvent(eventName string, payload json.RawMessage) bool {
+	if d == nil {
+		return false
+	}
+	switch eventName {
+	case EventDMChannelCreate, EventChannelUpdate:
+		var ch rest.Channel
+		if err := json.Unmarshal(payload, &ch); err != nil || strings.TrimSpace(ch.ID) == "" {
+			return true
+		}
+		d.mu.Lock()
+		d.channels[ch.ID] = ch
+		d.mu.Unlock()
+
+	case EventChannelDelete:
+		var ev struct {
+			ChannelID string `json:"channelId"`
+		}
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return true
+		}
+		d.mu.Lock()
+		delete(d.channels, ev.ChannelID)
+		d.mu.Unlock()
+
+	case EventCategoryUpdate:
+		var cat rest.Category
+		if err := json.Unmarshal(payload, &cat); err != nil || cat.ID == "" || cat.ServerID == "" {
+			return true
+		}
+		d.mu.Lock()
+		if d.categories[cat.ServerID] == nil {
+			d.categories[cat.ServerID] = map[string]rest.Category{}
+		}
+		d.categories[cat.ServerID][cat.ID] = cat
+		d.mu.Unlock()
+
+	case EventCategoryDelete:
+		var ev struct {
+			CategoryID string `json:"categoryId"`
+		}
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return true
+		}
+		d.mu.Lock()
+		for _, byID := range d.categories {
+			delete(byID, ev.CategoryID)
+		}
+		d.mu.Unlock()
+
+	case EventServerUpdate:
+		var srv rest.Server
+		if err := json.Unmarshal(payload, &srv); err != nil || srv.ID == "" {
+			return true
+		}
+		d.mu.Lock()
+		d.servers[srv.ID] = srv
+		d.mu.Unlock()
+
+	case EventServerDelete:
+		var ev struct {
+			ServerID string `json:"serverId"`
+		}
+		if err := json.Unmarshal(payload, &ev); err != nil {
+			return true
+		}
+		d.dropServer(ev.ServerID)
+
+	case EventMemberJoin, EventMemberLeave, EventPermissionsUpdate:
+		var ev struct {
+			ServerID string `json:"serverId"`
+			UserID   string `json:"userId"`
+		}
+		if err := json.Unmarshal(payload, &ev); err != nil || ev.ServerID == "" {
+			return true
+		}
+		if eventName == EventMemberLeave && d.isBot(ev.UserID) {
+			d.dropServer(ev.ServerID)
+			return true
+		}
+		d.mu.Lock()
+		delete(d.members, ev.ServerID)
+		if eventName == EventPermissionsUpdate {
+			delete(d.roles, ev.ServerID)
+		}
+		d.mu.Unlock()
+		if eventName == EventMemberJoin && d.isBot(ev.UserID) {
+			// Joined a new server: its channels are unknown until the next
+			// refresh; let the next lookup run it.
+			d.stale.Store(true)
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (d *Directory) isBot(userID string) bool {
+	if d.session == nil || strings.TrimSpace(userID) == "" {
+		return false
+	}
+	return d.session.Me().ID == strings.TrimSpace(userID)
+}
+
+func (d *Directory) dropServer(serverID string) {
+	serverID = strings.TrimSpace(serverID)
+	if serverID == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.servers, serverID)
+	delete(d.categories, serverID)
+	delete(d.members, serverID)
+	delete(d.roles, serverID)
+	for id, ch := range d.channels {
+		if ch.ServerID == serverID {
+			delete(d.channels, id)
+		}
+	}
+}
+
+// IsDM reports whether a channel is a DM channel the bot is part of.
+func (d *Directory) IsDM(ctx context.Context, channelID string) (bool, error) {
+	ch, ok, err := d.Channel(ctx, channelID)
+	if err != nil || !ok {
+		return false, err
+	}
+	return ch.IsDM(), nil
+}
@@ -0,0 +1,284 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+	"mew/plugins/pkg/api/rest"
+)
+
+func newDirectoryTestSession(t *testing.T, roleFetches *int32) *BotSession {
+	t.Helper()
+	routes := map[string]string{
+		"/users/@me/servers":     `[{"_id":"s1","name":"Guild","everyoneRoleId":"r0"}]`,
+		"/users/@me/channels":    `[{"_id":"dm1","type":"DM","recipients":["u1","` + testBotID + `"]}]`,
+		"/servers/s1/channels":   `[{"_id":"c2","type":"GUILD_TEXT","name":"b","serverId":"s1","position":2},{"_id":"c1","type":"GUILD_TEXT","name":"a","serverId":"s1","position":1}]`,
+		"/servers/s1/categories": `[{"_id":"cat1","name":"Text","serverId":"s1"}]`,
+		"/servers/s1/members":    `[{"_id":"m1","serverId":"s1","userId":{"_id":"u1","username":"alice"},"roleIds":["r0","r1"],"nickname":"Al"},{"_id":"w1","serverId":"s1","channelId":"c1","userId":{"_id":"hook","username":"hook"},"roleIds":["r0"]}]`,
+		"/servers/s1/roles":      `[{"_id":"r1","name":"mod","serverId":"s1","permissions":["MANAGE_MESSAGES"],"position":1},{"_id":"r0","name":"@everyone","serverId":"s1","permissions":["SEND_MESSAGES"],"position":0,"isDefault":true},{"_id":"r2","name":"admin","serverId":"s1","permissions":["ADMINISTRATOR"],"position":2}]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/servers/s1/roles" {
+			atomic.AddInt32(roleFetches, 1)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	api, err := rest.New(srv.URL, srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+	return &BotSession{api: api, me: sdkapi.User{ID: testBotID}}
+}
+
+func TestDirectory_RefreshAndLookups(t *testing.T) {
+	var roleFetches int32
+	d := NewDirectory(newDirectoryTestSession(t, &roleFetches))
+	ctx := context.Background()
+	if err := d.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if isDM, err := d.IsDM(ctx, "dm1"); err != nil || !isDM {
+		t.Fatalf("dm1 should be a DM: %v %v", isDM, err)
+	}
+	chs := d.ServerChannels("s1")
+	if len(chs) != 2 || chs[0].ID != "c1" || chs[1].ID != "c2" {
+		t.Fatalf("unexpected server channels: %+v", chs)
+	}
+	if cats := d.Categories("s1"); len(cats) != 1 || cats[0].Name != "Text" {
+		t.Fatalf("unexpected categories: %+v", cats)
+	}
+
+	members, err := d.Members(ctx, "s1")
+	if err != nil || len(members) != 1 || members[0].DisplayName() != "Al" {
+		t.Fatalf("webhook members should be skipped: %+v %v", members, err)
+	}
+	roles, err := d.MemberRoles(ctx, "s1", "u1")
+	if err != nil || len(roles) != 2 || roles[0].Name != "@everyone" || !roles[1].Has("MANAGE_MESSAGES") {
+		t.Fatalf("unexpected member roles: %+v %v", roles, err)
+	}
+	if _, err := d.Roles(ctx, "s1"); err != nil || atomic.LoadInt32(&roleFetches) != 1 {
+		t.Fatalf("roles should be cached, fetched %d times (%v)", roleFetches, err)
+	}
+
+	d.HandleEvent(EventPermissionsUpdate, json.RawMessage(`{"serverId":"s1"}`))
+	if _, err := d.Roles(ctx, "s1"); err != nil || atomic.LoadInt32(&roleFetches) != 2 {
+		t.Fatalf("PERMISSIONS_UPDATE should drop cached roles, fetched %d times (%v)", roleFetches, err)
+	}
+}
+
+func TestDirectory_HandleEvent(t *testing.T) {
+	var roleFetches int32
+	d := NewDirectory(newDirectoryTestSession(t, &roleFetches))
+	if err := d.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if !d.HandleEvent(EventChannelUpdate, json.RawMessage(`{"_id":"c1","type":"GUILD_TEXT","name":"renamed","serverId":"s1","position":1}`)) {
+		t.Fatalf("CHANNEL_UPDATE should be handled")
+	}
+	if ch, ok := d.cachedChannel("c1"); !ok || ch.Name != "renamed" {
+		t.Fatalf("channel not updated: %+v", ch)
+	}
+
+	d.HandleEvent(EventChannelDelete, json.RawMessage(`{"channelId":"c2","serverId":"s1"}`))
+	if _, ok := d.cachedChannel("c2"); ok {
+		t.Fatalf("channel c2 should be deleted")
+	}
+
+	d.HandleEvent(EventServerUpdate, json.RawMessage(`{"_id":"s1","name":"Renamed Guild"}`))
+	if srv, _ := d.Server("s1"); srv.Name != "Renamed Guild" {
+		t.Fatalf("server not updated: %+v", srv)
+	}
+
+	d.HandleEvent(EventMemberLeave, json.RawMessage(`{"serverId":"s1","userId":"`+testBotID+`"}`))
+	if _, ok := d.Server("s1"); ok || len(d.ServerChannels("s1")) != 0 {
+		t.Fatalf("leaving a server should drop it and its channels")
+	}
+
+	if d.HandleEvent("MESSAGE_CREATE", json.RawMessage(`{}`)) {
+		t.Fatalf("MESSAGE_CREATE is not a directory event")
+	}
+}
+
+func TestDirectory_BotJoinDoesNotWaitForRefresh(t *testing.T) {
+	var roleFetches int32
+	d := NewDirectory(newDirectoryTestSession(t, &roleFetches))
+	ctx := context.Background()
+	if err := d.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	before := d.lastRefresh
+
+	// A refresh in flight holds refreshMu across the network.
+	d.refreshMu.Lock()
+	handled := make(chan struct{})
+	go func() {
+		d.HandleEvent(EventMemberJoin, json.RawMessage(`{"serverId":"s2","userId":"`+testBotID+`"}`))
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatalf("HandleEvent blocked on the refresh lock")
+	}
+	d.refreshMu.Unlock()
+
+	if _, _, err := d.Channel(ctx, "unknown"); err != nil {
+		t.Fatalf("Channel: %v", err)
+	}
+	if !d.lastRefresh.After(before) || d.stale.Load() {
+		t.Fatalf("joining a server should force the next lookup to refresh")
+	}
+}
+
+func TestMessageRouter_UsesDirectory(t *testing.T) {
+	var roleFetches int32
+	d := NewDirectory(newDirectoryTestSession(t, &roleFetches))
+	if err := d.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	dm := NewDMChannelCache()
+	r := NewMessageRouter(nil, dm, sdkapi.AddressingPolicy{BotUserID: testBotID}).UseDirectory(d)
+
+	got, err := r.Route(context.Background(), testMessage("m1", "dm1", "u1", "hi"))
+	if err != nil || !got.Addressed || got.Reason != sdkapi.AddressedByDM {
+		t.Fatalf("DM from directory should be addressed: %+v %v", got, err)
+	}
+	if !dm.Has("dm1") {
+		t.Fatalf("DM found through the directory should be cached")
+	}
+	if got, err := r.Route(context.Background(), testMessage("m2", "c1", "u1", "hi")); err != nil || got.Addressed {
+		t.Fatalf("plain guild message should not be addressed: %+v %v", got, err)
+	}
+}
+
+func TestDirectory_RefreshFailuresBackOffAndKeepSnapshot(t *testing.T) {
+	var down atomic.Bool
+	var serverLists int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/@me/servers" {
+			atomic.AddInt32(&serverLists, 1)
+		}
+		if down.Load() {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/users/@me/servers":
+			_, _ = w.Write([]byte(`[]`))
+		case "/users/@me/channels":
+			_, _ = w.Write([]byte(`[{"_id":"dm1","type":"DM","recipients":["u1","` + testBotID + `"]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	api, err := rest.New(srv.URL, srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+	d := NewDirectory(&BotSession{api: api, me: sdkapi.User{ID: testBotID}})
+	r := NewMessageRouter(nil, NewDMChannelCache(), sdkapi.AddressingPolicy{BotUserID: testBotID}).UseDirectory(d)
+	ctx := context.Background()
+
+	// Never loaded and the API is down: route instead of failing, and do not
+	// retry on every message.
+	down.Store(true)
+	for i := 0; i < 3; i++ {
+		got, err := r.Route(ctx, testMessage("m"+strconv.Itoa(i), "c9", "u1", "<@"+testBotID+"> hi"))
+		if err != nil || !got.Addressed {
+			t.Fatalf("mention should route while the directory is down: %+v %v", got, err)
+		}
+	}
+	if n := atomic.LoadInt32(&serverLists); n != 1 {
+		t.Fatalf("failed refresh should be throttled, got %d attempts", n)
+	}
+
+	// Load a snapshot, then lose the API: lookups answer from the snapshot.
+	down.Store(false)
+	if err := d.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	down.Store(true)
+	d.refreshMu.Lock()
+	d.lastRefresh = time.Time{}
+	d.refreshMu.Unlock()
+	if _, ok, err := d.Channel(ctx, "unknown"); err != nil || ok {
+		t.Fatalf("stale snapshot should answer without error: ok=%v err=%v", ok, err)
+	}
+	if isDM, err := d.IsDM(ctx, "dm1"); err != nil || !isDM {
+		t.Fatalf("dm1 should still be known: %v %v", isDM, err)
+	}
+
+	d.refreshMu.Lock()
+	d.failures = 3
+	gap := d.refreshGapLocked()
+	d.failures = 10
+	capped := d.refreshGapLocked()
+	d.refreshMu.Unlock()
+	if gap != 4*DefaultDirectoryRefreshInterval || capped != maxDirectoryRefreshBackoff {
+		t.Fatalf("unexpected back-off: gap=%s capped=%s", gap, capped)
+	}
+}
+
+func TestDirectory_CanView(t *testing.T) {
+	d := NewDirectory(nil)
+	d.channels["dm1"] = rest.Channel{ID: "dm1", Type: rest.ChannelTypeDM, RecipientsRaw: []json.RawMessage{json.RawMessage(`"u1"`), json.RawMessage(`"` + testBotID + `"`)}}
+	d.channels["open"] = rest.Channel{ID: "open", ServerID: "s1"}
+	d.channels["staff"] = rest.Channel{ID: "staff", ServerID: "s1", PermissionOverrides: []rest.PermissionOverride{
+		{TargetType: "role", TargetID: "r0", Deny: []string{"VIEW_CHANNEL"}},
+		{TargetType: "role", TargetID: "mod", Allow: []string{"VIEW_CHANNEL"}},
+		{TargetType: "member", TargetID: "banned", Deny: []string{"VIEW_CHANNEL"}},
+	}}
+	d.members["s1"] = map[string]rest.Member{
+		"u1":     {RoleIDs: []string{"r0"}},
+		"modu":   {RoleIDs: []string{"r0", "mod"}},
+		"banned": {RoleIDs: []string{"r0", "mod"}},
+		"admin":  {RoleIDs: []string{"r0", "adm"}},
+		"owner":  {IsOwner: true},
+	}
+	d.roles["s1"] = []rest.Role{
+		{ID: "r0", Name: "@everyone", IsDefault: true, Position: 0},
+		{ID: "mod", Position: 1},
+		{ID: "adm", Permissions: []string{"ADMINISTRATOR"}, Position: 2},
+	}
+
+	cases := []struct {
+		channel, user string
+		want          bool
+	}{
+		{"dm1", "u1", true},
+		{"dm1", "modu", false},
+		{"open", "u1", true},
+		{"open", "stranger", false},
+		{"staff", "u1", false},
+		{"staff", "modu", true},
+		{"staff", "banned", false},
+		{"staff", "admin", true},
+		{"staff", "owner", true},
+		{"missing", "u1", false},
+	}
+	d.refreshMu.Lock()
+	d.lastRefresh = time.Now() // keep "missing" from triggering a refresh
+	d.refreshMu.Unlock()
+	for _, c := range cases {
+		got, err := d.CanView(context.Background(), c.channel, c.user)
+		if err != nil || got != c.want {
+			t.Errorf("CanView(%s, %s) = %v, %v; want %v", c.channel, c.user, got, err, c.want)
+		}
+	}
+}
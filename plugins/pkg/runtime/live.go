@@ -0,0 +1,270 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+	"mew/plugins/pkg/api/rest"
+)
+
+const (
+	DefaultLiveMessagePlaceholder = "思考中…"
+	DefaultLiveMessageInterval    = time.Second
+)
+
+type LiveMessageOptions struct {
+	// Type is the message type; empty means a plain text message.
+	Type string
+
+	// Payload builds the message payload for the given content. Set it for
+	// card types that render from payload (e.g. {"content": text}).
+	Payload func(content string) any
+
+	// ReplyTo threads the posted message under another message.
+	ReplyTo string
+
+	// Placeholder is posted by Start. Defaults to DefaultLiveMessagePlaceholder.
+	Placeholder string
+
+	// Interval is the minimum gap between edits. Defaults to
+	// DefaultLiveMessageInterval; the server broadcasts every edit, so keep it
+	// well above token granularity.
+	Interval time.Duration
+}
+
+// LiveMessage is a bot message that is posted once and then edited in place
+// as content arrives (LLM tokens, agent turns), so users see progress on long
+// replies instead of a silent channel.
+//
+// Typical use: Start, then Append/Set from the producer, then Finish or Fail.
+// Edits are coalesced and throttled in the background; Finish always writes
+// the final content.
+type LiveMessage struct {
+	api       *rest.Client
+	channelID string
+	opts      LiveMessageOptions
+
+	mu   sync.Mutex
+	id   string
+	text string
+	sent string
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func NewLiveMessage(api *rest.Client, channelID string, opts LiveMessageOptions) *LiveMessage {
+	if strings.TrimSpace(opts.Placeholder) == "" {
+		opts.Placeholder = DefaultLiveMessagePlaceholder
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultLiveMessageInterval
+	}
+	return &LiveMessage{
+		api:       api,
+		channelID: strings.TrimSpace(channelID),
+		opts:      opts,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start posts the placeholder and begins background edits. ctx bounds the
+// background edits, not just the initial post.
+func (m *LiveMessage) Start(ctx context.Context) error {
+	if m.api == nil {
+		return fmt.Errorf("missing api client")
+	}
+	msg, err := m.api.Messages.Create(ctx, m.channelID, m.createBody(m.opts.Placeholder))
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.id = msg.ID
+	m.sent = m.opts.Placeholder
+	m.mu.Unlock()
+
+	go m.loop(ctx)
+	return nil
+}
+
+// ID returns the posted message ID ("" before Start succeeds).
+func (m *LiveMessage) ID() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.id
+}
+
+// Text returns the content accumulated so far.
+func (m *LiveMessage) Text() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.text
+}
+
+// Append adds a delta (e.g. one streamed token) to the content.
+func (m *LiveMessage) Append(delta string) {
+	if delta == "" {
+		return
+	}
+	m.mu.Lock()
+	m.text += delta
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Set replaces the content (e.g. the latest agent turn).
+func (m *LiveMessage) Set(content string) {
+	m.mu.Lock()
+	m.text = content
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Finish stops background edits and writes the final content. An empty final
+// keeps the accumulated content. If Start was never called (or failed), the
+// final content is posted as a new message instead.
+func (m *LiveMessage) Finish(ctx context.Context, final string) (sdkapi.ChannelMessage, error) {
+	m.halt()
+
+	m.mu.Lock()
+	if strings.TrimSpace(final) != "" {
+		m.text = final
+	}
+	id, text := m.id, m.text
+	m.mu.Unlock()
+
+	if strings.TrimSpace(text) == "" {
+		return sdkapi.ChannelMessage{}, fmt.Errorf("live message content is empty")
+	}
+	if m.api == nil {
+		return sdkapi.ChannelMessage{}, fmt.Errorf("missing api client")
+	}
+	if id == "" {
+		return m.api.Messages.Create(ctx, m.channelID, m.createBody(text))
+	}
+	msg, err := m.api.Messages.Update(ctx, m.channelID, id, m.editBody(text))
+	if err == nil {
+		m.mu.Lock()
+		m.sent = text
+		m.mu.Unlock()
+	}
+	return msg, err
+}
+
+// Fail finalizes the message with an error callout. Partial content, if any,
+// is kept above the callout.
+func (m *LiveMessage) Fail(ctx context.Context, cause error) (sdkapi.ChannelMessage, error) {
+	errMsg := "未知错误"
+	if cause != nil {
+		if s := strings.TrimSpace(strings.ReplaceAll(cause.Error(), "\n", " ")); s != "" {
+			errMsg = s
+		}
+	}
+	callout := fmt.Sprintf("> [!warning] 生成失败\n> 错误：`%s`", errMsg)
+	if partial := strings.TrimSpace(m.Text()); partial != "" {
+		callout = partial + "\n\n" + callout
+	}
+	return m.Finish(ctx, callout)
+}
+
+// Discard stops background edits and deletes the placeholder, for producers
+// that ended up replying some other way (e.g. only with files).
+func (m *LiveMessage) Discard(ctx context.Context) error {
+	m.halt()
+	m.mu.Lock()
+	id := m.id
+	m.id = ""
+	m.mu.Unlock()
+	if id == "" || m.api == nil {
+		return nil
+	}
+	_, err := m.api.Messages.Delete(ctx, m.channelID, id)
+	return err
+}
+
+func (m *LiveMessage) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *LiveMessage) halt() {
+	m.once.Do(func() { close(m.stop) })
+	m.mu.Lock()
+	started := m.id != ""
+	m.mu.Unlock()
+	if started {
+		<-m.done
+	}
+}
+
+func (m *LiveMessage) loop(ctx context.Context) {
+	defer close(m.done)
+	// The placeholder post counts as the first write.
+	last := time.Now()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		}
+		if wait := m.opts.Interval - time.Since(last); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-m.stop:
+				t.Stop()
+				return
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		m.flush(ctx)
+		last = time.Now()
+	}
+}
+
+// flush sends the latest content if it changed. Errors are dropped: the next
+// edit (or Finish) retries with newer content anyway.
+func (m *LiveMessage) flush(ctx context.Context) {
+	m.mu.Lock()
+	id, text, sent := m.id, m.text, m.sent
+	m.mu.Unlock()
+	if text == sent || strings.TrimSpace(text) == "" {
+		return
+	}
+	if _, err := m.api.Messages.Update(ctx, m.channelID, id, m.editBody(text)); err != nil {
+		return
+	}
+	m.mu.Lock()
+	m.sent = text
+	m.mu.Unlock()
+}
+
+func (m *LiveMessage) createBody(content string) rest.CreateMessage {
+	body := rest.CreateMessage{Type: m.opts.Type, Content: content, ReferencedMessageID: strings.TrimSpace(m.opts.ReplyTo)}
+	if m.opts.Payload != nil {
+		body.Payload = m.opts.Payload(content)
+	}
+	return body
+}
+
+func (m *LiveMessage) editBody(content string) rest.EditMessage {
+	edit := rest.EditMessage{Content: content}
+	if m.opts.Payload != nil {
+		edit.Payload = m.opts.Payload(content)
+	}
+	return edit
+}
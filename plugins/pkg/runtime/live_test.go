@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mew/plugins/pkg/api/rest"
+)
+
+type liveTestServer struct {
+	mu      sync.Mutex
+	posts   []map[string]any
+	edits   []map[string]any
+	deletes []string
+}
+
+func newLiveTestClient(t *testing.T, s *liveTestServer) *rest.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.mu.Lock()
+		switch r.Method {
+		case http.MethodPost:
+			s.posts = append(s.posts, body)
+		case http.MethodPatch:
+			s.edits = append(s.edits, body)
+		case http.MethodDelete:
+			s.deletes = append(s.deletes, r.URL.Path)
+		}
+		s.mu.Unlock()
+		_, _ = w.Write([]byte(`{"_id":"live1","channelId":"ch1"}`))
+	}))
+	t.Cleanup(srv.Close)
+	c, err := rest.New(srv.URL, srv.Client(), rest.Options{})
+	if err != nil {
+		t.Fatalf("rest.New: %v", err)
+	}
+	return c
+}
+
+func TestLiveMessage_ThrottlesEditsAndFinishes(t *testing.T) {
+	s := &liveTestServer{}
+	live := NewLiveMessage(newLiveTestClient(t, s), "ch1", LiveMessageOptions{
+		Type:     "app/x-test-card",
+		Payload:  func(content string) any { return map[string]any{"content": content} },
+		Interval: 50 * time.Millisecond,
+	})
+
+	ctx := context.Background()
+	if err := live.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if live.ID() != "live1" {
+		t.Fatalf("unexpected id %q", live.ID())
+	}
+	for _, tok := range strings.Split("a b c d e f g h", " ") {
+		live.Append(tok)
+	}
+	time.Sleep(120 * time.Millisecond)
+	if _, err := live.Finish(ctx, ""); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.posts) != 1 || s.posts[0]["content"] != DefaultLiveMessagePlaceholder || s.posts[0]["type"] != "app/x-test-card" {
+		t.Fatalf("unexpected placeholder post: %+v", s.posts)
+	}
+	if len(s.edits) == 0 || len(s.edits) > 3 {
+		t.Fatalf("expected a few coalesced edits, got %d", len(s.edits))
+	}
+	last := s.edits[len(s.edits)-1]
+	payload, _ := last["payload"].(map[string]any)
+	if last["content"] != "abcdefgh" || payload["content"] != "abcdefgh" {
+		t.Fatalf("unexpected final edit: %+v", last)
+	}
+}
+
+func TestLiveMessage_FailKeepsPartialContent(t *testing.T) {
+	s := &liveTestServer{}
+	live := NewLiveMessage(newLiveTestClient(t, s), "ch1", LiveMessageOptions{Interval: time.Hour})
+
+	ctx := context.Background()
+	if err := live.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	live.Append("partial")
+	if _, err := live.Fail(ctx, errors.New("upstream\ntimeout")); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.edits) != 1 {
+		t.Fatalf("expected only the final edit, got %d", len(s.edits))
+	}
+	content, _ := s.edits[0]["content"].(string)
+	if !strings.HasPrefix(content, "partial\n\n> [!warning]") || !strings.Contains(content, "upstream timeout") {
+		t.Fatalf("unexpected failure content: %q", content)
+	}
+}
+
+func TestLiveMessage_FinishWithoutStartPosts(t *testing.T) {
+	s := &liveTestServer{}
+	live := NewLiveMessage(newLiveTestClient(t, s), "ch1", LiveMessageOptions{})
+	if _, err := live.Finish(context.Background(), "done"); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.posts) != 1 || s.posts[0]["content"] != "done" || len(s.edits) != 0 {
+		t.Fatalf("expected a single post, got posts=%+v edits=%+v", s.posts, s.edits)
+	}
+}
+
+func TestLiveMessage_DiscardDeletesPlaceholder(t *testing.T) {
+	s := &liveTestServer{}
+	live := NewLiveMessage(newLiveTestClient(t, s), "ch1", LiveMessageOptions{})
+	ctx := context.Background()
+	if err := live.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := live.Discard(ctx); err != nil {
+		t.Fatalf("Discard: %v", err)
+	}
+	if err := live.Discard(ctx); err != nil {
+		t.Fatalf("second Discard: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.deletes) != 1 || s.deletes[0] != "/channels/ch1/messages/live1" {
+		t.Fatalf("unexpected deletes: %v", s.deletes)
+	}
+}
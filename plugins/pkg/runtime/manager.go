@@ -24,6 +24,7 @@ type ServiceTypeRegistration struct {
 	Icon           string
 	Description    string
 	ConfigTemplate string
+	Commands       []CommandSpec
 }
 
 type BotManager struct {
@@ -38,6 +39,7 @@ type BotManager struct {
 
 type runningBot struct {
 	configHash string
+	ownerID    string
 	cancel     context.CancelFunc
 	done       chan struct{}
 }
@@ -87,6 +89,7 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 		Icon:           m.registration.Icon,
 		Description:    m.registration.Description,
 		ConfigTemplate: m.registration.ConfigTemplate,
+		Commands:       CommandInfos(m.registration.Commands),
 	}); err != nil {
 		return err
 	}
@@ -103,6 +106,7 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 		botName     string
 		accessToken string
 		rawConfig   string
+		ownerID     string
 		configHash  string
 	}
 
@@ -118,7 +122,9 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 
 		configHash := sha256String(bot.Config)
 		if existing, ok := m.bots[botID]; ok {
-			if existing.configHash == configHash {
+			// The owner reaches the runner through its context, so an owner
+			// change needs a restart just like a config change.
+			if existing.configHash == configHash && existing.ownerID == bot.OwnerID {
 				continue
 			}
 			log.Printf("%s reloading bot %s (%s)", m.logPrefix, botID, bot.Name)
@@ -133,6 +139,7 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 			botName:     bot.Name,
 			accessToken: bot.AccessToken,
 			rawConfig:   bot.Config,
+			ownerID:     bot.OwnerID,
 			configHash:  configHash,
 		})
 	}
@@ -159,7 +166,7 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 			continue
 		}
 
-		botCtx, cancel := context.WithCancel(ctx)
+		botCtx, cancel := context.WithCancel(withBotOwnerID(ctx, s.ownerID))
 		done := make(chan struct{})
 		go func(botID, botName string) {
 			defer close(done)
@@ -169,13 +176,26 @@ func (m *BotManager) SyncOnce(ctx context.Context) error {
 		}(s.botID, s.botName)
 
 		m.mu.Lock()
-		m.bots[s.botID] = &runningBot{configHash: s.configHash, cancel: cancel, done: done}
+		m.bots[s.botID] = &runningBot{configHash: s.configHash, ownerID: s.ownerID, cancel: cancel, done: done}
 		m.mu.Unlock()
 	}
 
 	return nil
 }
 
+type botOwnerKey struct{}
+
+func withBotOwnerID(ctx context.Context, ownerID string) context.Context {
+	return context.WithValue(ctx, botOwnerKey{}, strings.TrimSpace(ownerID))
+}
+
+// BotOwnerID returns the user ID of the bot's owner from the context
+// BotManager passes to Runner.Run ("" when unknown).
+func BotOwnerID(ctx context.Context) string {
+	id, _ := ctx.Value(botOwnerKey{}).(string)
+	return id
+}
+
 func sha256String(s string) string {
 	sum := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(sum[:])
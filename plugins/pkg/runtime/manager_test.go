@@ -59,6 +59,11 @@ func TestBotManager_SyncOnce_StartReloadStop(t *testing.T) {
 					{ID: "b1", Name: "bot1", Config: `{"a":99}`, AccessToken: "t1"},
 				}
 			}
+			if n == 4 {
+				out = []apiclient.BootstrapBot{
+					{ID: "b1", Name: "bot1", Config: `{"a":99}`, AccessToken: "t1", OwnerID: "u2"},
+				}
+			}
 			mu.Unlock()
 
 			w.Header().Set("Content-Type", "application/json")
@@ -145,8 +150,15 @@ func TestBotManager_SyncOnce_StartReloadStop(t *testing.T) {
 	waitStopped("b1", 0)
 	waitStarted("b1", 1)
 
-	mgr.StopAll()
+	// Fourth sync: only the owner changes => reload.
+	if err := mgr.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce #4: %v", err)
+	}
 	waitStopped("b1", 1)
+	waitStarted("b1", 2)
+
+	mgr.StopAll()
+	waitStopped("b1", 2)
 
 	mu.Lock()
 	if gotRegister == 0 {
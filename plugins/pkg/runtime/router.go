@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+	"mew/plugins/pkg/state"
+)
+
+const defaultOwnMessageMemory = 500
+
+// MessageRouter applies an AddressingPolicy to gateway messages using the
+// bot's DM channel cache and the IDs of recently seen bot messages (for
+// reply-to-bot detection). It is shared by all agents so they route alike.
+type MessageRouter struct {
+	policy  sdkapi.AddressingPolicy
+	session *BotSession
+	dm      *DMChannelCache
+	dir     *Directory
+	own     *state.SeenSet
+}
+
+// NewMessageRouter builds a router. When policy.BotUserID is empty, the
+// session's current user is used.
+func NewMessageRouter(session *BotSession, dm *DMChannelCache, policy sdkapi.AddressingPolicy) *MessageRouter {
+	if dm == nil {
+		dm = NewDMChannelCache()
+	}
+	return &MessageRouter{
+		policy:  policy,
+		session: session,
+		dm:      dm,
+		own:     state.NewSeenSet(defaultOwnMessageMemory),
+	}
+}
+
+func (r *MessageRouter) botUserID() string {
+	if id := strings.TrimSpace(r.policy.BotUserID); id != "" {
+		return id
+	}
+	if r.session == nil {
+		return ""
+	}
+	return r.session.Me().ID
+}
+
+// UseDirectory makes the router look up channel types in dir instead of
+// refreshing the DM list whenever a message arrives from a channel that is
+// not a known DM. DMs found through dir are added to the DM cache.
+func (r *MessageRouter) UseDirectory(dir *Directory) *MessageRouter {
+	r.dir = dir
+	return r
+}
+
+// Route decides whether msg is addressed to the bot. The bot's own messages
+// are never addressed; their IDs are remembered so replies to them route.
+// Without a Directory, unknown channels trigger one on-demand DM refresh,
+// since DM channels can be created after the bot connects.
+func (r *MessageRouter) Route(ctx context.Context, msg sdkapi.ChannelMessage) (sdkapi.Addressing, error) {
+	policy := r.policy
+	policy.BotUserID = r.botUserID()
+
+	if sdkapi.IsOwnMessage(msg.AuthorRaw, policy.BotUserID) {
+		r.own.Add(strings.TrimSpace(msg.ID))
+		return sdkapi.Addressing{}, nil
+	}
+
+	repliedToBot := false
+	if ref := strings.TrimSpace(msg.ReferencedMessageID); ref != "" {
+		repliedToBot = r.own.Has(ref)
+	}
+
+	if r.dir != nil {
+		ch, ok, err := r.dir.Channel(ctx, msg.ChannelID)
+		if err != nil {
+			// The directory has never loaded; route with what the DM cache
+			// knows rather than dropping the message.
+			return policy.Resolve(msg, r.dm.Has(msg.ChannelID), repliedToBot), nil
+		}
+		isDM := ok && ch.IsDM()
+		if isDM {
+			r.dm.add(ch.ID)
+		}
+		return policy.Resolve(msg, isDM, repliedToBot), nil
+	}
+
+	isDM := r.dm.Has(msg.ChannelID)
+	res := policy.Resolve(msg, isDM, repliedToBot)
+	if res.Addressed || isDM {
+		return res, nil
+	}
+
+	if err := r.dm.RefreshWithBotSession(ctx, r.session); err != nil {
+		return sdkapi.Addressing{}, err
+	}
+	if !r.dm.Has(msg.ChannelID) {
+		return res, nil
+	}
+	return policy.Resolve(msg, true, repliedToBot), nil
+}
+
+// RememberOwnMessage records a message ID the bot sent through a path that
+// does not echo back over the gateway (e.g. REST), so replies to it route.
+func (r *MessageRouter) RememberOwnMessage(messageID string) {
+	r.own.Add(strings.TrimSpace(messageID))
+}
+
+func (r *MessageRouter) DMChannels() *DMChannelCache { return r.dm }
@@ -0,0 +1,100 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+const testBotID = "64b000000000000000000001"
+
+func testMessage(id, channelID, authorID, content string) sdkapi.ChannelMessage {
+	author, _ := json.Marshal(authorID)
+	return sdkapi.ChannelMessage{ID: id, ChannelID: channelID, Content: content, AuthorRaw: author}
+}
+
+func TestMessageRouter_Route(t *testing.T) {
+	dm := NewDMChannelCache()
+	dm.channels["dm1"] = struct{}{}
+	// Configured the way agents read it from Bot.config.
+	var cfg sdkapi.AddressingConfig
+	if err := json.Unmarshal([]byte(`{"names":["jpdict"," "],"prefixes":["!"]}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	r := NewMessageRouter(nil, dm, cfg.Policy(testBotID))
+
+	mentionObj := sdkapi.ChannelMessage{ID: "m9", ChannelID: "ch1", Content: "look at this", AuthorRaw: json.RawMessage(`"u1"`)}
+	mentionObj.MentionsRaw = []json.RawMessage{json.RawMessage(`{"_id":"` + testBotID + `"}`)}
+
+	cases := []struct {
+		name       string
+		msg        sdkapi.ChannelMessage
+		wantOK     bool
+		wantReason sdkapi.AddressReason
+		wantText   string
+	}{
+		{"leading mention", testMessage("m1", "ch1", "u1", "<@"+testBotID+"> echo hi"), true, sdkapi.AddressedByMention, "echo hi"},
+		{"inline mention", testMessage("m2", "ch1", "u1", "hey <@!"+testBotID+"> what's up"), true, sdkapi.AddressedByMention, "hey what's up"},
+		{"other user mention", testMessage("m3", "ch1", "u1", "<@64b000000000000000000002> hi"), false, "", ""},
+		{"mentions list", mentionObj, true, sdkapi.AddressedByMention, "look at this"},
+		{"leading name", testMessage("m4", "ch1", "u1", "JPDICT, 猫"), true, sdkapi.AddressedByName, "猫"},
+		{"greeting then name", testMessage("m5", "ch1", "u1", "hey jpdict 猫"), true, sdkapi.AddressedByName, "猫"},
+		{"name as word prefix", testMessage("m6", "ch1", "u1", "jpdictionary"), false, "", ""},
+		{"prefix", testMessage("m7", "ch1", "u1", "!echo hi"), true, sdkapi.AddressedByPrefix, "echo hi"},
+		{"dm", testMessage("m8", "dm1", "u1", "echo hi"), true, sdkapi.AddressedByDM, "echo hi"},
+		{"plain channel", testMessage("m10", "ch1", "u1", "echo hi"), false, "", ""},
+	}
+	for _, tc := range cases {
+		got, err := r.Route(context.Background(), tc.msg)
+		if err != nil {
+			t.Fatalf("%s: Route: %v", tc.name, err)
+		}
+		if got.Addressed != tc.wantOK {
+			t.Fatalf("%s: Addressed=%v, want %v", tc.name, got.Addressed, tc.wantOK)
+		}
+		if !tc.wantOK {
+			continue
+		}
+		if got.Reason != tc.wantReason || got.Text != tc.wantText {
+			t.Fatalf("%s: got reason=%q text=%q, want reason=%q text=%q", tc.name, got.Reason, got.Text, tc.wantReason, tc.wantText)
+		}
+		wantReplyTo := tc.msg.ID
+		if tc.msg.ChannelID == "dm1" {
+			wantReplyTo = ""
+		}
+		if got.ReplyTo != wantReplyTo {
+			t.Fatalf("%s: ReplyTo=%q, want %q", tc.name, got.ReplyTo, wantReplyTo)
+		}
+	}
+}
+
+func TestMessageRouter_ReplyToOwnMessage(t *testing.T) {
+	r := NewMessageRouter(nil, nil, sdkapi.AddressingPolicy{BotUserID: testBotID})
+
+	own, err := r.Route(context.Background(), testMessage("bot1", "ch1", testBotID, "hello"))
+	if err != nil || own.Addressed {
+		t.Fatalf("own message should never be addressed: %+v err=%v", own, err)
+	}
+
+	reply := testMessage("m1", "ch1", "u1", "thanks")
+	reply.ReferencedMessageID = "bot1"
+	got, err := r.Route(context.Background(), reply)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if !got.Addressed || got.Reason != sdkapi.AddressedByReply || got.Text != "thanks" {
+		t.Fatalf("unexpected routing: %+v", got)
+	}
+
+	other := testMessage("m2", "ch1", "u1", "thanks")
+	other.ReferencedMessageID = "someone-else"
+	got, err = r.Route(context.Background(), other)
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if got.Addressed {
+		t.Fatalf("reply to another user's message should not be addressed: %+v", got)
+	}
+}
@@ -25,6 +25,10 @@ type ServiceOptions struct {
 	// Leave empty to provide no template.
 	ConfigTemplate string
 
+	// Commands are registered with the server so clients can offer
+	// slash-command autocomplete. Handlers live in each runner's CommandRegistry.
+	Commands []CommandSpec
+
 	// NewRunner builds a Runner for a single bot instance.
 	NewRunner func(botID, botName, accessToken, rawConfig string, cfg RuntimeConfig) (Runner, error)
 
@@ -76,6 +80,7 @@ func RunService(ctx context.Context, opts ServiceOptions) error {
 		Icon:           strings.TrimSpace(opts.Icon),
 		Description:    strings.TrimSpace(opts.Description),
 		ConfigTemplate: opts.ConfigTemplate,
+		Commands:       opts.Commands,
 	}
 	if reg.ServerName == "" {
 		reg.ServerName = reg.ServiceType
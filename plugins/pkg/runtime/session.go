@@ -12,6 +12,7 @@ import (
 
 	sdkapi "mew/plugins/pkg/api"
 	"mew/plugins/pkg/api/auth"
+	"mew/plugins/pkg/api/rest"
 )
 
 type BotSession struct {
@@ -19,6 +20,7 @@ type BotSession struct {
 	accessToken string
 	baseClient  *http.Client
 	authedClient *http.Client
+	api          *rest.Client
 
 	mu    sync.RWMutex
 	me    sdkapi.User
@@ -42,6 +44,7 @@ func NewBotSession(apiBase, accessToken string, httpClient *http.Client) *BotSes
 			Jar:       httpClient.Jar,
 			Timeout:   httpClient.Timeout,
 		}
+		s.api, _ = rest.New(s.apiBase, s.authedClient, rest.Options{})
 	}
 
 	return s
@@ -70,6 +73,12 @@ func (s *BotSession) HTTPClient() *http.Client {
 	return s.authedClient
 }
 
+// API returns the typed REST client authenticated as this bot. It is nil
+// when the session was created without an http.Client or API base.
+func (s *BotSession) API() *rest.Client {
+	return s.api
+}
+
 func (s *BotSession) ensureLoggedIn(ctx context.Context) error {
 	s.mu.RLock()
 	token := s.token
@@ -6,14 +6,20 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
 	sdkapi "mew/plugins/pkg/api"
 	"mew/plugins/pkg/api/auth"
+	"mew/plugins/pkg/api/cards"
 	"mew/plugins/pkg/api/channels"
+	"mew/plugins/pkg/api/chunked"
 	apiclient "mew/plugins/pkg/api/client"
 	"mew/plugins/pkg/api/messages"
+	"mew/plugins/pkg/api/rest"
+	"mew/plugins/pkg/api/stickers"
+	"mew/plugins/pkg/api/tts"
 	"mew/plugins/pkg/api/webhook"
 	"mew/plugins/pkg/runtime"
 	"mew/plugins/pkg/state"
@@ -126,6 +132,10 @@ func NewBotManagerWithRegistration(client *MewClient, reg ServiceTypeRegistratio
 	return runtime.NewBotManagerWithRegistration(client, reg, logPrefix, factory)
 }
 
+// BotOwnerID returns the bot owner's user ID from the context Runner.Run
+// receives; use it for CommandContext.IsOwner.
+func BotOwnerID(ctx context.Context) string { return runtime.BotOwnerID(ctx) }
+
 // ---- config helpers ----
 
 func DecodeTasks[T any](rawConfig string) ([]T, error) { return runtime.DecodeTasks[T](rawConfig) }
@@ -140,6 +150,12 @@ func TaskConfigTemplateJSON[T any]() (string, error) { return runtime.TaskTempla
 
 type HTTPClientOptions = httpx.ClientOptions
 
+// HTTPRoutes maps hosts to direct/env/pool/named proxies; see httpx.Routes.
+type HTTPRoute = httpx.Route
+type HTTPRoutes = httpx.Routes
+
+func ParseHTTPRoutes(raw string) (HTTPRoutes, error) { return httpx.ParseRoutes(raw) }
+
 type stripUserAgentRoundTripper struct {
 	base http.RoundTripper
 }
@@ -171,6 +187,20 @@ func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
 	return c, nil
 }
 
+// HTTPSession pins one proxy (and optionally a cookie jar and user agent) for
+// a request sequence; see httpx.Session.
+type HTTPSession = httpx.Session
+type HTTPSessionOptions = httpx.SessionOptions
+
+// NewHTTPSession opens a session. Unlike NewHTTPClient it keeps the
+// User-Agent header, since scrapers rely on it.
+func NewHTTPSession(opts HTTPSessionOptions) (*HTTPSession, error) { return httpx.NewSession(opts) }
+
+type ProxyScore = httpx.ProxyScore
+
+// ProxyPoolScores returns the built-in proxy pool's scores, best first.
+func ProxyPoolScores() []ProxyScore { return httpx.Default().Pool().Scores() }
+
 func ProxyFuncFromString(raw string) (func(*http.Request) (*url.URL, error), error) {
 	return httpx.ProxyFuncFromString(raw)
 }
@@ -211,16 +241,134 @@ type SeenSet = state.SeenSet
 
 func NewSeenSet(max int) *SeenSet { return state.NewSeenSet(max) }
 
+// ---- cards ----
+
+type Card = cards.Card
+type CardSchema = cards.Schema
+type CardValidationError = cards.ValidationError
+type RSSCard = cards.RSSCard
+type BilibiliCard = cards.BilibiliCard
+type BilibiliEmoji = cards.BilibiliEmoji
+type TwitterCard = cards.TwitterCard
+type InstagramCard = cards.InstagramCard
+type TikTokCard = cards.TikTokCard
+type PornhubCard = cards.PornhubCard
+type JpdictCard = cards.JpdictCard
+type RichCard = cards.RichCard
+type RichAuthor = cards.RichAuthor
+type RichMedia = cards.RichMedia
+type RichField = cards.RichField
+type RichLink = cards.RichLink
+type RichFooter = cards.RichFooter
+
+// CardPayload validates card and encodes it as a message payload.
+func CardPayload(card Card) (map[string]any, error) { return cards.Payload(card) }
+
+// CardWebhook builds a webhook message for card; content is the plain-text
+// fallback.
+func CardWebhook(content string, card Card) (WebhookPayload, error) { return cards.Webhook(content, card) }
+
+// ValidateCard checks an untyped payload against the registered card schema.
+func ValidateCard(cardType string, payload map[string]any) error {
+	return cards.Validate(cardType, payload)
+}
+
+func RegisterCard(schema CardSchema) error { return cards.Register(schema) }
+
 // ---- webhook ----
 
 type WebhookPayload = webhook.Payload
 type WebhookAttachment = webhook.Attachment
 type MediaCache = webhook.MediaCache
+type MediaOptions = webhook.MediaOptions
+type Media = webhook.Media
+type MediaUpload = webhook.MediaUpload
+type MediaKeys = webhook.MediaKeys
+type WebhookUploadOptions = webhook.UploadOptions
+type UploadProgress = chunked.Progress
+type UploadStateStore = chunked.StateStore
+type WebhookQueue = webhook.Queue
+type WebhookQueueOptions = webhook.QueueOptions
+type WebhookQueueStats = webhook.QueueStats
+type WebhookStatusError = webhook.StatusError
+type AvatarCache = webhook.AvatarCache
+type AvatarOptions = webhook.AvatarOptions
+type DownloadStrategy = webhook.DownloadStrategy
+type DownloadRequest = webhook.DownloadRequest
+type DownloadRule = webhook.DownloadRule
+type DownloadStats = webhook.DownloadStats
+type Downloader = webhook.Downloader
+
+var ErrMediaTooLarge = webhook.ErrMediaTooLarge
+var ErrDownloadStrategySkipped = webhook.ErrStrategySkipped
 
 func PostWebhook(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payload WebhookPayload, maxRetries int) error {
 	return webhook.Post(ctx, httpClient, apiBase, webhookURL, payload, maxRetries)
 }
 
+// NewAvatarCache loads (or starts) an avatar cache stored at path; an empty
+// UserAgent defaults to a random browser UA. See AvatarCacheFile for the
+// conventional per-bot location.
+func NewAvatarCache(path string, opts AvatarOptions) (*AvatarCache, error) {
+	if strings.TrimSpace(opts.UserAgent) == "" {
+		opts.UserAgent = RandomBrowserUserAgent()
+	}
+	return webhook.NewAvatarCache(path, opts)
+}
+
+// AvatarCacheFile is the avatar cache path shared by all tasks of a bot.
+func AvatarCacheFile(serviceType, botID string) string {
+	return filepath.Join(state.BotDir(serviceType, botID), "avatars.json")
+}
+
+// PostWebhookBatch posts payloads in order through the shared delivery queue
+// and returns how many were delivered before the first failure.
+func PostWebhookBatch(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, payloads []WebhookPayload) (int, error) {
+	return webhook.DefaultQueue.PostBatch(ctx, httpClient, apiBase, webhookURL, payloads)
+}
+
+// NewWebhookQueue builds a delivery queue with its own pacing; PostWebhook
+// uses a shared default one.
+func NewWebhookQueue(opts WebhookQueueOptions) *WebhookQueue { return webhook.NewQueue(opts) }
+
+// WebhookDeliveryStats returns the shared queue's counters for webhookURL.
+func WebhookDeliveryStats(webhookURL string) WebhookQueueStats {
+	return webhook.DefaultQueue.Stats(webhookURL)
+}
+
+// Webhook signature headers (see webhook.SignatureBase for the signed string).
+const (
+	WebhookSignatureHeader     = webhook.SignatureHeader
+	WebhookTimestampHeader     = webhook.TimestampHeader
+	WebhookContentSHA256Header = webhook.ContentSHA256Header
+)
+
+var (
+	ErrWebhookSignatureMissing = webhook.ErrMissingSignature
+	ErrWebhookSignatureInvalid = webhook.ErrInvalidSignature
+	ErrWebhookSignatureExpired = webhook.ErrSignatureExpired
+)
+
+// SetWebhookSigningSecret signs every post and upload to webhookURL with
+// secret (HMAC-SHA256 plus timestamp). A "#secret=..." fragment on the
+// webhook URL has the same effect.
+func SetWebhookSigningSecret(webhookURL, secret string) { webhook.SetSigningSecret(webhookURL, secret) }
+
+// SignWebhookRequest signs a request to a webhook route ("" for posts).
+func SignWebhookRequest(req *http.Request, secret, route string, body []byte) {
+	webhook.SignRequest(req, secret, route, body)
+}
+
+// VerifyWebhookRequest checks a signed request and returns its body (which
+// stays readable). tolerance <= 0 means 5 minutes.
+func VerifyWebhookRequest(r *http.Request, secret, route string, tolerance time.Duration) ([]byte, error) {
+	return webhook.VerifyRequest(r, secret, route, tolerance)
+}
+
+// IsWebhookRetryable reports whether a delivery error is transient (network,
+// 408/429/5xx) rather than permanent (other 4xx).
+func IsWebhookRetryable(err error) bool { return webhook.Retryable(err) }
+
 func PostWebhookJSONWithRetry(ctx context.Context, httpClient *http.Client, apiBase, webhookURL string, body []byte, attempts int) error {
 	return webhook.PostJSONWithRetry(ctx, httpClient, apiBase, webhookURL, body, attempts)
 }
@@ -237,6 +385,16 @@ func UploadWebhookReader(ctx context.Context, httpClient *http.Client, apiBase,
 	return webhook.UploadReader(ctx, httpClient, apiBase, webhookURL, filename, contentType, r)
 }
 
+// UploadWebhookReaderWithOptions reports progress and, for large files with a
+// known size, uploads them in resumable parts.
+func UploadWebhookReaderWithOptions(ctx context.Context, httpClient *http.Client, apiBase, webhookURL, filename, contentType string, r io.Reader, opts WebhookUploadOptions) (WebhookAttachment, error) {
+	return webhook.UploadReaderWithOptions(ctx, httpClient, apiBase, webhookURL, filename, contentType, r, opts)
+}
+
+// NewUploadStateStore persists chunked upload sessions under dir (default:
+// <state dir>/uploads) so interrupted uploads resume after a restart.
+func NewUploadStateStore(dir string) UploadStateStore { return chunked.NewFileStore(dir) }
+
 func UploadRemoteToWebhook(
 	ctx context.Context,
 	downloadClient *http.Client,
@@ -272,10 +430,80 @@ func UploadRemoteToWebhookCached(
 	return webhook.UploadRemoteKeyCached(ctx, cache, downloadClient, uploadClient, apiBase, webhookURL, remoteURL, fallbackFilename, ua)
 }
 
+// NormalizeMedia sniffs the real content type and applies size, format and
+// dimension limits before an upload.
+func NormalizeMedia(data []byte, filename, contentType string, opts MediaOptions) (Media, error) {
+	return webhook.NormalizeMedia(data, filename, contentType, opts)
+}
+
+// UploadRemoteMediaToWebhook is UploadRemoteToWebhook with the media
+// pipeline (size caps, re-encoding, thumbnails) applied.
+func UploadRemoteMediaToWebhook(
+	ctx context.Context,
+	downloadClient *http.Client,
+	uploadClient *http.Client,
+	apiBase, webhookURL, remoteURL, fallbackFilename string,
+	opts MediaOptions,
+	userAgent ...string,
+) (MediaUpload, error) {
+	ua := ""
+	if len(userAgent) > 0 {
+		ua = strings.TrimSpace(userAgent[0])
+	}
+	if ua == "" {
+		ua = RandomBrowserUserAgent()
+	}
+	return webhook.UploadRemoteMedia(ctx, downloadClient, uploadClient, apiBase, webhookURL, remoteURL, fallbackFilename, ua, opts)
+}
+
+func UploadRemoteMediaToWebhookCached(
+	ctx context.Context,
+	cache MediaCache,
+	downloadClient *http.Client,
+	uploadClient *http.Client,
+	apiBase, webhookURL, remoteURL, fallbackFilename string,
+	opts MediaOptions,
+	userAgent ...string,
+) (keys MediaKeys, usedCache bool, err error) {
+	ua := ""
+	if len(userAgent) > 0 {
+		ua = strings.TrimSpace(userAgent[0])
+	}
+	if ua == "" {
+		ua = RandomBrowserUserAgent()
+	}
+	return webhook.UploadRemoteMediaKeyCached(ctx, cache, downloadClient, uploadClient, apiBase, webhookURL, remoteURL, fallbackFilename, ua, opts)
+}
+
 func FilenameFromURL(rawURL, fallback string) string {
 	return webhook.FilenameFromURL(rawURL, fallback)
 }
 
+// AddDownloadRule configures remote downloads (UploadRemoteToWebhook and
+// friends) for matching hosts: extra headers such as Referer or Cookie, and
+// optionally a replacement strategy chain. Later rules take precedence.
+func AddDownloadRule(rule DownloadRule) { webhook.DefaultDownloader.AddRule(rule) }
+
+// DownloadStrategyStats returns the shared downloader's per-strategy counters.
+func DownloadStrategyStats() map[string]DownloadStats { return webhook.DefaultDownloader.Stats() }
+
+// NewDownloader builds a standalone chain; rules are matched in order.
+func NewDownloader(chain []DownloadStrategy, rules ...DownloadRule) *Downloader {
+	return webhook.NewDownloader(chain, rules...)
+}
+
+func DirectDownload() DownloadStrategy    { return webhook.DirectDownload() }
+func ProxyPoolDownload() DownloadStrategy { return webhook.ProxyPoolDownload() }
+func WsrvDownload() DownloadStrategy      { return webhook.WsrvDownload() }
+
+func ImageProxyDownload(name string, rewrite func(src string) string) DownloadStrategy {
+	return webhook.ImageProxyDownload(name, rewrite)
+}
+
+func FlareSolverrDownload(endpoint string) DownloadStrategy {
+	return webhook.FlareSolverrDownload(endpoint)
+}
+
 // ---- MEW user helpers ----
 
 	type User = sdkapi.User
@@ -315,6 +543,16 @@ func SearchChannelMessages(
 	return messages.SearchChannelMessages(ctx, httpClient, apiBase, userToken, channelID, query, limit, page)
 }
 
+func TranscribeVoiceMessage(
+	ctx context.Context,
+	httpClient *http.Client,
+	apiBase, userToken, channelID, messageID string,
+	filename, contentType string,
+	data []byte,
+) (string, error) {
+	return messages.Transcribe(ctx, httpClient, apiBase, userToken, channelID, messageID, filename, contentType, data)
+}
+
 func AuthorID(authorRaw json.RawMessage) string { return sdkapi.AuthorID(authorRaw) }
 
 func AuthorUsername(authorRaw json.RawMessage) string { return sdkapi.AuthorUsername(authorRaw) }
@@ -326,3 +564,134 @@ func IsOwnMessage(authorRaw json.RawMessage, botUserID string) bool {
 type DMChannelCache = runtime.DMChannelCache
 
 func NewDMChannelCache() *DMChannelCache { return runtime.NewDMChannelCache() }
+
+// ---- REST client ----
+
+type APIClient = rest.Client
+type APIClientOptions = rest.Options
+type APIError = sdkapi.Error
+type CreateMessage = rest.CreateMessage
+type EditMessage = rest.EditMessage
+type ListMessagesOptions = rest.ListMessagesOptions
+type SearchMessagesOptions = rest.SearchMessagesOptions
+type SearchScope = rest.SearchScope
+type SearchQuery = rest.SearchQuery
+type SearchResult = rest.SearchResult
+type VoiceOptions = rest.VoiceOptions
+type UploadedAttachment = rest.Attachment
+type UploadOptions = rest.UploadOptions
+
+// ChunkedUploadThreshold is the size above which uploads with a known size
+// are sent in resumable parts.
+const ChunkedUploadThreshold = rest.ChunkedUploadThreshold
+type Channel = rest.Channel
+type Server = rest.Server
+type Category = rest.Category
+type Member = rest.Member
+type Role = rest.Role
+type PermissionOverride = rest.PermissionOverride
+type Sticker = rest.Sticker
+type StickerUpdate = rest.StickerUpdate
+type StickerSyncOptions = stickers.SyncOptions
+type StickerSyncResult = stickers.SyncResult
+
+// NewAPIClient builds a REST client. Bots normally use BotSession.API() instead.
+func NewAPIClient(apiBase string, httpClient *http.Client, opts APIClientOptions) (*APIClient, error) {
+	return rest.New(apiBase, httpClient, opts)
+}
+
+func IsAPIStatus(err error, statusCode int) bool { return sdkapi.IsStatus(err, statusCode) }
+
+// StickerFromMessage returns the sticker a message/sticker message carries.
+func StickerFromMessage(msg ChannelMessage) (Sticker, bool) { return rest.StickerFromMessage(msg) }
+
+// SyncStickerDir makes the bot's own stickers match the images in dir
+// ("<name>.png" plus an optional "<name>.txt" description).
+func SyncStickerDir(ctx context.Context, api *APIClient, dir string, opts StickerSyncOptions) (StickerSyncResult, error) {
+	return stickers.SyncDir(ctx, api, dir, opts)
+}
+
+// ---- directory ----
+
+type Directory = runtime.Directory
+
+// NewDirectory builds a server/channel/member cache for the bot. Call Refresh
+// once connected and feed every gateway event to HandleEvent.
+func NewDirectory(session *BotSession) *Directory { return runtime.NewDirectory(session) }
+
+// ---- live messages ----
+
+type LiveMessage = runtime.LiveMessage
+type LiveMessageOptions = runtime.LiveMessageOptions
+
+func NewLiveMessage(api *APIClient, channelID string, opts LiveMessageOptions) *LiveMessage {
+	return runtime.NewLiveMessage(api, channelID, opts)
+}
+
+// ---- voice synthesis ----
+
+type VoiceSynthesizer = tts.VoiceSynthesizer
+type VoiceSynthesizerFunc = tts.SynthesizerFunc
+type SynthesizedAudio = tts.Audio
+type TTSServerOptions = tts.ServerOptions
+
+// NewServerSynthesizer synthesizes speech with the Mew server's TTS module.
+func NewServerSynthesizer(api *APIClient, opts TTSServerOptions) VoiceSynthesizer {
+	return tts.NewServerSynthesizer(api, opts)
+}
+
+// NewTTSCache wraps synth with an in-memory LRU (maxBytes <= 0 uses the default).
+func NewTTSCache(synth VoiceSynthesizer, maxBytes int64) VoiceSynthesizer {
+	return tts.NewCache(synth, maxBytes)
+}
+
+func SendVoiceMessageBySynthesizer(
+	ctx context.Context,
+	httpClient *http.Client,
+	apiBase, userToken, channelID string,
+	synth VoiceSynthesizer,
+	text string,
+	opts VoiceOptions,
+) (ChannelMessage, error) {
+	return messages.SendVoiceMessageBySynthesizer(ctx, httpClient, apiBase, userToken, channelID, synth, text, opts)
+}
+
+// ---- message addressing ----
+
+type AddressingPolicy = sdkapi.AddressingPolicy
+type AddressingConfig = sdkapi.AddressingConfig
+type Addressing = sdkapi.Addressing
+type AddressReason = sdkapi.AddressReason
+
+const (
+	AddressedByMention = sdkapi.AddressedByMention
+	AddressedByReply   = sdkapi.AddressedByReply
+	AddressedByName    = sdkapi.AddressedByName
+	AddressedByPrefix  = sdkapi.AddressedByPrefix
+	AddressedByDM      = sdkapi.AddressedByDM
+)
+
+type MessageRouter = runtime.MessageRouter
+
+func NewMessageRouter(session *BotSession, dm *DMChannelCache, policy AddressingPolicy) *MessageRouter {
+	return runtime.NewMessageRouter(session, dm, policy)
+}
+
+// ---- slash commands ----
+
+type CommandSpec = runtime.CommandSpec
+type CommandArg = runtime.CommandArg
+type CommandArgType = runtime.CommandArgType
+type CommandContext = runtime.CommandContext
+type CommandInvocation = runtime.CommandInvocation
+type CommandHandler = runtime.CommandHandler
+type CommandRegistry = runtime.CommandRegistry
+
+const (
+	CommandArgString = runtime.CommandArgString
+	CommandArgInt    = runtime.CommandArgInt
+	CommandArgBool   = runtime.CommandArgBool
+	CommandArgRest   = runtime.CommandArgRest
+)
+
+func NewCommandRegistry() *CommandRegistry { return runtime.NewCommandRegistry() }
@@ -27,22 +27,28 @@ func (s *SeenSet) Has(id string) bool {
 }
 
 func (s *SeenSet) Add(id string) {
+	s.TryAdd(id)
+}
+
+// TryAdd adds id and reports whether it was new. Unlike Has followed by Add it
+// is atomic, so concurrent callers can use it to claim an id exactly once.
+func (s *SeenSet) TryAdd(id string) bool {
 	if id == "" {
-		return
+		return false
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, ok := s.set[id]; ok {
-		return
+		return false
 	}
 
 	s.set[id] = struct{}{}
 	s.order = append(s.order, id)
 
 	if len(s.order) <= s.max {
-		return
+		return true
 	}
 
 	overflow := len(s.order) - s.max
@@ -51,6 +57,7 @@ func (s *SeenSet) Add(id string) {
 		delete(s.set, old)
 	}
 	s.order = append([]string(nil), s.order[overflow:]...)
+	return true
 }
 
 func (s *SeenSet) Snapshot() []string {
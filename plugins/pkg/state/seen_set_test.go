@@ -31,6 +31,19 @@ func TestSeenSet_AddAndHas(t *testing.T) {
 	}
 }
 
+func TestSeenSet_TryAdd(t *testing.T) {
+	s := NewSeenSet(3)
+	if s.TryAdd("") {
+		t.Fatalf("empty id should be ignored")
+	}
+	if !s.TryAdd("a") {
+		t.Fatalf("expected first TryAdd to claim the id")
+	}
+	if s.TryAdd("a") {
+		t.Fatalf("expected second TryAdd to report a duplicate")
+	}
+}
+
 func TestSeenSet_EvictsOldest(t *testing.T) {
 	s := NewSeenSet(2)
 	s.Add("a")
@@ -0,0 +1,167 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mew/plugins/pkg/api/webhook"
+)
+
+// record writes a post and an upload the way a plugin in DEV_MODE does.
+func record(t *testing.T, content string) webhook.Attachment {
+	t.Helper()
+	ctx := context.Background()
+	att, err := webhook.UploadBytes(ctx, nil, "http://mew/api", "http://mew/api/webhooks/w1/secret", "cover.png", "image/png", []byte("png-bytes"))
+	if err != nil {
+		t.Fatalf("UploadBytes: %v", err)
+	}
+	body, _ := json.Marshal(webhook.Payload{
+		Content:   content,
+		Type:      "app/x-rich-card",
+		AvatarURL: att.Key,
+		Payload: map[string]any{
+			"title":      "hello",
+			"image_urls": []any{att.Key, "https://cdn.example.com/a.jpg", "https://example.com/page"},
+		},
+	})
+	if err := webhook.PostJSON(ctx, nil, "http://mew/api", "http://mew/api/webhooks/w1/secret", body); err != nil {
+		t.Fatalf("PostJSON: %v", err)
+	}
+	return att
+}
+
+func TestLoad_ResolvesUploadsAndMedia(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DEV_MODE", "1")
+	t.Setenv("MEW_DEV_DIR", dir)
+
+	att := record(t, "first")
+	record(t, "second")
+
+	snap, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(snap.Posts) != 2 || len(snap.Uploads) != 2 {
+		t.Fatalf("posts=%d uploads=%d", len(snap.Posts), len(snap.Uploads))
+	}
+	p := snap.Posts[0]
+	if p.Content != "first" || snap.Posts[1].Content != "second" {
+		t.Fatalf("expected time order, got %q then %q", p.Content, snap.Posts[1].Content)
+	}
+	if p.Type != "app/x-rich-card" || p.ServiceType == "" || p.ServiceType == "unknown" {
+		t.Fatalf("unexpected post: %+v", p)
+	}
+	if strings.Contains(p.Webhook, "secret") {
+		t.Fatalf("webhook token leaked: %q", p.Webhook)
+	}
+	if !strings.HasPrefix(p.AvatarURL, "/files/") {
+		t.Fatalf("avatar not resolved: %q", p.AvatarURL)
+	}
+	if len(p.Media) != 2 {
+		t.Fatalf("expected 2 media, got %+v", p.Media)
+	}
+	if m := p.Media[0]; m.Key != att.Key || m.ContentType != "image/png" || m.Field != "payload.image_urls[0]" {
+		t.Fatalf("unexpected upload media: %+v", m)
+	}
+	if m := p.Media[1]; m.URL != "https://cdn.example.com/a.jpg" || m.ContentType != "image/jpeg" {
+		t.Fatalf("unexpected remote media: %+v", m)
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	snap, err := Load(filepath.Join(t.TempDir(), "nope"))
+	if err != nil || len(snap.Posts) != 0 {
+		t.Fatalf("snap=%+v err=%v", snap, err)
+	}
+}
+
+func TestServer_RecordsFilesAndEvents(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DEV_MODE", "1")
+	t.Setenv("MEW_DEV_DIR", dir)
+	record(t, "first")
+
+	srv := httptest.NewServer(NewServer(dir, Options{PollInterval: 10 * time.Millisecond}))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/records")
+	if err != nil {
+		t.Fatalf("GET records: %v", err)
+	}
+	var snap Snapshot
+	err = json.NewDecoder(res.Body).Decode(&snap)
+	res.Body.Close()
+	if err != nil || len(snap.Posts) != 1 {
+		t.Fatalf("snap=%+v err=%v", snap, err)
+	}
+
+	res, err = http.Get(srv.URL + snap.Posts[0].AvatarURL)
+	if err != nil {
+		t.Fatalf("GET file: %v", err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(b) != "png-bytes" {
+		t.Fatalf("file status=%d body=%q", res.StatusCode, b)
+	}
+
+	// Record JSON files and anything outside the upload directory stay hidden.
+	u := snap.Uploads[0]
+	for _, bad := range []string{"/files/..%2Fpost", "/files/" + u.ServiceType + "-" + u.ID + ".json"} {
+		res, err := http.Get(srv.URL + bad)
+		if err != nil {
+			t.Fatalf("GET %s: %v", bad, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Fatalf("GET %s: status=%d", bad, res.StatusCode)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET events: %v", err)
+	}
+	defer res.Body.Close()
+	events := make(chan string, 4)
+	go func() {
+		sc := bufio.NewScanner(res.Body)
+		for sc.Scan() {
+			if name, ok := strings.CutPrefix(sc.Text(), "event: "); ok {
+				events <- name
+			}
+		}
+		close(events)
+	}()
+	if got := <-events; got != "hello" {
+		t.Fatalf("first event=%q", got)
+	}
+	// Make sure the new record gets a different modification time.
+	time.Sleep(20 * time.Millisecond)
+	record(t, "second")
+	if got := <-events; got != "change" {
+		t.Fatalf("expected change event, got %q", got)
+	}
+}
+
+func TestServiceType(t *testing.T) {
+	id := "20260101T000000.000Z-0011223344556677"
+	if got := serviceType("rss-fetcher-"+id+".json", id); got != "rss-fetcher" {
+		t.Fatalf("got %q", got)
+	}
+	if got := serviceType("other.json", id); got != "unknown" {
+		t.Fatalf("got %q", got)
+	}
+}
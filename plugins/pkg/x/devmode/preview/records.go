@@ -0,0 +1,270 @@
+// Package preview serves the webhook posts and uploads recorded in dev mode
+// (devmode.Dir()/webhook) as a local web page, so fetcher formatting can be
+// checked without a running Mew stack.
+package preview
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Upload is one recorded webhook upload.
+type Upload struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	ServiceType string    `json:"serviceType"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Key         string    `json:"key"`
+	// URL serves the recorded bytes from the preview server.
+	URL string `json:"url"`
+
+	dataFile string
+}
+
+// Media is an image, video or audio reference found in a post.
+type Media struct {
+	// Field is the JSON path of the value, e.g. "payload.image_urls[0]".
+	Field       string `json:"field"`
+	Key         string `json:"key,omitempty"`
+	URL         string `json:"url"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// Post is one recorded webhook post.
+type Post struct {
+	ID          string          `json:"id"`
+	Time        time.Time       `json:"time"`
+	ServiceType string          `json:"serviceType"`
+	Webhook     string          `json:"webhook,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Content     string          `json:"content"`
+	Username    string          `json:"username,omitempty"`
+	AvatarURL   string          `json:"avatarUrl,omitempty"`
+	Payload     map[string]any  `json:"payload,omitempty"`
+	Media       []Media         `json:"media,omitempty"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// Snapshot is everything recorded under a dev directory, oldest first.
+type Snapshot struct {
+	Posts   []Post   `json:"posts"`
+	Uploads []Upload `json:"uploads"`
+}
+
+// recordFile mirrors the JSON written by the webhook package for both posts
+// and uploads.
+type recordFile struct {
+	ID          string          `json:"id"`
+	Time        string          `json:"time"`
+	Kind        string          `json:"kind"`
+	Webhook     string          `json:"webhookURL"`
+	Body        json.RawMessage `json:"body"`
+	Filename    string          `json:"filename"`
+	ContentType string          `json:"contentType"`
+	Size        int64           `json:"size"`
+	Key         string          `json:"key"`
+	FilePath    string          `json:"filePath"`
+}
+
+func postDir(dir string) string   { return filepath.Join(dir, "webhook", "post") }
+func uploadDir(dir string) string { return filepath.Join(dir, "webhook", "upload") }
+
+// Load reads the records under dir (a devmode.Dir()). Unreadable or foreign
+// files are skipped; missing directories yield an empty snapshot.
+func Load(dir string) (Snapshot, error) {
+	uploads, err := loadUploads(dir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	byKey := make(map[string]Upload, len(uploads))
+	for _, u := range uploads {
+		byKey[u.Key] = u
+	}
+
+	var posts []Post
+	err = eachRecord(postDir(dir), func(name string, rec recordFile) {
+		if rec.Kind != "webhook.post" {
+			return
+		}
+		posts = append(posts, newPost(name, rec, byKey))
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Time.Before(posts[j].Time) })
+	return Snapshot{Posts: posts, Uploads: uploads}, nil
+}
+
+func loadUploads(dir string) ([]Upload, error) {
+	var out []Upload
+	err := eachRecord(uploadDir(dir), func(name string, rec recordFile) {
+		if rec.Kind != "webhook.upload" || rec.Key == "" {
+			return
+		}
+		data := filepath.Base(rec.FilePath)
+		if data == "." || data == string(filepath.Separator) {
+			data = path.Base(rec.Key)
+		}
+		out = append(out, Upload{
+			ID:          rec.ID,
+			Time:        parseTime(rec.Time),
+			ServiceType: serviceType(name, rec.ID),
+			Filename:    rec.Filename,
+			ContentType: rec.ContentType,
+			Size:        rec.Size,
+			Key:         rec.Key,
+			URL:         "/files/" + url.PathEscape(data),
+			dataFile:    data,
+		})
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, err
+}
+
+// eachRecord decodes every *.json file in dir. Upload data files that happen
+// to be JSON decode without a kind and are dropped by the callers.
+func eachRecord(dir string, fn func(name string, rec recordFile)) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var rec recordFile
+		if json.Unmarshal(b, &rec) != nil || rec.ID == "" {
+			continue
+		}
+		fn(name, rec)
+	}
+	return nil
+}
+
+func newPost(name string, rec recordFile, uploads map[string]Upload) Post {
+	p := Post{
+		ID:          rec.ID,
+		Time:        parseTime(rec.Time),
+		ServiceType: serviceType(name, rec.ID),
+		Webhook:     redactWebhook(rec.Webhook),
+		Body:        rec.Body,
+	}
+	var body struct {
+		Content   string         `json:"content"`
+		Type      string         `json:"type"`
+		Payload   map[string]any `json:"payload"`
+		Username  string         `json:"username"`
+		AvatarURL string         `json:"avatar_url"`
+	}
+	if json.Unmarshal(rec.Body, &body) == nil {
+		p.Content, p.Type, p.Payload, p.Username = body.Content, body.Type, body.Payload, body.Username
+		if m, ok := resolveMedia("avatar_url", body.AvatarURL, uploads); ok {
+			p.AvatarURL = m.URL
+		} else {
+			p.AvatarURL = body.AvatarURL
+		}
+		p.Media = collectMedia("payload", body.Payload, uploads, nil)
+	}
+	if len(p.Body) == 0 {
+		p.Body = json.RawMessage("null")
+	}
+	return p
+}
+
+// collectMedia walks v depth-first (map keys sorted) and returns the values
+// that resolve to media.
+func collectMedia(field string, v any, uploads map[string]Upload, out []Media) []Media {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = collectMedia(field+"."+k, t[k], uploads, out)
+		}
+	case []any:
+		for i, e := range t {
+			out = collectMedia(field+"["+strconv.Itoa(i)+"]", e, uploads, out)
+		}
+	case string:
+		if m, ok := resolveMedia(field, t, uploads); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// resolveMedia maps a recorded upload key to its local file, and an http(s)
+// URL to itself when its extension says image, video or audio.
+func resolveMedia(field, value string, uploads map[string]Upload) (Media, bool) {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return Media{}, false
+	}
+	if u, ok := uploads[v]; ok {
+		return Media{Field: field, Key: v, URL: u.URL, ContentType: u.ContentType}, true
+	}
+	if !strings.HasPrefix(v, "http://") && !strings.HasPrefix(v, "https://") {
+		return Media{}, false
+	}
+	pu, err := url.Parse(v)
+	if err != nil {
+		return Media{}, false
+	}
+	ct := mime.TypeByExtension(strings.ToLower(path.Ext(pu.Path)))
+	if !isMediaType(ct) {
+		return Media{}, false
+	}
+	return Media{Field: field, URL: v, ContentType: ct}, true
+}
+
+func isMediaType(ct string) bool {
+	return strings.HasPrefix(ct, "image/") || strings.HasPrefix(ct, "video/") || strings.HasPrefix(ct, "audio/")
+}
+
+// serviceType recovers the prefix of "<serviceType>-<id>.json".
+func serviceType(name, id string) string {
+	st, ok := strings.CutSuffix(strings.TrimSuffix(name, ".json"), "-"+id)
+	if !ok || st == "" {
+		return "unknown"
+	}
+	return st
+}
+
+// redactWebhook keeps the webhook path but drops its token.
+func redactWebhook(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if n := len(segs); n >= 2 {
+		segs[n-1] = "…"
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
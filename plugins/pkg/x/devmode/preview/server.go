@@ -0,0 +1,153 @@
+package preview
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// Options tunes a Server. Zero values use the defaults.
+type Options struct {
+	// PollInterval is how often the record directories are checked for
+	// changes pushed to open pages (default 1s).
+	PollInterval time.Duration
+	// Limit caps how many of the most recent posts a page loads (default 200).
+	Limit int
+}
+
+func (o Options) withDefaults() Options {
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Limit <= 0 {
+		o.Limit = 200
+	}
+	return o
+}
+
+// Server renders the records under one dev directory:
+//
+//	GET /              the preview page
+//	GET /api/records   Snapshot JSON (?limit=N most recent posts)
+//	GET /files/{name}  recorded upload bytes
+//	GET /events        server-sent "change" events when records are added
+type Server struct {
+	dir  string
+	opts Options
+	mux  *http.ServeMux
+}
+
+// NewServer serves the records under dir (usually devmode.Dir()).
+func NewServer(dir string, opts Options) *Server {
+	s := &Server{dir: dir, opts: opts.withDefaults(), mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /api/records", s.handleRecords)
+	s.mux.HandleFunc("GET /files/{name}", s.handleFile)
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) { s.mux.ServeHTTP(w, r) }
+
+func (s *Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	snap, err := Load(s.dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	limit := s.opts.Limit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	if len(snap.Posts) > limit {
+		snap.Posts = snap.Posts[len(snap.Posts)-limit:]
+	}
+	if snap.Posts == nil {
+		snap.Posts = []Post{}
+	}
+	if snap.Uploads == nil {
+		snap.Uploads = []Upload{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(snap)
+}
+
+// handleFile serves only base names from the upload directory.
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".json") {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(uploadDir(s.dir), name))
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+
+	last := s.version()
+	fmt.Fprintf(w, "retry: 2000\nevent: hello\ndata: %s\n\n", last)
+	flusher.Flush()
+
+	t := time.NewTicker(s.opts.PollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+			v := s.version()
+			if v == last {
+				continue
+			}
+			last = v
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", v)
+			flusher.Flush()
+		}
+	}
+}
+
+// version fingerprints both record directories by entry count and newest
+// modification time, which changes whenever a record is written or removed.
+func (s *Server) version() string {
+	var n int
+	var newest int64
+	for _, dir := range []string{postDir(s.dir), uploadDir(s.dir)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !strings.HasSuffix(e.Name(), ".json") {
+				continue
+			}
+			n++
+			if info, err := e.Info(); err == nil && info.ModTime().UnixNano() > newest {
+				newest = info.ModTime().UnixNano()
+			}
+		}
+	}
+	return strconv.Itoa(n) + "-" + strconv.FormatInt(newest, 36)
+}
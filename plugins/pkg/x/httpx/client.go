@@ -31,12 +31,59 @@ type ClientOptions struct {
 	// CookieJar enables a cookie jar (required by some scrapers).
 	CookieJar bool
 
+	// Routes overrides Mode per host. Rules from MEW_HTTP_ROUTES are
+	// appended, so the caller's rules win.
+	Routes Routes
+
 	// Transport allows providing a pre-configured transport.
 	// When nil, it clones http.DefaultTransport.
 	Transport *http.Transport
 }
 
+// Validate reports the error NewClient would return for opts without
+// building any transport.
+func (o ClientOptions) Validate() error {
+	mode, err := o.mode()
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case ModeDirect, ModeEnv:
+	case ModeProxy:
+		if strings.TrimSpace(o.Proxy) != "" {
+			if _, err := ProxyFuncFromString(o.Proxy); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid mode %q (expected %q, %q or %q)", mode, ModeDirect, ModeEnv, ModeProxy)
+	}
+	envRoutes, err := RoutesFromEnv()
+	if err != nil {
+		return err
+	}
+	return o.Routes.Merge(envRoutes).Validate()
+}
+
+// mode returns Mode, resolved from MEW_API_PROXY when empty.
+func (o ClientOptions) mode() (string, error) {
+	if mode := strings.ToLower(strings.TrimSpace(o.Mode)); mode != "" {
+		return mode, nil
+	}
+	return resolveModeFromEnv()
+}
+
 func NewClient(opts ClientOptions) (*http.Client, error) {
+	return newClient(opts, func(base *http.Transport) http.RoundTripper {
+		// Outcomes of pooled requests feed the proxy scores.
+		return &scoringRoundTripper{next: NewTransport(base), pool: Default().Pool()}
+	}, false)
+}
+
+// newClient builds a client for opts; pooled makes the proxy-pool stage of
+// ModeProxy from a clone of the base transport. poolOnly drops the env and
+// direct fallbacks of ModeProxy, so pool failures reach the caller.
+func newClient(opts ClientOptions, pooled func(base *http.Transport) http.RoundTripper, poolOnly bool) (*http.Client, error) {
 	var base *http.Transport
 	if opts.Transport != nil {
 		base = opts.Transport.Clone()
@@ -44,13 +91,18 @@ func NewClient(opts ClientOptions) (*http.Client, error) {
 		base = http.DefaultTransport.(*http.Transport).Clone()
 	}
 
-	mode := strings.ToLower(strings.TrimSpace(opts.Mode))
-	if mode == "" {
-		var err error
-		mode, err = resolveModeFromEnv()
-		if err != nil {
-			return nil, err
+	mode, err := opts.mode()
+	if err != nil {
+		return nil, err
+	}
+
+	// All stages share one pool round tripper (sessions pin through it).
+	var poolRT http.RoundTripper
+	pool := func() http.RoundTripper {
+		if poolRT == nil {
+			poolRT = pooled(base.Clone())
 		}
+		return poolRT
 	}
 
 	var rt http.RoundTripper
@@ -64,7 +116,11 @@ func NewClient(opts ClientOptions) (*http.Client, error) {
 		env.Proxy = http.ProxyFromEnvironment
 		rt = env
 	case ModeProxy:
-		poolFirst := NewTransport(base.Clone())
+		poolFirst := pool()
+		if poolOnly {
+			rt = poolFirst
+			break
+		}
 
 		var fallback http.RoundTripper
 		if strings.TrimSpace(opts.Proxy) != "" {
@@ -95,6 +151,18 @@ func NewClient(opts ClientOptions) (*http.Client, error) {
 		return nil, fmt.Errorf("invalid mode %q (expected %q, %q or %q)", mode, ModeDirect, ModeEnv, ModeProxy)
 	}
 
+	envRoutes, err := RoutesFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if routes := opts.Routes.Merge(envRoutes); !routes.IsZero() {
+		routed, err := newRoutingRoundTripper(routes, base, pool, rt)
+		if err != nil {
+			return nil, err
+		}
+		rt = routed
+	}
+
 	var jar http.CookieJar
 	if opts.CookieJar {
 		jar, _ = cookiejar.New(nil)
@@ -137,6 +205,19 @@ func hasEnvProxyConfigured() bool {
 	return strings.TrimSpace(os.Getenv("HTTP_PROXY")) != "" || strings.TrimSpace(os.Getenv("HTTPS_PROXY")) != ""
 }
 
+// closeIdler is implemented by *http.Transport and by this package's
+// wrappers, so http.Client.CloseIdleConnections reaches every transport
+// behind a client.
+type closeIdler interface{ CloseIdleConnections() }
+
+func closeIdle(rts ...http.RoundTripper) {
+	for _, rt := range rts {
+		if c, ok := rt.(closeIdler); ok {
+			c.CloseIdleConnections()
+		}
+	}
+}
+
 type fallbackRoundTripper struct {
 	primary  http.RoundTripper
 	fallback http.RoundTripper
@@ -147,6 +228,8 @@ type fallbackRoundTripper struct {
 	tryDirect bool
 }
 
+func (t *fallbackRoundTripper) CloseIdleConnections() { closeIdle(t.primary, t.fallback, t.direct) }
+
 func (t *fallbackRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	if t == nil || t.primary == nil {
 		return http.DefaultTransport.RoundTrip(req)
@@ -124,3 +124,26 @@ func TestNewClient_ModeProxy_ExplicitProxyOverride(t *testing.T) {
 		t.Fatalf("expected fallback to be configured for explicit proxy override")
 	}
 }
+
+func TestClientOptions_ValidateMatchesNewClient(t *testing.T) {
+	t.Setenv("MEW_HTTP_ROUTES", "")
+	t.Setenv("MEW_API_PROXY", "")
+	cases := map[string]ClientOptions{
+		"direct":      {Mode: ModeDirect},
+		"bad mode":    {Mode: "sideways"},
+		"bad proxy":   {Mode: ModeProxy, Proxy: "ftp://1.2.3.4:21"},
+		"bad routes":  {Mode: ModeDirect, Routes: Routes{Rules: []Route{{Hosts: []string{"a.com"}, Via: []string{"nowhere"}}}}},
+		"good routes": {Mode: ModeDirect, Routes: Routes{Rules: []Route{{Hosts: []string{"a.com"}, Via: []string{ViaDirect}}}}},
+	}
+	for name, opts := range cases {
+		_, newErr := NewClient(opts)
+		if err := opts.Validate(); (err == nil) != (newErr == nil) {
+			t.Errorf("%s: Validate=%v, NewClient=%v", name, err, newErr)
+		}
+	}
+
+	t.Setenv("MEW_API_PROXY", "sideways")
+	if err := (ClientOptions{}).Validate(); err == nil {
+		t.Fatalf("expected invalid MEW_API_PROXY to be rejected")
+	}
+}
@@ -1,8 +1,6 @@
 package httpx
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
@@ -20,8 +18,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-
-	netproxy "golang.org/x/net/proxy"
 )
 
 const defaultProxyListURL = "https://raw.githubusercontent.com/ClearProxy/checked-proxy-list/main/socks5/raw/all.txt"
@@ -30,7 +26,11 @@ const defaultProxyListCacheTTL = 5 * time.Minute
 var ipPortRegex = regexp.MustCompile(`([0-9]{1,3}(?:\.[0-9]{1,3}){3}):([0-9]{1,5})`)
 
 type Config struct {
+	// ProxyListURLs are fetched on every update; file:// URLs and plain paths
+	// are read from disk. StaticProxies are used as given. Both accept the
+	// entry formats in proxy_entry.go.
 	ProxyListURLs []string
+	StaticProxies []string
 
 	UpdateInterval          time.Duration
 	HealthCheckConcurrency  int
@@ -39,6 +39,12 @@ type Config struct {
 
 	HealthCheckTargetAddr string // host:port
 	HealthCheckSNI        string
+
+	// Scoring (see proxy_score.go). Zero values use the defaults:
+	// 3 consecutive failures, 30s doubling up to 30m.
+	QuarantineAfter int
+	QuarantineBase  time.Duration
+	QuarantineMax   time.Duration
 }
 
 func ConfigFromEnv() Config {
@@ -52,6 +58,10 @@ func ConfigFromEnv() Config {
 		HealthCheckSNI:          "www.cloudflare.com",
 	}
 
+	// Inline proxies: PROXY_LIST. On their own they replace the default
+	// remote list, so the pool works offline.
+	cfg.StaticProxies = splitEnvList(os.Getenv("PROXY_LIST"))
+
 	// Proxy list source env: PROXY_LIST_URLS.
 	if raw, exists := lookupProxyListURLsEnv(); exists {
 		if strings.TrimSpace(raw) == "" {
@@ -60,6 +70,8 @@ func ConfigFromEnv() Config {
 		} else if urls := splitEnvList(raw); len(urls) > 0 {
 			cfg.ProxyListURLs = urls
 		}
+	} else if len(cfg.StaticProxies) > 0 {
+		cfg.ProxyListURLs = nil
 	}
 
 	return cfg
@@ -102,23 +114,30 @@ func splitEnvList(raw string) []string {
 type Pool struct {
 	mu       sync.RWMutex
 	proxies  []string
-	idx      uint64
+	scores   map[string]*proxyScore
 	updating int32
 
 	cfg Config
 	log *log.Logger
+	now func() time.Time
 }
 
 func NewPool(cfg Config) *Pool {
 	return &Pool{
 		proxies: make([]string, 0),
+		scores:  make(map[string]*proxyScore),
 		cfg:     cfg,
 		log:     log.New(log.Writer(), "[proxy] ", log.LstdFlags),
+		now:     time.Now,
 	}
 }
 
+func (p *Pool) hasSources() bool {
+	return len(p.cfg.ProxyListURLs) > 0 || len(p.cfg.StaticProxies) > 0
+}
+
 func (p *Pool) StartBackground(ctx context.Context) {
-	if len(p.cfg.ProxyListURLs) == 0 || p.cfg.UpdateInterval <= 0 {
+	if !p.hasSources() || p.cfg.UpdateInterval <= 0 {
 		return
 	}
 
@@ -137,7 +156,7 @@ func (p *Pool) StartBackground(ctx context.Context) {
 }
 
 func (p *Pool) UpdateNow(ctx context.Context) {
-	if len(p.cfg.ProxyListURLs) == 0 {
+	if !p.hasSources() {
 		return
 	}
 	if !atomic.CompareAndSwapInt32(&p.updating, 0, 1) {
@@ -147,11 +166,15 @@ func (p *Pool) UpdateNow(ctx context.Context) {
 
 	// p.log.Printf("refreshing proxy pool from %d source(s)...", len(p.cfg.ProxyListURLs))
 
-	raw, err := fetchProxyLists(ctx, p.cfg.ProxyListURLs)
+	raw := p.staticEntries()
+	fetched, err := fetchProxyLists(ctx, p.cfg.ProxyListURLs)
 	if err != nil {
 		p.log.Printf("fetch proxy lists failed: %v", err)
-		return
+		if len(raw) == 0 {
+			return
+		}
 	}
+	raw = mergeProxyEntries(raw, fetched)
 	if len(raw) == 0 {
 		p.log.Printf("no proxies fetched")
 		return
@@ -166,26 +189,50 @@ func (p *Pool) UpdateNow(ctx context.Context) {
 	p.mu.Lock()
 	old := len(p.proxies)
 	p.proxies = healthy
-	atomic.StoreUint64(&p.idx, 0)
+	p.pruneScoresLocked()
 	p.mu.Unlock()
 
 	p.log.Printf("proxy pool updated: %d -> %d", old, len(healthy))
 }
 
+func (p *Pool) staticEntries() []string {
+	out := make([]string, 0, len(p.cfg.StaticProxies))
+	for _, raw := range p.cfg.StaticProxies {
+		entry, ok := canonicalProxyEntry(raw)
+		if !ok {
+			p.log.Printf("ignoring invalid proxy %q", redactProxyEntry(raw))
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// mergeProxyEntries appends the entries of b missing from a.
+func mergeProxyEntries(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, entry := range list {
+			if _, ok := seen[entry]; ok {
+				continue
+			}
+			seen[entry] = struct{}{}
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
 func (p *Pool) Len() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	return len(p.proxies)
 }
 
+// GetNext returns a score-weighted proxy for any target; see Pick.
 func (p *Pool) GetNext() (string, bool) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	if len(p.proxies) == 0 {
-		return "", false
-	}
-	i := atomic.AddUint64(&p.idx, 1) % uint64(len(p.proxies))
-	return p.proxies[i], true
+	return p.Pick("")
 }
 
 func (p *Pool) healthCheck(ctx context.Context, proxies []string) []string {
@@ -229,7 +276,7 @@ func (p *Pool) healthCheck(ctx context.Context, proxies []string) []string {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			ok := checkSOCKS5ProxyTLS(ctx, addr, targetAddr, sni, timeout, threshold)
+			ok := checkProxyTLS(ctx, addr, targetAddr, sni, timeout, threshold)
 			if !ok {
 				return
 			}
@@ -243,20 +290,17 @@ func (p *Pool) healthCheck(ctx context.Context, proxies []string) []string {
 	return healthy
 }
 
-func checkSOCKS5ProxyTLS(parent context.Context, proxyAddr, targetAddr, sni string, totalTimeout, handshakeThreshold time.Duration) bool {
+// checkProxyTLS completes a verified TLS handshake with targetAddr through the
+// proxy entry within handshakeThreshold.
+func checkProxyTLS(parent context.Context, proxyAddr, targetAddr, sni string, totalTimeout, handshakeThreshold time.Duration) bool {
 	ctx, cancel := context.WithTimeout(parent, totalTimeout)
 	defer cancel()
 
-	dialer, err := netproxy.SOCKS5("tcp", proxyAddr, nil, timeoutDialer{timeout: totalTimeout})
-	if err != nil {
-		return false
-	}
-
 	done := make(chan bool, 1)
 	go func() {
 		start := time.Now()
 
-		conn, err := dialer.Dial("tcp", targetAddr)
+		conn, err := dialProxy(ctx, proxyAddr, "tcp", targetAddr, totalTimeout)
 		if err != nil {
 			done <- false
 			return
@@ -498,6 +542,18 @@ func fetchProxyListURLWithCache(ctx context.Context, client *http.Client, url st
 	return body, nil
 }
 
+// readProxyListSource reads a local list (file:// URL or plain path) from
+// disk and fetches anything else over HTTP.
+func readProxyListSource(ctx context.Context, client *http.Client, src string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(src, "file://"); ok {
+		return os.ReadFile(path)
+	}
+	if !strings.Contains(src, "://") {
+		return os.ReadFile(src)
+	}
+	return fetchProxyListURLWithCache(ctx, client, src)
+}
+
 func fetchProxyLists(ctx context.Context, urls []string) ([]string, error) {
 	if len(urls) == 0 {
 		return nil, nil
@@ -515,46 +571,18 @@ func fetchProxyLists(ctx context.Context, urls []string) ([]string, error) {
 			continue
 		}
 
-		body, err := fetchProxyListURLWithCache(ctx, client, u)
+		body, err := readProxyListSource(ctx, client, u)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 
-		scanner := bufio.NewScanner(bytes.NewReader(body))
-		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-
-			// Fast-path known prefixes.
-			line = strings.TrimPrefix(line, "socks5://")
-			line = strings.TrimPrefix(line, "socks4://")
-			line = strings.TrimPrefix(line, "https://")
-			line = strings.TrimPrefix(line, "http://")
-
-			hostPort := ""
-			if m := ipPortRegex.FindStringSubmatch(line); len(m) >= 3 {
-				hostPort = fmt.Sprintf("%s:%s", m[1], m[2])
-			} else if strings.Contains(line, ":") && !strings.Contains(line, " ") && !strings.Contains(line, "\t") {
-				hostPort = line
-			}
-
-			hostPort = strings.TrimSpace(hostPort)
-			if hostPort == "" {
+		for _, entry := range parseProxyList(body) {
+			if _, ok := seen[entry]; ok {
 				continue
 			}
-			if _, ok := seen[hostPort]; ok {
-				continue
-			}
-			seen[hostPort] = struct{}{}
-			out = append(out, hostPort)
-		}
-
-		if err := scanner.Err(); err != nil {
-			lastErr = err
+			seen[entry] = struct{}{}
+			out = append(out, entry)
 		}
 	}
 
@@ -583,6 +611,12 @@ func (m *Manager) ensureStarted() {
 }
 
 func (m *Manager) DialContext(ctx context.Context, baseDial func(ctx context.Context, network, addr string) (net.Conn, error), network, addr string) (net.Conn, error) {
+	return m.dialWith(ctx, network, addr, m.pool.pick, nil)
+}
+
+// dialWith dials addr through up to three proxies picked by choose; failed is
+// told about every proxy that couldn't connect.
+func (m *Manager) dialWith(ctx context.Context, network, addr string, choose func(host string, tried map[string]bool) (string, bool), failed func(proxyAddr string)) (net.Conn, error) {
 	m.ensureStarted()
 
 	if m.pool.Len() == 0 {
@@ -594,22 +628,29 @@ func (m *Manager) DialContext(ctx context.Context, baseDial func(ctx context.Con
 		maxAttempts = l
 	}
 
+	host, _, _ := net.SplitHostPort(addr)
+	tried := make(map[string]bool, maxAttempts)
+
 	var lastErr error
 	for i := 0; i < maxAttempts; i++ {
-		proxyAddr, ok := m.pool.GetNext()
+		proxyAddr, ok := choose(host, tried)
 		if !ok || proxyAddr == "" {
 			break
 		}
+		tried[proxyAddr] = true
 
-		dialer, err := netproxy.SOCKS5("tcp", proxyAddr, nil, timeoutDialer{timeout: 10 * time.Second})
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		conn, err := dialer.Dial(network, addr)
+		start := time.Now()
+		conn, err := dialProxy(ctx, proxyAddr, network, addr, 10*time.Second)
 		if err == nil {
-			return conn, nil
+			m.pool.observeDial(proxyAddr, time.Since(start))
+			return &proxiedConn{Conn: conn, proxy: proxyAddr}, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		m.pool.ReportFailure(proxyAddr, host)
+		if failed != nil {
+			failed(proxyAddr)
 		}
 		lastErr = err
 	}
@@ -625,6 +666,9 @@ var (
 	defaultManager     *Manager
 )
 
+// Pool returns the manager's proxy pool.
+func (m *Manager) Pool() *Pool { return m.pool }
+
 func Default() *Manager {
 	defaultManagerOnce.Do(func() {
 		defaultManager = NewManager(ConfigFromEnv())
@@ -0,0 +1,362 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"time"
+)
+
+// Proxy scoring. Every proxy that passed the health check starts with the same
+// weight; real traffic then moves it:
+//   - successRate is an EWMA of request outcomes, latency an EWMA of dial and
+//     response times. Selection is weighted by successRate² / (1 + latency in s).
+//   - QuarantineAfter consecutive failures take the proxy out of rotation for
+//     QuarantineBase, doubling on every repeat up to QuarantineMax. A success
+//     resets the streak and the back-off.
+//   - Blocked responses (403/429) only count against the target host, so a
+//     proxy banned by one site keeps serving the others.
+
+const (
+	scoreAlpha         = 0.2
+	initialSuccessRate = 0.75
+	unknownLatency     = time.Second
+	minProxyWeight     = 0.001
+
+	defaultQuarantineAfter     = 3
+	defaultHostQuarantineAfter = 2
+	defaultQuarantineBase      = 30 * time.Second
+	defaultQuarantineMax       = 30 * time.Minute
+)
+
+type backoff struct {
+	streak  int // consecutive failures
+	strikes int // quarantines since the last success
+	until   time.Time
+}
+
+// fail counts one failure and starts a quarantine once the streak reaches after.
+func (b *backoff) fail(now time.Time, after int, base, ceiling time.Duration) {
+	b.streak++
+	if b.streak < after {
+		return
+	}
+	b.streak = 0
+	b.strikes++
+	d := base << min(b.strikes-1, 20)
+	if d <= 0 || d > ceiling {
+		d = ceiling
+	}
+	b.until = now.Add(d)
+}
+
+func (b *backoff) reset() { *b = backoff{} }
+
+func (b *backoff) quarantined(now time.Time) bool { return now.Before(b.until) }
+
+type proxyScore struct {
+	successRate float64
+	latency     time.Duration
+	successes   uint64
+	failures    uint64
+
+	backoff
+	hosts map[string]*backoff
+}
+
+func newProxyScore() *proxyScore {
+	return &proxyScore{successRate: initialSuccessRate}
+}
+
+func (s *proxyScore) weight(now time.Time, host string) float64 {
+	if s == nil {
+		return initialSuccessRate * initialSuccessRate / (1 + unknownLatency.Seconds())
+	}
+	if s.quarantined(now) {
+		return 0
+	}
+	if hb := s.hosts[host]; host != "" && hb != nil && hb.quarantined(now) {
+		return 0
+	}
+	lat := s.latency
+	if lat <= 0 {
+		lat = unknownLatency
+	}
+	return math.Max(s.successRate*s.successRate/(1+lat.Seconds()), minProxyWeight)
+}
+
+func (s *proxyScore) observeLatency(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if s.latency <= 0 {
+		s.latency = d
+		return
+	}
+	s.latency = time.Duration(float64(s.latency)*(1-scoreAlpha) + float64(d)*scoreAlpha)
+}
+
+func (s *proxyScore) host(host string) *backoff {
+	if s.hosts == nil {
+		s.hosts = make(map[string]*backoff)
+	}
+	hb := s.hosts[host]
+	if hb == nil {
+		hb = &backoff{}
+		s.hosts[host] = hb
+	}
+	return hb
+}
+
+// ProxyScore is a snapshot of one proxy's standing in the pool.
+type ProxyScore struct {
+	Addr             string // password redacted
+	Weight           float64
+	SuccessRate      float64
+	Latency          time.Duration
+	Successes        uint64
+	Failures         uint64
+	QuarantinedUntil time.Time
+	BlockedHosts     []string
+}
+
+func (p *Pool) scoreLocked(addr string) *proxyScore {
+	s := p.scores[addr]
+	if s == nil {
+		s = newProxyScore()
+		p.scores[addr] = s
+	}
+	return s
+}
+
+func (p *Pool) quarantineConfig() (after, hostAfter int, base, ceiling time.Duration) {
+	after, hostAfter = p.cfg.QuarantineAfter, defaultHostQuarantineAfter
+	if after <= 0 {
+		after = defaultQuarantineAfter
+	}
+	base, ceiling = p.cfg.QuarantineBase, p.cfg.QuarantineMax
+	if base <= 0 {
+		base = defaultQuarantineBase
+	}
+	if ceiling <= 0 {
+		ceiling = defaultQuarantineMax
+	}
+	return after, hostAfter, base, ceiling
+}
+
+// ReportSuccess records a request through addr that got a usable response
+// from host after latency.
+func (p *Pool) ReportSuccess(addr, host string, latency time.Duration) {
+	if addr == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.scoreLocked(addr)
+	s.successes++
+	s.successRate = s.successRate*(1-scoreAlpha) + scoreAlpha
+	s.observeLatency(latency)
+	s.reset()
+	if hb := s.hosts[host]; hb != nil {
+		hb.reset()
+	}
+}
+
+// ReportFailure records a dial, TLS or transport error through addr. It
+// counts against the proxy as a whole and against host.
+func (p *Pool) ReportFailure(addr, host string) {
+	if addr == "" {
+		return
+	}
+	after, hostAfter, base, ceiling := p.quarantineConfig()
+	now := p.now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.scoreLocked(addr)
+	s.failures++
+	s.successRate *= 1 - scoreAlpha
+	s.fail(now, after, base, ceiling)
+	if host != "" {
+		s.host(host).fail(now, hostAfter, base, ceiling)
+	}
+}
+
+// ReportBlocked records that host refused a request coming through addr
+// (403/429). Only the proxy's standing with host is affected.
+func (p *Pool) ReportBlocked(addr, host string) {
+	if addr == "" || host == "" {
+		return
+	}
+	_, hostAfter, base, ceiling := p.quarantineConfig()
+	now := p.now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scoreLocked(addr).host(host).fail(now, hostAfter, base, ceiling)
+}
+
+func (p *Pool) observeDial(addr string, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scoreLocked(addr).observeLatency(latency)
+}
+
+// Pick returns a proxy for host ("" for any), chosen at random weighted by
+// score. Quarantined proxies are skipped; ok is false when none is left.
+func (p *Pool) Pick(host string) (string, bool) {
+	return p.pick(host, nil)
+}
+
+func (p *Pool) pick(host string, skip map[string]bool) (string, bool) {
+	now := p.now()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.proxies) == 0 {
+		return "", false
+	}
+
+	weights := make([]float64, len(p.proxies))
+	total := 0.0
+	for i, addr := range p.proxies {
+		if skip[addr] {
+			continue
+		}
+		w := p.scores[addr].weight(now, host)
+		weights[i] = w
+		total += w
+	}
+	if total <= 0 {
+		return "", false
+	}
+	r := rand.Float64() * total
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if r < w {
+			return p.proxies[i], true
+		}
+		r -= w
+	}
+	// Rounding: fall back to the last candidate.
+	for i := len(weights) - 1; i >= 0; i-- {
+		if weights[i] > 0 {
+			return p.proxies[i], true
+		}
+	}
+	return "", false
+}
+
+// Scores returns a snapshot of the pool, best proxies first.
+func (p *Pool) Scores() []ProxyScore {
+	now := p.now()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]ProxyScore, 0, len(p.proxies))
+	for _, addr := range p.proxies {
+		s := p.scores[addr]
+		ps := ProxyScore{Addr: redactProxyEntry(addr), Weight: s.weight(now, ""), SuccessRate: initialSuccessRate}
+		if s != nil {
+			ps.SuccessRate = s.successRate
+			ps.Latency = s.latency
+			ps.Successes = s.successes
+			ps.Failures = s.failures
+			if s.quarantined(now) {
+				ps.QuarantinedUntil = s.until
+			}
+			for h, hb := range s.hosts {
+				if hb.quarantined(now) {
+					ps.BlockedHosts = append(ps.BlockedHosts, h)
+				}
+			}
+			sort.Strings(ps.BlockedHosts)
+		}
+		out = append(out, ps)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Weight > out[j].Weight })
+	return out
+}
+
+// pruneScoresLocked drops scores of proxies that left the pool.
+func (p *Pool) pruneScoresLocked() {
+	keep := make(map[string]struct{}, len(p.proxies))
+	for _, addr := range p.proxies {
+		keep[addr] = struct{}{}
+	}
+	for addr := range p.scores {
+		if _, ok := keep[addr]; !ok {
+			delete(p.scores, addr)
+		}
+	}
+}
+
+// proxiedConn remembers which proxy a pooled connection goes through, so the
+// round tripper can attribute the outcome of requests sent over it.
+type proxiedConn struct {
+	net.Conn
+	proxy string
+}
+
+func proxyOfConn(c net.Conn) string {
+	if tc, ok := c.(*tls.Conn); ok {
+		c = tc.NetConn()
+	}
+	if pc, ok := c.(*proxiedConn); ok {
+		return pc.proxy
+	}
+	return ""
+}
+
+// scoringRoundTripper reports the outcome of every request that went through
+// a pool proxy back to the pool. failed, if set, also hears about failures
+// and blocks.
+type scoringRoundTripper struct {
+	next   http.RoundTripper
+	pool   *Pool
+	failed func(proxyAddr string)
+}
+
+func (t *scoringRoundTripper) CloseIdleConnections() { closeIdle(t.next) }
+
+func (t *scoringRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Latency is timed from GotConn: the pool already saw the dial through
+	// observeDial.
+	var proxyAddr string
+	var start time.Time
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			proxyAddr = proxyOfConn(info.Conn)
+			start = time.Now()
+		},
+	}
+	ctx := httptrace.WithClientTrace(req.Context(), trace)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if proxyAddr == "" {
+		return resp, err
+	}
+
+	host := req.URL.Hostname()
+	switch {
+	case err != nil:
+		if req.Context().Err() == nil && !errors.Is(err, context.Canceled) {
+			t.pool.ReportFailure(proxyAddr, host)
+			if t.failed != nil {
+				t.failed(proxyAddr)
+			}
+		}
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		t.pool.ReportBlocked(proxyAddr, host)
+		if t.failed != nil {
+			t.failed(proxyAddr)
+		}
+	default:
+		t.pool.ReportSuccess(proxyAddr, host, time.Since(start))
+	}
+	return resp, err
+}
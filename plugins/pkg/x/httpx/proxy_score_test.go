@@ -0,0 +1,197 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestPool(proxies ...string) (*Pool, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	p := NewPool(Config{})
+	p.proxies = proxies
+	p.now = func() time.Time { return now }
+	return p, &now
+}
+
+func TestPool_QuarantinesWithExponentialCooldown(t *testing.T) {
+	p, now := newTestPool("a:1", "b:1")
+
+	for i := 0; i < 3; i++ {
+		p.ReportFailure("a:1", "")
+	}
+	for i := 0; i < 50; i++ {
+		if got, ok := p.Pick(""); !ok || got != "b:1" {
+			t.Fatalf("pick %d = %q, %v; want b:1 while a:1 is quarantined", i, got, ok)
+		}
+	}
+	if q := p.Scores()[1]; q.Addr != "a:1" || !q.QuarantinedUntil.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected score for a:1: %+v", q)
+	}
+
+	// Failing again right after release doubles the cool-down.
+	*now = now.Add(31 * time.Second)
+	for i := 0; i < 3; i++ {
+		p.ReportFailure("a:1", "")
+	}
+	if q := p.Scores()[1]; !q.QuarantinedUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected 1m cool-down, got until=%v", q.QuarantinedUntil)
+	}
+
+	// A success after release resets the back-off.
+	*now = now.Add(2 * time.Minute)
+	p.ReportSuccess("a:1", "", 100*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		p.ReportFailure("a:1", "")
+	}
+	if q := p.Scores()[1]; !q.QuarantinedUntil.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("expected back-off reset to 30s, got until=%v", q.QuarantinedUntil)
+	}
+
+	p.ReportFailure("b:1", "")
+	p.ReportFailure("b:1", "")
+	p.ReportFailure("b:1", "")
+	if got, ok := p.Pick(""); ok {
+		t.Fatalf("expected no proxy while all are quarantined, got %q", got)
+	}
+}
+
+func TestPool_BlockedHostOnlyAffectsThatHost(t *testing.T) {
+	p, _ := newTestPool("a:1", "b:1")
+
+	p.ReportBlocked("a:1", "www.instagram.com")
+	p.ReportBlocked("a:1", "www.instagram.com")
+
+	for i := 0; i < 50; i++ {
+		if got, _ := p.Pick("www.instagram.com"); got != "b:1" {
+			t.Fatalf("pick for instagram = %q, want b:1", got)
+		}
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		got, _ := p.Pick("www.tiktok.com")
+		seen[got] = true
+	}
+	if !seen["a:1"] {
+		t.Fatalf("a:1 should still serve other hosts")
+	}
+	if s := p.Scores(); len(s[0].BlockedHosts)+len(s[1].BlockedHosts) != 1 {
+		t.Fatalf("expected one blocked host, got %+v", s)
+	}
+}
+
+func TestPool_PrefersFastReliableProxies(t *testing.T) {
+	p, _ := newTestPool("fast:1", "slow:1")
+	for i := 0; i < 10; i++ {
+		p.ReportSuccess("fast:1", "", 100*time.Millisecond)
+		p.ReportSuccess("slow:1", "", 4*time.Second)
+		p.ReportFailure("slow:1", "")
+	}
+
+	fast := 0
+	for i := 0; i < 1000; i++ {
+		if got, _ := p.Pick(""); got == "fast:1" {
+			fast++
+		}
+	}
+	if fast < 800 {
+		t.Fatalf("fast proxy picked %d/1000 times", fast)
+	}
+	if s := p.Scores(); s[0].Addr != "fast:1" {
+		t.Fatalf("expected fast:1 ranked first, got %+v", s)
+	}
+}
+
+func TestPool_UpdateKeepsScoresOfSurvivors(t *testing.T) {
+	p, _ := newTestPool("a:1", "b:1")
+	p.ReportSuccess("a:1", "", time.Second)
+	p.ReportSuccess("b:1", "", time.Second)
+
+	p.mu.Lock()
+	p.proxies = []string{"a:1", "c:1"}
+	p.pruneScoresLocked()
+	p.mu.Unlock()
+
+	if _, ok := p.scores["b:1"]; ok {
+		t.Fatalf("expected b:1 score to be dropped")
+	}
+	if s := p.scores["a:1"]; s == nil || s.successes != 1 {
+		t.Fatalf("expected a:1 score to survive, got %+v", s)
+	}
+}
+
+func TestScoringRoundTripper_AttributesOutcomeToProxy(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, _ := newTestPool("a:1")
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &proxiedConn{Conn: conn, proxy: "a:1"}, nil
+		},
+	}
+	t.Cleanup(tr.CloseIdleConnections)
+	client := &http.Client{Transport: &scoringRoundTripper{next: tr, pool: p}}
+
+	do := func() {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	do()
+	if s := p.scores["a:1"]; s == nil || s.successes != 1 || s.latency <= 0 {
+		t.Fatalf("expected one success with latency, got %+v", s)
+	}
+
+	status = http.StatusTooManyRequests
+	do()
+	do()
+	host := "127.0.0.1"
+	if _, ok := p.Pick(host); ok {
+		t.Fatalf("expected a:1 to be blocked for %s", host)
+	}
+	if _, ok := p.Pick("other.example"); !ok {
+		t.Fatalf("expected a:1 to remain available for other hosts")
+	}
+}
+
+func TestScoringRoundTripper_LatencyExcludesDial(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	p, _ := newTestPool("a:1")
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			time.Sleep(300 * time.Millisecond)
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &proxiedConn{Conn: conn, proxy: "a:1"}, nil
+		},
+	}
+	t.Cleanup(tr.CloseIdleConnections)
+	client := &http.Client{Transport: &scoringRoundTripper{next: tr, pool: p}}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	if s := p.scores["a:1"]; s == nil || s.latency <= 0 || s.latency >= 200*time.Millisecond {
+		t.Fatalf("expected the request latency without the dial, got %+v", s)
+	}
+}
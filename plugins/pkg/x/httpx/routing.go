@@ -0,0 +1,289 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// Route stages. Anything else in Route.Via is a name from Routes.Proxies or a
+// proxy URL (socks5://, http://, https://, optionally with user:pass@).
+const (
+	ViaDirect = "direct"
+	ViaEnv    = "env"
+	ViaPool   = "pool"
+)
+
+// Route sends requests for Hosts through the stages in Via, falling back to
+// the next stage on transport errors.
+//
+// A plain host ("instagram.com") matches itself and its subdomains; patterns
+// with wildcards ("*.s3.*.amazonaws.com") are globs over the whole host; "*"
+// matches everything.
+type Route struct {
+	Hosts []string `json:"hosts"`
+	Via   []string `json:"via"`
+}
+
+// Routes is a per-host routing table. The first matching rule wins; requests
+// no rule matches follow ClientOptions.Mode.
+type Routes struct {
+	Rules   []Route           `json:"rules,omitempty"`
+	Proxies map[string]string `json:"proxies,omitempty"`
+}
+
+func (r Routes) IsZero() bool { return len(r.Rules) == 0 }
+
+// Merge returns r followed by next: r's rules are matched first and r's proxy
+// names shadow next's.
+func (r Routes) Merge(next Routes) Routes {
+	out := Routes{Rules: make([]Route, 0, len(r.Rules)+len(next.Rules))}
+	out.Rules = append(append(out.Rules, r.Rules...), next.Rules...)
+	if len(r.Proxies)+len(next.Proxies) > 0 {
+		out.Proxies = make(map[string]string, len(r.Proxies)+len(next.Proxies))
+		for k, v := range next.Proxies {
+			out.Proxies[k] = v
+		}
+		for k, v := range r.Proxies {
+			out.Proxies[k] = v
+		}
+	}
+	return out
+}
+
+// Validate checks every rule and named proxy. Proxy credentials are redacted
+// from its errors, which end up in config errors and logs.
+func (r Routes) Validate() error {
+	for name, raw := range r.Proxies {
+		if _, err := parseProxyEntry(raw); err != nil {
+			return fmt.Errorf("proxies.%s: %w", name, err)
+		}
+	}
+	for i, rule := range r.Rules {
+		if len(rule.Hosts) == 0 {
+			return fmt.Errorf("rules[%d]: hosts required", i)
+		}
+		for _, h := range rule.Hosts {
+			if _, err := path.Match(strings.ToLower(strings.TrimSpace(h)), ""); err != nil || strings.TrimSpace(h) == "" {
+				return fmt.Errorf("rules[%d]: invalid host pattern %q", i, h)
+			}
+		}
+		if len(rule.Via) == 0 {
+			return fmt.Errorf("rules[%d]: via required", i)
+		}
+		for _, via := range rule.Via {
+			if _, err := r.proxyFor(via); err != nil {
+				return fmt.Errorf("rules[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// proxyFor resolves a non-builtin stage to a proxy entry.
+func (r Routes) proxyFor(via string) (string, error) {
+	via = strings.TrimSpace(via)
+	switch strings.ToLower(via) {
+	case ViaDirect, ViaEnv, ViaPool:
+		return "", nil
+	}
+	raw, ok := r.Proxies[via]
+	if !ok {
+		if !strings.Contains(via, "://") {
+			return "", fmt.Errorf("unknown via %q (expected direct, env, pool, a proxy name or URL)", redactProxyEntry(via))
+		}
+		raw = via
+	}
+	if _, err := parseProxyEntry(raw); err != nil {
+		return "", fmt.Errorf("via %q: %w", redactProxyEntry(via), err)
+	}
+	return raw, nil
+}
+
+// ParseRoutes reads a routing table from JSON (a Routes object or an array of
+// Route) or from the compact form used in env files:
+//
+//	cdninstagram.com,fbcdn.net=direct,pool; *.instagram.com=pool
+func ParseRoutes(raw string) (Routes, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Routes{}, nil
+	}
+
+	var r Routes
+	switch raw[0] {
+	case '{':
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			return Routes{}, err
+		}
+	case '[':
+		if err := json.Unmarshal([]byte(raw), &r.Rules); err != nil {
+			return Routes{}, err
+		}
+	default:
+		for _, part := range strings.FieldsFunc(raw, func(c rune) bool { return c == ';' || c == '\n' }) {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			hosts, via, ok := strings.Cut(part, "=")
+			if !ok {
+				return Routes{}, fmt.Errorf("invalid route %q (expected hosts=via)", redactProxyEntry(part))
+			}
+			r.Rules = append(r.Rules, Route{Hosts: splitRouteList(hosts), Via: splitRouteList(via)})
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return Routes{}, err
+	}
+	return r, nil
+}
+
+func splitRouteList(raw string) []string {
+	parts := strings.FieldsFunc(raw, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' })
+	out := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// RoutesFromEnv reads MEW_HTTP_ROUTES. Plugins set it in their own
+// .env.local to route all of their clients.
+func RoutesFromEnv() (Routes, error) {
+	r, err := ParseRoutes(os.Getenv("MEW_HTTP_ROUTES"))
+	if err != nil {
+		return Routes{}, fmt.Errorf("invalid MEW_HTTP_ROUTES: %w", err)
+	}
+	return r, nil
+}
+
+func matchRouteHost(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return true
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, _ := path.Match(pattern, host)
+		return ok
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+type compiledRoute struct {
+	hosts []string
+	rt    http.RoundTripper
+}
+
+// routingRoundTripper picks a stage chain by request host.
+type routingRoundTripper struct {
+	routes   []compiledRoute
+	fallback http.RoundTripper
+}
+
+func newRoutingRoundTripper(r Routes, base *http.Transport, pool func() http.RoundTripper, fallback http.RoundTripper) (*routingRoundTripper, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	stages := make(map[string]http.RoundTripper)
+	stage := func(via string) (http.RoundTripper, error) {
+		key := strings.TrimSpace(via)
+		if rt, ok := stages[key]; ok {
+			return rt, nil
+		}
+		var rt http.RoundTripper
+		switch strings.ToLower(key) {
+		case ViaDirect:
+			t := base.Clone()
+			t.Proxy = nil
+			rt = t
+		case ViaEnv:
+			t := base.Clone()
+			t.Proxy = http.ProxyFromEnvironment
+			rt = t
+		case ViaPool:
+			rt = pool()
+		default:
+			raw, err := r.proxyFor(key)
+			if err != nil {
+				return nil, err
+			}
+			u, err := parseProxyEntry(raw)
+			if err != nil {
+				return nil, err
+			}
+			t := base.Clone()
+			t.Proxy = http.ProxyURL(u)
+			rt = t
+		}
+		stages[key] = rt
+		return rt, nil
+	}
+
+	out := &routingRoundTripper{fallback: fallback}
+	for _, rule := range r.Rules {
+		chain := &chainRoundTripper{}
+		for _, via := range rule.Via {
+			rt, err := stage(via)
+			if err != nil {
+				return nil, err
+			}
+			chain.stages = append(chain.stages, rt)
+		}
+		out.routes = append(out.routes, compiledRoute{hosts: rule.Hosts, rt: chain})
+	}
+	return out, nil
+}
+
+func (t *routingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	for _, route := range t.routes {
+		for _, pattern := range route.hosts {
+			if matchRouteHost(pattern, host) {
+				return route.rt.RoundTrip(req)
+			}
+		}
+	}
+	return t.fallback.RoundTrip(req)
+}
+
+func (t *routingRoundTripper) CloseIdleConnections() {
+	for _, route := range t.routes {
+		closeIdle(route.rt)
+	}
+	closeIdle(t.fallback)
+}
+
+// chainRoundTripper tries each stage in order until one gets a response.
+type chainRoundTripper struct {
+	stages []http.RoundTripper
+}
+
+func (t *chainRoundTripper) CloseIdleConnections() { closeIdle(t.stages...) }
+
+func (t *chainRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for i, stage := range t.stages {
+		r := req
+		if i > 0 {
+			var err error
+			if r, err = cloneRequestForRetry(req); err != nil {
+				return nil, lastErr
+			}
+		}
+		resp, err := stage.RoundTrip(r)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,182 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SessionOptions configures a Session. The embedded ClientOptions work as in
+// NewClient, except that ModeProxy never falls back to the env proxy or a
+// direct connection; CookieJar gives the session its own jar.
+type SessionOptions struct {
+	ClientOptions
+
+	// UserAgent is set on every request of the session, overriding the
+	// request's own. RandomUserAgent picks one with RandomBrowserUserAgent
+	// when UserAgent is empty.
+	UserAgent       string
+	RandomUserAgent bool
+}
+
+// Session is a client for a sequence of requests that must look like they
+// come from one browser: in ModeProxy every connection goes out through the
+// same pool proxy until that proxy fails (dial or transport error, 403/429),
+// and only then is another one pinned. A request whose pinned proxy fails
+// returns the error instead of leaving through another exit IP; the next one
+// pins a new proxy. Open one per fetch cycle and Close it at the end.
+type Session struct {
+	client    *http.Client
+	userAgent string
+	manager   *Manager
+	pooled    *http.Transport // nil outside ModeProxy
+
+	mu     sync.Mutex
+	proxy  string
+	failed map[string]bool // proxies this session rotated away from
+	// stale is set when the pin changes while a response through the old
+	// proxy may still be open; its connection is dropped before the next
+	// request instead of being reused.
+	stale atomic.Bool
+}
+
+// NewSession opens a session on the default proxy pool.
+func NewSession(opts SessionOptions) (*Session, error) {
+	return newSession(Default(), opts)
+}
+
+func newSession(m *Manager, opts SessionOptions) (*Session, error) {
+	s := &Session{manager: m, userAgent: strings.TrimSpace(opts.UserAgent)}
+	if s.userAgent == "" && opts.RandomUserAgent {
+		s.userAgent = RandomBrowserUserAgent()
+	}
+
+	c, err := newClient(opts.ClientOptions, func(base *http.Transport) http.RoundTripper {
+		base.Proxy = nil
+		base.DialContext = s.dialContext
+		s.pooled = base
+		return &sessionRoundTripper{s: s, next: &scoringRoundTripper{next: base, pool: m.pool, failed: s.unpin}}
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	if s.userAgent != "" {
+		c.Transport = &userAgentRoundTripper{next: c.Transport, userAgent: s.userAgent}
+	}
+	s.client = c
+	return s, nil
+}
+
+// Client returns the session's HTTP client.
+func (s *Session) Client() *http.Client { return s.client }
+
+// UserAgent returns the session's pinned user agent ("" when none).
+func (s *Session) UserAgent() string { return s.userAgent }
+
+// Proxy returns the currently pinned proxy with its password redacted (""
+// before the first pooled dial or outside ModeProxy).
+func (s *Session) Proxy() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proxy == "" {
+		return ""
+	}
+	return redactProxyEntry(s.proxy)
+}
+
+// Close closes the idle connections of every transport behind the session.
+// Call it once the session's responses have been read.
+func (s *Session) Close() { s.client.CloseIdleConnections() }
+
+// Rotate drops the pinned proxy; the next connection pins a new one.
+func (s *Session) Rotate() {
+	s.mu.Lock()
+	s.proxy = ""
+	s.mu.Unlock()
+	s.dropConnections()
+}
+
+func (s *Session) dropConnections() {
+	if s.pooled == nil {
+		return
+	}
+	s.stale.Store(true)
+	s.pooled.CloseIdleConnections()
+}
+
+func (s *Session) choose(host string, tried map[string]bool) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proxy != "" && !tried[s.proxy] {
+		return s.proxy, true
+	}
+	skip := make(map[string]bool, len(tried)+len(s.failed))
+	for addr := range tried {
+		skip[addr] = true
+	}
+	for addr := range s.failed {
+		skip[addr] = true
+	}
+	addr, ok := s.manager.pool.pick(host, skip)
+	if !ok && len(s.failed) > 0 {
+		// Every other proxy is out; give the earlier ones another chance.
+		s.failed = nil
+		addr, ok = s.manager.pool.pick(host, tried)
+	}
+	if ok {
+		s.proxy = addr
+	}
+	return addr, ok
+}
+
+// unpin drops proxyAddr if it is still the pinned proxy, along with the
+// connections open through it.
+func (s *Session) unpin(proxyAddr string) {
+	s.mu.Lock()
+	if s.failed == nil {
+		s.failed = make(map[string]bool)
+	}
+	s.failed[proxyAddr] = true
+	changed := s.proxy == proxyAddr
+	if changed {
+		s.proxy = ""
+	}
+	s.mu.Unlock()
+	if changed {
+		s.dropConnections()
+	}
+}
+
+func (s *Session) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return s.manager.dialWith(ctx, network, addr, s.choose, s.unpin)
+}
+
+type sessionRoundTripper struct {
+	s    *Session
+	next http.RoundTripper
+}
+
+func (t *sessionRoundTripper) CloseIdleConnections() { closeIdle(t.next) }
+
+func (t *sessionRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.s.stale.Swap(false) {
+		t.s.pooled.CloseIdleConnections()
+	}
+	return t.next.RoundTrip(req)
+}
+
+type userAgentRoundTripper struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentRoundTripper) CloseIdleConnections() { closeIdle(t.next) }
+
+func (t *userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r2 := req.Clone(req.Context())
+	r2.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(r2)
+}
@@ -0,0 +1,296 @@
+package httpx
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSOCKS5 is a minimal SOCKS5 CONNECT proxy counting connections. With
+// user set it requires username/password auth.
+type testSOCKS5 struct {
+	ln         net.Listener
+	conns      atomic.Int32
+	user, pass string
+}
+
+func startTestSOCKS5(t *testing.T) *testSOCKS5 {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSOCKS5{ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns.Add(1)
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *testSOCKS5) addr() string { return s.ln.Addr().String() }
+
+func (s *testSOCKS5) serve(c net.Conn) {
+	defer c.Close()
+	buf := make([]byte, 262)
+	// Greeting: VER NMETHODS METHODS...
+	if _, err := io.ReadFull(c, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(c, buf[:buf[1]]); err != nil {
+		return
+	}
+	if s.user != "" {
+		_, _ = c.Write([]byte{5, 2})
+		// VER ULEN UNAME PLEN PASSWD
+		if _, err := io.ReadFull(c, buf[:2]); err != nil {
+			return
+		}
+		user := make([]byte, buf[1])
+		if _, err := io.ReadFull(c, user); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(c, buf[:1]); err != nil {
+			return
+		}
+		pass := make([]byte, buf[0])
+		if _, err := io.ReadFull(c, pass); err != nil {
+			return
+		}
+		if string(user) != s.user || string(pass) != s.pass {
+			_, _ = c.Write([]byte{1, 1})
+			return
+		}
+		_, _ = c.Write([]byte{1, 0})
+	} else {
+		_, _ = c.Write([]byte{5, 0})
+	}
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	if _, err := io.ReadFull(c, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		if _, err := io.ReadFull(c, buf[:4]); err != nil {
+			return
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err := io.ReadFull(c, buf[:1]); err != nil {
+			return
+		}
+		n := int(buf[0])
+		if _, err := io.ReadFull(c, buf[:n]); err != nil {
+			return
+		}
+		host = string(buf[:n])
+	default:
+		return
+	}
+	if _, err := io.ReadFull(c, buf[:2]); err != nil {
+		return
+	}
+	port := binary.BigEndian.Uint16(buf[:2])
+	up, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		_, _ = c.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer up.Close()
+	_, _ = c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go func() { _, _ = io.Copy(up, c) }()
+	_, _ = io.Copy(c, up)
+}
+
+func newTestManager(proxies ...string) *Manager {
+	m := NewManager(Config{})
+	m.once.Do(func() {})
+	m.pool.proxies = proxies
+	return m
+}
+
+func TestSession_PinsOneProxyAndRotatesOnFailure(t *testing.T) {
+	block := atomic.Bool{}
+	var gotUA atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA.Store(r.UserAgent())
+		if block.Load() {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	a, b := startTestSOCKS5(t), startTestSOCKS5(t)
+	m := newTestManager(a.addr(), b.addr())
+
+	sess, err := newSession(m, SessionOptions{
+		ClientOptions: ClientOptions{Mode: ModeProxy, CookieJar: true, Transport: &http.Transport{DisableKeepAlives: true}},
+		UserAgent:     "session-ua",
+	})
+	if err != nil {
+		t.Fatalf("newSession: %v", err)
+	}
+	if sess.Client().Jar == nil {
+		t.Fatalf("expected a session cookie jar")
+	}
+
+	get := func() int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("User-Agent", "caller-ua")
+		resp, err := sess.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for i := 0; i < 6; i++ {
+		get()
+	}
+	first := sess.Proxy()
+	if first == "" {
+		t.Fatalf("expected a pinned proxy")
+	}
+	if a.conns.Load() != 0 && b.conns.Load() != 0 {
+		t.Fatalf("connections spread over both proxies: a=%d b=%d", a.conns.Load(), b.conns.Load())
+	}
+	if ua, _ := gotUA.Load().(string); ua != "session-ua" {
+		t.Fatalf("User-Agent=%q, want session-ua", ua)
+	}
+
+	// A blocked response unpins; the next request goes out elsewhere.
+	block.Store(true)
+	if got := get(); got != http.StatusTooManyRequests {
+		t.Fatalf("status=%d", got)
+	}
+	if sess.Proxy() != "" {
+		t.Fatalf("expected pin to be dropped after 429, still %q", sess.Proxy())
+	}
+	block.Store(false)
+	get()
+	if second := sess.Proxy(); second == "" || second == first {
+		t.Fatalf("expected a new pin, got %q (was %q)", second, first)
+	}
+}
+
+func TestSession_RotatesWhenPinnedProxyDies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	a, b := startTestSOCKS5(t), startTestSOCKS5(t)
+	m := newTestManager(a.addr(), b.addr())
+	sess, err := newSession(m, SessionOptions{
+		ClientOptions: ClientOptions{Mode: ModeProxy, Transport: &http.Transport{DisableKeepAlives: true}},
+	})
+	if err != nil {
+		t.Fatalf("newSession: %v", err)
+	}
+
+	resp, err := sess.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	pinned := sess.Proxy()
+	dead, alive := a, b
+	if pinned == b.addr() {
+		dead, alive = b, a
+	}
+	_ = dead.ln.Close()
+
+	resp, err = sess.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET after proxy death: %v", err)
+	}
+	_ = resp.Body.Close()
+	if sess.Proxy() != alive.addr() {
+		t.Fatalf("pin=%q, want %q", sess.Proxy(), alive.addr())
+	}
+}
+
+func TestSession_DoesNotFallBackWhenPinnedProxyFails(t *testing.T) {
+	t.Setenv("MEW_HTTP_ROUTES", "")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	t.Cleanup(srv.Close)
+
+	a := startTestSOCKS5(t)
+	m := newTestManager(a.addr())
+	sess, err := newSession(m, SessionOptions{
+		ClientOptions: ClientOptions{Mode: ModeProxy, Transport: &http.Transport{DisableKeepAlives: true}},
+	})
+	if err != nil {
+		t.Fatalf("newSession: %v", err)
+	}
+
+	resp, err := sess.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	_ = a.ln.Close()
+
+	if resp, err := sess.Client().Get(srv.URL); err == nil {
+		_ = resp.Body.Close()
+		t.Fatalf("expected the request to fail with the pinned proxy down")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("server saw %d requests, want only the proxied one", n)
+	}
+}
+
+func TestSession_CloseDropsIdleConnections(t *testing.T) {
+	t.Setenv("MEW_HTTP_ROUTES", "")
+	var closed atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, st http.ConnState) {
+		if st == http.StateClosed {
+			closed.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	// Routes and a user agent put wrappers in front of the transports.
+	s, err := NewSession(SessionOptions{
+		ClientOptions: ClientOptions{Mode: ModeDirect, Routes: Routes{Rules: []Route{{Hosts: []string{"127.0.0.1"}, Via: []string{ViaDirect}}}}},
+		UserAgent:     "test-agent",
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	resp, err := s.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	s.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for closed.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected Close to drop the idle connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,257 @@
+// Package imagex holds small image helpers shared by the upload pipeline and
+// the LLM multimodal path: content sniffing, decoding, resizing and encoding.
+//
+// WebP encoding shells out to cwebp (golang.org/x/image only decodes WebP);
+// callers should treat ErrNoWebPEncoder as "fall back to JPEG".
+package imagex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Output formats understood by Encode.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// DefaultQuality is used when Encode gets a quality <= 0.
+const DefaultQuality = 82
+
+var ErrNoWebPEncoder = errors.New("cwebp not found in PATH")
+
+// Sniff returns the content type of data, recognising formats that
+// http.DetectContentType misses (HEIC/AVIF, MP4/MOV/M4A). It returns "" when
+// nothing specific is detected.
+func Sniff(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		switch brand := string(data[8:12]); brand {
+		case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+			return "image/heic"
+		case "avif", "avis":
+			return "image/avif"
+		case "qt  ":
+			return "video/quicktime"
+		case "M4A ", "M4B ":
+			return "audio/mp4"
+		default:
+			return "video/mp4"
+		}
+	}
+	ct := http.DetectContentType(data)
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	switch ct {
+	case "application/octet-stream", "text/plain":
+		return ""
+	}
+	return ct
+}
+
+// Ext returns the preferred filename extension for a content type, or "".
+func Ext(contentType string) string {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = strings.TrimSpace(ct[:i])
+	}
+	switch ct {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	case "image/tiff":
+		return ".tiff"
+	case "image/heic":
+		return ".heic"
+	case "image/avif":
+		return ".avif"
+	case "video/mp4":
+		return ".mp4"
+	case "video/quicktime":
+		return ".mov"
+	case "video/webm":
+		return ".webm"
+	case "audio/mp4":
+		return ".m4a"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/ogg", "application/ogg":
+		return ".ogg"
+	case "application/pdf":
+		return ".pdf"
+	}
+	return ""
+}
+
+// ContentType maps an Encode format to its MIME type.
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	}
+	return ""
+}
+
+// DecodeConfig returns the image dimensions without decoding pixels.
+func DecodeConfig(data []byte) (w, h int, format string, ok bool) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return 0, 0, "", false
+	}
+	return cfg.Width, cfg.Height, format, true
+}
+
+// Decode decodes any registered format (JPEG, PNG, GIF, WebP, BMP, TIFF).
+// Animated GIFs decode to their first frame.
+func Decode(data []byte) (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(data))
+}
+
+// FitSize scales w×h down so the longer side is at most maxDim, keeping the
+// aspect ratio. maxDim <= 0 or an image that already fits is returned as-is.
+func FitSize(w, h, maxDim int) (int, int) {
+	if maxDim <= 0 || (w <= maxDim && h <= maxDim) {
+		return w, h
+	}
+	scale := float64(maxDim) / float64(max(w, h))
+	nw := max(int(math.Round(float64(w)*scale)), 1)
+	nh := max(int(math.Round(float64(h)*scale)), 1)
+	return nw, nh
+}
+
+// Resize scales img down to fit maxDim. Images that already fit are
+// returned unchanged.
+func Resize(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	nw, nh := FitSize(b.Dx(), b.Dy(), maxDim)
+	if nw == b.Dx() && nh == b.Dy() {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// Encode writes img in the given format. quality applies to JPEG and WebP.
+func Encode(img image.Image, format string, quality int) ([]byte, error) {
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+	var buf bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatWebP:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return CWebP(buf.Bytes(), 0, quality)
+	}
+	return nil, fmt.Errorf("unsupported image format: %q", format)
+}
+
+// CWebP converts an encoded image (any format cwebp reads) to WebP, resizing
+// so the longer side is at most maxDim (<= 0 keeps the size).
+func CWebP(data []byte, maxDim, quality int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	cwebpPath, err := exec.LookPath("cwebp")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoWebPEncoder, err)
+	}
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+
+	in, err := os.CreateTemp("", "mew-img-*"+Ext(Sniff(data)))
+	if err != nil {
+		return nil, err
+	}
+	inPath := in.Name()
+	defer func() { _ = os.Remove(inPath) }()
+
+	if _, err := in.Write(data); err != nil {
+		_ = in.Close()
+		return nil, err
+	}
+	if err := in.Close(); err != nil {
+		return nil, err
+	}
+
+	out, err := os.CreateTemp("", "mew-out-*.webp")
+	if err != nil {
+		return nil, err
+	}
+	outPath := out.Name()
+	_ = out.Close()
+	defer func() { _ = os.Remove(outPath) }()
+
+	args := []string{"-quiet", "-metadata", "none", "-q", fmt.Sprint(quality)}
+	if w, h, _, ok := DecodeConfig(data); ok {
+		if nw, nh := FitSize(w, h, maxDim); nw != w || nh != h {
+			args = append(args, "-resize", fmt.Sprint(nw), fmt.Sprint(nh))
+		}
+	}
+	args = append(args, inPath, "-o", outPath)
+
+	cmd := exec.Command(cwebpPath, args...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("cwebp failed: %w: %s", err, stderr.String())
+		}
+		return nil, fmt.Errorf("cwebp failed: %w", err)
+	}
+	return os.ReadFile(outPath)
+}
+
+// flatten paints img over white so transparent areas don't turn black in
+// JPEG output.
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,32 @@
+package imagex
+
+import "testing"
+
+func TestSniff(t *testing.T) {
+	cases := map[string]string{
+		"\x89PNG\r\n\x1a\n0000":          "image/png",
+		"\xff\xd8\xff\xe0":               "image/jpeg",
+		"\x00\x00\x00\x18ftypheic0000":   "image/heic",
+		"\x00\x00\x00\x18ftypisom0000":   "video/mp4",
+		"\x00\x00\x00\x14ftypqt  0000":   "video/quicktime",
+		"RIFF\x00\x00\x00\x00WEBPVP8 ":   "image/webp",
+		"\x00\x01\x02\x03 binary junk..": "",
+	}
+	for in, want := range cases {
+		if got := Sniff([]byte(in)); got != want {
+			t.Errorf("Sniff(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	if w, h := FitSize(2000, 1000, 720); w != 720 || h != 360 {
+		t.Fatalf("got %dx%d", w, h)
+	}
+	if w, h := FitSize(100, 50, 720); w != 100 || h != 50 {
+		t.Fatalf("small images must keep their size, got %dx%d", w, h)
+	}
+	if w, h := FitSize(3000, 1, 300); w != 300 || h != 1 {
+		t.Fatalf("sides must stay >= 1, got %dx%d", w, h)
+	}
+}
@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"context"
+	"strings"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+func isAudioAttachment(a sdkapi.AttachmentRef) bool {
+	ct := strings.ToLower(strings.TrimSpace(a.ContentType))
+	if !strings.HasPrefix(ct, "audio/") {
+		return false
+	}
+	return strings.TrimSpace(a.URL) != "" || strings.TrimSpace(a.Key) != ""
+}
+
+// transcribeAudio downloads and transcribes audio attachments in order.
+// Attachments that are too large or fail to download/transcribe are skipped.
+func transcribeAudio(ctx context.Context, attachments []sdkapi.AttachmentRef, opts BuildUserContentOptions) []string {
+	if opts.Transcribe == nil || opts.Download == nil {
+		return nil
+	}
+	var out []string
+	for _, a := range attachments {
+		if !isAudioAttachment(a) {
+			continue
+		}
+		if a.Size > 0 && a.Size > opts.MaxAudioBytes {
+			continue
+		}
+		data, err := opts.Download(ctx, a, opts.MaxAudioBytes)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		text, err := opts.Transcribe(ctx, a, data)
+		if err != nil {
+			continue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			out = append(out, voiceTranscriptPrefix+text)
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdkapi "mew/plugins/pkg/api"
+)
+
+func TestBuildUserMessageParam_TranscribesAudio(t *testing.T) {
+	atts := []sdkapi.AttachmentRef{
+		{ChannelID: "c1", Filename: "voice", ContentType: "audio/webm", Key: "k1"},
+		{ChannelID: "c1", Filename: "broken", ContentType: "audio/webm", Key: "k2"},
+	}
+	msg, err := BuildUserMessageParam(context.Background(), "alice", "u1", time.Time{}, "voice: 00:03", atts, BuildUserContentOptions{
+		Download: func(ctx context.Context, att sdkapi.AttachmentRef, limit int64) ([]byte, error) {
+			return []byte("audio-" + att.Key), nil
+		},
+		Transcribe: func(ctx context.Context, att sdkapi.AttachmentRef, audio []byte) (string, error) {
+			if att.Key == "k2" {
+				return "", errors.New("upstream failed")
+			}
+			return " 今天吃什么 ", nil
+		},
+		SpeakerMeta: func(username, userID string, sentAt time.Time) string {
+			return "[" + username + "]"
+		},
+		ReplaceTextWithTranscript: true,
+	})
+	if err != nil {
+		t.Fatalf("BuildUserMessageParam: %v", err)
+	}
+	if msg.OfUser == nil {
+		t.Fatalf("expected a user message")
+	}
+	if got := msg.OfUser.Content.OfString.Value; got != "[alice]\nvoice: 今天吃什么" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
+
+func TestBuildUserMessageParam_AudioIgnoredWithoutTranscriber(t *testing.T) {
+	atts := []sdkapi.AttachmentRef{{ContentType: "audio/ogg", Key: "k1"}}
+	msg, err := BuildUserMessageParam(context.Background(), "", "", time.Time{}, "hello", atts, BuildUserContentOptions{})
+	if err != nil {
+		t.Fatalf("BuildUserMessageParam: %v", err)
+	}
+	if got := msg.OfUser.Content.OfString.Value; got != "hello" {
+		t.Fatalf("unexpected content: %q", got)
+	}
+}
@@ -5,4 +5,6 @@ const (
 	defaultImagePrompt              = "请识别图片中的内容，并结合上下文回复。"
 	defaultMaxImageBytes      int64 = 5 * 1024 * 1024
 	defaultMaxTotalImageBytes int64 = 12 * 1024 * 1024
+	defaultMaxAudioBytes      int64 = 10 * 1024 * 1024
+	voiceTranscriptPrefix           = "voice: "
 )
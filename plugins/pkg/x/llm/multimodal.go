@@ -16,6 +16,10 @@ type DownloadFunc func(ctx context.Context, att sdkapi.AttachmentRef, limit int6
 
 type SpeakerMetaFunc func(username, userID string, sentAt time.Time) string
 
+// TranscribeFunc turns downloaded audio into text (e.g. rest
+// MessagesService.Transcribe for voice messages).
+type TranscribeFunc func(ctx context.Context, att sdkapi.AttachmentRef, audio []byte) (string, error)
+
 type BuildUserContentOptions struct {
 	DefaultTextPrompt     string
 	DefaultImagePrompt    string
@@ -24,6 +28,14 @@ type BuildUserContentOptions struct {
 	Download              DownloadFunc
 	KeepEmptyWhenNoImages bool
 	SpeakerMeta           SpeakerMetaFunc
+
+	// Transcribe enables audio attachments; each transcript is added to the
+	// text as a "voice: ..." line. Audio is skipped when nil.
+	Transcribe    TranscribeFunc
+	MaxAudioBytes int64
+	// ReplaceTextWithTranscript drops text once a transcript exists, for
+	// voice messages whose text is only a duration placeholder.
+	ReplaceTextWithTranscript bool
 }
 
 func (o BuildUserContentOptions) withDefaults() BuildUserContentOptions {
@@ -39,6 +51,9 @@ func (o BuildUserContentOptions) withDefaults() BuildUserContentOptions {
 	if o.MaxTotalImageBytes <= 0 {
 		o.MaxTotalImageBytes = defaultMaxTotalImageBytes
 	}
+	if o.MaxAudioBytes <= 0 {
+		o.MaxAudioBytes = defaultMaxAudioBytes
+	}
 	return o
 }
 
@@ -50,6 +65,13 @@ func BuildUserMessageParam(ctx context.Context, speakerUsername, speakerUserID s
 		meta = strings.TrimSpace(opts.SpeakerMeta(speakerUsername, speakerUserID, sentAt))
 	}
 
+	if transcripts := transcribeAudio(ctx, attachments, opts); len(transcripts) > 0 {
+		if opts.ReplaceTextWithTranscript {
+			text = ""
+		}
+		text = strings.TrimSpace(text + "\n" + strings.Join(transcripts, "\n"))
+	}
+
 	images := make([]sdkapi.AttachmentRef, 0, len(attachments))
 	for _, a := range attachments {
 		ct := strings.ToLower(strings.TrimSpace(a.ContentType))
@@ -57,9 +57,65 @@ func CallOpenAIChatCompletion(
 	messages []openaigo.ChatCompletionMessageParamUnion,
 	tools []openaigo.ChatCompletionToolUnionParam,
 ) (*openaigo.ChatCompletion, error) {
+	client, params, err := newChatCompletionRequest(httpClient, cfg, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	return client.Chat.Completions.New(ctx, params)
+}
+
+// StreamOpenAIChatCompletion streams a completion without tools, calling
+// onDelta for every content delta. It returns the full content.
+//
+// Retries only happen before the first delta (SDK-level); once text has been
+// delivered a failure is returned as-is so callers don't duplicate output.
+func StreamOpenAIChatCompletion(
+	ctx context.Context,
+	httpClient *http.Client,
+	cfg OpenAIChatConfig,
+	messages []openaigo.ChatCompletionMessageParamUnion,
+	onDelta func(delta string) error,
+) (string, error) {
+	client, params, err := newChatCompletionRequest(httpClient, cfg, messages, nil)
+	if err != nil {
+		return "", err
+	}
+
+	stream := client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var b strings.Builder
+	for stream.Next() {
+		chunk := stream.Current()
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		if delta == "" {
+			continue
+		}
+		b.WriteString(delta)
+		if onDelta != nil {
+			if err := onDelta(delta); err != nil {
+				return b.String(), err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return b.String(), err
+	}
+	return b.String(), nil
+}
+
+func newChatCompletionRequest(
+	httpClient *http.Client,
+	cfg OpenAIChatConfig,
+	messages []openaigo.ChatCompletionMessageParamUnion,
+	tools []openaigo.ChatCompletionToolUnionParam,
+) (openaigo.Client, openaigo.ChatCompletionNewParams, error) {
 	cfg = cfg.withDefaults()
 	if strings.TrimSpace(cfg.APIKey) == "" {
-		return nil, fmt.Errorf("api key is required")
+		return openaigo.Client{}, openaigo.ChatCompletionNewParams{}, fmt.Errorf("api key is required")
 	}
 	if httpClient == nil {
 		httpClient = NewHTTPClient()
@@ -80,6 +136,5 @@ func CallOpenAIChatCompletion(
 	if len(tools) > 0 {
 		params.Tools = tools
 	}
-
-	return client.Chat.Completions.New(ctx, params)
+	return client, params, nil
 }
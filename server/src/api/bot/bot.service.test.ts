@@ -153,12 +153,12 @@ describe('bot.service', () => {
   it('bootstrapBots returns bots with tokens', async () => {
     vi.mocked(botRepository.findByServiceTypeWithToken).mockResolvedValue([
       // Legacy-style bot (plaintext token, no accessTokenEnc) should still be bootstrappable.
-      { _id: 'b1', name: 'Bot1', config: '{}', accessToken: 't'.repeat(32), accessTokenEnc: '', serviceType: 'rss-fetcher', dmEnabled: false } as any,
+      { _id: 'b1', name: 'Bot1', config: '{}', accessToken: 't'.repeat(32), accessTokenEnc: '', serviceType: 'rss-fetcher', dmEnabled: false, ownerId: 'u-owner' } as any,
     ]);
 
     const result = await botService.bootstrapBots('rss-fetcher');
     expect(result).toEqual([
-      expect.objectContaining({ _id: 'b1', serviceType: 'rss-fetcher', accessToken: 't'.repeat(32) }),
+      expect.objectContaining({ _id: 'b1', serviceType: 'rss-fetcher', accessToken: 't'.repeat(32), ownerId: 'u-owner' }),
     ]);
   });
 
@@ -264,6 +264,7 @@ export const bootstrapBots = async (serviceType: string) => {
       accessToken: await getRawAccessToken(bot),
       serviceType: normalizeServiceType(bot.serviceType),
       dmEnabled: bot.dmEnabled,
+      ownerId: bot.ownerId ? String(bot.ownerId) : undefined,
     }))
   );
 };
@@ -286,6 +287,7 @@ export const bootstrapBotById = async (botId: string, expectedServiceType?: stri
     accessToken: await getRawAccessToken(bot as any),
     serviceType: effectiveServiceType,
     dmEnabled: bot.dmEnabled,
+    ownerId: bot.ownerId ? String(bot.ownerId) : undefined,
   };
 };
 
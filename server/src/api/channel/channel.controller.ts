@@ -112,3 +112,14 @@ export const ackChannelHandler = asyncHandler(async (req: Request, res: Response
 
   res.status(204).send();
 });
+
+export const getReadStateHandler = asyncHandler(async (req: Request, res: Response) => {
+  if (!req.user) {
+    throw new UnauthorizedError('Not authenticated');
+  }
+  const { channelId } = req.params;
+
+  const readState = await readStateService.getReadState(req.user.id, channelId);
+
+  res.status(200).json(readState);
+});
@@ -8,7 +8,7 @@ const router = Router();
 // Protect all DM channel routes
 router.use(protect);
 
-import { ackChannelHandler } from './channel.controller';
+import { ackChannelHandler, getReadStateHandler } from './channel.controller';
 import { ackChannelSchema, updateMyChannelNotificationSettingsSchema } from './channel.validation';
 import validate from '../../middleware/validate';
 import { searchMessagesSchema } from '../search/search.validation';
@@ -26,6 +26,7 @@ router.use('/:channelId/messages', messageRoutes);
 
 router.get('/:channelId/search', validate(searchMessagesSchema), searchChannelMessagesHandler);
 router.post('/:channelId/ack', validate(ackChannelSchema), ackChannelHandler);
+router.get('/:channelId/read-state', getReadStateHandler);
 
 router.get('/:channelId/notification-settings', asyncHandler(async (req, res) => {
   if (!req.user) throw new UnauthorizedError('Not authenticated');
@@ -33,11 +33,18 @@ export const searchDmMessagesHandler = asyncHandler(
     const limit = Number((req.query as any)?.limit ?? 20);
     const page = Number((req.query as any)?.page ?? 1);
 
+    const { authorId, type, hasAttachment, after, before } = req.query as any;
+
     const result = await searchMessagesInChannel({
       channelId,
       query: q,
       limit,
       page,
+      authorId,
+      type,
+      hasAttachment,
+      after,
+      before,
     });
 
     res.json(result);
@@ -64,4 +64,14 @@ describe('ReadState Service', () => {
     await expect(readStateService.ackChannel(outsider._id.toString(), testDmChannel._id.toString(), messageId))
       .rejects.toThrow('You do not have access to this DM channel.');
   });
-});
\ No newline at end of file
+
+  it('should return the last read message id, or null when never acked', async () => {
+    const empty = await readStateService.getReadState(testUser._id.toString(), testChannel._id.toString());
+    expect(empty).toEqual({ lastReadMessageId: null });
+
+    const messageId = new mongoose.Types.ObjectId().toHexString();
+    await readStateService.ackChannel(testUser._id.toString(), testChannel._id.toString(), messageId);
+    const state = await readStateService.getReadState(testUser._id.toString(), testChannel._id.toString());
+    expect(state).toEqual({ lastReadMessageId: messageId });
+  });
+});
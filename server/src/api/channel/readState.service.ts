@@ -30,6 +30,11 @@ const readStateService = {
       { upsert: true }
     );
   },
+
+  async getReadState(userId: string, channelId: string): Promise<{ lastReadMessageId: string | null }> {
+    const readState = await ChannelReadState.findOne({ userId, channelId }).select('lastReadMessageId').lean();
+    return { lastReadMessageId: readState?.lastReadMessageId ? readState.lastReadMessageId.toString() : null };
+  },
 };
 
 export default readStateService;
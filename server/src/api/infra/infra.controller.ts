@@ -1,10 +1,45 @@
 import { Request, Response } from 'express';
 import asyncHandler from '../../utils/asyncHandler';
-import ServiceTypeModel from './serviceType.model';
+import ServiceTypeModel, { IServiceTypeCommand } from './serviceType.model';
 import { infraRegistry } from '../../infra/infraRegistry';
 import Bot from '../bot/bot.model';
 
 const RESERVED_SERVICE_TYPES = new Set(['sdk']);
+const MAX_SERVICE_COMMANDS = 100;
+
+const asTrimmedString = (v: unknown) => (typeof v === 'string' ? v.trim() : '');
+
+// Bots declare slash commands at registration so clients can offer autocomplete.
+// Malformed entries are dropped rather than rejecting the whole registration.
+function sanitizeCommands(raw: unknown): IServiceTypeCommand[] {
+  if (!Array.isArray(raw)) return [];
+  const out: IServiceTypeCommand[] = [];
+  for (const item of raw.slice(0, MAX_SERVICE_COMMANDS)) {
+    if (!item || typeof item !== 'object') continue;
+    const cmd = item as any;
+    const name = asTrimmedString(cmd.name).toLowerCase();
+    if (!name) continue;
+    out.push({
+      name,
+      aliases: Array.isArray(cmd.aliases) ? cmd.aliases.map(asTrimmedString).filter(Boolean) : [],
+      description: asTrimmedString(cmd.description),
+      usage: asTrimmedString(cmd.usage),
+      args: Array.isArray(cmd.args)
+        ? cmd.args
+            .filter((a: any) => a && typeof a === 'object' && asTrimmedString(a.name))
+            .map((a: any) => ({
+              name: asTrimmedString(a.name),
+              type: asTrimmedString(a.type) || 'string',
+              required: a.required === true,
+              description: asTrimmedString(a.description),
+            }))
+        : [],
+      dmOnly: cmd.dmOnly === true,
+      ownerOnly: cmd.ownerOnly === true,
+    });
+  }
+  return out;
+}
 
 export const getAvailableServicesHandler = asyncHandler(async (_req: Request, res: Response) => {
   const includeOfflineRaw = String((_req.query as any)?.includeOffline || '').trim().toLowerCase();
@@ -12,7 +47,7 @@ export const getAvailableServicesHandler = asyncHandler(async (_req: Request, re
 
   const types = await ServiceTypeModel.find(
     { name: { $nin: Array.from(RESERVED_SERVICE_TYPES) } },
-    { name: 1, serverName: 1, icon: 1, description: 1, configTemplate: 1 }
+    { name: 1, serverName: 1, icon: 1, description: 1, configTemplate: 1, commands: 1 }
   ).sort({ name: 1 });
   const onlineCounts = infraRegistry.getOnlineCounts();
 
@@ -25,6 +60,7 @@ export const getAvailableServicesHandler = asyncHandler(async (_req: Request, re
       icon: (t as any).icon || '',
       description: (t as any).description || '',
       configTemplate: (t as any).configTemplate || '',
+      commands: (t as any).commands || [],
       online: connections > 0,
       connections,
     };
@@ -59,9 +95,11 @@ export const registerServiceTypeHandler = asyncHandler(async (req: Request, res:
     }
   }
 
+  const commands = sanitizeCommands((req.body as any)?.commands);
+
   await ServiceTypeModel.updateOne(
     { name: serviceType },
-    { $set: { name: serviceType, serverName, icon, description, configTemplate, lastSeenAt: new Date() } },
+    { $set: { name: serviceType, serverName, icon, description, configTemplate, commands, lastSeenAt: new Date() } },
     { upsert: true }
   );
 
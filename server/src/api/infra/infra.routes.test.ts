@@ -84,6 +84,47 @@ describe('Infra routes (/api/infra)', () => {
     );
   });
 
+  it('stores declared slash commands and lists them', async () => {
+    const registerRes = await request(app)
+      .post('/api/infra/service-types/register')
+      .set('X-Mew-Admin-Secret', process.env.MEW_ADMIN_SECRET!)
+      .send({
+        serviceType: 'test-agent',
+        commands: [
+          {
+            name: 'Echo',
+            usage: '/echo <text...>',
+            args: [{ name: 'text', type: 'rest', required: true }, { type: 'int' }],
+            dmOnly: true,
+          },
+          { description: 'missing name is dropped' },
+        ],
+      });
+
+    expect(registerRes.statusCode).toBe(200);
+
+    const userData = { email: 'infra-user5@example.com', username: 'infrauser5', password: 'password123' };
+    await request(app).post('/api/auth/register').send(userData);
+    const loginRes = await request(app).post('/api/auth/login').send({ email: userData.email, password: userData.password });
+    const token = loginRes.body.token;
+
+    const listRes = await request(app)
+      .get('/api/infra/available-services?includeOffline=1')
+      .set('Authorization', `Bearer ${token}`);
+
+    expect(listRes.statusCode).toBe(200);
+    const svc = listRes.body.services.find((s: any) => s.serviceType === 'test-agent');
+    expect(svc.commands).toEqual([
+      expect.objectContaining({
+        name: 'echo',
+        usage: '/echo <text...>',
+        dmOnly: true,
+        ownerOnly: false,
+        args: [expect.objectContaining({ name: 'text', type: 'rest', required: true })],
+      }),
+    ]);
+  });
+
   it('rejects reserved service types', async () => {
     const registerRes = await request(app)
       .post('/api/infra/service-types/register')
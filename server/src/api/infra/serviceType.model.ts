@@ -1,16 +1,57 @@
 import mongoose, { Schema, Document } from 'mongoose';
 
+export interface IServiceTypeCommandArg {
+  name: string;
+  type: string;
+  required?: boolean;
+  description?: string;
+}
+
+export interface IServiceTypeCommand {
+  name: string;
+  aliases?: string[];
+  description?: string;
+  usage?: string;
+  args?: IServiceTypeCommandArg[];
+  dmOnly?: boolean;
+  ownerOnly?: boolean;
+}
+
 export interface IServiceType extends Document {
   name: string;
   serverName?: string;
   icon?: string;
   description?: string;
   configTemplate?: string;
+  commands?: IServiceTypeCommand[];
   lastSeenAt?: Date;
   createdAt: Date;
   updatedAt: Date;
 }
 
+const ServiceTypeCommandArgSchema = new Schema(
+  {
+    name: { type: String, required: true },
+    type: { type: String, default: 'string' },
+    required: { type: Boolean, default: false },
+    description: { type: String, default: '' },
+  },
+  { _id: false }
+);
+
+const ServiceTypeCommandSchema = new Schema(
+  {
+    name: { type: String, required: true },
+    aliases: { type: [String], default: [] },
+    description: { type: String, default: '' },
+    usage: { type: String, default: '' },
+    args: { type: [ServiceTypeCommandArgSchema], default: [] },
+    dmOnly: { type: Boolean, default: false },
+    ownerOnly: { type: Boolean, default: false },
+  },
+  { _id: false }
+);
+
 const ServiceTypeSchema: Schema = new Schema(
   {
     name: { type: String, required: true, unique: true, index: true },
@@ -18,6 +59,7 @@ const ServiceTypeSchema: Schema = new Schema(
     icon: { type: String, default: '' },
     description: { type: String, default: '' },
     configTemplate: { type: String, default: '' },
+    commands: { type: [ServiceTypeCommandSchema], default: [] },
     lastSeenAt: { type: Date },
   },
   { timestamps: true }
@@ -104,7 +104,7 @@ describe('message.controller', () => {
 
     await updateMessageHandler(req, res, next);
 
-    expect(messageService.updateMessage).toHaveBeenCalledWith('m1', 'u1', 'x');
+    expect(messageService.updateMessage).toHaveBeenCalledWith('m1', 'u1', 'x', undefined);
     expect(res.status).toHaveBeenCalledWith(200);
   });
 
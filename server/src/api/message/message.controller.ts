@@ -47,12 +47,13 @@ export const updateMessageHandler = asyncHandler(async (req: Request, res: Respo
     throw new UnauthorizedError('Not authenticated');
   }
 
-  const { content } = updateMessageSchema.parse(req).body;
+  const { content, payload } = updateMessageSchema.parse(req).body;
 
   const updatedMessage = await messageService.updateMessage(
     req.params.messageId,
     req.user.id,
-    content
+    content,
+    payload
   );
 
   res.status(200).json(updatedMessage);
@@ -6,6 +6,7 @@ import Channel from '../channel/channel.model';
 import User from '../user/user.model';
 import Server from '../server/server.model';
 import Message from './message.model';
+import { BadRequestError } from '../../utils/errors';
 
 // Mock the mention service
 vi.mock('./mention.service', () => ({
@@ -91,6 +92,73 @@ describe('Message Service', () => {
         const dbMessage = await Message.findById(message._id);
         expect(dbMessage.mentions.map(String)).toEqual(newMentionIds.map(String));
     });
+
+    it('should update only the payload of a card message when content is omitted', async () => {
+        const card = await Message.create({
+          content: 'Original',
+          channelId: testChannel._id,
+          authorId: testUser._id,
+          type: 'app/x-jpdict-card',
+          payload: { content: 'thinking' },
+        });
+
+        await updateMessage(card._id.toString(), testUser._id.toString(), undefined, { content: 'card body' });
+
+        expect(mentionService.processMentions).not.toHaveBeenCalled();
+        const dbMessage = await Message.findById(card._id);
+        expect(dbMessage.content).toBe('Original');
+        expect(dbMessage.payload).toEqual({ content: 'card body' });
+        expect(dbMessage.editedAt).toBeTruthy();
+    });
+
+    it('should update content and payload of a claude-code card together', async () => {
+        const card = await Message.create({
+          content: '⏳ 处理中…',
+          channelId: testChannel._id,
+          authorId: testUser._id,
+          type: 'app/x-claudecode-card',
+          payload: { content: '⏳ 处理中…' },
+        });
+
+        await updateMessage(card._id.toString(), testUser._id.toString(), 'final answer', { content: 'final answer' });
+
+        const dbMessage = await Message.findById(card._id);
+        expect(dbMessage.content).toBe('final answer');
+        expect(dbMessage.payload).toEqual({ content: 'final answer' });
+        expect(dbMessage.editedAt).toBeTruthy();
+    });
+
+    it('should reject payload edits on non-card messages and invalid card payloads', async () => {
+        await expect(
+          updateMessage(message._id.toString(), testUser._id.toString(), undefined, { content: 'x' })
+        ).rejects.toThrow(BadRequestError);
+
+        const voice = await Message.create({
+          channelId: testChannel._id,
+          authorId: testUser._id,
+          type: 'message/voice',
+          payload: { voice: { key: 'v.webm', contentType: 'audio/webm', size: 10 } },
+        });
+        await expect(
+          updateMessage(voice._id.toString(), testUser._id.toString(), undefined, { voice: { key: 'other.webm' } })
+        ).rejects.toThrow(BadRequestError);
+
+        const card = await Message.create({
+          channelId: testChannel._id,
+          authorId: testUser._id,
+          type: 'app/x-jpdict-card',
+          payload: { content: 'thinking' },
+        });
+        await expect(
+          updateMessage(card._id.toString(), testUser._id.toString(), undefined, { content: ' ' })
+        ).rejects.toThrow(BadRequestError);
+        await expect(
+          updateMessage(card._id.toString(), testUser._id.toString(), undefined, { content: 'ok', s3_image: 'k' })
+        ).rejects.toThrow(BadRequestError);
+
+        const dbMessage = await Message.findById(card._id);
+        expect(dbMessage.payload).toEqual({ content: 'thinking' });
+    });
   });
 
 });
@@ -1,4 +1,4 @@
-import { IAttachment, IEmbed, IMessage } from './message.model';
+import { IAttachment, IEmbed, IMessage, IMessagePayload } from './message.model';
 import { BadRequestError, ForbiddenError, NotFoundError } from '../../utils/errors';
 import { socketManager } from '../../gateway/events';
 import { extractFirstUrl, getLinkPreviewWithSafety } from '../metadata/metadata.service';
@@ -16,6 +16,7 @@ import UserSticker from '../userSticker/userSticker.model';
 import BotModel from '../bot/bot.model';
 import { transcribeVoiceFileToText } from '../stt/stt.service';
 import { DM_PERMISSIONS, Permission } from '../../constants/permissions';
+import { editableCardPayloadSchemas } from './message.validation';
 
 // Convert stored attachment keys into client-consumable URLs.
 function hydrateAttachmentUrls<T extends { attachments?: IAttachment[] }>(messageObject: T): T {
@@ -636,7 +637,8 @@ export const getMessageById = async (messageId: string) => {
   export const updateMessage = async (
     messageId: string,
     userId: string,
-    content: string
+    content?: string,
+    payload?: Record<string, any>
   ) => {
     await checkMessagePermissions(messageId, userId, 'update');
     const message = await getMessageById(messageId);
@@ -646,15 +648,31 @@ export const getMessageById = async (messageId: string) => {
       throw new NotFoundError('Channel not found');
     }
 
-    const validatedMentions = await mentionService.processMentions(
-      content,
-      message.channelId.toString(),
-      userId
-    );
+    if (content !== undefined) {
+      const validatedMentions = await mentionService.processMentions(
+        content,
+        message.channelId.toString(),
+        userId
+      );
 
-    message.content = content;
+      message.content = content;
+      message.mentions = validatedMentions as mongoose.Types.ObjectId[]; // Update mentions field
+    }
+    if (payload !== undefined) {
+      // Only card messages that render from their payload can be edited in place; voice, sticker
+      // and forward payloads reference stored objects and stay immutable.
+      const schema = editableCardPayloadSchemas[message.type ?? ''];
+      if (!schema) {
+        throw new BadRequestError(`Payload of ${message.type || 'message/default'} messages cannot be edited`);
+      }
+      const parsed = schema.safeParse(payload);
+      if (!parsed.success) {
+        throw new BadRequestError(`Invalid ${message.type} payload: ${parsed.error.issues[0]?.message ?? 'invalid'}`);
+      }
+      message.payload = parsed.data as IMessagePayload;
+      message.markModified('payload');
+    }
     message.editedAt = new Date();
-    message.mentions = validatedMentions as mongoose.Types.ObjectId[]; // Update mentions field
     await messageRepository.save(message);
 
     const populatedMessage = await message.populate('authorId', 'username discriminator avatarUrl isBot');
@@ -1,5 +1,10 @@
 import { describe, expect, it } from 'vitest';
-import { createMessageSchema, getMessagesSchema, updateMessageSchema } from './message.validation';
+import {
+  createMessageSchema,
+  editableCardPayloadSchemas,
+  getMessagesSchema,
+  updateMessageSchema,
+} from './message.validation';
 
 describe('message.validation', () => {
   it('getMessagesSchema defaults limit to 50', () => {
@@ -105,4 +110,25 @@ describe('message.validation', () => {
     const parsed = updateMessageSchema.parse({ body: { content: 'ok' } });
     expect(parsed.body.content).toBe('ok');
   });
+
+  it('updateMessageSchema accepts payload-only edits and rejects empty bodies', () => {
+    const parsed = updateMessageSchema.parse({ body: { payload: { content: 'card' } } });
+    expect(parsed.body.payload).toEqual({ content: 'card' });
+    expect(() => updateMessageSchema.parse({ body: {} })).toThrow();
+  });
+
+  it('editableCardPayloadSchemas only covers cards rendered from payload', () => {
+    expect(editableCardPayloadSchemas['message/voice']).toBeUndefined();
+    expect(editableCardPayloadSchemas['app/x-forward-card']).toBeUndefined();
+
+    const jpdict = editableCardPayloadSchemas['app/x-jpdict-card'];
+    expect(jpdict.safeParse({ content: 'ok', card_version: 1 }).success).toBe(true);
+    expect(jpdict.safeParse({ content: '' }).success).toBe(false);
+    expect(jpdict.safeParse({ content: 'ok', extra: true }).success).toBe(false);
+
+    const claudecode = editableCardPayloadSchemas['app/x-claudecode-card'];
+    expect(claudecode.safeParse({ content: 'ok' }).success).toBe(true);
+    expect(claudecode.safeParse({ content: ' ' }).success).toBe(false);
+    expect(claudecode.safeParse({ content: 'ok', card_version: 1 }).success).toBe(false);
+  });
 });
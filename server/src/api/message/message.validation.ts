@@ -64,8 +64,31 @@ export const createMessageSchema = z.object({
     ),
 });
 
+// Card types whose payload may be replaced by PATCH, keyed by message type. Keep in sync with the
+// typed cards in plugins/pkg/api/cards.
+export const editableCardPayloadSchemas: Record<string, z.ZodType<Record<string, any>>> = {
+  'app/x-jpdict-card': z
+    .object({
+      content: z.string().trim().min(1, 'content is required'),
+      card_version: z.number().int().positive().optional(),
+    })
+    .strict(),
+  'app/x-claudecode-card': z
+    .object({
+      content: z.string().trim().min(1, 'content is required'),
+    })
+    .strict(),
+};
+
 export const updateMessageSchema = z.object({
-  body: z.object({
-    content: z.string().min(1, 'Content is required'),
-  }),
+  body: z
+    .object({
+      content: z.string().min(1, 'Content is required').optional(),
+      // Card messages (e.g. app/x-jpdict-card) render from payload, so bots editing them in place send both.
+      // The service checks the payload against editableCardPayloadSchemas for the message's type.
+      payload: z.record(z.string(), z.any()).optional(),
+    })
+    .refine((data) => data.content !== undefined || data.payload !== undefined, {
+      message: 'Content or payload is required',
+    }),
 });
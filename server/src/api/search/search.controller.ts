@@ -10,7 +10,12 @@ export const searchMessagesHandler = asyncHandler(
       channelId,
       limit,
       page,
-    } = req.query as { [key: string]: string };
+      authorId,
+      type,
+      hasAttachment,
+      after,
+      before,
+    } = req.query as any;
 
     const result = await searchMessagesInServer({
       serverId,
@@ -18,6 +23,11 @@ export const searchMessagesHandler = asyncHandler(
       channelId,
       limit: limit ? parseInt(limit, 10) : undefined,
       page: page ? parseInt(page, 10) : undefined,
+      authorId,
+      type,
+      hasAttachment,
+      after,
+      before,
     });
 
     res.status(200).json(result);
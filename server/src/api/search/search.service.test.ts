@@ -118,4 +118,41 @@ describe('search.service searchMessagesInServer', () => {
     expect(result.messages[0].attachments[0].url).toBe('http://cdn.local/a.png');
     expect(result.messages[0].attachments[1].url).toBe('http://already.full/url.png');
   });
+
+  it('applies author, type, attachment and date filters', async () => {
+    vi.mocked((Channel as any).find).mockReturnValue({
+      select: vi.fn().mockReturnValue({
+        lean: vi.fn().mockResolvedValue([{ _id: 'c1' }]),
+      }),
+    } as any);
+    vi.mocked((Message as any).countDocuments).mockResolvedValue(0);
+    vi.mocked((Message as any).find).mockReturnValue({
+      populate: vi.fn().mockReturnThis(),
+      sort: vi.fn().mockReturnThis(),
+      limit: vi.fn().mockReturnThis(),
+      skip: vi.fn().mockReturnThis(),
+      lean: vi.fn().mockResolvedValue([]),
+    } as any);
+
+    const after = new Date('2024-01-01T00:00:00Z');
+    const before = new Date('2024-02-01T00:00:00Z');
+    await searchMessagesInServer({
+      serverId: 's1',
+      query: 'cats',
+      authorId: 'u1',
+      type: 'message/voice',
+      hasAttachment: true,
+      after,
+      before,
+    });
+
+    expect((Message as any).countDocuments).toHaveBeenCalledWith(
+      expect.objectContaining({
+        authorId: 'u1',
+        type: 'message/voice',
+        'attachments.0': { $exists: true },
+        createdAt: { $gt: after, $lt: before },
+      })
+    );
+  });
 });
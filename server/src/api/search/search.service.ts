@@ -5,7 +5,15 @@ import { getS3PublicUrl } from '../../utils/s3';
 
 const escapeRegex = (input: string): string => input.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
 
-interface SearchMessagesParams {
+export interface SearchFilters {
+  authorId?: string;
+  type?: string;
+  hasAttachment?: boolean;
+  after?: Date;
+  before?: Date;
+}
+
+interface SearchMessagesParams extends SearchFilters {
   serverId: string;
   query: string;
   channelId?: string;
@@ -13,13 +21,34 @@ interface SearchMessagesParams {
   page?: number;
 }
 
-interface SearchMessagesInChannelParams {
+interface SearchMessagesInChannelParams extends SearchFilters {
   channelId: string;
   query: string;
   limit?: number;
   page?: number;
 }
 
+const applySearchFilters = (matchQuery: any, filters: SearchFilters) => {
+  if (filters.authorId) {
+    matchQuery.authorId = filters.authorId;
+  }
+  if (filters.type) {
+    matchQuery.type = filters.type;
+  }
+  if (filters.hasAttachment === true) {
+    matchQuery['attachments.0'] = { $exists: true };
+  } else if (filters.hasAttachment === false) {
+    matchQuery['attachments.0'] = { $exists: false };
+  }
+  if (filters.after || filters.before) {
+    matchQuery.createdAt = {
+      ...(filters.after ? { $gt: filters.after } : {}),
+      ...(filters.before ? { $lt: filters.before } : {}),
+    };
+  }
+  return matchQuery;
+};
+
 const normalizeMessageForClient = (message: any) => {
   if (message?.retractedAt) {
     return {
@@ -67,6 +96,7 @@ export const searchMessagesInServer = async ({
   channelId,
   limit = 20,
   page = 1,
+  ...filters
 }: SearchMessagesParams) => {
   const trimmedQuery = (query || '').trim();
   const safeNeedle = escapeRegex(trimmedQuery);
@@ -88,6 +118,7 @@ export const searchMessagesInServer = async ({
   if (channelId) {
     matchQuery.channelId = channelId;
   }
+  applySearchFilters(matchQuery, filters);
 
   const [total, messages] = await Promise.all([
     Message.countDocuments(matchQuery),
@@ -115,6 +146,7 @@ export const searchMessagesInChannel = async ({
   query,
   limit = 20,
   page = 1,
+  ...filters
 }: SearchMessagesInChannelParams) => {
   const trimmedQuery = (query || '').trim();
   const safeNeedle = escapeRegex(trimmedQuery);
@@ -124,6 +156,7 @@ export const searchMessagesInChannel = async ({
     channelId,
     retractedAt: null,
   };
+  applySearchFilters(matchQuery, filters);
 
   const [total, messages] = await Promise.all([
     Message.countDocuments(matchQuery),
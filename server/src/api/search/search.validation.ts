@@ -1,9 +1,16 @@
 import { z } from 'zod';
 
+const booleanQuery = z.enum(['true', 'false']).transform((value) => value === 'true');
+
 export const searchMessagesSchema = z.object({
   query: z.object({
     q: z.string().trim().min(1, 'Search query (q) cannot be empty').max(200, 'Search query (q) is too long'),
     channelId: z.string().optional(),
+    authorId: z.string().trim().min(1).optional(),
+    type: z.string().trim().min(1).optional(),
+    hasAttachment: booleanQuery.optional(),
+    after: z.coerce.date().optional(),
+    before: z.coerce.date().optional(),
     limit: z.coerce.number().int().min(1).max(50).optional().default(20),
     page: z.coerce.number().int().min(1).optional().default(1),
   }),
@@ -0,0 +1,180 @@
+import { Request, Response } from 'express';
+import asyncHandler from '../../utils/asyncHandler';
+import { BadRequestError, NotFoundError } from '../../utils/errors';
+import { nanoid } from 'nanoid';
+import { MAX_UPLOAD_BYTES } from '../../constants/upload';
+import MultipartUpload from './multipartUpload.model';
+
+// Resumable multipart uploads. Mounted under both /api/channels/:channelId/uploads
+// and /api/webhooks/:webhookId/:token, so clients can PUT large files in parts,
+// retry a single part, and resume after a restart by listing what already landed.
+// Every call after create must come from the owner that started the upload.
+
+// S3 requires every part but the last to be at least 5 MiB and allows 10,000 parts.
+const MIN_PART_BYTES = 8 * 1024 * 1024;
+const MAX_PARTS = 10_000;
+const MAX_PARTS_PER_SIGN = 100;
+// How long an unfinished upload can be resumed.
+const UPLOAD_TTL_MS = 7 * 24 * 60 * 60 * 1000;
+
+const KEY_PATTERN = /^[A-Za-z0-9_-]+(\.[A-Za-z0-9]{1,16})?$/;
+
+export const multipartPartSize = (size: number): number => {
+  const mib = 1024 * 1024;
+  const needed = Math.ceil(size / MAX_PARTS / mib) * mib;
+  return Math.max(MIN_PART_BYTES, needed);
+};
+
+const safeExt = (filename: string): string => {
+  const idx = filename.lastIndexOf('.');
+  if (idx < 0) return '';
+  const ext = filename.slice(idx + 1);
+  return /^[A-Za-z0-9]{1,16}$/.test(ext) ? `.${ext}` : '';
+};
+
+const readUploadRef = (source: any) => {
+  const key = typeof source?.key === 'string' ? source.key.trim() : '';
+  const uploadId = typeof source?.uploadId === 'string' ? source.uploadId.trim() : '';
+  if (!key || !KEY_PATTERN.test(key)) throw new BadRequestError('key is invalid');
+  if (!uploadId) throw new BadRequestError('uploadId is required');
+  return { key, uploadId };
+};
+
+// The owner of an upload: the webhook on /webhooks/:webhookId/:token, otherwise the
+// authenticated user in /channels/:channelId.
+const uploadOwner = (req: Request): string => {
+  const webhookId = typeof req.params?.webhookId === 'string' ? req.params.webhookId : '';
+  if (webhookId) return `webhook:${webhookId}`;
+  const channelId = typeof req.params?.channelId === 'string' ? req.params.channelId : '';
+  const userId = (req as any).user?.id ? String((req as any).user.id) : '';
+  if (!channelId || !userId) throw new BadRequestError('upload owner is unknown');
+  return `channel:${channelId}:user:${userId}`;
+};
+
+// Resolves a client-sent key/uploadId to an upload started by the same owner. Uploads of
+// other owners look exactly like missing ones.
+const readOwnedUploadRef = async (req: Request, source: any) => {
+  const ref = readUploadRef(source);
+  const found = await MultipartUpload.exists({ key: ref.key, uploadId: ref.uploadId, owner: uploadOwner(req) });
+  if (!found) throw new NotFoundError('Upload not found.');
+  return ref;
+};
+
+const forgetUpload = async (ref: { key: string; uploadId: string }) => {
+  await MultipartUpload.deleteOne({ key: ref.key, uploadId: ref.uploadId });
+};
+
+const isNoSuchUpload = (err: any) => {
+  const name = String(err?.name || '');
+  const code = String(err?.Code || err?.code || '');
+  return name === 'NoSuchUpload' || code === 'NoSuchUpload';
+};
+
+export const createMultipartHandler = asyncHandler(async (req: Request, res: Response) => {
+  const body = (req.body || {}) as any;
+  const filename = typeof body.filename === 'string' ? body.filename.trim() : '';
+  const contentType = typeof body.contentType === 'string' ? body.contentType.trim() : '';
+  const size = typeof body.size === 'number' ? body.size : Number.parseInt(String(body.size || ''), 10);
+
+  if (!filename) throw new BadRequestError('filename is required');
+  if (Number.isNaN(size) || size <= 0) throw new BadRequestError('size is required');
+  if (size > MAX_UPLOAD_BYTES) throw new BadRequestError('file is too large');
+
+  const owner = uploadOwner(req);
+  const key = `${nanoid()}${safeExt(filename)}`;
+  const { createMultipartUpload } = await import('../../utils/s3');
+  const { default: config } = await import('../../config');
+  const uploadId = await createMultipartUpload({ key, contentType: contentType || undefined });
+  await MultipartUpload.create({ key, uploadId, owner, expiresAt: new Date(Date.now() + UPLOAD_TTL_MS) });
+
+  const partSize = multipartPartSize(size);
+  res.status(200).json({
+    key,
+    uploadId,
+    partSize,
+    partCount: Math.ceil(size / partSize),
+    expiresInSeconds: config.s3.presignExpiresSeconds,
+  });
+});
+
+export const signPartsHandler = asyncHandler(async (req: Request, res: Response) => {
+  const body = (req.body || {}) as any;
+  const { key, uploadId } = readUploadRef(body);
+  const partNumbers: number[] = Array.isArray(body.partNumbers) ? body.partNumbers : [];
+  if (partNumbers.length === 0) throw new BadRequestError('partNumbers is required');
+  if (partNumbers.length > MAX_PARTS_PER_SIGN) throw new BadRequestError(`at most ${MAX_PARTS_PER_SIGN} parts per request`);
+  for (const n of partNumbers) {
+    if (!Number.isInteger(n) || n < 1 || n > MAX_PARTS) throw new BadRequestError('partNumbers must be integers in 1..10000');
+  }
+  await readOwnedUploadRef(req, body);
+
+  const { createPresignedPartUrl } = await import('../../utils/s3');
+  const { default: config } = await import('../../config');
+  const parts = await Promise.all(
+    partNumbers.map(async (partNumber) => ({
+      partNumber,
+      url: await createPresignedPartUrl({ key, uploadId, partNumber }),
+    }))
+  );
+  res.status(200).json({ parts, expiresInSeconds: config.s3.presignExpiresSeconds });
+});
+
+export const listPartsHandler = asyncHandler(async (req: Request, res: Response) => {
+  const { key, uploadId } = await readOwnedUploadRef(req, req.query);
+  const { listUploadedParts } = await import('../../utils/s3');
+  try {
+    const parts = await listUploadedParts({ key, uploadId });
+    res.status(200).json({ parts });
+  } catch (err: any) {
+    if (isNoSuchUpload(err)) throw new NotFoundError('Upload not found.');
+    throw err;
+  }
+});
+
+export const completeMultipartHandler = asyncHandler(async (req: Request, res: Response) => {
+  const body = (req.body || {}) as any;
+  const { key, uploadId } = await readOwnedUploadRef(req, body);
+  const filename = typeof body.filename === 'string' && body.filename.trim() ? body.filename.trim() : key;
+  const contentType = typeof body.contentType === 'string' ? body.contentType.trim() : '';
+
+  const { listUploadedParts, completeMultipartUpload, abortMultipartUpload } = await import('../../utils/s3');
+  let parts;
+  try {
+    // The server's part list is authoritative; clients may have lost ETags across a resume.
+    parts = await listUploadedParts({ key, uploadId });
+  } catch (err: any) {
+    if (isNoSuchUpload(err)) throw new NotFoundError('Upload not found.');
+    throw err;
+  }
+  if (parts.length === 0) throw new BadRequestError('no parts uploaded');
+  for (let i = 0; i < parts.length; i++) {
+    if (parts[i].partNumber !== i + 1) throw new BadRequestError(`part ${i + 1} is missing`);
+  }
+  const size = parts.reduce((sum, p) => sum + p.size, 0);
+  if (size > MAX_UPLOAD_BYTES) {
+    await abortMultipartUpload({ key, uploadId });
+    await forgetUpload({ key, uploadId });
+    throw new BadRequestError('file is too large');
+  }
+
+  await completeMultipartUpload({ key, uploadId, parts });
+  await forgetUpload({ key, uploadId });
+  res.status(201).json({
+    filename,
+    contentType: contentType || 'application/octet-stream',
+    key,
+    size,
+  });
+});
+
+export const abortMultipartHandler = asyncHandler(async (req: Request, res: Response) => {
+  const { key, uploadId } = await readOwnedUploadRef(req, req.body || {});
+  const { abortMultipartUpload } = await import('../../utils/s3');
+  try {
+    await abortMultipartUpload({ key, uploadId });
+  } catch (err: any) {
+    if (!isNoSuchUpload(err)) throw err;
+  }
+  await forgetUpload({ key, uploadId });
+  res.status(204).send();
+});
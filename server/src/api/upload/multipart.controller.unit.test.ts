@@ -0,0 +1,192 @@
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import { BadRequestError, NotFoundError } from '../../utils/errors';
+
+vi.mock('nanoid', () => ({
+  nanoid: () => 'id123',
+}));
+
+vi.mock('../../config', () => ({
+  default: {
+    s3: {
+      presignExpiresSeconds: 123,
+    },
+  },
+}));
+
+vi.mock('./multipartUpload.model', () => ({
+  default: {
+    create: vi.fn(),
+    exists: vi.fn(),
+    deleteOne: vi.fn(),
+  },
+}));
+
+vi.mock('../../utils/s3', () => ({
+  createMultipartUpload: vi.fn(),
+  createPresignedPartUrl: vi.fn(),
+  listUploadedParts: vi.fn(),
+  completeMultipartUpload: vi.fn(),
+  abortMultipartUpload: vi.fn(),
+}));
+
+import {
+  abortMultipartHandler,
+  completeMultipartHandler,
+  createMultipartHandler,
+  listPartsHandler,
+  multipartPartSize,
+  signPartsHandler,
+} from './multipart.controller';
+import {
+  abortMultipartUpload,
+  completeMultipartUpload,
+  createMultipartUpload,
+  createPresignedPartUrl,
+  listUploadedParts,
+} from '../../utils/s3';
+import MultipartUpload from './multipartUpload.model';
+
+const makeRes = () => {
+  const res: any = {};
+  res.status = vi.fn().mockReturnValue(res);
+  res.json = vi.fn().mockReturnValue(res);
+  res.send = vi.fn().mockReturnValue(res);
+  return res;
+};
+
+const MiB = 1024 * 1024;
+
+describe('api/upload/multipart.controller', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+    vi.mocked(MultipartUpload.exists).mockResolvedValue({ _id: 'rec1' } as any);
+  });
+
+  it('multipartPartSize keeps at least 8 MiB parts', () => {
+    expect(multipartPartSize(1)).toBe(8 * MiB);
+    expect(multipartPartSize(200_000 * MiB)).toBe(20 * MiB);
+  });
+
+  it('createMultipartHandler validates and starts an upload', async () => {
+    const next = vi.fn();
+    await createMultipartHandler({ body: { filename: 'a.mp4', size: 0 } } as any, makeRes(), next);
+    expect(next.mock.calls[0][0]).toBeInstanceOf(BadRequestError);
+
+    vi.mocked(createMultipartUpload).mockResolvedValue('up1' as any);
+    const res = makeRes();
+    await createMultipartHandler(
+      { params: { webhookId: 'wh1' }, body: { filename: 'clip.mp4', contentType: 'video/mp4', size: 20 * MiB } } as any,
+      res,
+      vi.fn()
+    );
+    expect(createMultipartUpload).toHaveBeenCalledWith({ key: 'id123.mp4', contentType: 'video/mp4' });
+    expect(MultipartUpload.create).toHaveBeenCalledWith(
+      expect.objectContaining({ key: 'id123.mp4', uploadId: 'up1', owner: 'webhook:wh1' })
+    );
+    expect(res.json).toHaveBeenCalledWith({
+      key: 'id123.mp4',
+      uploadId: 'up1',
+      partSize: 8 * MiB,
+      partCount: 3,
+      expiresInSeconds: 123,
+    });
+  });
+
+  it('signPartsHandler rejects bad keys and part numbers', async () => {
+    const next = vi.fn();
+    await signPartsHandler({ body: { key: '../x', uploadId: 'up1', partNumbers: [1] } } as any, makeRes(), next);
+    expect(next.mock.calls[0][0]).toBeInstanceOf(BadRequestError);
+
+    next.mockClear();
+    await signPartsHandler({ body: { key: 'id123.mp4', uploadId: 'up1', partNumbers: [0] } } as any, makeRes(), next);
+    expect(next.mock.calls[0][0]).toBeInstanceOf(BadRequestError);
+
+    vi.mocked(createPresignedPartUrl).mockImplementation(async ({ partNumber }: any) => `https://s3.example/p${partNumber}`);
+    const res = makeRes();
+    await signPartsHandler(
+      { params: { webhookId: 'wh1' }, body: { key: 'id123.mp4', uploadId: 'up1', partNumbers: [1, 2] } } as any,
+      res,
+      vi.fn()
+    );
+    expect(MultipartUpload.exists).toHaveBeenCalledWith({ key: 'id123.mp4', uploadId: 'up1', owner: 'webhook:wh1' });
+    expect(res.json).toHaveBeenCalledWith({
+      parts: [
+        { partNumber: 1, url: 'https://s3.example/p1' },
+        { partNumber: 2, url: 'https://s3.example/p2' },
+      ],
+      expiresInSeconds: 123,
+    });
+  });
+
+  it('listPartsHandler maps NoSuchUpload to 404', async () => {
+    vi.mocked(listUploadedParts).mockRejectedValue(Object.assign(new Error('gone'), { name: 'NoSuchUpload' }));
+    const next = vi.fn();
+    await listPartsHandler({ params: { webhookId: 'wh1' }, query: { key: 'id123.mp4', uploadId: 'up1' } } as any, makeRes(), next);
+    expect(next.mock.calls[0][0]).toBeInstanceOf(NotFoundError);
+  });
+
+  it('completeMultipartHandler completes with the server part list', async () => {
+    vi.mocked(listUploadedParts).mockResolvedValue([
+      { partNumber: 1, etag: '"a"', size: 8 * MiB },
+      { partNumber: 2, etag: '"b"', size: 10 },
+    ] as any);
+    const res = makeRes();
+    await completeMultipartHandler(
+      {
+        params: { channelId: 'ch1' },
+        user: { id: 'u1' },
+        body: { key: 'id123.mp4', uploadId: 'up1', filename: 'clip.mp4', contentType: 'video/mp4' },
+      } as any,
+      res,
+      vi.fn()
+    );
+    expect(completeMultipartUpload).toHaveBeenCalledWith({
+      key: 'id123.mp4',
+      uploadId: 'up1',
+      parts: [
+        { partNumber: 1, etag: '"a"', size: 8 * MiB },
+        { partNumber: 2, etag: '"b"', size: 10 },
+      ],
+    });
+    expect(MultipartUpload.exists).toHaveBeenCalledWith({ key: 'id123.mp4', uploadId: 'up1', owner: 'channel:ch1:user:u1' });
+    expect(MultipartUpload.deleteOne).toHaveBeenCalledWith({ key: 'id123.mp4', uploadId: 'up1' });
+    expect(res.status).toHaveBeenCalledWith(201);
+    expect(res.json).toHaveBeenCalledWith({ filename: 'clip.mp4', contentType: 'video/mp4', key: 'id123.mp4', size: 8 * MiB + 10 });
+  });
+
+  it('completeMultipartHandler rejects gaps in the part list', async () => {
+    vi.mocked(listUploadedParts).mockResolvedValue([{ partNumber: 2, etag: '"b"', size: 10 }] as any);
+    const next = vi.fn();
+    await completeMultipartHandler({ params: { webhookId: 'wh1' }, body: { key: 'id123.mp4', uploadId: 'up1' } } as any, makeRes(), next);
+    expect(next.mock.calls[0][0]).toBeInstanceOf(BadRequestError);
+    expect(completeMultipartUpload).not.toHaveBeenCalled();
+  });
+
+  it('abortMultipartHandler ignores already-finished uploads', async () => {
+    vi.mocked(abortMultipartUpload).mockRejectedValue(Object.assign(new Error('gone'), { Code: 'NoSuchUpload' }));
+    const res = makeRes();
+    await abortMultipartHandler({ params: { webhookId: 'wh1' }, body: { key: 'id123.mp4', uploadId: 'up1' } } as any, res, vi.fn());
+    expect(res.status).toHaveBeenCalledWith(204);
+  });
+
+  it('rejects uploads started by another owner', async () => {
+    vi.mocked(MultipartUpload.exists).mockResolvedValue(null as any);
+    const ref = { key: 'id123.mp4', uploadId: 'up1' };
+
+    const calls: Array<[any, any]> = [
+      [signPartsHandler, { params: { webhookId: 'other' }, body: { ...ref, partNumbers: [1] } }],
+      [listPartsHandler, { params: { webhookId: 'other' }, query: ref }],
+      [completeMultipartHandler, { params: { webhookId: 'other' }, body: ref }],
+      [abortMultipartHandler, { params: { channelId: 'ch1' }, user: { id: 'u2' }, body: ref }],
+    ];
+    for (const [handler, req] of calls) {
+      const next = vi.fn();
+      await handler(req, makeRes(), next);
+      expect(next.mock.calls[0][0]).toBeInstanceOf(NotFoundError);
+    }
+    expect(createPresignedPartUrl).not.toHaveBeenCalled();
+    expect(listUploadedParts).not.toHaveBeenCalled();
+    expect(completeMultipartUpload).not.toHaveBeenCalled();
+    expect(abortMultipartUpload).not.toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,28 @@
+import mongoose, { Schema, Document } from 'mongoose';
+
+// Records who started a multipart upload so later sign/list/complete/abort calls
+// can only touch uploads of the same webhook, or the same user in the same channel.
+export interface IMultipartUpload extends Document {
+  key: string;
+  uploadId: string;
+  owner: string;
+  expiresAt: Date;
+  createdAt: Date;
+  updatedAt: Date;
+}
+
+const MultipartUploadSchema = new Schema(
+  {
+    key: { type: String, required: true },
+    uploadId: { type: String, required: true },
+    owner: { type: String, required: true },
+    expiresAt: { type: Date, required: true },
+  },
+  { timestamps: true }
+);
+
+MultipartUploadSchema.index({ key: 1, uploadId: 1 }, { unique: true });
+// Abandoned uploads are forgotten after expiresAt; S3 lifecycle rules clean up the parts.
+MultipartUploadSchema.index({ expiresAt: 1 }, { expireAfterSeconds: 0 });
+
+export default mongoose.model<IMultipartUpload>('MultipartUpload', MultipartUploadSchema);
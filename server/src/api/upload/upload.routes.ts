@@ -2,6 +2,7 @@ import { Router } from 'express';
 import { authorizeChannel } from '../../middleware/checkPermission';
 import { uploadAttachment } from '../../middleware/upload';
 import * as uploadController from './upload.controller';
+import * as multipartController from './multipart.controller';
 
 const router = Router({ mergeParams: true });
 
@@ -15,6 +16,13 @@ router.post(
   uploadController.uploadFileHandler
 );
 
+// Resumable multipart uploads for large files.
+router.post('/multipart', authorizeChannel('ATTACH_FILES'), multipartController.createMultipartHandler);
+router.post('/multipart/parts', authorizeChannel('ATTACH_FILES'), multipartController.signPartsHandler);
+router.get('/multipart/parts', authorizeChannel('ATTACH_FILES'), multipartController.listPartsHandler);
+router.post('/multipart/complete', authorizeChannel('ATTACH_FILES'), multipartController.completeMultipartHandler);
+router.post('/multipart/abort', authorizeChannel('ATTACH_FILES'), multipartController.abortMultipartHandler);
+
 // Download attachment by key (useful for bot services in Docker where S3 public domain may be host-only).
 router.get('/:key', authorizeChannel('SEND_MESSAGES'), uploadController.downloadFileHandler);
 
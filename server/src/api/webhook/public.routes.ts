@@ -1,15 +1,25 @@
 import { Router } from 'express';
 import { uploadAttachment } from '../../middleware/upload';
 import * as WebhookController from './webhook.controller';
+import * as MultipartController from '../upload/multipart.controller';
 
 const router = Router();
 
-router.post('/:webhookId/:token', WebhookController.executeWebhook);
-router.post('/:webhookId/:token/presign', WebhookController.presignWebhookFile);
+router.post('/:webhookId/:token', WebhookController.requireWebhookToken, WebhookController.executeWebhook);
+router.post('/:webhookId/:token/presign', WebhookController.requireWebhookToken, WebhookController.presignWebhookFile);
 router.post(
   '/:webhookId/:token/upload',
+  WebhookController.requireWebhookUploadToken,
   uploadAttachment.single('file'),
   WebhookController.uploadWebhookFile
 );
 
+// Resumable multipart uploads (same protocol as /channels/:channelId/uploads/multipart).
+const multipart = '/:webhookId/:token/multipart';
+router.post(multipart, WebhookController.requireWebhookToken, MultipartController.createMultipartHandler);
+router.post(`${multipart}/parts`, WebhookController.requireWebhookToken, MultipartController.signPartsHandler);
+router.get(`${multipart}/parts`, WebhookController.requireWebhookToken, MultipartController.listPartsHandler);
+router.post(`${multipart}/complete`, WebhookController.requireWebhookToken, MultipartController.completeMultipartHandler);
+router.post(`${multipart}/abort`, WebhookController.requireWebhookToken, MultipartController.abortMultipartHandler);
+
 export default router;
@@ -1,5 +1,5 @@
 import { beforeEach, describe, expect, it, vi } from 'vitest';
-import { BadRequestError } from '../../utils/errors';
+import { BadRequestError, UnauthorizedError } from '../../utils/errors';
 
 vi.mock('./webhook.service', () => ({
   getWebhooksByChannel: vi.fn(),
@@ -15,6 +15,7 @@ vi.mock('./webhook.service', () => ({
 vi.mock('../../utils/s3', () => ({
   uploadFile: vi.fn(),
   createPresignedPutUrl: vi.fn(),
+  deleteObject: vi.fn(),
 }));
 
 vi.mock('../../config', () => ({
@@ -28,7 +29,7 @@ vi.mock('nanoid', () => ({
 }));
 
 import * as WebhookService from './webhook.service';
-import { createPresignedPutUrl, uploadFile } from '../../utils/s3';
+import { createPresignedPutUrl, deleteObject, uploadFile } from '../../utils/s3';
 import {
   createWebhook,
   deleteWebhook,
@@ -42,7 +43,7 @@ import {
 } from './webhook.controller';
 
 const makeRes = () => {
-  const res: any = {};
+  const res: any = { locals: {} };
   res.status = vi.fn().mockReturnValue(res);
   res.json = vi.fn().mockReturnValue(res);
   res.send = vi.fn().mockReturnValue(res);
@@ -167,6 +168,25 @@ describe('webhook.controller', () => {
     expect(res.json).toHaveBeenCalledWith({ filename: 'a.png', contentType: 'image/png', key: 'k2', size: 11 });
   });
 
+  it('uploadWebhookFile deletes a signed upload whose content does not match the signed hash', async () => {
+    const req: any = {
+      params: { webhookId: 'w1', token: 't1' },
+      file: { originalname: 'a.png', mimetype: 'image/png', size: 10, key: 'k1', sha256: 'b'.repeat(64) },
+    };
+    const res = makeRes();
+    res.locals.webhookContentSha256 = 'a'.repeat(64);
+    const next = vi.fn();
+
+    await uploadWebhookFile(req, res, next);
+    expect(deleteObject).toHaveBeenCalledWith('k1');
+    expect(next.mock.calls[0][0]).toBeInstanceOf(UnauthorizedError);
+    expect(res.status).not.toHaveBeenCalled();
+
+    req.file.sha256 = 'a'.repeat(64);
+    await uploadWebhookFile(req, res, next);
+    expect(res.status).toHaveBeenCalledWith(201);
+  });
+
   it('presignWebhookFile validates input fields', async () => {
     const res = makeRes();
 
@@ -1,10 +1,12 @@
-import { Request, Response } from 'express';
+import { NextFunction, Request, Response } from 'express';
 import * as WebhookService from './webhook.service';
 import asyncHandler from '../../utils/asyncHandler';
-import { BadRequestError } from '../../utils/errors';
+import { BadRequestError, UnauthorizedError } from '../../utils/errors';
 import { nanoid } from 'nanoid';
 import path from 'path';
+import crypto from 'crypto';
 import { MAX_UPLOAD_BYTES } from '../../constants/upload';
+import { SignedWebhookRequest } from '../../utils/webhookSignature';
 
 export const getWebhooks = asyncHandler(async (req: Request, res: Response) => {
   const { channelId } = req.params;
@@ -42,6 +44,18 @@ export const resetWebhookToken = asyncHandler(async (req: Request, res: Response
   res.status(200).json(result);
 });
 
+export const enableWebhookSigning = asyncHandler(async (req: Request, res: Response) => {
+  const { webhookId } = req.params;
+  const result = await WebhookService.enableWebhookSigning(webhookId);
+  res.status(200).json(result);
+});
+
+export const disableWebhookSigning = asyncHandler(async (req: Request, res: Response) => {
+  const { webhookId } = req.params;
+  await WebhookService.disableWebhookSigning(webhookId);
+  res.status(204).send();
+});
+
 export const executeWebhook = asyncHandler(async (req: Request, res: Response) => {
     const { webhookId, token } = req.params;
     const message = await WebhookService.executeWebhook(webhookId, token, req.body);
@@ -62,6 +76,21 @@ export const uploadWebhookFile = asyncHandler(async (req: Request, res: Response
   if (!uploaded.key) {
     const { uploadFile } = await import('../../utils/s3');
     Object.assign(uploaded, await uploadFile(req.file));
+    if (!uploaded.sha256 && uploaded.buffer) {
+      uploaded.sha256 = crypto.createHash('sha256').update(uploaded.buffer).digest('hex');
+    }
+  }
+
+  // Signed uploads cover the file's hash, which is only known once it is stored.
+  const signedSha256: string | undefined = res.locals?.webhookContentSha256;
+  if (signedSha256 && uploaded.sha256 !== signedSha256) {
+    const { deleteObject } = await import('../../utils/s3');
+    try {
+      await deleteObject(uploaded.key);
+    } catch {
+      // The object is unreferenced either way.
+    }
+    throw new UnauthorizedError('Invalid webhook signature');
   }
 
   const attachment = {
@@ -74,6 +103,38 @@ export const uploadWebhookFile = asyncHandler(async (req: Request, res: Response
   res.status(201).json(attachment);
 });
 
+// signedWebhookRequest describes req for signature checks; the route is the path
+// below /:webhookId/:token, which survives any prefix a reverse proxy adds, plus the
+// raw query string.
+const signedWebhookRequest = (req: Request): SignedWebhookRequest => {
+  const { webhookId, token } = req.params;
+  const prefix = `/${webhookId}/${token}`;
+  const route = req.path.startsWith(prefix) ? req.path.slice(prefix.length).replace(/\/$/, '') : '';
+  const q = req.originalUrl.indexOf('?');
+  const query = q >= 0 && q < req.originalUrl.length - 1 ? req.originalUrl.slice(q) : '';
+  return { method: req.method, route: route + query, headers: req.headers, rawBody: (req as any).rawBody };
+};
+
+const webhookAuth = (options: { streamedBody?: boolean } = {}) =>
+  asyncHandler(async (req: Request, res: Response, next: NextFunction) => {
+    const { webhookId, token } = req.params;
+    await WebhookService.assertValidWebhookToken(webhookId, token);
+    const contentSha256 = await WebhookService.assertValidWebhookSignature(webhookId, signedWebhookRequest(req), options);
+    if (options.streamedBody && contentSha256) {
+      res.locals.webhookContentSha256 = contentSha256;
+    }
+    next();
+  });
+
+// requireWebhookToken guards token-authenticated routes and, for webhooks with a
+// signing secret, checks the request signature.
+export const requireWebhookToken = webhookAuth();
+
+// requireWebhookUploadToken runs before multer, so the streamed file can't be hashed
+// yet; signed uploads carry the file's hash, and uploadWebhookFile compares it with
+// the stored file.
+export const requireWebhookUploadToken = webhookAuth({ streamedBody: true });
+
 export const presignWebhookFile = asyncHandler(async (req: Request, res: Response) => {
   const { webhookId, token } = req.params;
   await WebhookService.assertValidWebhookToken(webhookId, token);
@@ -6,6 +6,7 @@ export interface IWebhook extends Document {
   channelId: mongoose.Types.ObjectId;
   serverId: mongoose.Types.ObjectId;
   token: string;
+  signingSecret?: string;
   botUserId: mongoose.Types.ObjectId;
   createdAt: Date;
   updatedAt: Date;
@@ -19,6 +20,8 @@ const webhookSchema = new Schema<IWebhook>(
     serverId: { type: Schema.Types.ObjectId, ref: 'Server', required: true, index: true },
     // Secret used by public webhook endpoints; do not return in read APIs.
     token: { type: String, required: true, select: false },
+    // When set, public requests must carry an HMAC signature (utils/webhookSignature).
+    signingSecret: { type: String, select: false },
     botUserId: { type: Schema.Types.ObjectId, ref: 'User', required: true },
   },
   { timestamps: true }
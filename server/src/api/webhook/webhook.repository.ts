@@ -17,6 +17,16 @@ class WebhookRepository {
     return Webhook.findOne({ _id: webhookId, token });
   }
 
+  public async findSigningSecret(webhookId: string): Promise<string | undefined> {
+    const webhook = await Webhook.findById(webhookId).select('+signingSecret');
+    return webhook?.signingSecret || undefined;
+  }
+
+  public async updateSigningSecret(webhookId: string, signingSecret?: string): Promise<IWebhook | null> {
+    const update = signingSecret ? { $set: { signingSecret } } : { $unset: { signingSecret: 1 } };
+    return Webhook.findByIdAndUpdate(webhookId, update, { new: true });
+  }
+
   public async create(data: Partial<IWebhook>): Promise<IWebhook> {
     return Webhook.create(data);
   }
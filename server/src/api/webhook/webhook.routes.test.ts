@@ -8,9 +8,12 @@ vi.mock('../../utils/s3', () => ({
   getS3PublicUrl: (key: string) => `http://cdn.local/${key}`,
   uploadFile: vi.fn(),
   createPresignedPutUrl: vi.fn(),
+  deleteObject: vi.fn(),
 }));
 
-import { uploadStream } from '../../utils/s3';
+import { deleteObject, uploadStream } from '../../utils/s3';
+import { computeWebhookSignature } from '../../utils/webhookSignature';
+import crypto from 'crypto';
 
 describe('Webhook Routes', () => {
   let token: string;
@@ -21,12 +24,13 @@ describe('Webhook Routes', () => {
     vi.clearAllMocks();
     // Default mock for streaming uploads (must drain stream to avoid hanging busboy)
     vi.mocked(uploadStream).mockImplementation(async (opts: any) => {
+      const hash = crypto.createHash('sha256');
       await new Promise<void>((resolve, reject) => {
-        opts.stream.on('data', () => {});
+        opts.stream.on('data', (chunk: Buffer) => hash.update(chunk));
         opts.stream.on('end', () => resolve());
         opts.stream.on('error', reject);
       });
-      return { key: 'mock-file.txt', mimetype: 'text/plain', size: 42 } as any;
+      return { key: 'mock-file.txt', mimetype: 'text/plain', size: 42, sha256: hash.digest('hex') } as any;
     });
 
     // 1. Create a user and get token
@@ -404,6 +408,78 @@ describe('Webhook Routes', () => {
       expect(res.statusCode).toBe(401);
     });
 
+    it('should require a valid signature once signing is enabled', async () => {
+      const enableRes = await request(app)
+        .post(`/api/servers/${serverId}/channels/${channelId}/webhooks/${webhook._id}/signing-secret`)
+        .set('Authorization', `Bearer ${token}`);
+      expect(enableRes.statusCode).toBe(200);
+      const secret: string = enableRes.body.signingSecret;
+      expect(secret).toMatch(/^whsec_/);
+
+      const body = JSON.stringify({ content: 'signed' });
+      const unsigned = await request(app)
+        .post(`/api/webhooks/${webhook._id}/${webhook.token}`)
+        .set('Content-Type', 'application/json')
+        .send(body);
+      expect(unsigned.statusCode).toBe(401);
+
+      const ts = Math.floor(Date.now() / 1000);
+      const sha = crypto.createHash('sha256').update(body).digest('hex');
+      const signed = await request(app)
+        .post(`/api/webhooks/${webhook._id}/${webhook.token}`)
+        .set('Content-Type', 'application/json')
+        .set('X-Mew-Timestamp', String(ts))
+        .set('X-Mew-Content-SHA256', sha)
+        .set('X-Mew-Signature', `v1=${computeWebhookSignature(secret, ts, 'POST', '', sha)}`)
+        .send(body);
+      expect(signed.statusCode).toBe(200);
+      expect(signed.body.content).toBe('signed');
+
+      const disableRes = await request(app)
+        .delete(`/api/servers/${serverId}/channels/${channelId}/webhooks/${webhook._id}/signing-secret`)
+        .set('Authorization', `Bearer ${token}`);
+      expect(disableRes.statusCode).toBe(204);
+      const afterDisable = await request(app)
+        .post(`/api/webhooks/${webhook._id}/${webhook.token}`)
+        .send({ content: 'plain again' });
+      expect(afterDisable.statusCode).toBe(200);
+    });
+
+    it('should check the signed query and the signed hash of uploaded files', async () => {
+      const enableRes = await request(app)
+        .post(`/api/servers/${serverId}/channels/${channelId}/webhooks/${webhook._id}/signing-secret`)
+        .set('Authorization', `Bearer ${token}`);
+      const secret: string = enableRes.body.signingSecret;
+      const ts = Math.floor(Date.now() / 1000);
+
+      const body = JSON.stringify({ content: 'signed' });
+      const sha = crypto.createHash('sha256').update(body).digest('hex');
+      const otherQuery = await request(app)
+        .post(`/api/webhooks/${webhook._id}/${webhook.token}?thread=2`)
+        .set('Content-Type', 'application/json')
+        .set('X-Mew-Timestamp', String(ts))
+        .set('X-Mew-Content-SHA256', sha)
+        .set('X-Mew-Signature', `v1=${computeWebhookSignature(secret, ts, 'POST', '?thread=1', sha)}`)
+        .send(body);
+      expect(otherQuery.statusCode).toBe(401);
+
+      const upload = (fileSha: string) =>
+        request(app)
+          .post(`/api/webhooks/${webhook._id}/${webhook.token}/upload`)
+          .set('X-Mew-Timestamp', String(ts))
+          .set('X-Mew-Content-SHA256', fileSha)
+          .set('X-Mew-Signature', `v1=${computeWebhookSignature(secret, ts, 'POST', '/upload', fileSha)}`)
+          .attach('file', Buffer.from('hello'), 'hello.txt');
+
+      const replayed = await upload(crypto.createHash('sha256').update('other file').digest('hex'));
+      expect(replayed.status).toBe(401);
+      expect(deleteObject).toHaveBeenCalledWith('mock-file.txt');
+
+      const ok = await upload(crypto.createHash('sha256').update('hello').digest('hex'));
+      expect(ok.status).toBe(201);
+      expect(ok.body.key).toBe('mock-file.txt');
+    });
+
     it('should upload a file via webhook token and return attachment metadata', async () => {
       const res = await request(app)
         .post(`/api/webhooks/${webhook._id}/${webhook.token}/upload`)
@@ -17,6 +17,10 @@ router.get('/:webhookId/token', WebhookController.getWebhookToken);
 
 router.post('/:webhookId/reset-token', WebhookController.resetWebhookToken);
 
+router.route('/:webhookId/signing-secret')
+  .post(WebhookController.enableWebhookSigning)
+  .delete(WebhookController.disableWebhookSigning);
+
 router.route('/:webhookId')
   .patch(uploadImage.single('avatar'), WebhookController.updateWebhook)
   .delete(WebhookController.deleteWebhook);
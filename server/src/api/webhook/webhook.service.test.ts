@@ -11,6 +11,8 @@ vi.mock('./webhook.repository', () => ({
     findByIdAndToken: vi.fn(),
     countOtherWebhooksByBotUserId: vi.fn(),
     updateOne: vi.fn(),
+    findSigningSecret: vi.fn(),
+    updateSigningSecret: vi.fn(),
   },
 }));
 
@@ -49,7 +51,10 @@ import UserModel from '../user/user.model';
 import * as MessageService from '../message/message.service';
 import { uploadFile } from '../../utils/s3';
 import {
+  assertValidWebhookSignature,
   assertValidWebhookToken,
+  disableWebhookSigning,
+  enableWebhookSigning,
   createWebhook,
   deleteWebhook,
   executeWebhook,
@@ -231,6 +236,40 @@ describe('webhook.service', () => {
     expect(result).toEqual({ _id: 'm1' });
   });
 
+  it('executeWebhook forwards referencedMessageId as a reply target', async () => {
+    vi.mocked((webhookRepository as any).findByIdAndToken).mockResolvedValue({
+      _id: 'w1',
+      name: 'Hook',
+      avatarUrl: 'hook.png',
+      channelId: 'c1',
+      botUserId: 'u-bot',
+      serverId: 's1',
+    });
+    vi.mocked((webhookRepository as any).countOtherWebhooksByBotUserId).mockResolvedValue(0);
+    vi.mocked((UserModel as any).findById).mockResolvedValue({ _id: 'u-bot' });
+    vi.mocked(MessageService.createMessage).mockResolvedValue({ _id: 'm1' } as any);
+
+    await executeWebhook('w1', 't1', { content: 'hi', referencedMessageId: '507f1f77bcf86cd799439011' });
+
+    const data = vi.mocked(MessageService.createMessage).mock.calls[0][0] as any;
+    expect(data.referencedMessageId.toString()).toBe('507f1f77bcf86cd799439011');
+  });
+
+  it('executeWebhook rejects malformed referencedMessageId', async () => {
+    vi.mocked((webhookRepository as any).findByIdAndToken).mockResolvedValue({
+      _id: 'w1',
+      name: 'Hook',
+      avatarUrl: 'hook.png',
+      channelId: 'c1',
+      botUserId: 'u-bot',
+      serverId: 's1',
+    });
+    vi.mocked((webhookRepository as any).countOtherWebhooksByBotUserId).mockResolvedValue(0);
+    vi.mocked((UserModel as any).findById).mockResolvedValue({ _id: 'u-bot' });
+
+    await expect(executeWebhook('w1', 't1', { content: 'hi', referencedMessageId: 'nope' })).rejects.toBeInstanceOf(BadRequestError);
+  });
+
   it('executeWebhook rejects empty content for message/default', async () => {
     vi.mocked((webhookRepository as any).findByIdAndToken).mockResolvedValue({
       _id: 'w1',
@@ -375,4 +414,31 @@ describe('webhook.service', () => {
     );
     expect(result).toEqual({ _id: 'm1' });
   });
+
+  it('assertValidWebhookSignature is a no-op without a signing secret', async () => {
+    vi.mocked((webhookRepository as any).findSigningSecret).mockResolvedValue(undefined);
+    await expect(
+      assertValidWebhookSignature('w1', { method: 'POST', route: '', headers: {} })
+    ).resolves.toBeUndefined();
+  });
+
+  it('assertValidWebhookSignature rejects unsigned requests once a secret is set', async () => {
+    vi.mocked((webhookRepository as any).findSigningSecret).mockResolvedValue('whsec_x');
+    await expect(
+      assertValidWebhookSignature('w1', { method: 'POST', route: '', headers: {} })
+    ).rejects.toBeInstanceOf(UnauthorizedError);
+  });
+
+  it('enableWebhookSigning stores and returns a fresh secret', async () => {
+    vi.mocked((webhookRepository as any).updateSigningSecret).mockResolvedValue({ _id: 'w1' });
+    const result = await enableWebhookSigning('w1');
+    expect(result.webhookId).toBe('w1');
+    expect(result.signingSecret).toMatch(/^whsec_[0-9a-f]{64}$/);
+    expect((webhookRepository as any).updateSigningSecret).toHaveBeenCalledWith('w1', result.signingSecret);
+  });
+
+  it('disableWebhookSigning throws when webhook is missing', async () => {
+    vi.mocked((webhookRepository as any).updateSigningSecret).mockResolvedValue(null);
+    await expect(disableWebhookSigning('w1')).rejects.toBeInstanceOf(NotFoundError);
+  });
 });
@@ -8,6 +8,11 @@ import ServerModel from '../server/server.model';
 import * as MessageService from '../message/message.service';
 import { BadRequestError, NotFoundError, UnauthorizedError } from '../../utils/errors';
 import { getS3PublicUrl, uploadFile } from '../../utils/s3';
+import {
+  SignedWebhookRequest,
+  VerifyWebhookSignatureOptions,
+  verifyWebhookSignature,
+} from '../../utils/webhookSignature';
 
 interface WebhookCreationData {
   name: string;
@@ -23,6 +28,7 @@ const ALLOWED_WEBHOOK_MESSAGE_TYPES = new Set([
   'app/x-instagram-card',
   'app/x-tiktok-card',
   'app/x-jpdict-card',
+  'app/x-rich-card',
 ]);
 
 const RESERVED_PAYLOAD_KEYS = new Set(['webhookName', 'overrides']);
@@ -216,12 +222,43 @@ export const resetWebhookToken = async (webhookId: string): Promise<{ webhookId:
   return { webhookId: updated._id.toString(), token };
 };
 
+// Signing is optional per webhook: without a secret only the URL token is checked.
+// Resolves to the signed content hash, or undefined when the webhook has no signing secret.
+export const assertValidWebhookSignature = async (
+  webhookId: string,
+  request: SignedWebhookRequest,
+  options?: VerifyWebhookSignatureOptions
+): Promise<string | undefined> => {
+  const secret = await webhookRepository.findSigningSecret(webhookId);
+  if (!secret) return undefined;
+  return verifyWebhookSignature(secret, request, options);
+};
+
+export const enableWebhookSigning = async (
+  webhookId: string
+): Promise<{ webhookId: string; signingSecret: string }> => {
+  const signingSecret = `whsec_${crypto.randomBytes(32).toString('hex')}`;
+  const updated = await webhookRepository.updateSigningSecret(webhookId, signingSecret);
+  if (!updated) {
+    throw new NotFoundError('Webhook not found');
+  }
+  return { webhookId: updated._id.toString(), signingSecret };
+};
+
+export const disableWebhookSigning = async (webhookId: string): Promise<void> => {
+  const updated = await webhookRepository.updateSigningSecret(webhookId);
+  if (!updated) {
+    throw new NotFoundError('Webhook not found');
+  }
+};
+
 interface ExecuteWebhookPayload {
     content?: string;
     username?: string;
     avatar_url?: string;
     type?: string;
     payload?: Record<string, any>;
+    referencedMessageId?: string;
 }
 
 export const executeWebhook = async (webhookId: string, token: string, payload: ExecuteWebhookPayload) => {
@@ -272,11 +309,19 @@ export const executeWebhook = async (webhookId: string, token: string, payload:
     const hydratedPayload = hydrateS3PrefixedFields(customPayload);
     const sanitizedPayload = sanitizeCustomPayload(messageType, hydratedPayload);
 
+    const referencedMessageId = typeof payload.referencedMessageId === 'string'
+        ? payload.referencedMessageId.trim()
+        : '';
+    if (referencedMessageId && !mongoose.Types.ObjectId.isValid(referencedMessageId)) {
+      throw new BadRequestError('Invalid referencedMessageId');
+    }
+
     const messageData: any = {
       channelId: webhook.channelId,
       authorId: webhook.botUserId,
       type: messageType,
       content,
+      ...(referencedMessageId ? { referencedMessageId: new mongoose.Types.ObjectId(referencedMessageId) } : {}),
       payload: {
         ...sanitizedPayload,
         webhookName: webhook.name,
@@ -69,12 +69,28 @@ app.use(
     origin: corsOrigin,
     credentials: true,
     methods: ['GET', 'HEAD', 'POST', 'PUT', 'PATCH', 'DELETE', 'OPTIONS'],
-    allowedHeaders: ['Authorization', 'Content-Type', 'X-Mew-Admin-Secret', 'X-Mew-Csrf-Token'],
+    allowedHeaders: [
+      'Authorization',
+      'Content-Type',
+      'X-Mew-Admin-Secret',
+      'X-Mew-Csrf-Token',
+      'X-Mew-Signature',
+      'X-Mew-Timestamp',
+      'X-Mew-Content-SHA256',
+    ],
     exposedHeaders: ['Content-Length'],
     maxAge: 86400,
   })
 );
-app.use(express.json({ limit: '1mb' }));
+app.use(
+  express.json({
+    limit: '1mb',
+    // Signed webhook requests are verified against the exact bytes received.
+    verify: (req, _res, buf) => {
+      if ((req as any).originalUrl?.startsWith('/api/webhooks/')) (req as any).rawBody = buf;
+    },
+  })
+);
 
 // express-rate-limit's handler type currently doesn't line up with Express 5's `app.use` overloads.
 // Cast to Express' RequestHandler to keep runtime behavior while satisfying TypeScript.
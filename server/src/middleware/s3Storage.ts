@@ -3,7 +3,8 @@ import { uploadStream } from '../utils/s3';
 
 /**
  * Multer storage engine that streams uploads directly to S3 (no buffering in RAM / disk).
- * It stores the resulting S3 object key on `req.file.key`.
+ * It stores the resulting S3 object key on `req.file.key` and the content's SHA-256 on
+ * `req.file.sha256`.
  */
 export class S3StreamingStorage implements StorageEngine {
   async _handleFile(req: any, file: any, cb: (error?: any, info?: Partial<any>) => void) {
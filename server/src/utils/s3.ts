@@ -1,9 +1,21 @@
-import { DeleteObjectCommand, GetObjectCommand, PutObjectCommand, S3Client, PutBucketCorsCommand } from '@aws-sdk/client-s3';
+import {
+  AbortMultipartUploadCommand,
+  CompleteMultipartUploadCommand,
+  CreateMultipartUploadCommand,
+  DeleteObjectCommand,
+  GetObjectCommand,
+  ListPartsCommand,
+  PutObjectCommand,
+  S3Client,
+  PutBucketCorsCommand,
+  UploadPartCommand,
+} from '@aws-sdk/client-s3';
 import { Upload } from '@aws-sdk/lib-storage';
 import { getSignedUrl } from '@aws-sdk/s3-request-presigner';
 import config from '../config';
 import { nanoid } from 'nanoid';
 import path from 'path';
+import crypto from 'crypto';
 import { Transform } from 'stream';
 
 const protocol = config.s3.useSsl ? 'https' : 'http';
@@ -172,13 +184,7 @@ export const uploadFile = async (file: Express.Multer.File) => {
   return { key: newFilename, mimetype: file.mimetype, size: file.size };
 };
 
-export const createPresignedPutUrl = async (opts: { key: string; contentType?: string }) => {
-  const command = new PutObjectCommand({
-    Bucket: config.s3.bucketName,
-    Key: opts.key,
-    ContentType: opts.contentType || undefined,
-  });
-
+const signWithPresignClient = async (command: unknown) => {
   const client = presignClientInfo?.client ?? s3Client;
 
   // Some AWS SDK packages can pull in mismatched `@smithy/types` versions under pnpm, which breaks TS assignability.
@@ -200,13 +206,100 @@ export const createPresignedPutUrl = async (opts: { key: string; contentType?: s
   return url;
 };
 
+export const createPresignedPutUrl = async (opts: { key: string; contentType?: string }) => {
+  const command = new PutObjectCommand({
+    Bucket: config.s3.bucketName,
+    Key: opts.key,
+    ContentType: opts.contentType || undefined,
+  });
+  return signWithPresignClient(command);
+};
+
+// ---- Multipart uploads (resumable, chunked PUTs) ----
+
+export type UploadedPart = { partNumber: number; etag: string; size: number };
+
+export const createMultipartUpload = async (opts: { key: string; contentType?: string }) => {
+  const resp = await s3Client.send(
+    new CreateMultipartUploadCommand({
+      Bucket: config.s3.bucketName,
+      Key: opts.key,
+      ContentType: opts.contentType || undefined,
+    })
+  );
+  if (!resp.UploadId) throw new Error('S3 did not return an upload id');
+  return resp.UploadId;
+};
+
+export const createPresignedPartUrl = async (opts: { key: string; uploadId: string; partNumber: number }) => {
+  const command = new UploadPartCommand({
+    Bucket: config.s3.bucketName,
+    Key: opts.key,
+    UploadId: opts.uploadId,
+    PartNumber: opts.partNumber,
+  });
+  return signWithPresignClient(command);
+};
+
+export const listUploadedParts = async (opts: { key: string; uploadId: string }): Promise<UploadedPart[]> => {
+  const parts: UploadedPart[] = [];
+  let marker: string | undefined;
+  for (;;) {
+    const resp = await s3Client.send(
+      new ListPartsCommand({
+        Bucket: config.s3.bucketName,
+        Key: opts.key,
+        UploadId: opts.uploadId,
+        PartNumberMarker: marker,
+      })
+    );
+    for (const p of resp.Parts ?? []) {
+      if (!p.PartNumber || !p.ETag) continue;
+      parts.push({ partNumber: p.PartNumber, etag: p.ETag, size: p.Size ?? 0 });
+    }
+    if (!resp.IsTruncated || !resp.NextPartNumberMarker) break;
+    marker = String(resp.NextPartNumberMarker);
+  }
+  return parts.sort((a, b) => a.partNumber - b.partNumber);
+};
+
+export const completeMultipartUpload = async (opts: { key: string; uploadId: string; parts: UploadedPart[] }) => {
+  await s3Client.send(
+    new CompleteMultipartUploadCommand({
+      Bucket: config.s3.bucketName,
+      Key: opts.key,
+      UploadId: opts.uploadId,
+      MultipartUpload: {
+        Parts: opts.parts.map((p) => ({ PartNumber: p.partNumber, ETag: p.etag })),
+      },
+    })
+  );
+};
+
+export const abortMultipartUpload = async (opts: { key: string; uploadId: string }) => {
+  await s3Client.send(
+    new AbortMultipartUploadCommand({
+      Bucket: config.s3.bucketName,
+      Key: opts.key,
+      UploadId: opts.uploadId,
+    })
+  );
+};
+
+// ByteCounter counts and hashes what passes through it.
 class ByteCounter extends Transform {
   public bytes = 0;
+  private hash = crypto.createHash('sha256');
 
   _transform(chunk: any, encoding: BufferEncoding, callback: (error?: Error | null, data?: any) => void) {
     this.bytes += chunk?.length ?? 0;
+    this.hash.update(chunk);
     callback(null, chunk);
   }
+
+  sha256() {
+    return this.hash.digest('hex');
+  }
 }
 
 export const uploadStream = async (options: {
@@ -232,7 +325,7 @@ export const uploadStream = async (options: {
 
   await upload.done();
 
-  return { key: newFilename, mimetype: options.mimetype, size: counter.bytes };
+  return { key: newFilename, mimetype: options.mimetype, size: counter.bytes, sha256: counter.sha256() };
 };
 
 export const getObjectStream = async (key: string) => {
@@ -0,0 +1,76 @@
+import { describe, expect, it } from 'vitest';
+import crypto from 'crypto';
+import { UnauthorizedError } from './errors';
+import { computeWebhookSignature, verifyWebhookSignature } from './webhookSignature';
+
+const now = 1_700_000_000;
+const body = Buffer.from('{"content":"hi"}');
+const sha = crypto.createHash('sha256').update(body).digest('hex');
+
+const signed = (overrides: Partial<{ route: string; method: string; ts: number; sha: string; secret: string }> = {}) => {
+  const ts = overrides.ts ?? now;
+  const contentSha = overrides.sha ?? sha;
+  const sig = computeWebhookSignature(overrides.secret ?? 'secret', ts, overrides.method ?? 'POST', overrides.route ?? '', contentSha);
+  return {
+    'x-mew-timestamp': String(ts),
+    'x-mew-content-sha256': contentSha,
+    'x-mew-signature': `v1=${sig}`,
+  };
+};
+
+describe('verifyWebhookSignature', () => {
+  it('accepts a valid signature', () => {
+    expect(() =>
+      verifyWebhookSignature('secret', { method: 'POST', route: '', headers: signed(), rawBody: body }, { nowSeconds: now })
+    ).not.toThrow();
+  });
+
+  it('matches the Go SDK signature for a known vector', () => {
+    // Same vector as TestComputeSignature_KnownVector in plugins/pkg/api/webhook.
+    const empty = crypto.createHash('sha256').update('').digest('hex');
+    expect(computeWebhookSignature('secret', now, 'post', '', empty)).toBe(
+      '717645404240efa154cb7b54c70e4b4e539f22b8c051539cecbb02f9a532a18a'
+    );
+  });
+
+  it('accepts any of several signatures (secret rotation)', () => {
+    const headers = signed();
+    headers['x-mew-signature'] = `v1=deadbeef, ${headers['x-mew-signature']}`;
+    expect(() =>
+      verifyWebhookSignature('secret', { method: 'POST', route: '', headers, rawBody: body }, { nowSeconds: now })
+    ).not.toThrow();
+  });
+
+  it.each([
+    ['missing headers', {}, body, now],
+    ['stale timestamp', signed(), body, now + 301],
+    ['edited body', signed(), Buffer.from('{"content":"ho"}'), now],
+    ['other route', signed({ route: '/presign' }), body, now],
+    ['wrong secret', signed({ secret: 'other' }), body, now],
+  ])('rejects %s', (_name, headers, rawBody, nowSeconds) => {
+    expect(() =>
+      verifyWebhookSignature('secret', { method: 'POST', route: '', headers, rawBody }, { nowSeconds })
+    ).toThrow(UnauthorizedError);
+  });
+
+  it('rejects a signature made for another query', () => {
+    const headers = signed({ route: '/multipart/parts?key=k&uploadId=u1' });
+    expect(() =>
+      verifyWebhookSignature('secret', { method: 'POST', route: '/multipart/parts?key=k&uploadId=u2', headers, rawBody: body }, { nowSeconds: now })
+    ).toThrow(UnauthorizedError);
+  });
+
+  it('returns the signed hash of a streamed body', () => {
+    const fileSha = crypto.createHash('sha256').update('file').digest('hex');
+    const req = { method: 'POST', route: '/upload', headers: signed({ route: '/upload', sha: fileSha }) };
+    expect(() => verifyWebhookSignature('secret', req, { nowSeconds: now })).toThrow(UnauthorizedError);
+    expect(verifyWebhookSignature('secret', req, { nowSeconds: now, streamedBody: true })).toBe(fileSha);
+  });
+
+  it('requires a content hash on streamed bodies', () => {
+    const req = { method: 'POST', route: '/upload', headers: signed({ route: '/upload', sha: 'UNSIGNED-PAYLOAD' }) };
+    expect(() => verifyWebhookSignature('secret', req, { nowSeconds: now, streamedBody: true })).toThrow(
+      UnauthorizedError
+    );
+  });
+});
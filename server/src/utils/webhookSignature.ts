@@ -0,0 +1,100 @@
+import crypto from 'crypto';
+import { UnauthorizedError } from './errors';
+
+// Optional HMAC signing of public webhook requests. Senders add:
+//   X-Mew-Timestamp:      unix seconds
+//   X-Mew-Content-SHA256: hex sha256 of the body (of the uploaded file on /upload)
+//   X-Mew-Signature:      v1=<hex hmac-sha256(secret, signatureBase(...))>[, v1=...]
+// The route is the path below /webhooks/:id/:token ('' for execute) plus
+// '?query' when there is one, so a signature can't be replayed against another
+// endpoint or with other parameters.
+export const WEBHOOK_SIGNATURE_HEADER = 'x-mew-signature';
+export const WEBHOOK_TIMESTAMP_HEADER = 'x-mew-timestamp';
+export const WEBHOOK_CONTENT_SHA256_HEADER = 'x-mew-content-sha256';
+export const WEBHOOK_SIGNATURE_TOLERANCE_SECONDS = 300;
+
+export interface SignedWebhookRequest {
+  method: string;
+  route: string;
+  headers: Record<string, string | string[] | undefined>;
+  rawBody?: Buffer;
+}
+
+export interface VerifyWebhookSignatureOptions {
+  // The body is streamed to storage before it can be hashed (/upload): trust the
+  // signed X-Mew-Content-SHA256 for now; the caller must compare it with the stored file.
+  streamedBody?: boolean;
+  toleranceSeconds?: number;
+  nowSeconds?: number;
+}
+
+export const signatureBase = (timestamp: number, method: string, route: string, contentSha256: string) =>
+  `v1\n${timestamp}\n${method.toUpperCase()}\n${route}\n${contentSha256}`;
+
+export const computeWebhookSignature = (
+  secret: string,
+  timestamp: number,
+  method: string,
+  route: string,
+  contentSha256: string
+) => crypto.createHmac('sha256', secret).update(signatureBase(timestamp, method, route, contentSha256)).digest('hex');
+
+const header = (headers: SignedWebhookRequest['headers'], name: string): string => {
+  const v = headers[name];
+  return (Array.isArray(v) ? v[0] : v || '').trim();
+};
+
+const sha256Hex = (body?: Buffer) => crypto.createHash('sha256').update(body ?? Buffer.alloc(0)).digest('hex');
+
+const SHA256_HEX = /^[0-9a-f]{64}$/i;
+
+const safeEqual = (a: string, b: string) => {
+  const ab = Buffer.from(a);
+  const bb = Buffer.from(b);
+  return ab.length === bb.length && crypto.timingSafeEqual(ab, bb);
+};
+
+// verifyWebhookSignature throws UnauthorizedError unless req is signed with secret.
+// It returns the signed content hash.
+export const verifyWebhookSignature = (
+  secret: string,
+  req: SignedWebhookRequest,
+  options: VerifyWebhookSignatureOptions = {}
+): string => {
+  const rawTimestamp = header(req.headers, WEBHOOK_TIMESTAMP_HEADER);
+  const rawSignature = header(req.headers, WEBHOOK_SIGNATURE_HEADER);
+  if (!rawTimestamp || !rawSignature) {
+    throw new UnauthorizedError('Webhook signature required');
+  }
+
+  const timestamp = Number.parseInt(rawTimestamp, 10);
+  const now = options.nowSeconds ?? Math.floor(Date.now() / 1000);
+  const tolerance = options.toleranceSeconds ?? WEBHOOK_SIGNATURE_TOLERANCE_SECONDS;
+  if (!Number.isFinite(timestamp) || Math.abs(now - timestamp) > tolerance) {
+    throw new UnauthorizedError('Webhook signature expired');
+  }
+
+  const claimed = header(req.headers, WEBHOOK_CONTENT_SHA256_HEADER).toLowerCase();
+  let contentSha256: string;
+  if (options.streamedBody) {
+    if (!SHA256_HEX.test(claimed)) {
+      throw new UnauthorizedError('Webhook signature must cover the body');
+    }
+    contentSha256 = claimed;
+  } else {
+    contentSha256 = sha256Hex(req.rawBody);
+    if (claimed && claimed !== contentSha256) {
+      throw new UnauthorizedError('Invalid webhook signature');
+    }
+  }
+
+  const expected = computeWebhookSignature(secret, timestamp, req.method, req.route, contentSha256);
+  const ok = rawSignature
+    .split(',')
+    .map((part) => part.trim())
+    .some((part) => part.startsWith('v1=') && safeEqual(part.slice(3).toLowerCase(), expected));
+  if (!ok) {
+    throw new UnauthorizedError('Invalid webhook signature');
+  }
+  return contentSha256;
+};